@@ -1,11 +1,14 @@
 package api
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strings"
 
 	"github.com/containous/mux"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/middlewares"
+	"github.com/containous/traefik/middlewares/cache"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/containous/traefik/version"
@@ -22,6 +25,9 @@ type Handler struct {
 	Statistics            *types.Statistics `description:"Enable more detailed statistics" export:"true"`
 	Stats                 *thoas_stats.Stats
 	StatsRecorder         *middlewares.StatsRecorder
+	CircuitBreakers       *middlewares.CircuitBreakers
+	Caches                *cache.Caches
+	CachePurgeToken       string `description:"Bearer token required to purge the response caches"`
 }
 
 var (
@@ -41,12 +47,16 @@ func (p Handler) AddRoutes(router *mux.Router) {
 	router.Methods("GET").Path("/api/providers/{provider}").HandlerFunc(p.getProviderHandler)
 	router.Methods("GET").Path("/api/providers/{provider}/backends").HandlerFunc(p.getBackendsHandler)
 	router.Methods("GET").Path("/api/providers/{provider}/backends/{backend}").HandlerFunc(p.getBackendHandler)
+	router.Methods("GET").Path("/api/providers/{provider}/backends/{backend}/circuitbreaker").HandlerFunc(p.getCircuitBreakerHandler)
 	router.Methods("GET").Path("/api/providers/{provider}/backends/{backend}/servers").HandlerFunc(p.getServersHandler)
 	router.Methods("GET").Path("/api/providers/{provider}/backends/{backend}/servers/{server}").HandlerFunc(p.getServerHandler)
+	router.Methods("GET").Path("/api/circuitbreakers").HandlerFunc(p.getCircuitBreakersHandler)
 	router.Methods("GET").Path("/api/providers/{provider}/frontends").HandlerFunc(p.getFrontendsHandler)
 	router.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}").HandlerFunc(p.getFrontendHandler)
 	router.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}/routes").HandlerFunc(p.getRoutesHandler)
 	router.Methods("GET").Path("/api/providers/{provider}/frontends/{frontend}/routes/{route}").HandlerFunc(p.getRouteHandler)
+	router.Methods("DELETE").Path("/api/cache").HandlerFunc(p.purgeCachesHandler)
+	router.Methods("DELETE").Path("/api/cache/{frontend}").HandlerFunc(p.purgeCacheHandler)
 
 	// health route
 	router.Methods("GET").Path("/health").HandlerFunc(p.getHealthHandler)
@@ -69,7 +79,11 @@ func getProviderIDFromVars(vars map[string]string) string {
 
 func (p Handler) getConfigHandler(response http.ResponseWriter, request *http.Request) {
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
-	err := templatesRenderer.JSON(response, http.StatusOK, currentConfigurations)
+	configurations := make(types.Configurations, len(currentConfigurations))
+	for providerID, provider := range currentConfigurations {
+		configurations[providerID] = provider.WithoutSecrets()
+	}
+	err := templatesRenderer.JSON(response, http.StatusOK, configurations)
 	if err != nil {
 		log.Error(err)
 	}
@@ -80,7 +94,7 @@ func (p Handler) getProviderHandler(response http.ResponseWriter, request *http.
 
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	if provider, ok := currentConfigurations[providerID]; ok {
-		err := templatesRenderer.JSON(response, http.StatusOK, provider)
+		err := templatesRenderer.JSON(response, http.StatusOK, provider.WithoutSecrets())
 		if err != nil {
 			log.Error(err)
 		}
@@ -94,7 +108,7 @@ func (p Handler) getBackendsHandler(response http.ResponseWriter, request *http.
 
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	if provider, ok := currentConfigurations[providerID]; ok {
-		err := templatesRenderer.JSON(response, http.StatusOK, provider.Backends)
+		err := templatesRenderer.JSON(response, http.StatusOK, provider.WithoutSecrets().Backends)
 		if err != nil {
 			log.Error(err)
 		}
@@ -111,7 +125,7 @@ func (p Handler) getBackendHandler(response http.ResponseWriter, request *http.R
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	if provider, ok := currentConfigurations[providerID]; ok {
 		if backend, ok := provider.Backends[backendID]; ok {
-			err := templatesRenderer.JSON(response, http.StatusOK, backend)
+			err := templatesRenderer.JSON(response, http.StatusOK, backend.WithoutSecrets())
 			if err != nil {
 				log.Error(err)
 			}
@@ -121,6 +135,106 @@ func (p Handler) getBackendHandler(response http.ResponseWriter, request *http.R
 	http.NotFound(response, request)
 }
 
+// getCircuitBreakerHandler returns the states of the circuit breakers of a backend, by entry point.
+func (p Handler) getCircuitBreakerHandler(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	providerID := getProviderIDFromVars(vars)
+	backendID := vars["backend"]
+
+	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
+	if provider, ok := currentConfigurations[providerID]; ok && p.CircuitBreakers != nil {
+		if _, ok := provider.Backends[backendID]; ok {
+			if states, ok := p.CircuitBreakers.States()[backendID]; ok {
+				err := templatesRenderer.JSON(response, http.StatusOK, states)
+				if err != nil {
+					log.Error(err)
+				}
+				return
+			}
+		}
+	}
+	http.NotFound(response, request)
+}
+
+// getCircuitBreakersHandler returns the states of the circuit breakers, by backend then by entry point.
+func (p Handler) getCircuitBreakersHandler(response http.ResponseWriter, request *http.Request) {
+	states := make(map[string]map[string]middlewares.CircuitBreakerState)
+	if p.CircuitBreakers != nil {
+		states = p.CircuitBreakers.States()
+	}
+
+	err := templatesRenderer.JSON(response, http.StatusOK, states)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// purgeCachesHandler purges the response caches of all the frontends,
+// either entirely or only the responses of the url query parameter.
+func (p Handler) purgeCachesHandler(response http.ResponseWriter, request *http.Request) {
+	if !p.authorizePurge(response, request) {
+		return
+	}
+
+	var purged int
+	if p.Caches != nil {
+		var err error
+		purged, err = p.Caches.Purge(request.URL.Query().Get("url"))
+		if err != nil {
+			http.Error(response, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	renderPurged(response, purged)
+}
+
+// purgeCacheHandler purges the response cache of a frontend,
+// either entirely or only the responses of the url query parameter.
+func (p Handler) purgeCacheHandler(response http.ResponseWriter, request *http.Request) {
+	if !p.authorizePurge(response, request) {
+		return
+	}
+
+	if p.Caches != nil {
+		if c, ok := p.Caches.Get(mux.Vars(request)["frontend"]); ok {
+			purged, err := c.Purge(request.URL.Query().Get("url"))
+			if err != nil {
+				http.Error(response, err.Error(), http.StatusBadRequest)
+				return
+			}
+			renderPurged(response, purged)
+			return
+		}
+	}
+	http.NotFound(response, request)
+}
+
+// authorizePurge checks the bearer token of a purge request, and answers the request when it isn't authorized.
+// The purge is forbidden when no token is configured.
+func (p Handler) authorizePurge(response http.ResponseWriter, request *http.Request) bool {
+	if len(p.CachePurgeToken) == 0 {
+		http.Error(response, "cache purge is disabled", http.StatusForbidden)
+		return false
+	}
+
+	const prefix = "Bearer "
+	authorization := request.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, prefix) ||
+		subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(authorization, prefix)), []byte(p.CachePurgeToken)) != 1 {
+		response.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(response, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func renderPurged(response http.ResponseWriter, purged int) {
+	err := templatesRenderer.JSON(response, http.StatusOK, map[string]int{"purged": purged})
+	if err != nil {
+		log.Error(err)
+	}
+}
+
 func (p Handler) getServersHandler(response http.ResponseWriter, request *http.Request) {
 	vars := mux.Vars(request)
 	providerID := getProviderIDFromVars(vars)
@@ -165,7 +279,7 @@ func (p Handler) getFrontendsHandler(response http.ResponseWriter, request *http
 
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	if provider, ok := currentConfigurations[providerID]; ok {
-		err := templatesRenderer.JSON(response, http.StatusOK, provider.Frontends)
+		err := templatesRenderer.JSON(response, http.StatusOK, provider.WithoutSecrets().Frontends)
 		if err != nil {
 			log.Error(err)
 		}
@@ -182,7 +296,7 @@ func (p Handler) getFrontendHandler(response http.ResponseWriter, request *http.
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
 	if provider, ok := currentConfigurations[providerID]; ok {
 		if frontend, ok := provider.Frontends[frontendID]; ok {
-			err := templatesRenderer.JSON(response, http.StatusOK, frontend)
+			err := templatesRenderer.JSON(response, http.StatusOK, frontend.WithoutSecrets())
 			if err != nil {
 				log.Error(err)
 			}
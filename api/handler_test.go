@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerHidesOIDCSecrets(t *testing.T) {
+	configurations := types.Configurations{
+		"file": {
+			Frontends: map[string]*types.Frontend{
+				"frontend1": {
+					Backend: "backend1",
+					Auth: &types.Auth{
+						OIDC: &types.OIDC{
+							Issuer:       "https://idp.example.com",
+							ClientID:     "client",
+							ClientSecret: "client-secret",
+							Secret:       "cookie-secret",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	router := mux.NewRouter()
+	Handler{CurrentConfigurations: safe.New(configurations)}.AddRoutes(router)
+
+	testCases := []string{
+		"/api",
+		"/api/providers",
+		"/api/providers/file",
+		"/api/providers/file/frontends",
+		"/api/providers/file/frontends/frontend1",
+	}
+
+	for _, path := range testCases {
+		path := path
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "https://idp.example.com")
+			assert.NotContains(t, recorder.Body.String(), "client-secret")
+			assert.NotContains(t, recorder.Body.String(), "cookie-secret")
+		})
+	}
+
+	// The current configuration keeps its secrets.
+	assert.Equal(t, "client-secret", configurations["file"].Frontends["frontend1"].Auth.OIDC.ClientSecret)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/containous/traefik/provider/etcd"
 	"github.com/containous/traefik/provider/eureka"
 	"github.com/containous/traefik/provider/file"
+	httpprovider "github.com/containous/traefik/provider/http"
 	"github.com/containous/traefik/provider/kubernetes"
 	"github.com/containous/traefik/provider/marathon"
 	"github.com/containous/traefik/provider/mesos"
@@ -163,6 +164,13 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 	var defaultEureka eureka.Provider
 	defaultEureka.Delay = "30s"
 
+	// default HTTP
+	var defaultHTTP httpprovider.Provider
+	defaultHTTP.Watch = true
+	defaultHTTP.PollInterval = flaeg.Duration(5 * time.Second)
+	defaultHTTP.PollTimeout = flaeg.Duration(5 * time.Second)
+	defaultHTTP.Constraints = types.Constraints{}
+
 	// default Ping
 	var defaultPing = ping.Handler{
 		EntryPoint: "traefik",
@@ -246,7 +254,9 @@ func NewTraefikDefaultPointersConfiguration() *TraefikConfiguration {
 		Rancher:            &defaultRancher,
 		Eureka:             &defaultEureka,
 		DynamoDB:           &defaultDynamoDB,
+		HTTP:               &defaultHTTP,
 		Retry:              &configuration.Retry{},
+		RateLimitStore:     &configuration.RateLimitStore{Backend: "redis", Address: "127.0.0.1:6379"},
 		HealthCheck:        &healthCheck,
 		RespondingTimeouts: &respondingTimeouts,
 		ForwardingTimeouts: &forwardingTimeouts,
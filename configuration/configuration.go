@@ -2,6 +2,7 @@ package configuration
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/containous/traefik/provider/etcd"
 	"github.com/containous/traefik/provider/eureka"
 	"github.com/containous/traefik/provider/file"
+	httpprovider "github.com/containous/traefik/provider/http"
 	"github.com/containous/traefik/provider/kubernetes"
 	"github.com/containous/traefik/provider/marathon"
 	"github.com/containous/traefik/provider/mesos"
@@ -69,6 +71,7 @@ type GlobalConfiguration struct {
 	InsecureSkipVerify        bool                    `description:"Disable SSL certificate verification" export:"true"`
 	RootCAs                   tls.RootCAs             `description:"Add cert file for self-signed certificate"`
 	Retry                     *Retry                  `description:"Enable retry sending request if network error" export:"true"`
+	RateLimitStore            *RateLimitStore         `description:"Share the rate limiters counters between the Traefik instances" export:"true"`
 	HealthCheck               *HealthCheckConfig      `description:"Health check parameters" export:"true"`
 	RespondingTimeouts        *RespondingTimeouts     `description:"Timeouts for incoming requests to the Traefik instance" export:"true"`
 	ForwardingTimeouts        *ForwardingTimeouts     `description:"Timeouts for requests forwarded to the backend servers" export:"true"`
@@ -87,7 +90,9 @@ type GlobalConfiguration struct {
 	ECS                       *ecs.Provider           `description:"Enable ECS backend with default settings" export:"true"`
 	Rancher                   *rancher.Provider       `description:"Enable Rancher backend with default settings" export:"true"`
 	DynamoDB                  *dynamodb.Provider      `description:"Enable DynamoDB backend with default settings" export:"true"`
+	HTTP                      *httpprovider.Provider  `description:"Enable HTTP polling backend with default settings" export:"true"`
 	Rest                      *rest.Provider          `description:"Enable Rest backend with default settings" export:"true"`
+	Providers                 *Providers              `export:"true"` // Named provider instances, TOML only
 	API                       *api.Handler            `description:"Enable api/dashboard" export:"true"`
 	Metrics                   *types.Metrics          `description:"Enable a metrics exporter" export:"true"`
 	Ping                      *ping.Handler           `description:"Enable ping" export:"true"`
@@ -230,6 +235,14 @@ func (gc *GlobalConfiguration) SetEffectiveConfiguration(configFile string) {
 
 	// Try to fallback to traefik config file in case the file provider is enabled
 	// but has no file name configured.
+	if gc.File != nil {
+		gc.File.TraefikFile = configFile
+	}
+	if gc.Providers != nil {
+		for _, fileProvider := range gc.Providers.File {
+			fileProvider.TraefikFile = configFile
+		}
+	}
 	if gc.File != nil && len(gc.File.Filename) == 0 {
 		if len(configFile) > 0 {
 			gc.File.Filename = configFile
@@ -328,8 +341,23 @@ func (ep *EntryPoints) Set(value string) error {
 		whiteListSourceRange = strings.Split(result["whitelistsourcerange"], ",")
 	}
 
+	whiteList, err := parseWhiteList(result)
+	if err != nil {
+		return err
+	}
+
 	compress := toBool(result, "compress")
 
+	compression, err := parseCompression(result)
+	if err != nil {
+		return err
+	}
+	if compression != nil {
+		compress = true
+	}
+
+	requestID := parseRequestID(result)
+
 	var proxyProtocol *ProxyProtocol
 	ppTrustedIPs := result["proxyprotocol_trustedips"]
 	if len(result["proxyprotocol_insecure"]) > 0 || len(ppTrustedIPs) > 0 {
@@ -363,14 +391,128 @@ func (ep *EntryPoints) Set(value string) error {
 		TLS:                  configTLS,
 		Redirect:             redirect,
 		Compress:             compress,
+		Compression:          compression,
 		WhitelistSourceRange: whiteListSourceRange,
+		WhiteList:            whiteList,
 		ProxyProtocol:        proxyProtocol,
 		ForwardedHeaders:     forwardedHeaders,
+		RequestID:            requestID,
 	}
 
 	return nil
 }
 
+// parseCompression builds the compression options from the compress.* keys, nil when none is set.
+func parseCompression(result map[string]string) (*types.Compress, error) {
+	compression := &types.Compress{}
+	var found bool
+
+	if len(result["compress_encodings"]) > 0 {
+		compression.Encodings = strings.Split(result["compress_encodings"], ",")
+		found = true
+	}
+	if len(result["compress_contenttypes"]) > 0 {
+		compression.ContentTypes = strings.Split(result["compress_contenttypes"], ",")
+		found = true
+	}
+	if len(result["compress_excludedcontenttypes"]) > 0 {
+		compression.ExcludedContentTypes = strings.Split(result["compress_excludedcontenttypes"], ",")
+		found = true
+	}
+
+	for key, value := range map[string]*int{
+		"compress_level":       &compression.Level,
+		"compress_brotlilevel": &compression.BrotliLevel,
+		"compress_minsize":     &compression.MinSize,
+	} {
+		if len(result[key]) == 0 {
+			continue
+		}
+		v, err := strconv.Atoi(result[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: %v", strings.Replace(key, "_", ".", -1), result[key], err)
+		}
+		*value = v
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return compression, nil
+}
+
+// parseRequestID builds the request ID options from the requestID and requestID.* keys, nil when none is set.
+// The request ID is enabled by RequestID:true, or by any request ID option.
+func parseRequestID(result map[string]string) *types.RequestID {
+	requestID := &types.RequestID{
+		Header:        result["requestid_header"],
+		Format:        result["requestid_format"],
+		TrustIncoming: toBool(result, "requestid_trustincoming"),
+	}
+
+	if !toBool(result, "requestid") && len(requestID.Header) == 0 && len(requestID.Format) == 0 && !requestID.TrustIncoming {
+		return nil
+	}
+	return requestID
+}
+
+// parseWhiteList builds the white list options from the whiteList.* keys, nil when none is set.
+func parseWhiteList(result map[string]string) (*types.WhiteList, error) {
+	whiteList := &types.WhiteList{}
+	var found bool
+
+	if len(result["whitelist_sourcerange"]) > 0 {
+		whiteList.SourceRange = strings.Split(result["whitelist_sourcerange"], ",")
+		found = true
+	}
+	if len(result["whitelist_ipstrategy_trustedproxies"]) > 0 {
+		whiteList.IPStrategy = &types.IPStrategy{
+			TrustedProxies: strings.Split(result["whitelist_ipstrategy_trustedproxies"], ","),
+		}
+		found = true
+	}
+	if len(result["whitelist_ipstrategy_depth"]) > 0 {
+		depth, err := strconv.Atoi(result["whitelist_ipstrategy_depth"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid whiteList.ipStrategy.depth value %q: %v", result["whitelist_ipstrategy_depth"], err)
+		}
+		if whiteList.IPStrategy == nil {
+			whiteList.IPStrategy = &types.IPStrategy{}
+		}
+		whiteList.IPStrategy.Depth = depth
+		found = true
+	}
+	if _, ok := result["whitelist_ipstrategy"]; ok {
+		// use the forwarded headers trusted IPs of the entry point
+		if whiteList.IPStrategy == nil {
+			whiteList.IPStrategy = &types.IPStrategy{}
+		}
+		found = true
+	}
+	if _, ok := result["whitelist_deny"]; ok {
+		whiteList.Deny = toBool(result, "whitelist_deny")
+		found = true
+	}
+	if len(result["whitelist_rejectstatuscode"]) > 0 {
+		statusCode, err := strconv.Atoi(result["whitelist_rejectstatuscode"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid whiteList.rejectStatusCode value %q: %v", result["whitelist_rejectstatuscode"], err)
+		}
+		whiteList.RejectStatusCode = statusCode
+		found = true
+	}
+	if len(result["whitelist_rejectbody"]) > 0 {
+		whiteList.RejectBody = result["whitelist_rejectbody"]
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return whiteList, nil
+}
+
 func parseEntryPointsConfiguration(raw string) map[string]string {
 	sections := strings.Fields(raw)
 
@@ -423,9 +565,30 @@ type EntryPoint struct {
 	Redirect             *Redirect   `export:"true"`
 	Auth                 *types.Auth `export:"true"`
 	WhitelistSourceRange []string
-	Compress             bool              `export:"true"`
-	ProxyProtocol        *ProxyProtocol    `export:"true"`
-	ForwardedHeaders     *ForwardedHeaders `export:"true"`
+	WhiteList            *types.WhiteList           `export:"true"`
+	Compress             bool                       `export:"true"`
+	Compression          *types.Compress            `export:"true"`
+	ProxyProtocol        *ProxyProtocol             `export:"true"`
+	ForwardedHeaders     *ForwardedHeaders          `export:"true"`
+	Errors               map[string]types.ErrorPage `export:"true"`
+	RequestID            *types.RequestID           `export:"true"`
+}
+
+// GetWhiteList returns the white list of the entry point, merging WhitelistSourceRange, or nil when there is none
+func (ep *EntryPoint) GetWhiteList() *types.WhiteList {
+	if ep.WhiteList == nil {
+		if len(ep.WhitelistSourceRange) == 0 {
+			return nil
+		}
+		return &types.WhiteList{SourceRange: ep.WhitelistSourceRange}
+	}
+
+	if len(ep.WhiteList.SourceRange) == 0 && len(ep.WhitelistSourceRange) > 0 {
+		whiteList := *ep.WhiteList
+		whiteList.SourceRange = ep.WhitelistSourceRange
+		return &whiteList
+	}
+	return ep.WhiteList
 }
 
 // Redirect configures a redirection of an entry point to another, or to an URL
@@ -440,6 +603,16 @@ type Retry struct {
 	Attempts int `description:"Number of attempts" export:"true"`
 }
 
+// RateLimitStore configures the store of the rate limiters counters, shared between the Traefik instances
+type RateLimitStore struct {
+	Backend    string `description:"Store of the counters: kv (the KV store of the cluster) or redis" export:"true"`
+	Address    string `description:"Address of the Redis server" export:"true"`
+	Password   string `description:"Password of the Redis server"`
+	Database   int    `description:"Database of the Redis server" export:"true"`
+	Prefix     string `description:"Prefix of the keys of the counters" export:"true"`
+	FailClosed bool   `description:"Reject the requests while the store is unavailable" export:"true"`
+}
+
 // HealthCheckConfig contains health check configuration parameters.
 type HealthCheckConfig struct {
 	Interval flaeg.Duration `description:"Default periodicity of enabled health checks" export:"true"`
@@ -470,6 +643,14 @@ type ForwardedHeaders struct {
 	TrustedIPs []string
 }
 
+// GetTrustedIPs returns the trusted IPs, or nil when the forwarded headers are not configured
+func (f *ForwardedHeaders) GetTrustedIPs() []string {
+	if f == nil {
+		return nil
+	}
+	return f.TrustedIPs
+}
+
 // LifeCycle contains configurations relevant to the lifecycle (such as the
 // shutdown phase) of Traefik.
 type LifeCycle struct {
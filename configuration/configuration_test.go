@@ -8,6 +8,7 @@ import (
 	"github.com/containous/traefik/provider"
 	"github.com/containous/traefik/provider/file"
 	"github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -288,6 +289,76 @@ func TestEntryPoints_Set(t *testing.T) {
 				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
 			},
 		},
+		{
+			name:                   "compress options",
+			expression:             "Name:foo Compress.Encodings:gzip Compress.Level:9 Compress.BrotliLevel:4 Compress.MinSize:1024 Compress.ContentTypes:text/html,application/json Compress.ExcludedContentTypes:text/event-stream",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				Compress: true,
+				Compression: &types.Compress{
+					Encodings:            []string{"gzip"},
+					Level:                9,
+					BrotliLevel:          4,
+					MinSize:              1024,
+					ContentTypes:         []string{"text/html", "application/json"},
+					ExcludedContentTypes: []string{"text/event-stream"},
+				},
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+			},
+		},
+		{
+			name:                   "request id true",
+			expression:             "Name:foo RequestID:true",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				RequestID:            &types.RequestID{},
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+			},
+		},
+		{
+			name:                   "request id options",
+			expression:             "Name:foo RequestID.Header:X-Correlation-Id RequestID.Format:hex RequestID.TrustIncoming:true",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				RequestID: &types.RequestID{
+					Header:        "X-Correlation-Id",
+					Format:        "hex",
+					TrustIncoming: true,
+				},
+				WhitelistSourceRange: []string{},
+				ForwardedHeaders:     &ForwardedHeaders{Insecure: true},
+			},
+		},
+		{
+			name:                   "whitelist options",
+			expression:             "Name:foo WhiteList.SourceRange:10.0.0.0/8,192.168.1.7 WhiteList.IPStrategy.Depth:2 WhiteList.Deny:true WhiteList.RejectStatusCode:404 WhiteList.RejectBody:Gone",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				WhitelistSourceRange: []string{},
+				WhiteList: &types.WhiteList{
+					SourceRange:      []string{"10.0.0.0/8", "192.168.1.7"},
+					IPStrategy:       &types.IPStrategy{Depth: 2},
+					Deny:             true,
+					RejectStatusCode: 404,
+					RejectBody:       "Gone",
+				},
+				ForwardedHeaders: &ForwardedHeaders{Insecure: true},
+			},
+		},
+		{
+			name:                   "whitelist with the forwarded headers trusted IPs",
+			expression:             "Name:foo WhiteListSourceRange:10.0.0.0/8 WhiteList.IPStrategy ForwardedHeaders.TrustedIPs:172.16.0.0/12",
+			expectedEntryPointName: "foo",
+			expectedEntryPoint: &EntryPoint{
+				WhitelistSourceRange: []string{"10.0.0.0/8"},
+				WhiteList: &types.WhiteList{
+					IPStrategy: &types.IPStrategy{},
+				},
+				ForwardedHeaders: &ForwardedHeaders{TrustedIPs: []string{"172.16.0.0/12"}},
+			},
+		},
 	}
 
 	for _, test := range testCases {
@@ -390,3 +461,18 @@ func TestSetEffectiveConfigurationFileProviderFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestSetEffectiveConfigurationFileProviderInstancesTraefikFile(t *testing.T) {
+	gc := &GlobalConfiguration{
+		File: &file.Provider{},
+		Providers: &Providers{
+			File: map[string]*file.Provider{"rules": {BaseProvider: provider.BaseProvider{Filename: "rules.toml.tmpl"}}},
+		},
+	}
+
+	gc.SetEffectiveConfiguration(defaultConfigFile)
+
+	assert.Equal(t, defaultConfigFile, gc.File.TraefikFile)
+	assert.Equal(t, defaultConfigFile, gc.Providers.File["rules"].TraefikFile)
+	assert.Equal(t, "rules.toml.tmpl", gc.Providers.File["rules"].Filename)
+}
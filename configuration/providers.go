@@ -0,0 +1,150 @@
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/boltdb"
+	"github.com/containous/traefik/provider/consul"
+	"github.com/containous/traefik/provider/docker"
+	"github.com/containous/traefik/provider/dynamodb"
+	"github.com/containous/traefik/provider/ecs"
+	"github.com/containous/traefik/provider/etcd"
+	"github.com/containous/traefik/provider/eureka"
+	"github.com/containous/traefik/provider/file"
+	httpprovider "github.com/containous/traefik/provider/http"
+	"github.com/containous/traefik/provider/kubernetes"
+	"github.com/containous/traefik/provider/marathon"
+	"github.com/containous/traefik/provider/mesos"
+	"github.com/containous/traefik/provider/rancher"
+	"github.com/containous/traefik/provider/zk"
+	"github.com/mitchellh/copystructure"
+)
+
+// Providers holds the named instances of the providers, e.g. [providers.docker.east] and [providers.docker.west].
+// Each instance starts from the default settings of its provider type,
+// and sends its configurations under its own provider name (e.g. docker.east).
+type Providers struct {
+	Docker        map[string]*docker.Provider        `export:"true"`
+	File          map[string]*file.Provider          `export:"true"`
+	Marathon      map[string]*marathon.Provider      `export:"true"`
+	Consul        map[string]*consul.Provider        `export:"true"`
+	ConsulCatalog map[string]*consul.CatalogProvider `export:"true"`
+	Etcd          map[string]*etcd.Provider          `export:"true"`
+	Zookeeper     map[string]*zk.Provider            `export:"true"`
+	Boltdb        map[string]*boltdb.Provider        `export:"true"`
+	Kubernetes    map[string]*kubernetes.Provider    `export:"true"`
+	Mesos         map[string]*mesos.Provider         `export:"true"`
+	Eureka        map[string]*eureka.Provider        `export:"true"`
+	ECS           map[string]*ecs.Provider           `export:"true"`
+	Rancher       map[string]*rancher.Provider       `export:"true"`
+	DynamoDB      map[string]*dynamodb.Provider      `export:"true"`
+	HTTP          map[string]*httpprovider.Provider  `export:"true"`
+	defaults      *GlobalConfiguration
+}
+
+// NewProviders creates the named provider instances holder.
+// The instances are initialized with the provider settings of the given configuration, if any.
+func NewProviders(defaults *GlobalConfiguration) *Providers {
+	return &Providers{defaults: defaults}
+}
+
+// UnmarshalTOML decodes the [providers.<type>.<name>] sections over the default settings of each provider type.
+func (p *Providers) UnmarshalTOML(data interface{}) error {
+	providerTypes, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid providers section: %v", data)
+	}
+
+	*p = Providers{defaults: p.defaults}
+	providersValue := reflect.ValueOf(p).Elem()
+	for typeName, rawInstances := range providerTypes {
+		field, fieldName := findField(providersValue, typeName)
+		if !field.IsValid() {
+			return fmt.Errorf("unknown provider type %q", typeName)
+		}
+
+		instances, ok := rawInstances.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid %s providers section: %v", typeName, rawInstances)
+		}
+
+		field.Set(reflect.MakeMap(field.Type()))
+		for name, rawInstance := range instances {
+			instance, err := p.decodeInstance(fieldName, field.Type().Elem().Elem(), rawInstance)
+			if err != nil {
+				return fmt.Errorf("invalid %s provider %q: %v", typeName, name, err)
+			}
+			instance.Interface().(namedProvider).SetInstanceName(name)
+			field.SetMapIndex(reflect.ValueOf(name), instance)
+		}
+	}
+	return nil
+}
+
+// decodeInstance decodes one provider instance over a deep copy of the default settings of its type,
+// so that the slices, maps and pointers of the defaults are not shared between the instances.
+func (p *Providers) decodeInstance(fieldName string, typ reflect.Type, rawInstance interface{}) (reflect.Value, error) {
+	instance := reflect.New(typ)
+	if p.defaults != nil {
+		if defaultInstance := reflect.ValueOf(p.defaults).Elem().FieldByName(fieldName); defaultInstance.IsValid() && !defaultInstance.IsNil() {
+			copied, err := copystructure.Copy(defaultInstance.Interface())
+			if err != nil {
+				return instance, fmt.Errorf("unable to copy the default settings: %v", err)
+			}
+			instance = reflect.ValueOf(copied)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(rawInstance); err != nil {
+		return instance, err
+	}
+	_, err := toml.Decode(buf.String(), instance.Interface())
+	return instance, err
+}
+
+// findField returns the instances field matching the provider type name, case-insensitively.
+func findField(providersValue reflect.Value, typeName string) (reflect.Value, string) {
+	providersType := providersValue.Type()
+	for i := 0; i < providersType.NumField(); i++ {
+		if name := providersType.Field(i).Name; strings.EqualFold(name, typeName) && providersType.Field(i).PkgPath == "" {
+			return providersValue.Field(i), name
+		}
+	}
+	return reflect.Value{}, ""
+}
+
+type namedProvider interface {
+	provider.Provider
+	SetInstanceName(name string)
+}
+
+// GetInstances returns the provider instances, sorted by type and name.
+func (p *Providers) GetInstances() []provider.Provider {
+	var instances []provider.Provider
+
+	providersValue := reflect.ValueOf(p).Elem()
+	for i := 0; i < providersValue.NumField(); i++ {
+		field := providersValue.Field(i)
+		if field.Kind() != reflect.Map {
+			continue
+		}
+
+		var names []string
+		for _, key := range field.MapKeys() {
+			names = append(names, key.String())
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			instances = append(instances, field.MapIndex(reflect.ValueOf(name)).Interface().(provider.Provider))
+		}
+	}
+	return instances
+}
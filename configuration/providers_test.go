@@ -0,0 +1,121 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containous/traefik/provider/docker"
+	"github.com/containous/traefik/provider/file"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvidersUnmarshalTOML(t *testing.T) {
+	defaults := &GlobalConfiguration{
+		Docker: &docker.Provider{
+			Endpoint:         "unix:///var/run/docker.sock",
+			ExposedByDefault: true,
+		},
+	}
+	defaults.Docker.Watch = true
+	defaults.Docker.Constraints = types.Constraints{{Key: "tag", MustMatch: true, Regex: "api"}}
+	defaults.Docker.TLS = &types.ClientTLS{CA: "ca.pem"}
+
+	content := `
+[providers]
+  [providers.docker.east]
+  endpoint = "tcp://east.example.com:2375"
+  domain = "east.example.com"
+
+  [providers.docker.west]
+  endpoint = "tcp://west.example.com:2375"
+  exposedByDefault = false
+  watch = false
+    [providers.docker.west.tls]
+    ca = "west.pem"
+
+  [providers.file.rules]
+  filename = "rules.toml"
+`
+
+	gc := &GlobalConfiguration{Providers: NewProviders(defaults)}
+	_, err := toml.Decode(content, gc)
+	require.NoError(t, err)
+
+	require.Len(t, gc.Providers.Docker, 2)
+
+	east := gc.Providers.Docker["east"]
+	assert.Equal(t, "tcp://east.example.com:2375", east.Endpoint)
+	assert.Equal(t, "east.example.com", east.Domain)
+	assert.True(t, east.ExposedByDefault)
+	assert.True(t, east.Watch)
+	assert.Equal(t, "docker.east", east.GetProviderName("docker"))
+
+	west := gc.Providers.Docker["west"]
+	assert.Equal(t, "tcp://west.example.com:2375", west.Endpoint)
+	assert.False(t, west.ExposedByDefault)
+	assert.False(t, west.Watch)
+	assert.Equal(t, "docker.west", west.GetProviderName("docker"))
+
+	// the instances don't share the slices and pointers of the defaults
+	assert.Equal(t, "ca.pem", east.TLS.CA)
+	assert.Equal(t, "west.pem", west.TLS.CA)
+	east.Constraints[0].Regex = "web"
+	assert.Equal(t, "api", west.Constraints[0].Regex)
+
+	// the defaults are left untouched
+	assert.Equal(t, "unix:///var/run/docker.sock", defaults.Docker.Endpoint)
+	assert.Equal(t, "ca.pem", defaults.Docker.TLS.CA)
+	assert.Equal(t, "api", defaults.Docker.Constraints[0].Regex)
+	assert.Equal(t, "docker", defaults.Docker.GetProviderName("docker"))
+
+	require.Len(t, gc.Providers.File, 1)
+	assert.Equal(t, "rules.toml", gc.Providers.File["rules"].Filename)
+
+	instances := gc.Providers.GetInstances()
+	require.Len(t, instances, 3)
+	assert.Equal(t, east, instances[0])
+	assert.Equal(t, west, instances[1])
+	assert.IsType(t, &file.Provider{}, instances[2])
+}
+
+func TestProvidersUnmarshalTOMLErrors(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		content string
+	}{
+		{
+			desc: "unknown provider type",
+			content: `
+[providers.foo.bar]
+  endpoint = "foo"
+`,
+		},
+		{
+			desc: "invalid instance",
+			content: `
+[providers.docker]
+  east = "foo"
+`,
+		},
+		{
+			desc: "invalid setting",
+			content: `
+[providers.docker.east]
+  watch = "foo"
+`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			gc := &GlobalConfiguration{Providers: NewProviders(nil)}
+			_, err := toml.Decode(test.content, gc)
+			assert.Error(t, err)
+		})
+	}
+}
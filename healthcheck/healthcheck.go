@@ -41,8 +41,9 @@ func (opt Options) String() string {
 // BackendHealthCheck HealthCheck configuration for a backend
 type BackendHealthCheck struct {
 	Options
-	disabledURLs   []*url.URL
-	requestTimeout time.Duration
+	disabledURLs    []*url.URL
+	disabledWeights map[string]int
+	requestTimeout  time.Duration
 }
 
 //HealthCheck struct
@@ -58,6 +59,11 @@ type LoadBalancer interface {
 	Servers() []*url.URL
 }
 
+// weighted is implemented by the load balancers exposing the weights of their servers.
+type weighted interface {
+	ServerWeight(u *url.URL) (int, bool)
+}
+
 func newHealthCheck() *HealthCheck {
 	return &HealthCheck{
 		Backends: make(map[string]*BackendHealthCheck),
@@ -113,7 +119,12 @@ func checkBackend(currentBackend *BackendHealthCheck) {
 	for _, url := range currentBackend.disabledURLs {
 		if checkHealth(url, currentBackend) {
 			log.Debugf("HealthCheck is up [%s]: Upsert in server list", url.String())
-			currentBackend.LB.UpsertServer(url, roundrobin.Weight(1))
+			weight, ok := currentBackend.disabledWeights[url.String()]
+			if !ok {
+				weight = 1
+			}
+			delete(currentBackend.disabledWeights, url.String())
+			currentBackend.LB.UpsertServer(url, roundrobin.Weight(weight))
 		} else {
 			log.Warnf("HealthCheck is still failing [%s]", url.String())
 			newDisabledURLs = append(newDisabledURLs, url)
@@ -124,6 +135,15 @@ func checkBackend(currentBackend *BackendHealthCheck) {
 	for _, url := range enabledURLs {
 		if !checkHealth(url, currentBackend) {
 			log.Warnf("HealthCheck has failed [%s]: Remove from server list", url.String())
+			// the weight of the server is restored when it is healthy again
+			if lb, ok := currentBackend.LB.(weighted); ok {
+				if weight, ok := lb.ServerWeight(url); ok {
+					if currentBackend.disabledWeights == nil {
+						currentBackend.disabledWeights = make(map[string]int)
+					}
+					currentBackend.disabledWeights[url.String()] = weight
+				}
+			}
 			currentBackend.LB.RemoveServer(url)
 			currentBackend.disabledURLs = append(currentBackend.disabledURLs, url)
 		}
@@ -122,6 +122,35 @@ func TestSetBackendsConfiguration(t *testing.T) {
 	}
 }
 
+func TestServerWeightRestored(t *testing.T) {
+	ts := newTestServer(func() {}, []bool{false, true})
+	defer ts.Close()
+
+	lb, err := roundrobin.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverURL := testhelpers.MustParseURL(ts.URL)
+	if err := lb.UpsertServer(serverURL, roundrobin.Weight(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := NewBackendHealthCheck(Options{
+		Path: "/path",
+		LB:   lb,
+	})
+
+	checkBackend(backend)
+	if len(lb.Servers()) != 0 {
+		t.Fatalf("got %d servers, wanted the sick server to be removed", len(lb.Servers()))
+	}
+
+	checkBackend(backend)
+	if weight, ok := lb.ServerWeight(serverURL); !ok || weight != 5 {
+		t.Errorf("got weight %d (found: %t), wanted 5", weight, ok)
+	}
+}
+
 func TestNewRequest(t *testing.T) {
 	tests := []struct {
 		desc     string
@@ -22,6 +22,7 @@ const (
 	ddMetricsReqsName    = "requests.total"
 	ddMetricsLatencyName = "request.duration"
 	ddRetriesTotalName   = "backend.retries.total"
+	ddCircuitBreakerName = "backend.circuitbreaker.state"
 )
 
 // RegisterDatadog registers the metrics pusher if this didn't happen yet and creates a datadog Registry instance.
@@ -35,6 +36,7 @@ func RegisterDatadog(config *types.Datadog) Registry {
 		reqsCounter:          datadogClient.NewCounter(ddMetricsReqsName, 1.0),
 		reqDurationHistogram: datadogClient.NewHistogram(ddMetricsLatencyName, 1.0),
 		retriesCounter:       datadogClient.NewCounter(ddRetriesTotalName, 1.0),
+		circuitBreakerGauge:  datadogClient.NewGauge(ddCircuitBreakerName),
 	}
 
 	return registry
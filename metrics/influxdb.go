@@ -28,6 +28,7 @@ const (
 	influxDBMetricsReqsName    = "traefik.requests.total"
 	influxDBMetricsLatencyName = "traefik.request.duration"
 	influxDBRetriesTotalName   = "traefik.backend.retries.total"
+	influxDBCircuitBreakerName = "traefik.backend.circuitbreaker.state"
 )
 
 // RegisterInfluxDB registers the metrics pusher if this didn't happen yet and creates a InfluxDB Registry instance.
@@ -41,6 +42,7 @@ func RegisterInfluxDB(config *types.InfluxDB) Registry {
 		reqsCounter:          influxDBClient.NewCounter(influxDBMetricsReqsName),
 		reqDurationHistogram: influxDBClient.NewHistogram(influxDBMetricsLatencyName),
 		retriesCounter:       influxDBClient.NewCounter(influxDBRetriesTotalName),
+		circuitBreakerGauge:  influxDBClient.NewGauge(influxDBCircuitBreakerName),
 	}
 }
 
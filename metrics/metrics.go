@@ -12,6 +12,8 @@ type Registry interface {
 	ReqsCounter() metrics.Counter
 	ReqDurationHistogram() metrics.Histogram
 	RetriesCounter() metrics.Counter
+	// CircuitBreakerStateGauge is the state of the circuit breakers: 0 for standby, 1 for tripped and 2 for recovering.
+	CircuitBreakerStateGauge() metrics.Gauge
 }
 
 // NewMultiRegistry creates a new standardRegistry that wraps multiple Registries.
@@ -19,11 +21,13 @@ func NewMultiRegistry(registries []Registry) Registry {
 	reqsCounters := []metrics.Counter{}
 	reqDurationHistograms := []metrics.Histogram{}
 	retriesCounters := []metrics.Counter{}
+	circuitBreakerStateGauges := []metrics.Gauge{}
 
 	for _, r := range registries {
 		reqsCounters = append(reqsCounters, r.ReqsCounter())
 		reqDurationHistograms = append(reqDurationHistograms, r.ReqDurationHistogram())
 		retriesCounters = append(retriesCounters, r.RetriesCounter())
+		circuitBreakerStateGauges = append(circuitBreakerStateGauges, r.CircuitBreakerStateGauge())
 	}
 
 	return &standardRegistry{
@@ -31,6 +35,7 @@ func NewMultiRegistry(registries []Registry) Registry {
 		reqsCounter:          multi.NewCounter(reqsCounters...),
 		reqDurationHistogram: multi.NewHistogram(reqDurationHistograms...),
 		retriesCounter:       multi.NewCounter(retriesCounters...),
+		circuitBreakerGauge:  multi.NewGauge(circuitBreakerStateGauges...),
 	}
 }
 
@@ -39,6 +44,7 @@ type standardRegistry struct {
 	reqsCounter          metrics.Counter
 	reqDurationHistogram metrics.Histogram
 	retriesCounter       metrics.Counter
+	circuitBreakerGauge  metrics.Gauge
 }
 
 func (r *standardRegistry) IsEnabled() bool {
@@ -57,6 +63,10 @@ func (r *standardRegistry) RetriesCounter() metrics.Counter {
 	return r.retriesCounter
 }
 
+func (r *standardRegistry) CircuitBreakerStateGauge() metrics.Gauge {
+	return r.circuitBreakerGauge
+}
+
 // NewVoidRegistry is a noop implementation of metrics.Registry.
 // It is used to avoid nil checking in components that do metric collections.
 func NewVoidRegistry() Registry {
@@ -65,6 +75,7 @@ func NewVoidRegistry() Registry {
 		reqsCounter:          &voidCounter{},
 		reqDurationHistogram: &voidHistogram{},
 		retriesCounter:       &voidCounter{},
+		circuitBreakerGauge:  &voidGauge{},
 	}
 }
 
@@ -73,6 +84,11 @@ type voidCounter struct{}
 func (v *voidCounter) With(labelValues ...string) metrics.Counter { return v }
 func (v *voidCounter) Add(delta float64)                          {}
 
+type voidGauge struct{}
+
+func (g *voidGauge) With(labelValues ...string) metrics.Gauge { return g }
+func (g *voidGauge) Set(value float64)                        {}
+
 type voidHistogram struct{}
 
 func (h *voidHistogram) With(labelValues ...string) metrics.Histogram { return h }
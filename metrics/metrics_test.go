@@ -16,6 +16,7 @@ func TestNewVoidRegistry(t *testing.T) {
 	registry.ReqsCounter().With("some", "value").Add(1)
 	registry.ReqDurationHistogram().With("some", "value").Observe(1)
 	registry.RetriesCounter().With("some", "value").Add(1)
+	registry.CircuitBreakerStateGauge().With("some", "value").Set(1)
 }
 
 func TestNewMultiRegistry(t *testing.T) {
@@ -25,11 +26,13 @@ func TestNewMultiRegistry(t *testing.T) {
 	registry.ReqsCounter().With("key", "requests").Add(1)
 	registry.ReqDurationHistogram().With("key", "durations").Observe(2)
 	registry.RetriesCounter().With("key", "retries").Add(3)
+	registry.CircuitBreakerStateGauge().With("key", "state").Set(2)
 
 	for _, collectingRegistry := range registries {
 		cReqsCounter := collectingRegistry.ReqsCounter().(*counterMock)
 		cReqDurationHistogram := collectingRegistry.ReqDurationHistogram().(*histogramMock)
 		cRetriesCounter := collectingRegistry.RetriesCounter().(*counterMock)
+		cCircuitBreakerGauge := collectingRegistry.CircuitBreakerStateGauge().(*gaugeMock)
 
 		wantCounterValue := float64(1)
 		if cReqsCounter.counterValue != wantCounterValue {
@@ -47,6 +50,8 @@ func TestNewMultiRegistry(t *testing.T) {
 		assert.Equal(t, []string{"key", "requests"}, cReqsCounter.lastLabelValues)
 		assert.Equal(t, []string{"key", "durations"}, cReqDurationHistogram.lastLabelValues)
 		assert.Equal(t, []string{"key", "retries"}, cRetriesCounter.lastLabelValues)
+		assert.Equal(t, float64(2), cCircuitBreakerGauge.gaugeValue)
+		assert.Equal(t, []string{"key", "state"}, cCircuitBreakerGauge.lastLabelValues)
 	}
 }
 
@@ -55,6 +60,7 @@ func newCollectingRetryMetrics() Registry {
 		reqsCounter:          &counterMock{},
 		reqDurationHistogram: &histogramMock{},
 		retriesCounter:       &counterMock{},
+		circuitBreakerGauge:  &gaugeMock{},
 	}
 }
 
@@ -85,3 +91,17 @@ func (c *histogramMock) With(labelValues ...string) metrics.Histogram {
 func (c *histogramMock) Observe(value float64) {
 	c.lastHistogramValue = value
 }
+
+type gaugeMock struct {
+	gaugeValue      float64
+	lastLabelValues []string
+}
+
+func (g *gaugeMock) With(labelValues ...string) metrics.Gauge {
+	g.lastLabelValues = labelValues
+	return g
+}
+
+func (g *gaugeMock) Set(value float64) {
+	g.gaugeValue = value
+}
@@ -14,6 +14,7 @@ const (
 	reqsTotalName    = metricNamePrefix + "requests_total"
 	reqDurationName  = metricNamePrefix + "request_duration_seconds"
 	retriesTotalName = metricNamePrefix + "backend_retries_total"
+	cbStateName      = metricNamePrefix + "backend_circuit_breaker_state"
 )
 
 // PrometheusHandler expose Prometheus routes
@@ -43,7 +44,11 @@ func RegisterPrometheus(config *types.Prometheus) Registry {
 	}, []string{"service", "code"})
 	retryCounter := prometheus.NewCounterFrom(stdprometheus.CounterOpts{
 		Name: retriesTotalName,
-		Help: "How many request retries happened in total.",
+		Help: "How many request retries happened in total, partitioned by reason.",
+	}, []string{"service", "reason"})
+	cbStateGauge := prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+		Name: cbStateName,
+		Help: "The state of the circuit breakers: 0 for standby, 1 for tripped and 2 for recovering.",
 	}, []string{"service"})
 
 	return &standardRegistry{
@@ -51,5 +56,6 @@ func RegisterPrometheus(config *types.Prometheus) Registry {
 		reqsCounter:          reqCounter,
 		reqDurationHistogram: reqDurationHistogram,
 		retriesCounter:       retryCounter,
+		circuitBreakerGauge:  cbStateGauge,
 	}
 }
@@ -20,7 +20,8 @@ func TestPrometheus(t *testing.T) {
 	prometheusRegistry.ReqsCounter().With("service", "test", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet).Add(1)
 	prometheusRegistry.ReqDurationHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK)).Observe(10000)
 	prometheusRegistry.ReqDurationHistogram().With("service", "test", "code", strconv.Itoa(http.StatusOK)).Observe(10000)
-	prometheusRegistry.RetriesCounter().With("service", "test").Add(1)
+	prometheusRegistry.RetriesCounter().With("service", "test", "reason", "502").Add(1)
+	prometheusRegistry.CircuitBreakerStateGauge().With("service", "test").Set(1)
 
 	metricsFamilies, err := prometheus.DefaultGatherer.Gather()
 	if err != nil {
@@ -65,6 +66,7 @@ func TestPrometheus(t *testing.T) {
 			name: retriesTotalName,
 			labels: map[string]string{
 				"service": "test",
+				"reason":  "502",
 			},
 			assert: func(family *dto.MetricFamily) {
 				cv := family.Metric[0].Counter.GetValue()
@@ -74,6 +76,19 @@ func TestPrometheus(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: cbStateName,
+			labels: map[string]string{
+				"service": "test",
+			},
+			assert: func(family *dto.MetricFamily) {
+				gv := family.Metric[0].Gauge.GetValue()
+				expectedGv := float64(1)
+				if gv != expectedGv {
+					t.Errorf("gathered metrics do not contain correct value for circuit breaker state, got %f expected %f", gv, expectedGv)
+				}
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -21,6 +21,7 @@ const (
 	statsdMetricsReqsName    = "requests.total"
 	statsdMetricsLatencyName = "request.duration"
 	statsdRetriesTotalName   = "backend.retries.total"
+	statsdCircuitBreakerName = "backend.circuitbreaker.state"
 )
 
 // RegisterStatsd registers the metrics pusher if this didn't happen yet and creates a statsd Registry instance.
@@ -34,6 +35,7 @@ func RegisterStatsd(config *types.Statsd) Registry {
 		reqsCounter:          statsdClient.NewCounter(statsdMetricsReqsName, 1.0),
 		reqDurationHistogram: statsdClient.NewTiming(statsdMetricsLatencyName, 1.0),
 		retriesCounter:       statsdClient.NewCounter(statsdRetriesTotalName, 1.0),
+		circuitBreakerGauge:  statsdClient.NewGauge(statsdCircuitBreakerName),
 	}
 }
 
@@ -70,6 +70,14 @@ const (
 	Overhead = "Overhead"
 	// RetryAttempts is the map key used for the amount of attempts the request was retried.
 	RetryAttempts = "RetryAttempts"
+	// RetryReasons is the map key used for the comma separated reasons of the retries:
+	// network_error, or the retried response status code.
+	RetryReasons = "RetryReasons"
+	// CacheStatus is the map key used for the status of the request in the cache of the frontend:
+	// HIT, MISS, STALE, REVALIDATED or BYPASS.
+	CacheStatus = "CacheStatus"
+	// RequestID is the map key used for the request ID of the request, when the entry point identifies its requests.
+	RequestID = "RequestID"
 )
 
 // These are written out in the default case when no config is provided to specify keys of interest.
@@ -113,6 +121,9 @@ func init() {
 	allCoreKeys[StartLocal] = struct{}{}
 	allCoreKeys[Overhead] = struct{}{}
 	allCoreKeys[RetryAttempts] = struct{}{}
+	allCoreKeys[RetryReasons] = struct{}{}
+	allCoreKeys[CacheStatus] = struct{}{}
+	allCoreKeys[RequestID] = struct{}{}
 }
 
 // CoreLogData holds the fields computed from the request/response.
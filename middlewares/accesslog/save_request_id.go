@@ -0,0 +1,15 @@
+package accesslog
+
+import (
+	"net/http"
+)
+
+// SaveRequestID is an implementation of RequestIDListener that stores the RequestID in the LogDataTable.
+type SaveRequestID struct{}
+
+// RequestIdentified implements the RequestIDListener interface and will be called for each request.
+func (s *SaveRequestID) RequestIdentified(req *http.Request, id string) {
+	if table, ok := req.Context().Value(DataTableKey).(*LogData); ok {
+		table.Core[RequestID] = id
+	}
+}
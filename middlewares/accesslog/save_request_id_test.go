@@ -0,0 +1,25 @@
+package accesslog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSaveRequestID(t *testing.T) {
+	saveRequestID := &SaveRequestID{}
+
+	logDataTable := &LogData{Core: make(CoreLogData)}
+	req := httptest.NewRequest(http.MethodGet, "/some/path", nil)
+	reqWithDataTable := req.WithContext(context.WithValue(req.Context(), DataTableKey, logDataTable))
+
+	saveRequestID.RequestIdentified(reqWithDataTable, "abc")
+
+	if logDataTable.Core[RequestID] != "abc" {
+		t.Errorf("got %v in logDataTable, want %v", logDataTable.Core[RequestID], "abc")
+	}
+
+	// without access log, the request ID is ignored
+	saveRequestID.RequestIdentified(req, "abc")
+}
@@ -4,11 +4,11 @@ import (
 	"net/http"
 )
 
-// SaveRetries is an implementation of RetryListener that stores RetryAttempts in the LogDataTable.
+// SaveRetries is an implementation of RetryListener that stores RetryAttempts and RetryReasons in the LogDataTable.
 type SaveRetries struct{}
 
 // Retried implements the RetryListener interface and will be called for each retry that happens.
-func (s *SaveRetries) Retried(req *http.Request, attempt int) {
+func (s *SaveRetries) Retried(req *http.Request, attempt int, reason string) {
 	// it is the request attempt x, but the retry attempt is x-1
 	if attempt > 0 {
 		attempt--
@@ -16,4 +16,9 @@ func (s *SaveRetries) Retried(req *http.Request, attempt int) {
 
 	table := GetLogDataTable(req)
 	table.Core[RetryAttempts] = attempt
+
+	if reasons, ok := table.Core[RetryReasons].(string); ok && len(reasons) > 0 {
+		reason = reasons + "," + reason
+	}
+	table.Core[RetryReasons] = reason
 }
@@ -38,7 +38,7 @@ func TestSaveRetries(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/some/path", nil)
 			reqWithDataTable := req.WithContext(context.WithValue(req.Context(), DataTableKey, logDataTable))
 
-			saveRetries.Retried(reqWithDataTable, test.requestAttempt)
+			saveRetries.Retried(reqWithDataTable, test.requestAttempt, "502")
 
 			if logDataTable.Core[RetryAttempts] != test.wantRetryAttemptsInLog {
 				t.Errorf("got %v in logDataTable, want %v", logDataTable.Core[RetryAttempts], test.wantRetryAttemptsInLog)
@@ -46,3 +46,21 @@ func TestSaveRetries(t *testing.T) {
 		})
 	}
 }
+
+func TestSaveRetriesReasons(t *testing.T) {
+	saveRetries := &SaveRetries{}
+
+	logDataTable := &LogData{Core: make(CoreLogData)}
+	req := httptest.NewRequest(http.MethodGet, "/some/path", nil)
+	reqWithDataTable := req.WithContext(context.WithValue(req.Context(), DataTableKey, logDataTable))
+
+	saveRetries.Retried(reqWithDataTable, 2, "network_error")
+	saveRetries.Retried(reqWithDataTable, 3, "503")
+
+	if logDataTable.Core[RetryAttempts] != 2 {
+		t.Errorf("got %v in logDataTable, want %v", logDataTable.Core[RetryAttempts], 2)
+	}
+	if logDataTable.Core[RetryReasons] != "network_error,503" {
+		t.Errorf("got %v in logDataTable, want %v", logDataTable.Core[RetryReasons], "network_error,503")
+	}
+}
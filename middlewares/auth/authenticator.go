@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	goauth "github.com/abbot/go-http-auth"
 	"github.com/containous/traefik/log"
@@ -12,25 +15,46 @@ import (
 	"github.com/urfave/negroni"
 )
 
-// Authenticator is a middleware that provides HTTP basic and digest authentication
+const (
+	defaultRealm        = "traefik"
+	authorizationHeader = "Authorization"
+	// usersFileCheckInterval is the minimum delay between two checks of the users file modification.
+	usersFileCheckInterval = time.Second
+)
+
+// Authenticator is a middleware that provides HTTP basic, digest, forward, JWT and OpenID Connect authentication
 type Authenticator struct {
 	handler negroni.Handler
-	users   map[string]string
+
+	usersLock        sync.Mutex
+	users            map[string]string
+	usersFile        string
+	usersFileModTime time.Time
+	usersFileChecked time.Time
+	loadUsers        func() (map[string]string, error)
 }
 
 // NewAuthenticator builds a new Authenticator given a config
 func NewAuthenticator(authConfig *types.Auth) (*Authenticator, error) {
+	return NewAuthenticatorWithTrustedIPs(authConfig, nil)
+}
+
+// NewAuthenticatorWithTrustedIPs builds a new Authenticator given a config,
+// honoring the forwarded headers, e.g. X-Forwarded-Proto, of the requests sent by the trusted IPs
+func NewAuthenticatorWithTrustedIPs(authConfig *types.Auth, trustedIPs []string) (*Authenticator, error) {
 	if authConfig == nil {
 		return nil, fmt.Errorf("Error creating Authenticator: auth is nil")
 	}
 	var err error
 	authenticator := Authenticator{}
 	if authConfig.Basic != nil {
-		authenticator.users, err = parserBasicUsers(authConfig.Basic)
-		if err != nil {
+		authenticator.loadUsers = func() (map[string]string, error) {
+			return parserBasicUsers(authConfig.Basic)
+		}
+		if err = authenticator.initUsers(authConfig.Basic.UsersFile); err != nil {
 			return nil, err
 		}
-		basicAuth := goauth.NewBasicAuthenticator("traefik", authenticator.secretBasic)
+		basicAuth := goauth.NewBasicAuthenticator(getRealm(authConfig.Basic.Realm), authenticator.secretBasic)
 		authenticator.handler = negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 			if username := basicAuth.CheckAuth(r); username == "" {
 				log.Debug("Basic auth failed...")
@@ -40,15 +64,20 @@ func NewAuthenticator(authConfig *types.Auth) (*Authenticator, error) {
 				if authConfig.HeaderField != "" {
 					r.Header[authConfig.HeaderField] = []string{username}
 				}
+				if authConfig.Basic.RemoveHeader {
+					r.Header.Del(authorizationHeader)
+				}
 				next.ServeHTTP(w, r)
 			}
 		})
 	} else if authConfig.Digest != nil {
-		authenticator.users, err = parserDigestUsers(authConfig.Digest)
-		if err != nil {
+		authenticator.loadUsers = func() (map[string]string, error) {
+			return parserDigestUsers(authConfig.Digest)
+		}
+		if err = authenticator.initUsers(authConfig.Digest.UsersFile); err != nil {
 			return nil, err
 		}
-		digestAuth := goauth.NewDigestAuthenticator("traefik", authenticator.secretDigest)
+		digestAuth := goauth.NewDigestAuthenticator(getRealm(authConfig.Digest.Realm), authenticator.secretDigest)
 		authenticator.handler = negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 			if username, _ := digestAuth.CheckAuth(r); username == "" {
 				log.Debug("Digest auth failed...")
@@ -58,17 +87,92 @@ func NewAuthenticator(authConfig *types.Auth) (*Authenticator, error) {
 				if authConfig.HeaderField != "" {
 					r.Header[authConfig.HeaderField] = []string{username}
 				}
+				if authConfig.Digest.RemoveHeader {
+					r.Header.Del(authorizationHeader)
+				}
 				next.ServeHTTP(w, r)
 			}
 		})
 	} else if authConfig.Forward != nil {
-		authenticator.handler = negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-			Forward(authConfig.Forward, w, r, next)
-		})
+		authenticator.handler, err = newForwardAuth(authConfig.Forward)
+		if err != nil {
+			return nil, err
+		}
+	} else if authConfig.JWT != nil {
+		authenticator.handler, err = newJWTAuth(authConfig.JWT, authConfig.HeaderField)
+		if err != nil {
+			return nil, err
+		}
+	} else if authConfig.OIDC != nil {
+		authenticator.handler, err = newOIDCAuth(authConfig.OIDC, authConfig.HeaderField, trustedIPs)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("Error creating Authenticator: no authentication method configured")
 	}
 	return &authenticator, nil
 }
 
+func getRealm(realm string) string {
+	if realm == "" {
+		return defaultRealm
+	}
+	return realm
+}
+
+// initUsers loads the users, and keeps track of the users file to reload it when it changes.
+func (a *Authenticator) initUsers(usersFile string) error {
+	users, err := a.loadUsers()
+	if err != nil {
+		return err
+	}
+	a.users = users
+
+	if usersFile != "" {
+		a.usersFile = usersFile
+		a.usersFileChecked = time.Now()
+		if info, err := os.Stat(usersFile); err == nil {
+			a.usersFileModTime = info.ModTime()
+		}
+	}
+	return nil
+}
+
+// getUsers returns the users, reloading them first if the users file has changed.
+func (a *Authenticator) getUsers() map[string]string {
+	if a.usersFile == "" {
+		return a.users
+	}
+
+	a.usersLock.Lock()
+	defer a.usersLock.Unlock()
+
+	if time.Since(a.usersFileChecked) < usersFileCheckInterval {
+		return a.users
+	}
+	a.usersFileChecked = time.Now()
+
+	info, err := os.Stat(a.usersFile)
+	if err != nil {
+		log.Errorf("Unable to check the users file %s: %s", a.usersFile, err)
+		return a.users
+	}
+	if info.ModTime().Equal(a.usersFileModTime) {
+		return a.users
+	}
+
+	users, err := a.loadUsers()
+	if err != nil {
+		log.Errorf("Unable to reload the users file %s, keeping the previous users: %s", a.usersFile, err)
+		return a.users
+	}
+	log.Debugf("Users file %s reloaded", a.usersFile)
+	a.users = users
+	a.usersFileModTime = info.ModTime()
+	return a.users
+}
+
 func getLinesFromFile(filename string) ([]string, error) {
 	dat, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -87,7 +191,7 @@ func getLinesFromFile(filename string) ([]string, error) {
 }
 
 func (a *Authenticator) secretBasic(user, realm string) string {
-	if secret, ok := a.users[user]; ok {
+	if secret, ok := a.getUsers()[user]; ok {
 		return secret
 	}
 	log.Debugf("User not found: %s", user)
@@ -95,7 +199,7 @@ func (a *Authenticator) secretBasic(user, realm string) string {
 }
 
 func (a *Authenticator) secretDigest(user, realm string) string {
-	if secret, ok := a.users[user+":"+realm]; ok {
+	if secret, ok := a.getUsers()[user+":"+realm]; ok {
 		return secret
 	}
 	log.Debugf("User not found: %s:%s", user, realm)
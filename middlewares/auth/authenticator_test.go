@@ -7,10 +7,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/containous/traefik/testhelpers"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/urfave/negroni"
 )
 
@@ -186,3 +188,91 @@ func TestBasicAuthUserHeader(t *testing.T) {
 	assert.NoError(t, err, "there should be no error")
 	assert.Equal(t, "traefik\n", string(body), "they should be equal")
 }
+
+func TestBasicAuthRealmAndRemoveHeader(t *testing.T) {
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		Basic: &types.Basic{
+			Users:        []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+			Realm:        "myrealm",
+			RemoveHeader: true,
+		},
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	})
+	n := negroni.New(authMiddleware)
+	n.UseHandler(handler)
+
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil)
+	rw := httptest.NewRecorder()
+	n.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	assert.Equal(t, `Basic realm="myrealm"`, rw.Header().Get("WWW-Authenticate"))
+
+	req = testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil)
+	req.SetBasicAuth("test", "test")
+	rw = httptest.NewRecorder()
+	n.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Empty(t, rw.Body.String())
+}
+
+func TestBasicAuthUsersFileReload(t *testing.T) {
+	usersFile, err := ioutil.TempFile("", "auth-users")
+	require.NoError(t, err)
+	defer os.Remove(usersFile.Name())
+
+	_, err = usersFile.Write([]byte("test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/\n"))
+	require.NoError(t, err)
+	require.NoError(t, usersFile.Close())
+
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		Basic: &types.Basic{
+			UsersFile: usersFile.Name(),
+		},
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "traefik")
+	})
+	n := negroni.New(authMiddleware)
+	n.UseHandler(handler)
+
+	check := func(user, password string) int {
+		req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil)
+		req.SetBasicAuth(user, password)
+		rw := httptest.NewRecorder()
+		n.ServeHTTP(rw, req)
+		return rw.Code
+	}
+
+	assert.Equal(t, http.StatusOK, check("test", "test"))
+	assert.Equal(t, http.StatusUnauthorized, check("test2", "test2"))
+
+	err = ioutil.WriteFile(usersFile.Name(), []byte("test2:$apr1$d9hr9HBB$4HxwgUir3HP4EsggP/QNo0\n"), 0644)
+	require.NoError(t, err)
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(usersFile.Name(), modTime, modTime))
+	// Skip the check interval.
+	authMiddleware.usersFileChecked = time.Time{}
+
+	assert.Equal(t, http.StatusUnauthorized, check("test", "test"))
+	assert.Equal(t, http.StatusOK, check("test2", "test2"))
+
+	// An invalid file keeps the previous users.
+	err = ioutil.WriteFile(usersFile.Name(), []byte("invalid\n"), 0644)
+	require.NoError(t, err)
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(usersFile.Name(), modTime, modTime))
+	authMiddleware.usersFileChecked = time.Time{}
+
+	assert.Equal(t, http.StatusOK, check("test2", "test2"))
+}
+
+func TestNewAuthenticatorWithoutMethod(t *testing.T) {
+	_, err := NewAuthenticator(&types.Auth{HeaderField: "X-WebAuth-User"})
+	assert.Error(t, err)
+}
@@ -1,10 +1,15 @@
 package auth
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/types"
@@ -12,29 +17,100 @@ import (
 	"github.com/vulcand/oxy/utils"
 )
 
-// Forward the authentication to a external server
-func Forward(config *types.Forward, w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+const (
+	defaultForwardDialTimeout = 30 * time.Second
+	defaultForwardMaxBodySize = 1024 * 1024
+)
 
-	// Ensure our request client does not follow redirects
-	httpClient := http.Client{
-		CheckRedirect: func(r *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
+// forwardAuth forwards the authentication to an external server.
+// It reuses the same HTTP client for all the requests.
+type forwardAuth struct {
+	config *types.Forward
+	client *http.Client
+	cache  *forwardCache
+}
+
+func newForwardAuth(config *types.Forward) (*forwardAuth, error) {
+	dialTimeout := defaultForwardDialTimeout
+	if config.DialTimeout > 0 {
+		dialTimeout = time.Duration(config.DialTimeout)
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
 	}
 
 	if config.TLS != nil {
 		tlsConfig, err := config.TLS.CreateTLSConfig()
 		if err != nil {
-			log.Debugf("Impossible to configure TLS to call %s. Cause %s", config.Address, err)
+			return nil, fmt.Errorf("impossible to configure TLS to call %s: %s", config.Address, err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	fa := &forwardAuth{
+		config: config,
+		client: &http.Client{
+			// Ensure our request client does not follow redirects
+			CheckRedirect: func(r *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+			Transport: transport,
+			Timeout:   time.Duration(config.Timeout),
+		},
+	}
+
+	if config.Cache != nil && config.Cache.TTL > 0 {
+		fa.cache = newForwardCache(config.Cache)
+	}
+
+	return fa, nil
+}
+
+func (fa *forwardAuth) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	config := fa.config
+
+	var body []byte
+	if config.ForwardBody && r.Body != nil {
+		maxBodySize := int64(defaultForwardMaxBodySize)
+		if config.MaxBodySize > 0 {
+			maxBodySize = config.MaxBodySize
+		}
+
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			log.Debugf("Error reading request body. Cause: %s", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		httpClient.Transport = &http.Transport{
-			TLSClientConfig: tlsConfig,
+		if int64(len(body)) > maxBodySize {
+			log.Debugf("Request body exceeds the maximum size of %d bytes for forward auth", maxBodySize)
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	var cacheKey string
+	if fa.cache != nil && !config.ForwardBody {
+		cacheKey = fa.cache.key(r, config)
+		if cached, ok := fa.cache.get(cacheKey); ok {
+			log.Debugf("Forward auth response found in cache for %s", config.Address)
+			fa.forwardSuccess(w, r, next, cached)
+			return
 		}
 	}
 
-	forwardReq, err := http.NewRequest(http.MethodGet, config.Address, nil)
+	forwardReq, err := fa.newForwardRequest(r, body)
 	if err != nil {
 		log.Debugf("Error calling %s. Cause %s", config.Address, err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -43,14 +119,14 @@ func Forward(config *types.Forward, w http.ResponseWriter, r *http.Request, next
 
 	writeHeader(r, forwardReq, config.TrustForwardHeader)
 
-	forwardResponse, forwardErr := httpClient.Do(forwardReq)
+	forwardResponse, forwardErr := fa.client.Do(forwardReq)
 	if forwardErr != nil {
 		log.Debugf("Error calling %s. Cause: %s", config.Address, forwardErr)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	body, readError := ioutil.ReadAll(forwardResponse.Body)
+	respBody, readError := ioutil.ReadAll(forwardResponse.Body)
 	if readError != nil {
 		log.Debugf("Error reading body %s. Cause: %s", config.Address, readError)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -83,14 +159,58 @@ func Forward(config *types.Forward, w http.ResponseWriter, r *http.Request, next
 		}
 
 		w.WriteHeader(forwardResponse.StatusCode)
-		w.Write(body)
+		w.Write(respBody)
 		return
 	}
 
+	if len(cacheKey) > 0 {
+		fa.cache.set(cacheKey, forwardResponse.Header)
+	}
+
+	fa.forwardSuccess(w, r, next, forwardResponse.Header)
+}
+
+// forwardSuccess copies the configured headers of the authentication server response
+// onto the request and calls the next handler.
+func (fa *forwardAuth) forwardSuccess(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, authHeaders http.Header) {
+	for _, headerName := range fa.config.AuthResponseHeaders {
+		headerKey := http.CanonicalHeaderKey(headerName)
+		// Drop the header sent by the client so it can't be spoofed.
+		r.Header.Del(headerKey)
+		if values, ok := authHeaders[headerKey]; ok {
+			r.Header[headerKey] = append([]string(nil), values...)
+		}
+	}
+
 	r.RequestURI = r.URL.RequestURI()
 	next(w, r)
 }
 
+func (fa *forwardAuth) newForwardRequest(r *http.Request, body []byte) (*http.Request, error) {
+	address := fa.config.Address
+	if fa.config.ForwardPath {
+		authURL, err := url.Parse(address)
+		if err != nil {
+			return nil, err
+		}
+		authURL.Path = strings.TrimSuffix(authURL.Path, "/") + r.URL.Path
+		authURL.RawQuery = r.URL.RawQuery
+		address = authURL.String()
+	}
+
+	method := http.MethodGet
+	if fa.config.ForwardMethod {
+		method = r.Method
+	}
+
+	var forwardBody io.Reader
+	if body != nil {
+		forwardBody = bytes.NewReader(body)
+	}
+
+	return http.NewRequest(method, address, forwardBody)
+}
+
 func writeHeader(req *http.Request, forwardReq *http.Request, trustForwardHeader bool) {
 	utils.CopyHeaders(forwardReq.Header, req.Header)
 
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/forward"
+)
+
+const defaultForwardCacheMaxEntries = 10000
+
+type forwardCacheEntry struct {
+	headers http.Header
+	expire  time.Time
+}
+
+// forwardCache keeps the successful authentication server responses for a given TTL.
+type forwardCache struct {
+	lock       sync.Mutex
+	ttl        time.Duration
+	keyHeaders []string
+	keyCookies []string
+	maxEntries int
+	entries    map[string]forwardCacheEntry
+}
+
+func newForwardCache(config *types.ForwardCache) *forwardCache {
+	maxEntries := defaultForwardCacheMaxEntries
+	if config.MaxEntries > 0 {
+		maxEntries = config.MaxEntries
+	}
+	return &forwardCache{
+		ttl:        time.Duration(config.TTL),
+		keyHeaders: config.KeyHeaders,
+		keyCookies: config.KeyCookies,
+		maxEntries: maxEntries,
+		entries:    make(map[string]forwardCacheEntry),
+	}
+}
+
+// key builds the cache key of a request.
+// An empty key is returned when the request holds none of the configured headers and cookies.
+func (c *forwardCache) key(r *http.Request, config *types.Forward) string {
+	var parts []string
+	found := false
+	for _, name := range c.keyHeaders {
+		value := strings.Join(r.Header[http.CanonicalHeaderKey(name)], ",")
+		if len(value) > 0 {
+			found = true
+		}
+		parts = append(parts, "h:"+name+"="+value)
+	}
+	for _, name := range c.keyCookies {
+		var value string
+		if cookie, err := r.Cookie(name); err == nil {
+			value = cookie.Value
+			found = true
+		}
+		parts = append(parts, "c:"+name+"="+value)
+	}
+	if !found {
+		return ""
+	}
+
+	// The authentication server may decide on anything it receives about the request:
+	// the requested host, method and path, and the client address, are always part of the key.
+	parts = append(parts, "host:"+r.Host, "m:"+r.Method, "p:"+r.URL.RequestURI())
+	if clientIP, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		parts = append(parts, "ip:"+clientIP)
+	}
+	if config.TrustForwardHeader {
+		for _, name := range []string{forward.XForwardedFor, forward.XForwardedHost, forward.XForwardedProto, forward.XForwardedPort} {
+			parts = append(parts, "f:"+name+"="+strings.Join(r.Header[name], ","))
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func (c *forwardCache) get(key string) (http.Header, bool) {
+	if len(key) == 0 {
+		return nil, false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expire) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.headers, true
+}
+
+func (c *forwardCache) set(key string, headers http.Header) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expire) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	// Still full: evict arbitrary entries to make room.
+	for k := range c.entries {
+		if len(c.entries) < c.maxEntries {
+			break
+		}
+		delete(c.entries, k)
+	}
+
+	c.entries[key] = forwardCacheEntry{
+		headers: cloneHeader(headers),
+		expire:  now.Add(c.ttl),
+	}
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for k, v := range h {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
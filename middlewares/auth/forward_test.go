@@ -5,11 +5,16 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/containous/flaeg"
 	"github.com/containous/traefik/testhelpers"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/urfave/negroni"
 )
 
@@ -156,6 +161,180 @@ func TestForwardAuthCookie(t *testing.T) {
 	assert.Equal(t, "Forbidden\n", string(body), "they should be equal")
 }
 
+func TestForwardAuthResponseHeaders(t *testing.T) {
+	authTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Auth-User", "user@example.com")
+		fmt.Fprintln(w, "Success")
+	}))
+	defer authTs.Close()
+
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		Forward: &types.Forward{
+			Address:             authTs.URL,
+			AuthResponseHeaders: []string{"X-Auth-User", "X-Auth-Group"},
+		},
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("X-Auth-User"), r.Header.Get("X-Auth-Group"))
+	})
+	n := negroni.New(authMiddleware)
+	n.UseHandler(handler)
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	req := testhelpers.MustNewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("X-Auth-User", "spoofed")
+	req.Header.Set("X-Auth-Group", "admin")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com|", string(body))
+}
+
+func TestForwardAuthForwardRequest(t *testing.T) {
+	authTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Auth-Seen", fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), body))
+		fmt.Fprintln(w, "Success")
+	}))
+	defer authTs.Close()
+
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		Forward: &types.Forward{
+			Address:             authTs.URL + "/auth/",
+			ForwardMethod:       true,
+			ForwardPath:         true,
+			ForwardBody:         true,
+			MaxBodySize:         10,
+			AuthResponseHeaders: []string{"X-Auth-Seen"},
+		},
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("X-Auth-Seen"), body)
+	})
+	n := negroni.New(authMiddleware)
+	n.UseHandler(handler)
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	req := testhelpers.MustNewRequest(http.MethodPost, ts.URL+"/foo?bar=baz", strings.NewReader("payload"))
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "POST /auth/foo?bar=baz payload|payload", string(body))
+
+	req = testhelpers.MustNewRequest(http.MethodPost, ts.URL, strings.NewReader("payload too large"))
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+}
+
+func TestForwardAuthCache(t *testing.T) {
+	var calls int32
+	authTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") != "Bearer valid" {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintln(w, "Success")
+	}))
+	defer authTs.Close()
+
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		Forward: &types.Forward{
+			Address: authTs.URL,
+			Cache: &types.ForwardCache{
+				TTL:        flaeg.Duration(time.Minute),
+				KeyHeaders: []string{"Authorization"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "traefik")
+	})
+	n := negroni.New(authMiddleware)
+	n.UseHandler(handler)
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	for i := 0; i < 3; i++ {
+		req := testhelpers.MustNewRequest(http.MethodGet, ts.URL, nil)
+		req.Header.Set("Authorization", "Bearer valid")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// Another host, method or path is authenticated again.
+	otherHost := testhelpers.MustNewRequest(http.MethodGet, ts.URL, nil)
+	otherHost.Host = "other.localhost"
+	for _, req := range []*http.Request{
+		otherHost,
+		testhelpers.MustNewRequest(http.MethodPost, ts.URL, nil),
+		testhelpers.MustNewRequest(http.MethodGet, ts.URL+"/admin", nil),
+	} {
+		req.Header.Set("Authorization", "Bearer valid")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+	assert.EqualValues(t, 4, atomic.LoadInt32(&calls))
+
+	// Failed authentications are never cached.
+	for i := 0; i < 2; i++ {
+		req := testhelpers.MustNewRequest(http.MethodGet, ts.URL, nil)
+		req.Header.Set("Authorization", "Bearer invalid")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	}
+	assert.EqualValues(t, 6, atomic.LoadInt32(&calls))
+}
+
+func TestForwardAuthTimeout(t *testing.T) {
+	authTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprintln(w, "Success")
+	}))
+	defer authTs.Close()
+
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		Forward: &types.Forward{
+			Address: authTs.URL,
+			Timeout: flaeg.Duration(50 * time.Millisecond),
+		},
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "traefik")
+	})
+	n := negroni.New(authMiddleware)
+	n.UseHandler(handler)
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	req := testhelpers.MustNewRequest(http.MethodGet, ts.URL, nil)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
 func Test_writeHeader(t *testing.T) {
 
 	testCases := []struct {
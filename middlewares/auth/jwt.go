@@ -0,0 +1,406 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	traefikTls "github.com/containous/traefik/tls"
+	"github.com/containous/traefik/types"
+	"github.com/dgrijalva/jwt-go"
+	"gopkg.in/square/go-jose.v1"
+)
+
+const (
+	defaultJWKSRefresh = time.Hour
+	// minimum delay between two JWKS fetches triggered by an unknown key ID.
+	minJWKSRefresh = 10 * time.Second
+)
+
+// jwtAuth validates the JWT bearer token of the requests.
+type jwtAuth struct {
+	config      *types.JWT
+	headerField string
+	staticKeys  []interface{}
+	jwks        *jwksCache
+	parser      *jwt.Parser
+}
+
+func newJWTAuth(config *types.JWT, headerField string) (*jwtAuth, error) {
+	ja := &jwtAuth{
+		config:      config,
+		headerField: headerField,
+		parser:      &jwt.Parser{},
+	}
+
+	for _, key := range config.Keys {
+		content, err := traefikTls.FileOrContent(key).Read()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read JWT key: %s", err)
+		}
+		jwtKey, err := parseJWTKey(content)
+		if err != nil {
+			return nil, err
+		}
+		ja.staticKeys = append(ja.staticKeys, jwtKey)
+	}
+
+	if len(config.JWKSURL) > 0 {
+		refresh := defaultJWKSRefresh
+		if config.JWKSRefresh > 0 {
+			refresh = time.Duration(config.JWKSRefresh)
+		}
+		ja.jwks = &jwksCache{
+			url:     config.JWKSURL,
+			refresh: refresh,
+			client:  &http.Client{Timeout: 10 * time.Second},
+		}
+	}
+
+	if len(ja.staticKeys) == 0 && ja.jwks == nil {
+		return nil, errors.New("JWT auth requires at least one key or a JWKS URL")
+	}
+
+	if len(config.Algorithms) > 0 {
+		for _, alg := range config.Algorithms {
+			if jwt.GetSigningMethod(alg) == nil {
+				return nil, fmt.Errorf("unknown JWT signing algorithm %q", alg)
+			}
+		}
+		ja.parser.ValidMethods = config.Algorithms
+
+		// each key is only used with the algorithms of its type
+		for _, key := range ja.staticKeys {
+			if !keyMatchesAlgorithms(key, config.Algorithms) {
+				return nil, fmt.Errorf("JWT key of type %T matches none of the algorithms %v", key, config.Algorithms)
+			}
+		}
+	}
+
+	return ja, nil
+}
+
+// parseJWTKey parses a PEM encoded RSA or ECDSA public key, or returns the content as an HMAC secret.
+// PEM content is never used as an HMAC secret: a public key must not be usable to sign HS256 tokens.
+func parseJWTKey(content []byte) (interface{}, error) {
+	if !strings.Contains(string(content), "-----BEGIN") {
+		return content, nil
+	}
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(content); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(content); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unable to parse JWT key: PEM content is not an RSA or ECDSA public key")
+}
+
+// keyMatchesAlgorithms checks that a key can verify at least one of the algorithms.
+func keyMatchesAlgorithms(key interface{}, algorithms []string) bool {
+	for _, alg := range algorithms {
+		if keyMatchesMethod(key, jwt.GetSigningMethod(alg)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ja *jwtAuth) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	tokenString := extractBearerToken(r)
+	if len(tokenString) == 0 {
+		log.Debug("JWT auth failed: no bearer token")
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := ja.validate(tokenString)
+	if err != nil {
+		log.Debugf("JWT auth failed: %s", err)
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	log.Debug("JWT auth success...")
+	if ja.headerField != "" {
+		r.Header.Del(ja.headerField)
+		if subject, ok := claims.MapClaims["sub"]; ok {
+			r.Header[ja.headerField] = []string{claimToString(subject)}
+		}
+	}
+	for claim, header := range ja.config.ClaimsToHeaders {
+		// Drop the header sent by the client so it can't be spoofed.
+		r.Header.Del(header)
+		if value, ok := claims.MapClaims[claim]; ok {
+			r.Header.Set(header, claimToString(value))
+		}
+	}
+	next.ServeHTTP(w, r)
+}
+
+func extractBearerToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
+
+// validate checks the token signature and claims.
+func (ja *jwtAuth) validate(tokenString string) (*jwtClaims, error) {
+	var lastErr error
+	// The key ID and algorithm are only known once the token header is parsed,
+	// so the candidate keys are resolved in the key function.
+	var candidates []interface{}
+	resolved := false
+	keyFunc := func(index int) jwt.Keyfunc {
+		return func(token *jwt.Token) (interface{}, error) {
+			if !resolved {
+				kid, _ := token.Header["kid"].(string)
+				keys, err := ja.keys(kid)
+				if err != nil {
+					return nil, err
+				}
+				for _, key := range keys {
+					if keyMatchesMethod(key, token.Method) {
+						candidates = append(candidates, key)
+					}
+				}
+				resolved = true
+			}
+			if index >= len(candidates) {
+				return nil, fmt.Errorf("no key matching the %s algorithm", token.Method.Alg())
+			}
+			return candidates[index], nil
+		}
+	}
+
+	for i := 0; !resolved || i < len(candidates); i++ {
+		claims := &jwtClaims{clockSkew: time.Duration(ja.config.ClockSkew)}
+		token, err := ja.parser.ParseWithClaims(tokenString, claims, keyFunc(i))
+		if err == nil && token.Valid {
+			return claims, ja.checkClaims(claims)
+		}
+		lastErr = err
+
+		// Only a bad signature may be fixed by another key.
+		if !resolved {
+			break
+		}
+		if vErr, ok := err.(*jwt.ValidationError); !ok || vErr.Errors&jwt.ValidationErrorSignatureInvalid == 0 {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func (ja *jwtAuth) keys(kid string) ([]interface{}, error) {
+	if ja.jwks == nil {
+		return ja.staticKeys, nil
+	}
+	jwksKeys, err := ja.jwks.keys(kid)
+	if err != nil && len(ja.staticKeys) == 0 {
+		return nil, err
+	}
+	return append(jwksKeys, ja.staticKeys...), nil
+}
+
+func (ja *jwtAuth) checkClaims(claims *jwtClaims) error {
+	if len(ja.config.Issuer) > 0 && !claims.VerifyIssuer(ja.config.Issuer, true) {
+		return errors.New("token issuer is invalid")
+	}
+
+	if len(ja.config.Audience) > 0 && !claims.hasAudience(ja.config.Audience) {
+		return errors.New("token audience is invalid")
+	}
+
+	for claim, expected := range ja.config.RequiredClaims {
+		value, ok := claims.MapClaims[claim]
+		if !ok {
+			return fmt.Errorf("token claim %q is missing", claim)
+		}
+		if len(expected) > 0 && !claimContains(value, expected) {
+			return fmt.Errorf("token claim %q is invalid", claim)
+		}
+	}
+	return nil
+}
+
+// keyMatchesMethod binds the signing methods to the key types, so that a public key is never used as an HMAC secret.
+func keyMatchesMethod(key interface{}, method jwt.SigningMethod) bool {
+	switch key.(type) {
+	case []byte:
+		_, ok := method.(*jwt.SigningMethodHMAC)
+		return ok
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	}
+	return false
+}
+
+// jwtClaims holds the token claims and checks the time based claims with a clock skew.
+type jwtClaims struct {
+	jwt.MapClaims
+	clockSkew time.Duration
+}
+
+func (c *jwtClaims) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &c.MapClaims)
+}
+
+func (c *jwtClaims) Valid() error {
+	now := time.Now()
+	if !c.VerifyExpiresAt(now.Add(-c.clockSkew).Unix(), false) {
+		return errors.New("token is expired")
+	}
+	if !c.VerifyIssuedAt(now.Add(c.clockSkew).Unix(), false) {
+		return errors.New("token used before issued")
+	}
+	if !c.VerifyNotBefore(now.Add(c.clockSkew).Unix(), false) {
+		return errors.New("token is not valid yet")
+	}
+	return nil
+}
+
+// hasAudience checks the aud claim, which can be a string or an array of strings.
+func (c *jwtClaims) hasAudience(audiences []string) bool {
+	for _, audience := range audiences {
+		if claimContains(c.MapClaims["aud"], audience) {
+			return true
+		}
+	}
+	return false
+}
+
+func claimContains(value interface{}, expected string) bool {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if claimToString(item) == expected {
+				return true
+			}
+		}
+		return false
+	default:
+		return claimToString(v) == expected
+	}
+}
+
+func claimToString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, claimToString(item))
+		}
+		return strings.Join(values, ",")
+	case map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jwksCache fetches and caches the keys of a JSON Web Key Set.
+// The set is refreshed periodically, and when an unknown key ID is requested.
+// The previous keys are still served while the set is fetched.
+type jwksCache struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	lock      sync.Mutex
+	keySet    *jose.JsonWebKeySet
+	lastFetch time.Time
+	// fetching is closed when the fetch in progress completes, and is nil when there is none.
+	fetching chan struct{}
+}
+
+func (c *jwksCache) keys(kid string) ([]interface{}, error) {
+	c.lock.Lock()
+
+	elapsed := time.Since(c.lastFetch)
+	unknownKey := c.keySet == nil || len(kid) > 0 && len(c.find(kid)) == 0
+	fetching := c.fetching
+	if fetching == nil && (elapsed > c.refresh || unknownKey && elapsed > minJWKSRefresh) {
+		fetching = make(chan struct{})
+		c.fetching = fetching
+		c.lastFetch = time.Now()
+		c.lock.Unlock()
+
+		keySet, err := c.fetch()
+
+		c.lock.Lock()
+		if err != nil {
+			log.Errorf("Unable to fetch JWKS from %s: %s", c.url, err)
+		} else {
+			c.keySet = keySet
+		}
+		c.fetching = nil
+		close(fetching)
+	} else if fetching != nil && unknownKey {
+		// the key may come with the set being fetched
+		c.lock.Unlock()
+		<-fetching
+		c.lock.Lock()
+	}
+	defer c.lock.Unlock()
+
+	if c.keySet == nil {
+		return nil, fmt.Errorf("no JWKS available from %s", c.url)
+	}
+	return c.find(kid), nil
+}
+
+func (c *jwksCache) find(kid string) []interface{} {
+	if c.keySet == nil {
+		return nil
+	}
+
+	var keys []interface{}
+	for _, key := range c.keySet.Keys {
+		if len(kid) > 0 && key.KeyID != kid {
+			continue
+		}
+		if key.Use == "enc" {
+			continue
+		}
+		keys = append(keys, key.Key)
+	}
+	return keys
+}
+
+func (c *jwksCache) fetch() (*jose.JsonWebKeySet, error) {
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	keySet := &jose.JsonWebKeySet{}
+	if err := json.NewDecoder(resp.Body).Decode(keySet); err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+	"gopkg.in/square/go-jose.v1"
+)
+
+func TestJWTAuth(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pubPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+
+	hmacToken := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return token
+	}
+	rsaToken := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(rsaKey)
+		require.NoError(t, err)
+		return token
+	}
+
+	now := time.Now()
+
+	testCases := []struct {
+		desc           string
+		config         *types.JWT
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "missing token",
+			config:         &types.JWT{Keys: []string{"secret"}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "valid HMAC token",
+			config:         &types.JWT{Keys: []string{"secret"}},
+			token:          hmacToken(jwt.MapClaims{"sub": "foo"}),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "foo|",
+		},
+		{
+			desc:           "wrong HMAC secret",
+			config:         &types.JWT{Keys: []string{"other"}},
+			token:          hmacToken(jwt.MapClaims{"sub": "foo"}),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "valid RSA token with several keys",
+			config:         &types.JWT{Keys: []string{"secret", pubPEM}},
+			token:          rsaToken(jwt.MapClaims{"sub": "foo"}),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "foo|",
+		},
+		{
+			desc:           "RSA public key used as HMAC secret",
+			config:         &types.JWT{Keys: []string{pubPEM}},
+			token:          hmacToken(jwt.MapClaims{"sub": "foo"}),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "algorithm not allowed",
+			config:         &types.JWT{Keys: []string{"secret"}, Algorithms: []string{"HS512"}},
+			token:          hmacToken(jwt.MapClaims{"sub": "foo"}),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "expired token",
+			config:         &types.JWT{Keys: []string{"secret"}},
+			token:          hmacToken(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "expired token within clock skew",
+			config:         &types.JWT{Keys: []string{"secret"}, ClockSkew: flaeg.Duration(2 * time.Minute)},
+			token:          hmacToken(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "|",
+		},
+		{
+			desc:           "issuer and audience",
+			config:         &types.JWT{Keys: []string{"secret"}, Issuer: "https://issuer", Audience: []string{"api"}},
+			token:          hmacToken(jwt.MapClaims{"iss": "https://issuer", "aud": []string{"web", "api"}}),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "|",
+		},
+		{
+			desc:           "wrong audience",
+			config:         &types.JWT{Keys: []string{"secret"}, Audience: []string{"api"}},
+			token:          hmacToken(jwt.MapClaims{"aud": "web"}),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "required claims",
+			config:         &types.JWT{Keys: []string{"secret"}, RequiredClaims: map[string]string{"roles": "admin", "email": ""}},
+			token:          hmacToken(jwt.MapClaims{"roles": []string{"user", "admin"}, "email": "foo@bar.com"}),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "|user,admin",
+		},
+		{
+			desc:           "missing required claim",
+			config:         &types.JWT{Keys: []string{"secret"}, RequiredClaims: map[string]string{"roles": "admin"}},
+			token:          hmacToken(jwt.MapClaims{"roles": []string{"user"}}),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			test.config.ClaimsToHeaders = map[string]string{"roles": "X-Roles"}
+			authMiddleware, err := NewAuthenticator(&types.Auth{JWT: test.config, HeaderField: "X-User"})
+			require.NoError(t, err)
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "%s|%s", r.Header.Get("X-User"), r.Header.Get("X-Roles"))
+			})
+			n := negroni.New(authMiddleware)
+			n.UseHandler(handler)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil)
+			req.Header.Set("X-Roles", "spoofed")
+			if len(test.token) > 0 {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			rw := httptest.NewRecorder()
+			n.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expectedStatus, rw.Code)
+			if test.expectedStatus == http.StatusOK {
+				assert.Equal(t, test.expectedBody, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWTAuthJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwksCalls := 0
+	jwksTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwksCalls++
+		json.NewEncoder(w).Encode(jose.JsonWebKeySet{
+			Keys: []jose.JsonWebKey{{Key: &rsaKey.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"}},
+		})
+	}))
+	defer jwksTs.Close()
+
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		JWT: &types.JWT{JWKSURL: jwksTs.URL},
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "traefik")
+	})
+	n := negroni.New(authMiddleware)
+	n.UseHandler(handler)
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "foo"})
+	token.Header["kid"] = "key1"
+	tokenString, err := token.SignedString(rsaKey)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		req := testhelpers.MustNewRequest(http.MethodGet, ts.URL, nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "traefik\n", string(body))
+	}
+
+	// An unknown key ID must not trigger a new fetch right after the previous one.
+	token.Header["kid"] = "key2"
+	tokenString, err = token.SignedString(rsaKey)
+	require.NoError(t, err)
+
+	req := testhelpers.MustNewRequest(http.MethodGet, ts.URL, nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	assert.Equal(t, 1, jwksCalls)
+}
+
+func TestJWKSCacheServesKeysDuringFetch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var calls int32
+	release := make(chan struct{})
+	jwksTs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) > 1 {
+			<-release
+		}
+		json.NewEncoder(w).Encode(jose.JsonWebKeySet{
+			Keys: []jose.JsonWebKey{{Key: &rsaKey.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"}},
+		})
+	}))
+	defer jwksTs.Close()
+
+	cache := &jwksCache{url: jwksTs.URL, refresh: time.Hour, client: http.DefaultClient}
+	keys, err := cache.keys("key1")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	// The refresh is blocked on the server, the previous keys are still served.
+	cache.lock.Lock()
+	cache.lastFetch = time.Time{}
+	cache.lock.Unlock()
+
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		cache.keys("key1")
+	}()
+	for atomic.LoadInt32(&calls) < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	keys, err = cache.keys("key1")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	close(release)
+	<-refreshed
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func TestNewJWTAuthErrors(t *testing.T) {
+	_, err := NewAuthenticator(&types.Auth{JWT: &types.JWT{}})
+	assert.Error(t, err)
+
+	_, err = NewAuthenticator(&types.Auth{JWT: &types.JWT{Keys: []string{"secret"}, Algorithms: []string{"foo"}}})
+	assert.Error(t, err)
+
+	// an unparseable PEM key is not used as an HMAC secret
+	_, err = NewAuthenticator(&types.Auth{JWT: &types.JWT{Keys: []string{"-----BEGIN PUBLIC KEY-----\ninvalid\n-----END PUBLIC KEY-----\n"}}})
+	assert.Error(t, err)
+
+	// the HMAC secret can't verify RS256 tokens
+	_, err = NewAuthenticator(&types.Auth{JWT: &types.JWT{Keys: []string{"secret"}, Algorithms: []string{"RS256"}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,544 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/containous/traefik/whitelist"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultOIDCCallbackPath = "/oauth2/callback"
+	defaultOIDCCookieName   = "_traefik_oidc"
+	defaultOIDCSessionTTL   = 24 * time.Hour
+	defaultOIDCTokenTTL     = 5 * time.Minute
+	oidcStateTTL            = 10 * time.Minute
+	oidcMinDiscoveryBackoff = time.Second
+	oidcMaxDiscoveryBackoff = time.Minute
+)
+
+// errOIDCDiscoveryBackoff is returned until a failed discovery can be retried.
+var errOIDCDiscoveryBackoff = errors.New("the discovery of the OIDC provider failed and is not retried yet")
+
+var defaultOIDCScopes = []string{"openid", "profile", "email"}
+
+// oidcAuth is an OpenID Connect relying party.
+// Authenticated users get an encrypted session cookie holding their identity.
+type oidcAuth struct {
+	config       *types.OIDC
+	headerField  string
+	aead         cipher.AEAD
+	client       *http.Client
+	callbackPath string
+	cookieName   string
+	sessionTTL   time.Duration
+	tokenTTL     time.Duration
+	// trustedProxies are the addresses allowed to set the X-Forwarded-Proto header, nil when there is none
+	trustedProxies *whitelist.IP
+
+	lock     sync.Mutex
+	provider *oidcProvider
+	// discoveryBackoff is the delay before retrying a failed discovery, doubled on each failure
+	discoveryBackoff time.Duration
+	retryDiscoveryAt time.Time
+}
+
+// oidcProvider holds the configuration discovered from the OpenID Connect provider.
+type oidcProvider struct {
+	oauth2   oauth2.Config
+	verifier *jwtAuth
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcSession struct {
+	Claims       map[string]interface{} `json:"c,omitempty"`
+	RefreshToken string                 `json:"r,omitempty"`
+	Expiry       int64                  `json:"e"`
+	Created      int64                  `json:"t"`
+}
+
+type oidcState struct {
+	State string `json:"s"`
+	Nonce string `json:"n"`
+	URL   string `json:"u"`
+}
+
+func newOIDCAuth(config *types.OIDC, headerField string, trustedIPs []string) (*oidcAuth, error) {
+	if len(config.Issuer) == 0 || len(config.ClientID) == 0 {
+		return nil, errors.New("OIDC auth requires an issuer and a client ID")
+	}
+	if len(config.Secret) == 0 {
+		return nil, errors.New("OIDC auth requires a secret to encrypt the session cookie")
+	}
+
+	key := sha256.Sum256([]byte(config.Secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	oa := &oidcAuth{
+		config:       config,
+		headerField:  headerField,
+		aead:         aead,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		callbackPath: defaultOIDCCallbackPath,
+		cookieName:   defaultOIDCCookieName,
+		sessionTTL:   defaultOIDCSessionTTL,
+		tokenTTL:     defaultOIDCTokenTTL,
+	}
+
+	if len(config.RedirectURL) > 0 {
+		redirectURL, err := url.Parse(config.RedirectURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OIDC redirect URL: %s", err)
+		}
+		oa.callbackPath = redirectURL.Path
+	}
+	if len(config.CookieName) > 0 {
+		oa.cookieName = config.CookieName
+	}
+	if config.SessionTTL > 0 {
+		oa.sessionTTL = time.Duration(config.SessionTTL)
+	}
+	if config.TokenTTL > 0 {
+		oa.tokenTTL = time.Duration(config.TokenTTL)
+	}
+	if len(trustedIPs) > 0 {
+		oa.trustedProxies, err = whitelist.NewIP(trustedIPs, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OIDC trusted IPs: %s", err)
+		}
+	}
+
+	return oa, nil
+}
+
+func (oa *oidcAuth) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.URL.Path == oa.callbackPath {
+		oa.callback(w, r)
+		return
+	}
+
+	if len(oa.config.LogoutPath) > 0 && r.URL.Path == oa.config.LogoutPath {
+		oa.clearCookie(w, r, oa.cookieName)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Logged out"))
+		return
+	}
+
+	provider, err := oa.getProvider()
+	if err != nil {
+		oa.discoveryFailed(w, err)
+		return
+	}
+
+	session := oa.readSession(r)
+	refreshed := false
+	if session != nil && time.Now().Unix() > session.Expiry {
+		expired := session
+		session = nil
+		if len(expired.RefreshToken) > 0 {
+			session, err = oa.refresh(r, provider, expired)
+			if err != nil {
+				log.Debugf("OIDC token refresh failed: %s", err)
+			}
+			refreshed = session != nil
+		}
+	}
+
+	if session == nil {
+		oa.redirectToProvider(w, r, provider)
+		return
+	}
+
+	if refreshed {
+		if err := oa.writeCookie(w, r, oa.cookieName, session, oa.sessionTTL-time.Since(time.Unix(session.Created, 0))); err != nil {
+			log.Errorf("Unable to write the OIDC session cookie: %s", err)
+		}
+	}
+
+	log.Debug("OIDC auth success...")
+	if oa.headerField != "" {
+		r.Header.Del(oa.headerField)
+		if subject, ok := session.Claims["sub"]; ok {
+			r.Header[oa.headerField] = []string{claimToString(subject)}
+		}
+	}
+	for claim, header := range oa.config.ClaimsToHeaders {
+		// Drop the header sent by the client so it can't be spoofed.
+		r.Header.Del(header)
+		if value, ok := session.Claims[claim]; ok {
+			r.Header.Set(header, claimToString(value))
+		}
+	}
+	next.ServeHTTP(w, r)
+}
+
+// getProvider discovers the provider endpoints the first time it is called.
+// A failed discovery is retried after a backoff, errOIDCDiscoveryBackoff being returned meanwhile.
+func (oa *oidcAuth) getProvider() (*oidcProvider, error) {
+	oa.lock.Lock()
+	defer oa.lock.Unlock()
+
+	if oa.provider != nil {
+		return oa.provider, nil
+	}
+	if time.Now().Before(oa.retryDiscoveryAt) {
+		return nil, errOIDCDiscoveryBackoff
+	}
+
+	provider, err := oa.discover()
+	if err != nil {
+		oa.discoveryBackoff *= 2
+		if oa.discoveryBackoff < oidcMinDiscoveryBackoff {
+			oa.discoveryBackoff = oidcMinDiscoveryBackoff
+		} else if oa.discoveryBackoff > oidcMaxDiscoveryBackoff {
+			oa.discoveryBackoff = oidcMaxDiscoveryBackoff
+		}
+		oa.retryDiscoveryAt = time.Now().Add(oa.discoveryBackoff)
+		return nil, err
+	}
+
+	oa.provider = provider
+	return oa.provider, nil
+}
+
+func (oa *oidcAuth) discoveryFailed(w http.ResponseWriter, err error) {
+	if err == errOIDCDiscoveryBackoff {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	log.Errorf("Unable to discover the OIDC provider %s: %s", oa.config.Issuer, err)
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+// discover fetches the provider configuration.
+func (oa *oidcAuth) discover() (*oidcProvider, error) {
+	resp, err := oa.client.Get(strings.TrimSuffix(oa.config.Issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	discovery := &oidcDiscovery{}
+	if err := json.NewDecoder(resp.Body).Decode(discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(oa.config.Issuer, "/") {
+		return nil, fmt.Errorf("issuer %q does not match the configured issuer", discovery.Issuer)
+	}
+
+	verifier, err := newJWTAuth(&types.JWT{
+		JWKSURL:  discovery.JWKSURI,
+		Issuer:   discovery.Issuer,
+		Audience: []string{oa.config.ClientID},
+	}, "")
+	if err != nil {
+		return nil, err
+	}
+	verifier.jwks.client = oa.client
+
+	scopes := oa.config.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultOIDCScopes
+	}
+
+	return &oidcProvider{
+		oauth2: oauth2.Config{
+			ClientID:     oa.config.ClientID,
+			ClientSecret: oa.config.ClientSecret,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  discovery.AuthorizationEndpoint,
+				TokenURL: discovery.TokenEndpoint,
+			},
+		},
+		verifier: verifier,
+	}, nil
+}
+
+func (oa *oidcAuth) redirectToProvider(w http.ResponseWriter, r *http.Request, provider *oidcProvider) {
+	if r.Header.Get("X-Requested-With") == "XMLHttpRequest" {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	state := &oidcState{URL: oa.requestBaseURL(r) + r.URL.RequestURI()}
+	var err error
+	if state.State, err = randomString(); err == nil {
+		state.Nonce, err = randomString()
+	}
+	if err != nil {
+		log.Errorf("Unable to generate the OIDC state: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := oa.writeCookie(w, r, oa.stateCookieName(), state, oidcStateTTL); err != nil {
+		log.Errorf("Unable to write the OIDC state cookie: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	config := provider.oauth2
+	config.RedirectURL = oa.redirectURL(r)
+	http.Redirect(w, r, config.AuthCodeURL(state.State, oauth2.SetAuthURLParam("nonce", state.Nonce)), http.StatusFound)
+}
+
+func (oa *oidcAuth) callback(w http.ResponseWriter, r *http.Request) {
+	provider, err := oa.getProvider()
+	if err != nil {
+		oa.discoveryFailed(w, err)
+		return
+	}
+
+	state := &oidcState{}
+	if !oa.readCookie(r, oa.stateCookieName(), state) || state.State != r.URL.Query().Get("state") {
+		log.Debug("OIDC callback with an invalid state")
+		http.Error(w, "Invalid state", http.StatusBadRequest)
+		return
+	}
+	oa.clearCookie(w, r, oa.stateCookieName())
+
+	if providerErr := r.URL.Query().Get("error"); len(providerErr) > 0 {
+		log.Debugf("OIDC provider returned an error: %s", providerErr)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	config := provider.oauth2
+	config.RedirectURL = oa.redirectURL(r)
+	token, err := config.Exchange(oa.context(r), r.URL.Query().Get("code"))
+	if err != nil {
+		log.Debugf("OIDC code exchange failed: %s", err)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	session, err := oa.newSession(provider, token, state.Nonce)
+	if err != nil {
+		log.Debugf("OIDC ID token validation failed: %s", err)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	session.Created = time.Now().Unix()
+
+	if err := oa.writeCookie(w, r, oa.cookieName, session, oa.sessionTTL); err != nil {
+		log.Errorf("Unable to write the OIDC session cookie: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, state.URL, http.StatusFound)
+}
+
+func (oa *oidcAuth) refresh(r *http.Request, provider *oidcProvider, session *oidcSession) (*oidcSession, error) {
+	tokenSource := provider.oauth2.TokenSource(oa.context(r), &oauth2.Token{RefreshToken: session.RefreshToken})
+	token, err := tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	newSession, err := oa.newSession(provider, token, "")
+	if err != nil {
+		return nil, err
+	}
+	if newSession.Claims == nil {
+		// No new ID token, keep the identity of the previous one.
+		newSession.Claims = session.Claims
+	}
+	newSession.Created = session.Created
+	return newSession, nil
+}
+
+// newSession builds a session from a token response, validating its ID token.
+func (oa *oidcAuth) newSession(provider *oidcProvider, token *oauth2.Token, nonce string) (*oidcSession, error) {
+	session := &oidcSession{RefreshToken: token.RefreshToken}
+	if !token.Expiry.IsZero() {
+		session.Expiry = token.Expiry.Unix()
+	}
+
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if len(rawIDToken) == 0 {
+		if len(nonce) > 0 {
+			return nil, errors.New("no ID token in the token response")
+		}
+		oa.defaultExpiry(session)
+		return session, nil
+	}
+
+	claims, err := provider.verifier.validate(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) > 0 && claims.MapClaims["nonce"] != nonce {
+		return nil, errors.New("invalid ID token nonce")
+	}
+
+	// Only keep the claims needed, to limit the cookie size.
+	session.Claims = make(map[string]interface{})
+	for claim := range oa.config.ClaimsToHeaders {
+		if value, ok := claims.MapClaims[claim]; ok {
+			session.Claims[claim] = value
+		}
+	}
+	if subject, ok := claims.MapClaims["sub"]; ok {
+		session.Claims["sub"] = subject
+	}
+	if exp, ok := claims.MapClaims["exp"].(float64); ok && (session.Expiry == 0 || int64(exp) < session.Expiry) {
+		session.Expiry = int64(exp)
+	}
+	oa.defaultExpiry(session)
+	return session, nil
+}
+
+// defaultExpiry sets the expiry of a session whose token response and ID token have none,
+// so that it is not immediately expired.
+func (oa *oidcAuth) defaultExpiry(session *oidcSession) {
+	if session.Expiry == 0 {
+		session.Expiry = time.Now().Add(oa.tokenTTL).Unix()
+	}
+}
+
+func (oa *oidcAuth) readSession(r *http.Request) *oidcSession {
+	session := &oidcSession{}
+	if !oa.readCookie(r, oa.cookieName, session) {
+		return nil
+	}
+	if time.Since(time.Unix(session.Created, 0)) > oa.sessionTTL {
+		return nil
+	}
+	return session
+}
+
+func (oa *oidcAuth) context(r *http.Request) context.Context {
+	return context.WithValue(r.Context(), oauth2.HTTPClient, oa.client)
+}
+
+func (oa *oidcAuth) redirectURL(r *http.Request) string {
+	if strings.HasPrefix(oa.config.RedirectURL, "http://") || strings.HasPrefix(oa.config.RedirectURL, "https://") {
+		return oa.config.RedirectURL
+	}
+	return oa.requestBaseURL(r) + oa.callbackPath
+}
+
+func (oa *oidcAuth) stateCookieName() string {
+	return oa.cookieName + "_state"
+}
+
+func (oa *oidcAuth) writeCookie(w http.ResponseWriter, r *http.Request, name string, value interface{}, maxAge time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, oa.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    base64.RawURLEncoding.EncodeToString(oa.aead.Seal(nonce, nonce, data, []byte(name))),
+		Path:     "/",
+		Domain:   oa.config.CookieDomain,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   oa.requestScheme(r) == "https",
+		HttpOnly: true,
+	})
+	return nil
+}
+
+func (oa *oidcAuth) readCookie(r *http.Request, name string, value interface{}) bool {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return false
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(data) < oa.aead.NonceSize() {
+		return false
+	}
+
+	nonceSize := oa.aead.NonceSize()
+	plain, err := oa.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(name))
+	if err != nil {
+		log.Debugf("Unable to decrypt the %s cookie: %s", name, err)
+		return false
+	}
+
+	return json.Unmarshal(plain, value) == nil
+}
+
+func (oa *oidcAuth) clearCookie(w http.ResponseWriter, r *http.Request, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		Domain:   oa.config.CookieDomain,
+		MaxAge:   -1,
+		Secure:   oa.requestScheme(r) == "https",
+		HttpOnly: true,
+	})
+}
+
+// requestScheme returns the scheme of the request,
+// from the X-Forwarded-Proto header only when the request comes from a trusted proxy.
+func (oa *oidcAuth) requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	if oa.trustedProxies != nil {
+		trusted, _, err := oa.trustedProxies.ContainsRequest(r, nil)
+		if err == nil && trusted {
+			switch proto := r.Header.Get("X-Forwarded-Proto"); proto {
+			case "http", "https":
+				return proto
+			}
+		}
+	}
+	return "http"
+}
+
+func (oa *oidcAuth) requestBaseURL(r *http.Request) string {
+	return oa.requestScheme(r) + "://" + r.Host
+}
+
+func randomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
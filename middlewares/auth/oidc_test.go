@@ -0,0 +1,361 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+	"gopkg.in/square/go-jose.v1"
+)
+
+// mockOIDCProvider is a minimal OpenID Connect provider.
+// Every authorization code and refresh token is accepted.
+type mockOIDCProvider struct {
+	server       *httptest.Server
+	key          *rsa.PrivateKey
+	nonce        string
+	refreshCount int32
+	// bareRefresh makes the refresh responses have neither ID token nor expiry
+	bareRefresh bool
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	provider := &mockOIDCProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                provider.server.URL,
+			AuthorizationEndpoint: provider.server.URL + "/authorize",
+			TokenEndpoint:         provider.server.URL + "/token",
+			JWKSURI:               provider.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JsonWebKeySet{
+			Keys: []jose.JsonWebKey{{Key: &key.PublicKey, KeyID: "key1", Algorithm: "RS256", Use: "sig"}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		claims := jwt.MapClaims{
+			"iss":   provider.server.URL,
+			"aud":   "client",
+			"sub":   "user1",
+			"email": "user1@example.com",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		if r.Form.Get("grant_type") == "refresh_token" {
+			atomic.AddInt32(&provider.refreshCount, 1)
+			claims["email"] = "refreshed@example.com"
+		} else {
+			claims["nonce"] = provider.nonce
+		}
+		if r.Form.Get("grant_type") == "refresh_token" && provider.bareRefresh {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token":  "access",
+				"token_type":    "Bearer",
+				"refresh_token": "refresh",
+			})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key1"
+		idToken, err := token.SignedString(key)
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "access",
+			"token_type":    "Bearer",
+			"refresh_token": "refresh",
+			"expires_in":    3600,
+			"id_token":      idToken,
+		})
+	})
+	provider.server = httptest.NewServer(mux)
+	return provider
+}
+
+func TestOIDCAuth(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	defer provider.server.Close()
+
+	authMiddleware, err := NewAuthenticator(&types.Auth{
+		HeaderField: "X-User",
+		OIDC: &types.OIDC{
+			Issuer:          provider.server.URL,
+			ClientID:        "client",
+			ClientSecret:    "secret",
+			Secret:          "cookie-secret",
+			LogoutPath:      "/logout",
+			ClaimsToHeaders: map[string]string{"email": "X-Email"},
+		},
+	})
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("X-User"), r.Header.Get("X-Email"))
+	})
+	n := negroni.New(authMiddleware)
+	n.UseHandler(handler)
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	client := &http.Client{
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// Unauthenticated requests are redirected to the provider.
+	req := testhelpers.MustNewRequest(http.MethodGet, ts.URL+"/dashboard?tab=1", nil)
+	req.Header.Set("X-Email", "spoofed")
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	location, err := res.Location()
+	require.NoError(t, err)
+	assert.Equal(t, provider.server.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(t, "client", location.Query().Get("client_id"))
+	assert.Equal(t, ts.URL+"/oauth2/callback", location.Query().Get("redirect_uri"))
+	provider.nonce = location.Query().Get("nonce")
+	stateCookie := findCookie(res.Cookies(), "_traefik_oidc_state")
+	require.NotNil(t, stateCookie)
+
+	// A callback with another state is rejected.
+	req = testhelpers.MustNewRequest(http.MethodGet, ts.URL+"/oauth2/callback?code=abc&state=other", nil)
+	req.AddCookie(stateCookie)
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// The callback creates the session and redirects to the original URL.
+	req = testhelpers.MustNewRequest(http.MethodGet, ts.URL+"/oauth2/callback?code=abc&state="+url.QueryEscape(location.Query().Get("state")), nil)
+	req.AddCookie(stateCookie)
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, res.StatusCode)
+	assert.Equal(t, ts.URL+"/dashboard?tab=1", res.Header.Get("Location"))
+	sessionCookie := findCookie(res.Cookies(), "_traefik_oidc")
+	require.NotNil(t, sessionCookie)
+	assert.True(t, sessionCookie.HttpOnly)
+
+	req = testhelpers.MustNewRequest(http.MethodGet, ts.URL+"/dashboard", nil)
+	req.Header.Set("X-Email", "spoofed")
+	req.AddCookie(sessionCookie)
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "user1|user1@example.com", string(body))
+
+	// A tampered session cookie is rejected.
+	req = testhelpers.MustNewRequest(http.MethodGet, ts.URL+"/dashboard", nil)
+	req.AddCookie(&http.Cookie{Name: "_traefik_oidc", Value: sessionCookie.Value[1:]})
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+
+	req = testhelpers.MustNewRequest(http.MethodGet, ts.URL+"/logout", nil)
+	req.AddCookie(sessionCookie)
+	res, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, -1, findCookie(res.Cookies(), "_traefik_oidc").MaxAge)
+}
+
+func TestOIDCAuthRefresh(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	defer provider.server.Close()
+
+	config := &types.OIDC{
+		Issuer:          provider.server.URL,
+		ClientID:        "client",
+		Secret:          "cookie-secret",
+		ClaimsToHeaders: map[string]string{"email": "X-Email"},
+	}
+	oa, err := newOIDCAuth(config, "", nil)
+	require.NoError(t, err)
+
+	// Write an expired session holding a refresh token.
+	rw := httptest.NewRecorder()
+	session := &oidcSession{
+		Claims:       map[string]interface{}{"email": "user1@example.com"},
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(-time.Minute).Unix(),
+		Created:      time.Now().Unix(),
+	}
+	require.NoError(t, oa.writeCookie(rw, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil), "_traefik_oidc", session, time.Hour))
+	sessionCookie := findCookie(rw.Result().Cookies(), "_traefik_oidc")
+	require.NotNil(t, sessionCookie)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("X-Email"))
+	})
+
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar/", nil)
+	req.AddCookie(sessionCookie)
+	rw = httptest.NewRecorder()
+	oa.ServeHTTP(rw, req, handler)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "refreshed@example.com", rw.Body.String())
+	assert.EqualValues(t, 1, atomic.LoadInt32(&provider.refreshCount))
+	assert.NotNil(t, findCookie(rw.Result().Cookies(), "_traefik_oidc"))
+}
+
+func TestOIDCAuthRefreshWithoutExpiry(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	defer provider.server.Close()
+	provider.bareRefresh = true
+
+	config := &types.OIDC{
+		Issuer:          provider.server.URL,
+		ClientID:        "client",
+		Secret:          "cookie-secret",
+		ClaimsToHeaders: map[string]string{"email": "X-Email"},
+	}
+	oa, err := newOIDCAuth(config, "", nil)
+	require.NoError(t, err)
+
+	p, err := oa.getProvider()
+	require.NoError(t, err)
+	session, err := oa.refresh(testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil), p, &oidcSession{
+		Claims:       map[string]interface{}{"email": "user1@example.com"},
+		RefreshToken: "refresh",
+		Created:      time.Now().Unix(),
+	})
+	require.NoError(t, err)
+
+	// the session keeps its identity, and expires after the default token lifetime
+	assert.Equal(t, "user1@example.com", session.Claims["email"])
+	assert.InDelta(t, time.Now().Add(defaultOIDCTokenTTL).Unix(), session.Expiry, 5)
+}
+
+func TestOIDCAuthDiscoveryBackoff(t *testing.T) {
+	var calls int32
+	issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer issuer.Close()
+
+	oa, err := newOIDCAuth(&types.OIDC{Issuer: issuer.URL, ClientID: "client", Secret: "secret"}, "", nil)
+	require.NoError(t, err)
+
+	next := func(w http.ResponseWriter, r *http.Request) {}
+
+	recorder := httptest.NewRecorder()
+	oa.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil), next)
+	assert.Equal(t, http.StatusBadGateway, recorder.Code)
+
+	// The failure is kept until the backoff expires.
+	for i := 0; i < 3; i++ {
+		recorder = httptest.NewRecorder()
+		oa.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil), next)
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	oa.lock.Lock()
+	assert.Equal(t, oidcMinDiscoveryBackoff, oa.discoveryBackoff)
+	oa.retryDiscoveryAt = time.Time{}
+	oa.lock.Unlock()
+
+	recorder = httptest.NewRecorder()
+	oa.ServeHTTP(recorder, testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil), next)
+	assert.Equal(t, http.StatusBadGateway, recorder.Code)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	assert.Equal(t, 2*oidcMinDiscoveryBackoff, oa.discoveryBackoff)
+}
+
+func TestOIDCRequestScheme(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		trustedIPs []string
+		remoteAddr string
+		proto      string
+		expected   string
+	}{
+		{
+			desc:       "no trusted IPs",
+			remoteAddr: "10.0.0.1:1234",
+			proto:      "https",
+			expected:   "http",
+		},
+		{
+			desc:       "untrusted client",
+			trustedIPs: []string{"10.0.0.0/8"},
+			remoteAddr: "192.168.1.1:1234",
+			proto:      "https",
+			expected:   "http",
+		},
+		{
+			desc:       "trusted proxy",
+			trustedIPs: []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			proto:      "https",
+			expected:   "https",
+		},
+		{
+			desc:       "trusted proxy with invalid scheme",
+			trustedIPs: []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:1234",
+			proto:      "javascript",
+			expected:   "http",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			oa, err := newOIDCAuth(&types.OIDC{Issuer: "http://foo.bar", ClientID: "client", Secret: "secret"}, "", test.trustedIPs)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://foo.bar", nil)
+			req.RemoteAddr = test.remoteAddr
+			req.Header.Set("X-Forwarded-Proto", test.proto)
+			assert.Equal(t, test.expected, oa.requestScheme(req))
+		})
+	}
+}
+
+func TestNewOIDCAuthErrors(t *testing.T) {
+	_, err := NewAuthenticator(&types.Auth{OIDC: &types.OIDC{Issuer: "http://foo.bar", ClientID: "client"}})
+	assert.Error(t, err)
+
+	_, err = NewAuthenticator(&types.Auth{OIDC: &types.OIDC{Secret: "secret"}})
+	assert.Error(t, err)
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
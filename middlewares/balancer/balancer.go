@@ -0,0 +1,195 @@
+package balancer
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vulcand/oxy/roundrobin"
+	"github.com/vulcand/oxy/utils"
+)
+
+// strategy picks the server of the requests.
+type strategy interface {
+	// pick returns the server of the request, or nil when no server fits.
+	pick(servers []*server, req *http.Request) *server
+	// update is called with the servers, every time they change.
+	update(servers []*server)
+}
+
+type server struct {
+	url    *url.URL
+	weight int
+
+	// inflight is the number of requests in progress, updated atomically.
+	inflight int64
+
+	latencyLock sync.Mutex
+	latency     float64
+	lastLatency time.Time
+}
+
+// getLatency returns the moving average of the latencies of the server, decayed since its last request.
+func (s *server) getLatency() float64 {
+	s.latencyLock.Lock()
+	defer s.latencyLock.Unlock()
+	return decayedLatency(s.latency, s.lastLatency, time.Now())
+}
+
+// observe records the latency of a request in the moving average of the server.
+func (s *server) observe(start time.Time) {
+	now := time.Now()
+
+	s.latencyLock.Lock()
+	defer s.latencyLock.Unlock()
+
+	s.latency = decayedAverage(s.latency, s.lastLatency, float64(now.Sub(start)), now)
+	s.lastLatency = now
+}
+
+// load is the number of requests in progress, weighted by the weight of the server.
+func (s *server) load() float64 {
+	return float64(atomic.LoadInt64(&s.inflight)) / float64(s.weight)
+}
+
+// Balancer forwards the requests to one of its servers, picked by its strategy.
+// It implements the oxy load balancers interface, so that it can be health checked.
+type Balancer struct {
+	next     http.Handler
+	strategy strategy
+	// observeLatency is set when the strategy picks the servers by latency
+	observeLatency bool
+
+	mutex   sync.RWMutex
+	servers []*server
+}
+
+func newBalancer(next http.Handler, strategy strategy) *Balancer {
+	_, observeLatency := strategy.(*leastLatency)
+	return &Balancer{next: next, strategy: strategy, observeLatency: observeLatency}
+}
+
+func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	b.mutex.RLock()
+	srv := b.strategy.pick(b.servers, req)
+	b.mutex.RUnlock()
+
+	if srv == nil {
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	atomic.AddInt64(&srv.inflight, 1)
+	defer atomic.AddInt64(&srv.inflight, -1)
+	if b.observeLatency {
+		defer srv.observe(time.Now())
+	}
+
+	// make shallow copy of request before changing anything to avoid side effects
+	newReq := *req
+	newReq.URL = utils.CopyURL(srv.url)
+	b.next.ServeHTTP(rw, &newReq)
+}
+
+// Servers returns the URLs of the servers.
+func (b *Balancer) Servers() []*url.URL {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	urls := make([]*url.URL, len(b.servers))
+	for i, srv := range b.servers {
+		urls[i] = utils.CopyURL(srv.url)
+	}
+	return urls
+}
+
+// ServerWeight returns the weight of the server, if it is one of the servers.
+func (b *Balancer) ServerWeight(u *url.URL) (int, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if i := b.index(u); i >= 0 {
+		return b.servers[i].weight, true
+	}
+	return -1, false
+}
+
+// RemoveServer removes the server.
+func (b *Balancer) RemoveServer(u *url.URL) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	i := b.index(u)
+	if i < 0 {
+		return errors.New("server not found")
+	}
+
+	servers := make([]*server, 0, len(b.servers)-1)
+	servers = append(servers, b.servers[:i]...)
+	b.servers = append(servers, b.servers[i+1:]...)
+	b.strategy.update(b.servers)
+	return nil
+}
+
+// UpsertServer adds the server, or updates its weight.
+// The options are the oxy round robin ones, only the weight is used.
+func (b *Balancer) UpsertServer(u *url.URL, options ...roundrobin.ServerOption) error {
+	if u == nil {
+		return errors.New("server URL can't be nil")
+	}
+
+	weight, err := serverWeight(u, options)
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// the list of servers is copied on write, the strategies may keep it
+	servers := make([]*server, len(b.servers), len(b.servers)+1)
+	copy(servers, b.servers)
+
+	if i := b.index(u); i >= 0 {
+		servers[i].weight = weight
+	} else {
+		servers = append(servers, &server{url: utils.CopyURL(u), weight: weight})
+	}
+
+	b.servers = servers
+	b.strategy.update(b.servers)
+	return nil
+}
+
+func (b *Balancer) index(u *url.URL) int {
+	for i, srv := range b.servers {
+		if sameURL(srv.url, u) {
+			return i
+		}
+	}
+	return -1
+}
+
+// serverWeight applies the options to an oxy round robin, to read the weight they set.
+func serverWeight(u *url.URL, options []roundrobin.ServerOption) (int, error) {
+	rr, err := roundrobin.New(nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := rr.UpsertServer(u, options...); err != nil {
+		return 0, err
+	}
+
+	weight, _ := rr.ServerWeight(u)
+	if weight <= 0 {
+		return 1, nil
+	}
+	return weight, nil
+}
+
+func sameURL(a, b *url.URL) bool {
+	return a.Path == b.Path && a.Host == b.Host && a.Scheme == b.Scheme
+}
@@ -0,0 +1,307 @@
+package balancer
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+	"github.com/vulcand/oxy/utils"
+)
+
+// hostHandler answers with the host of the server the request is forwarded to.
+var hostHandler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	rw.Write([]byte(req.URL.Host))
+})
+
+func serve(handler http.Handler, req *http.Request) string {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder.Body.String()
+}
+
+func upsert(t *testing.T, b *Balancer, rawURL string, weight int) {
+	t.Helper()
+	require.NoError(t, b.UpsertServer(testhelpers.MustParseURL(rawURL), roundrobin.Weight(weight)))
+}
+
+func TestServers(t *testing.T) {
+	b := NewLeastConn(hostHandler)
+	upsert(t, b, "http://127.0.0.1:8081", 0)
+	upsert(t, b, "http://127.0.0.1:8082", 3)
+	upsert(t, b, "http://127.0.0.1:8082", 2)
+
+	assert.Equal(t, []string{"http://127.0.0.1:8081", "http://127.0.0.1:8082"}, urls(b))
+
+	weight, ok := b.ServerWeight(testhelpers.MustParseURL("http://127.0.0.1:8081"))
+	assert.True(t, ok)
+	assert.Equal(t, 1, weight)
+	weight, ok = b.ServerWeight(testhelpers.MustParseURL("http://127.0.0.1:8082"))
+	assert.True(t, ok)
+	assert.Equal(t, 2, weight)
+
+	require.NoError(t, b.RemoveServer(testhelpers.MustParseURL("http://127.0.0.1:8081")))
+	assert.Equal(t, []string{"http://127.0.0.1:8082"}, urls(b))
+	assert.Error(t, b.RemoveServer(testhelpers.MustParseURL("http://127.0.0.1:8081")))
+	assert.Error(t, b.UpsertServer(testhelpers.MustParseURL("http://127.0.0.1:8083"), roundrobin.Weight(-1)))
+}
+
+func urls(b *Balancer) []string {
+	var result []string
+	for _, u := range b.Servers() {
+		result = append(result, u.String())
+	}
+	return result
+}
+
+func TestNoServer(t *testing.T) {
+	for name, b := range map[string]*Balancer{
+		"leastconn":    NewLeastConn(hostHandler),
+		"leastlatency": NewLeastLatency(hostHandler),
+		"p2c":          NewP2C(hostHandler),
+		"ringhash":     NewRingHash(hostHandler, mustExtractor(t, "client.ip")),
+	} {
+		recorder := httptest.NewRecorder()
+		b.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code, name)
+	}
+}
+
+// blockingHandler holds the requests to the blocked servers until it is released.
+type blockingHandler struct {
+	blocked  map[string]bool
+	started  chan string
+	released chan struct{}
+}
+
+func (h *blockingHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if h.blocked[req.URL.Host] {
+		h.started <- req.URL.Host
+		<-h.released
+	}
+	rw.Write([]byte(req.URL.Host))
+}
+
+func TestLeastConn(t *testing.T) {
+	handler := &blockingHandler{
+		blocked:  map[string]bool{"127.0.0.1:8081": true},
+		started:  make(chan string),
+		released: make(chan struct{}),
+	}
+	b := NewLeastConn(handler)
+	upsert(t, b, "http://127.0.0.1:8081", 1)
+	upsert(t, b, "http://127.0.0.1:8082", 1)
+
+	// the first request to the blocked server keeps it busy
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for serve(b, httptest.NewRequest(http.MethodGet, "/", nil)) != "127.0.0.1:8081" {
+		}
+	}()
+	<-handler.started
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "127.0.0.1:8082", serve(b, httptest.NewRequest(http.MethodGet, "/", nil)))
+	}
+
+	close(handler.released)
+	wg.Wait()
+}
+
+func TestLeastConnSpreadsTies(t *testing.T) {
+	b := NewLeastConn(hostHandler)
+	upsert(t, b, "http://127.0.0.1:8081", 1)
+	upsert(t, b, "http://127.0.0.1:8082", 1)
+
+	counts := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		counts[serve(b, httptest.NewRequest(http.MethodGet, "/", nil))]++
+	}
+	assert.Equal(t, map[string]int{"127.0.0.1:8081": 5, "127.0.0.1:8082": 5}, counts)
+}
+
+func TestLeastLatency(t *testing.T) {
+	b := NewLeastLatency(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Host == "127.0.0.1:8081" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		rw.Write([]byte(req.URL.Host))
+	}))
+	upsert(t, b, "http://127.0.0.1:8081", 1)
+	upsert(t, b, "http://127.0.0.1:8082", 1)
+
+	counts := make(map[string]int)
+	for i := 0; i < 20; i++ {
+		counts[serve(b, httptest.NewRequest(http.MethodGet, "/", nil))]++
+	}
+
+	// the slow server is tried once, then avoided
+	assert.Equal(t, 1, counts["127.0.0.1:8081"])
+	assert.Equal(t, 19, counts["127.0.0.1:8082"])
+}
+
+func TestLeastLatencyRecovers(t *testing.T) {
+	b := NewLeastLatency(hostHandler)
+	upsert(t, b, "http://127.0.0.1:8081", 1)
+	upsert(t, b, "http://127.0.0.1:8082", 1)
+
+	now := time.Now()
+	// a single slow response, long ago
+	b.servers[0].latency = float64(time.Second)
+	b.servers[0].lastLatency = now.Add(-10 * latencyDecay)
+	b.servers[1].latency = float64(time.Millisecond)
+	b.servers[1].lastLatency = now
+
+	assert.Equal(t, "127.0.0.1:8081", serve(b, httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+func TestLatencyObservedByLeastLatencyOnly(t *testing.T) {
+	for name, b := range map[string]*Balancer{
+		"leastconn":    NewLeastConn(hostHandler),
+		"leastlatency": NewLeastLatency(hostHandler),
+	} {
+		require.NoError(t, b.UpsertServer(testhelpers.MustParseURL("http://127.0.0.1:8081")))
+		serve(b, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, name == "leastlatency", !b.servers[0].lastLatency.IsZero(), name)
+	}
+}
+
+func TestDecayedAverage(t *testing.T) {
+	start := time.Now()
+
+	assert.Equal(t, 100.0, decayedAverage(0, time.Time{}, 100, start))
+	assert.Equal(t, 200.0, decayedAverage(100, start, 200, start.Add(time.Second)))
+	assert.InDelta(t, 100.0, decayedAverage(100, start, 0, start.Add(time.Millisecond)), 0.1)
+	assert.InDelta(t, 0.0, decayedAverage(100, start, 0, start.Add(10*latencyDecay)), 0.01)
+}
+
+func TestDecayedLatency(t *testing.T) {
+	start := time.Now()
+
+	assert.Equal(t, 100.0, decayedLatency(100, time.Time{}, start))
+	assert.Equal(t, 100.0, decayedLatency(100, start, start))
+	assert.InDelta(t, 100.0/math.E, decayedLatency(100, start, start.Add(latencyDecay)), 0.01)
+	assert.InDelta(t, 0.0, decayedLatency(100, start, start.Add(10*latencyDecay)), 0.01)
+}
+
+func TestP2C(t *testing.T) {
+	b := NewP2C(hostHandler)
+	upsert(t, b, "http://127.0.0.1:8081", 3)
+	upsert(t, b, "http://127.0.0.1:8082", 1)
+	upsert(t, b, "http://127.0.0.1:8083", 1)
+
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		counts[serve(b, httptest.NewRequest(http.MethodGet, "/", nil))]++
+	}
+	assert.Len(t, counts, 3)
+
+	// without requests in progress, the first choice, by weight, is kept
+	assert.InDelta(t, 180, counts["127.0.0.1:8081"], 45)
+}
+
+func TestP2CPrefersTheLeastLoaded(t *testing.T) {
+	handler := &blockingHandler{
+		blocked:  map[string]bool{"127.0.0.1:8081": true},
+		started:  make(chan string),
+		released: make(chan struct{}),
+	}
+	b := NewP2C(handler)
+	upsert(t, b, "http://127.0.0.1:8081", 1)
+	upsert(t, b, "http://127.0.0.1:8082", 1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for serve(b, httptest.NewRequest(http.MethodGet, "/", nil)) != "127.0.0.1:8081" {
+		}
+	}()
+	<-handler.started
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "127.0.0.1:8082", serve(b, httptest.NewRequest(http.MethodGet, "/", nil)))
+	}
+
+	close(handler.released)
+	wg.Wait()
+}
+
+func TestRingHash(t *testing.T) {
+	servers := []string{"http://127.0.0.1:8081", "http://127.0.0.1:8082", "http://127.0.0.1:8083", "http://127.0.0.1:8084"}
+
+	b := NewRingHash(hostHandler, mustExtractor(t, "request.header.X-User"))
+	for _, server := range servers {
+		upsert(t, b, server, 1)
+	}
+
+	route := func(user string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(user) > 0 {
+			req.Header.Set("X-User", user)
+		}
+		return serve(b, req)
+	}
+
+	before := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		user := fmt.Sprintf("user%d", i)
+		before[user] = route(user)
+		counts[before[user]]++
+
+		assert.Equal(t, before[user], route(user))
+	}
+	for _, server := range servers {
+		assert.InDelta(t, 100, counts[testhelpers.MustParseURL(server).Host], 40, server)
+	}
+
+	// only the users of the removed server are remapped
+	removed := testhelpers.MustParseURL(servers[0])
+	require.NoError(t, b.RemoveServer(removed))
+	for user, server := range before {
+		if server == removed.Host {
+			assert.NotEqual(t, removed.Host, route(user))
+		} else {
+			assert.Equal(t, server, route(user))
+		}
+	}
+
+	// and they come back with the server
+	upsert(t, b, servers[0], 1)
+	for user, server := range before {
+		assert.Equal(t, server, route(user))
+	}
+
+	// the requests without the value are balanced
+	assert.NotEqual(t, route(""), route(""))
+}
+
+func TestRingHashWeights(t *testing.T) {
+	b := NewRingHash(hostHandler, mustExtractor(t, "request.header.X-User"))
+	upsert(t, b, "http://127.0.0.1:8081", 3)
+	upsert(t, b, "http://127.0.0.1:8082", 1)
+
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", fmt.Sprintf("user%d", i))
+		counts[serve(b, req)]++
+	}
+	assert.InDelta(t, 300, counts["127.0.0.1:8081"], 40)
+}
+
+func mustExtractor(t *testing.T, variable string) utils.SourceExtractor {
+	extractor, err := utils.NewExtractor(variable)
+	require.NoError(t, err)
+	return extractor
+}
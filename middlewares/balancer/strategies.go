@@ -0,0 +1,215 @@
+package balancer
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vulcand/oxy/utils"
+)
+
+const (
+	// latencyDecay is the time constant of the latency moving averages.
+	latencyDecay = 10 * time.Second
+	// ringReplicas is the number of points of a server on the hash ring, per unit of weight.
+	ringReplicas = 100
+)
+
+// NewLeastConn creates a balancer forwarding the requests to the server with the least requests in progress,
+// relative to its weight.
+func NewLeastConn(next http.Handler) *Balancer {
+	return newBalancer(next, &leastConn{})
+}
+
+// NewLeastLatency creates a balancer forwarding the requests to the server with the lowest moving average
+// of its latencies, multiplied by its requests in progress and relative to its weight.
+func NewLeastLatency(next http.Handler) *Balancer {
+	return newBalancer(next, &leastLatency{})
+}
+
+// NewP2C creates a balancer picking two random servers, by weight, and forwarding the requests
+// to the one with the least requests in progress.
+func NewP2C(next http.Handler) *Balancer {
+	return newBalancer(next, &p2c{random: rand.New(rand.NewSource(time.Now().UnixNano()))})
+}
+
+// NewRingHash creates a balancer forwarding the requests to the server owning the hash of their extracted value
+// on a consistent hash ring: when a server is added or removed, only its values are remapped.
+// The requests without value are forwarded to the server with the least requests in progress.
+func NewRingHash(next http.Handler, extractor utils.SourceExtractor) *Balancer {
+	return newBalancer(next, &ringHash{extractor: extractor})
+}
+
+type leastConn struct {
+	// offset rotates the first server, to spread the ties
+	offset uint64
+}
+
+func (l *leastConn) pick(servers []*server, _ *http.Request) *server {
+	return pickLowest(servers, &l.offset, (*server).load)
+}
+
+func (l *leastConn) update([]*server) {}
+
+type leastLatency struct {
+	offset uint64
+}
+
+func (l *leastLatency) pick(servers []*server, _ *http.Request) *server {
+	return pickLowest(servers, &l.offset, func(srv *server) float64 {
+		// the servers without latency yet are tried first
+		return srv.getLatency() * float64(atomic.LoadInt64(&srv.inflight)+1) / float64(srv.weight)
+	})
+}
+
+func (l *leastLatency) update([]*server) {}
+
+type p2c struct {
+	lock   sync.Mutex
+	random *rand.Rand
+}
+
+func (p *p2c) pick(servers []*server, _ *http.Request) *server {
+	if len(servers) < 2 {
+		return pickLowest(servers, new(uint64), (*server).load)
+	}
+
+	p.lock.Lock()
+	first := weightedChoice(servers, p.random, nil)
+	second := weightedChoice(servers, p.random, first)
+	p.lock.Unlock()
+
+	if second.load() < first.load() {
+		return second
+	}
+	return first
+}
+
+func (p *p2c) update([]*server) {}
+
+type ringHash struct {
+	extractor utils.SourceExtractor
+	fallback  leastConn
+
+	// the ring is rebuilt by update, under the lock of the balancer
+	hashes []uint64
+	owners []*server
+}
+
+func (r *ringHash) pick(servers []*server, req *http.Request) *server {
+	key, _, err := r.extractor.Extract(req)
+	if err != nil || len(key) == 0 || len(r.hashes) == 0 {
+		return r.fallback.pick(servers, req)
+	}
+
+	h := hash64(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[i]
+}
+
+func (r *ringHash) update(servers []*server) {
+	type point struct {
+		hash  uint64
+		owner *server
+	}
+
+	var points []point
+	for _, srv := range servers {
+		name := srv.url.String()
+		for replica := 0; replica < ringReplicas*srv.weight; replica++ {
+			points = append(points, point{hash: hash64(name + "#" + strconv.Itoa(replica)), owner: srv})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].hash < points[j].hash })
+
+	r.hashes = make([]uint64, len(points))
+	r.owners = make([]*server, len(points))
+	for i, p := range points {
+		r.hashes[i] = p.hash
+		r.owners[i] = p.owner
+	}
+}
+
+// pickLowest returns the server with the lowest score, starting from a rotating offset.
+func pickLowest(servers []*server, offset *uint64, score func(*server) float64) *server {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	start := int(atomic.AddUint64(offset, 1) % uint64(len(servers)))
+	var best *server
+	var bestScore float64
+	for i := range servers {
+		srv := servers[(start+i)%len(servers)]
+		if s := score(srv); best == nil || s < bestScore {
+			best = srv
+			bestScore = s
+		}
+	}
+	return best
+}
+
+// weightedChoice picks a random server by weight, other than the excluded one.
+func weightedChoice(servers []*server, random *rand.Rand, excluded *server) *server {
+	total := 0
+	for _, srv := range servers {
+		if srv != excluded {
+			total += srv.weight
+		}
+	}
+
+	n := random.Intn(total)
+	for _, srv := range servers {
+		if srv == excluded {
+			continue
+		}
+		if n < srv.weight {
+			return srv
+		}
+		n -= srv.weight
+	}
+	return nil
+}
+
+// decayedAverage adds the sample to the moving average, the weight of the previous samples decaying with time.
+// A sample above the average replaces it, so that slow servers are avoided at once.
+func decayedAverage(average float64, last time.Time, sample float64, now time.Time) float64 {
+	if last.IsZero() || sample > average {
+		return sample
+	}
+
+	w := math.Exp(-float64(now.Sub(last)) / float64(latencyDecay))
+	return average*w + sample*(1-w)
+}
+
+// decayedLatency returns the moving average decayed toward zero since the last sample,
+// so that a server avoided after a slow response is tried again after a while.
+func decayedLatency(average float64, last time.Time, now time.Time) float64 {
+	if last.IsZero() {
+		return average
+	}
+	return average * math.Exp(-float64(now.Sub(last))/float64(latencyDecay))
+}
+
+// hash64 hashes the key, with the bits of the FNV hash spread so that close keys are far apart.
+func hash64(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
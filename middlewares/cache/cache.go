@@ -0,0 +1,556 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/middlewares/accesslog"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+// Stores of the caches.
+const (
+	// StoreMemory keeps the responses in memory (default).
+	StoreMemory = "memory"
+	// StoreDisk keeps the responses in files, they survive the restarts.
+	StoreDisk = "disk"
+)
+
+const (
+	// DefaultMaxSize is the default size of the stores, in bytes.
+	DefaultMaxSize int64 = 64 << 20
+	// DefaultMaxEntrySize is the default size of the largest cached response body, in bytes.
+	DefaultMaxEntrySize int64 = 1 << 20
+)
+
+// StatusHeader is the response header holding the cache status of the request.
+const StatusHeader = "X-Cache-Status"
+
+// Cache statuses, in the StatusHeader of the responses and in the access logs.
+const (
+	// StatusHit is the status of the requests answered with a fresh cached response.
+	StatusHit = "HIT"
+	// StatusMiss is the status of the requests forwarded to the backend.
+	StatusMiss = "MISS"
+	// StatusStale is the status of the requests answered with a stale cached response,
+	// while it is revalidated in the background, or because the backend failed.
+	StatusStale = "STALE"
+	// StatusRevalidated is the status of the requests answered with a cached response, once validated by the backend.
+	StatusRevalidated = "REVALIDATED"
+	// StatusBypass is the status of the requests which can't be answered from the cache.
+	StatusBypass = "BYPASS"
+)
+
+// ValidateConfig checks the cache configuration.
+func ValidateConfig(config *types.Cache) error {
+	switch config.Store {
+	case "", StoreMemory:
+	case StoreDisk:
+		if len(config.Directory) == 0 {
+			return errors.New("the disk cache store needs a directory")
+		}
+	default:
+		return fmt.Errorf("unknown cache store %q", config.Store)
+	}
+
+	if config.MaxSize < 0 || config.MaxEntrySize < 0 {
+		return errors.New("negative cache sizes")
+	}
+	if config.DefaultTTL < 0 || config.StaleWhileRevalidate < 0 || config.StaleIfError < 0 {
+		return errors.New("negative cache durations")
+	}
+	return nil
+}
+
+// Cache is the HTTP cache of a frontend, shared by its entry points.
+type Cache struct {
+	name         string
+	config       types.Cache
+	store        Store
+	maxEntrySize int64
+	now          func() time.Time
+
+	lock         sync.Mutex
+	revalidating map[string]bool
+}
+
+// New creates the cache of a frontend.
+// A disk store keeps its files in a sub-directory of the configured directory, named after the hash of the frontend name.
+func New(name string, config *types.Cache) (*Cache, error) {
+	if err := ValidateConfig(config); err != nil {
+		return nil, err
+	}
+
+	maxSize := config.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	var store Store = NewMemoryStore(maxSize)
+	if config.Store == StoreDisk {
+		hash := sha256.Sum256([]byte(name))
+		diskStore, err := NewDiskStore(filepath.Join(config.Directory, hex.EncodeToString(hash[:8])), maxSize)
+		if err != nil {
+			return nil, err
+		}
+		store = diskStore
+	}
+
+	return newCache(name, config, store), nil
+}
+
+func newCache(name string, config *types.Cache, store Store) *Cache {
+	maxEntrySize := config.MaxEntrySize
+	if maxEntrySize == 0 {
+		maxEntrySize = DefaultMaxEntrySize
+	}
+
+	return &Cache{
+		name:         name,
+		config:       *config,
+		store:        store,
+		maxEntrySize: maxEntrySize,
+		now:          time.Now,
+		revalidating: make(map[string]bool),
+	}
+}
+
+// SameConfig tells whether the cache was created with the configuration, so that it can be kept on reload.
+func (c *Cache) SameConfig(config *types.Cache) bool {
+	return reflect.DeepEqual(c.config, *config)
+}
+
+// Purge deletes the cached response of the URL, with its variants, or all the responses when the URL is empty.
+// It returns the number of deleted entries.
+func (c *Cache) Purge(rawURL string) (int, error) {
+	if len(rawURL) == 0 {
+		return c.store.Clear(), nil
+	}
+
+	key, err := urlKey(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	return c.store.Delete(key), nil
+}
+
+func (c *Cache) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	requestCC := parseCacheControl(req.Header)
+	if !isCacheable(req, requestCC) {
+		rw.Header().Set(StatusHeader, StatusBypass)
+		saveStatus(req, StatusBypass)
+		next(rw, req)
+		return
+	}
+
+	key := requestKey(req)
+	entry, entryKey, ok := c.lookup(key, req)
+	if !ok {
+		c.fetch(rw, req, next, key)
+		return
+	}
+
+	age := c.age(entry)
+	refresh := requestCC.has("no-cache") || (len(requestCC) == 0 && req.Header.Get("Pragma") == "no-cache")
+	if maxAge, ok := requestCC.duration("max-age"); ok && age > maxAge {
+		refresh = true
+	}
+
+	switch {
+	case !refresh && age < entry.Lifetime:
+		c.serve(rw, req, entry, StatusHit)
+	case req.Method == http.MethodHead:
+		// the response to a HEAD request can't refresh a cached response
+		c.fetch(rw, req, next, key)
+	case !refresh && age < entry.Lifetime+entry.StaleWhileRevalidate:
+		c.serve(rw, req, entry, StatusStale)
+		c.revalidateInBackground(key, entryKey, entry, req, next)
+	default:
+		c.revalidate(rw, req, next, key, entry)
+	}
+}
+
+// isCacheable tells whether the request can be answered from the cache.
+func isCacheable(req *http.Request, requestCC cacheControl) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return len(req.Header.Get("Upgrade")) == 0 && len(req.Header.Get("Range")) == 0 && !requestCC.has("no-store")
+}
+
+// lookup returns the cached response of the request, and the key it is stored under.
+func (c *Cache) lookup(key string, req *http.Request) (*Entry, string, bool) {
+	entry, ok := c.store.Get(key)
+	if !ok || len(entry.Vary) == 0 {
+		return entry, key, ok
+	}
+
+	variant := variantKey(key, entry.Vary, req)
+	entry, ok = c.store.Get(variant)
+	return entry, variant, ok
+}
+
+// fetch forwards the request to the backend, and stores the response when it is cacheable.
+func (c *Cache) fetch(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc, key string) {
+	saveStatus(req, StatusMiss)
+	capture := newResponseCapture(rw, c.maxEntrySize, func(int) bool { return true }, setStatusHeader(StatusMiss))
+	next(capture, req)
+	capture.finish()
+
+	if req.Method == http.MethodGet {
+		c.save(key, req, capture)
+	}
+}
+
+// revalidate asks the backend whether the cached response is still valid,
+// and answers with it when it is, or when the backend fails and it can be served stale.
+func (c *Cache) revalidate(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc, key string, entry *Entry) {
+	age := c.age(entry)
+	forward := func(statusCode int) bool {
+		if statusCode == http.StatusNotModified {
+			return false
+		}
+		return !isServerError(statusCode) || age >= entry.Lifetime+entry.StaleIfError
+	}
+
+	capture := newResponseCapture(rw, c.maxEntrySize, forward, setStatusHeader(StatusMiss))
+	next(capture, conditionalRequest(req, entry))
+	capture.finish()
+
+	refreshed := c.update(key, req, entry, capture)
+	switch {
+	case capture.forwarded:
+		saveStatus(req, StatusMiss)
+	case refreshed != nil:
+		c.serve(rw, req, refreshed, StatusRevalidated)
+	default:
+		log.Debugf("Serving the stale response of %s from the cache of %s after a %d status code", key, c.name, capture.statusCode)
+		c.serve(rw, req, entry, StatusStale)
+	}
+}
+
+// revalidateInBackground revalidates the cached response after the request is answered,
+// once at a time for each cached response.
+func (c *Cache) revalidateInBackground(key, entryKey string, entry *Entry, req *http.Request, next http.HandlerFunc) {
+	c.lock.Lock()
+	if c.revalidating[entryKey] {
+		c.lock.Unlock()
+		return
+	}
+	c.revalidating[entryKey] = true
+	c.lock.Unlock()
+
+	outReq := detach(conditionalRequest(req, entry))
+	safe.Go(func() {
+		defer func() {
+			c.lock.Lock()
+			delete(c.revalidating, entryKey)
+			c.lock.Unlock()
+		}()
+
+		capture := newResponseCapture(nil, c.maxEntrySize, nil, nil)
+		next(capture, outReq)
+		capture.finish()
+		c.update(key, outReq, entry, capture)
+	})
+}
+
+// update stores the response of a revalidation.
+// It returns the refreshed cached response when the backend validated it, nil otherwise.
+func (c *Cache) update(key string, req *http.Request, entry *Entry, capture *responseCapture) *Entry {
+	if capture.statusCode != http.StatusNotModified {
+		c.save(key, req, capture)
+		return nil
+	}
+
+	header := make(http.Header)
+	for name, values := range entry.Header {
+		header[name] = values
+	}
+	for name, values := range capture.header {
+		if name != "Content-Length" {
+			header[name] = values
+		}
+	}
+
+	refreshed, ok := c.newEntry(req, entry.StatusCode, header, entry.Body)
+	if !ok {
+		// the response is valid, but can't be cached anymore
+		return &Entry{StatusCode: entry.StatusCode, Header: header, Body: entry.Body, Date: c.now()}
+	}
+	c.set(key, req, refreshed)
+	return refreshed
+}
+
+// save stores the captured response when it is cacheable.
+func (c *Cache) save(key string, req *http.Request, capture *responseCapture) {
+	if capture.overflow {
+		return
+	}
+
+	entry, ok := c.newEntry(req, capture.statusCode, capture.header, capture.body.Bytes())
+	if ok {
+		c.set(key, req, entry)
+	}
+}
+
+// set stores the response, the responses with a Vary header being stored as a variant.
+func (c *Cache) set(key string, req *http.Request, entry *Entry) {
+	vary := parseVary(entry.Header)
+	if len(vary) == 0 {
+		c.store.Set(key, entry)
+		return
+	}
+
+	c.store.Set(key, &Entry{Vary: vary, Date: entry.Date})
+	c.store.Set(variantKey(key, vary, req), entry)
+}
+
+// newEntry builds the cached response of the request, when the response can be stored.
+func (c *Cache) newEntry(req *http.Request, statusCode int, header http.Header, body []byte) (*Entry, bool) {
+	if !heuristicallyCacheable[statusCode] || len(header["Set-Cookie"]) > 0 {
+		return nil, false
+	}
+
+	cc := parseCacheControl(header)
+	if cc.has("no-store") || cc.has("no-cache") || cc.has("private") {
+		return nil, false
+	}
+	for _, name := range parseVary(header) {
+		if name == "*" {
+			return nil, false
+		}
+	}
+	if len(req.Header.Get("Authorization")) > 0 && !cc.has("public") && !cc.has("s-maxage") && !cc.has("must-revalidate") {
+		return nil, false
+	}
+
+	now := c.now()
+	date := now
+	if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 && age < maxDeltaSeconds {
+		date = now.Add(-time.Duration(age) * time.Second)
+	}
+
+	lifetime, explicit := cc.duration("s-maxage")
+	if !explicit {
+		lifetime, explicit = cc.duration("max-age")
+	}
+	if !explicit && len(header.Get("Expires")) > 0 {
+		// an invalid date means that the response is already expired
+		explicit = true
+		if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+			responseDate, err := http.ParseTime(header.Get("Date"))
+			if err != nil {
+				responseDate = now
+			}
+			lifetime = expires.Sub(responseDate)
+		}
+	}
+	if !explicit {
+		lifetime = time.Duration(c.config.DefaultTTL)
+	}
+
+	staleWhileRevalidate, ok := cc.duration("stale-while-revalidate")
+	if !ok {
+		staleWhileRevalidate = time.Duration(c.config.StaleWhileRevalidate)
+	}
+	staleIfError, ok := cc.duration("stale-if-error")
+	if !ok {
+		staleIfError = time.Duration(c.config.StaleIfError)
+	}
+	if cc.has("must-revalidate") || cc.has("proxy-revalidate") {
+		staleWhileRevalidate, staleIfError = 0, 0
+	}
+
+	if lifetime <= 0 && staleWhileRevalidate == 0 && staleIfError == 0 {
+		return nil, false
+	}
+
+	storedHeader := make(http.Header)
+	for name, values := range header {
+		storedHeader[name] = append([]string(nil), values...)
+	}
+	for _, name := range append(hopHeaders, "Age", StatusHeader) {
+		storedHeader.Del(name)
+	}
+
+	return &Entry{
+		StatusCode:           statusCode,
+		Header:               storedHeader,
+		Body:                 append([]byte(nil), body...),
+		Date:                 date,
+		Lifetime:             lifetime,
+		StaleWhileRevalidate: staleWhileRevalidate,
+		StaleIfError:         staleIfError,
+	}, true
+}
+
+// serve answers the request with the cached response.
+func (c *Cache) serve(rw http.ResponseWriter, req *http.Request, entry *Entry, status string) {
+	saveStatus(req, status)
+
+	header := rw.Header()
+	for name, values := range entry.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set("Age", strconv.FormatInt(int64(c.age(entry)/time.Second), 10))
+	header.Set(StatusHeader, status)
+	rw.WriteHeader(entry.StatusCode)
+
+	if req.Method != http.MethodHead {
+		if _, err := rw.Write(entry.Body); err != nil {
+			log.Debugf("Error writing the cached response of %s: %v", req.URL, err)
+		}
+	}
+}
+
+func (c *Cache) age(entry *Entry) time.Duration {
+	age := c.now().Sub(entry.Date)
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// conditionalRequest returns a copy of the request, validating the cached response.
+// The validators of the client are removed: they don't apply to the cached response.
+func conditionalRequest(req *http.Request, entry *Entry) *http.Request {
+	outReq := req.WithContext(req.Context())
+	outReq.Header = make(http.Header)
+	for name, values := range req.Header {
+		outReq.Header[name] = values
+	}
+
+	outReq.Header.Del("If-None-Match")
+	outReq.Header.Del("If-Modified-Since")
+	if etag := entry.Header.Get("ETag"); len(etag) > 0 {
+		outReq.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); len(lastModified) > 0 {
+		outReq.Header.Set("If-Modified-Since", lastModified)
+	}
+	return outReq
+}
+
+// detach returns a copy of the request outliving it, for the background revalidations:
+// it is not canceled with the request, and has its own access log data.
+func detach(req *http.Request) *http.Request {
+	ctx := context.Background()
+	if _, ok := req.Context().Value(accesslog.DataTableKey).(*accesslog.LogData); ok {
+		ctx = context.WithValue(ctx, accesslog.DataTableKey, &accesslog.LogData{Core: make(accesslog.CoreLogData), Request: req.Header})
+	}
+
+	outReq := req.WithContext(ctx)
+	outReq.Body = http.NoBody
+	outReq.ContentLength = 0
+	return outReq
+}
+
+// saveStatus records the cache status of the request in the access logs, when they are enabled.
+func saveStatus(req *http.Request, status string) {
+	if table, ok := req.Context().Value(accesslog.DataTableKey).(*accesslog.LogData); ok {
+		table.Core[accesslog.CacheStatus] = status
+	}
+}
+
+func setStatusHeader(status string) func(header http.Header) {
+	return func(header http.Header) {
+		header.Set(StatusHeader, status)
+	}
+}
+
+func isServerError(statusCode int) bool {
+	switch statusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// requestKey returns the key of the cached response of the request: its URL.
+func requestKey(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + strings.ToLower(req.Host) + req.URL.RequestURI()
+}
+
+// urlKey returns the key of the cached response of the URL.
+func urlKey(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return "", fmt.Errorf("missing scheme or host in the URL %q", rawURL)
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + u.RequestURI(), nil
+}
+
+// variantKey returns the key of the variant of the cached response, selected by the request headers.
+func variantKey(key string, vary []string, req *http.Request) string {
+	parts := []string{key}
+	for _, name := range vary {
+		parts = append(parts, name+": "+strings.Join(req.Header[name], ","))
+	}
+	return strings.Join(parts, variantSeparator)
+}
+
+// Caches holds the caches of the frontends, by frontend name.
+// The zero value is ready to use.
+type Caches struct {
+	lock   sync.RWMutex
+	caches map[string]*Cache
+}
+
+// Set replaces the caches, e.g. after a configuration reload.
+func (c *Caches) Set(caches map[string]*Cache) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.caches = caches
+}
+
+// Get returns the cache of the frontend.
+func (c *Caches) Get(name string) (*Cache, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	cache, ok := c.caches[name]
+	return cache, ok
+}
+
+// Purge deletes the cached responses of the URL from all the caches, or all their responses when the URL is empty.
+// It returns the number of deleted entries.
+func (c *Caches) Purge(rawURL string) (int, error) {
+	if len(rawURL) > 0 {
+		if _, err := urlKey(rawURL); err != nil {
+			return 0, err
+		}
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	purged := 0
+	for _, cache := range c.caches {
+		n, err := cache.Purge(rawURL)
+		if err != nil {
+			return purged, err
+		}
+		purged += n
+	}
+	return purged, nil
+}
@@ -0,0 +1,493 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/middlewares/accesslog"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backend is a test backend answering with its handler, and counting its requests.
+type backend struct {
+	lock     sync.Mutex
+	requests []*http.Request
+	handler  http.HandlerFunc
+}
+
+func (b *backend) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	b.lock.Lock()
+	b.requests = append(b.requests, req)
+	b.lock.Unlock()
+	b.handler(rw, req)
+}
+
+func (b *backend) count() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.requests)
+}
+
+func (b *backend) last() *http.Request {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.requests[len(b.requests)-1]
+}
+
+// clock is a manually advanced clock.
+type clock struct {
+	lock sync.Mutex
+	time time.Time
+}
+
+func (c *clock) now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.time
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.time = c.time.Add(d)
+}
+
+func newTestCache(t *testing.T, config *types.Cache) (*Cache, *clock) {
+	c, err := New("frontend", config)
+	require.NoError(t, err)
+
+	testClock := &clock{time: time.Date(2018, 3, 12, 10, 0, 0, 0, time.UTC)}
+	c.now = testClock.now
+	return c, testClock
+}
+
+func serve(c *Cache, b *backend, req *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	c.ServeHTTP(recorder, req, b.ServeHTTP)
+	return recorder
+}
+
+func get(c *Cache, b *backend, target string) *httptest.ResponseRecorder {
+	return serve(c, b, httptest.NewRequest(http.MethodGet, target, nil))
+}
+
+func TestCacheHit(t *testing.T) {
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.Write([]byte("response"))
+	}}
+	c, testClock := newTestCache(t, &types.Cache{})
+
+	recorder := get(c, b, "http://example.com/path")
+	assert.Equal(t, StatusMiss, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "response", recorder.Body.String())
+
+	testClock.advance(10 * time.Second)
+	recorder = get(c, b, "http://example.com/path")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, StatusHit, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "10", recorder.Header().Get("Age"))
+	assert.Equal(t, "text/plain", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "response", recorder.Body.String())
+	assert.Equal(t, 1, b.count())
+
+	recorder = serve(c, b, httptest.NewRequest(http.MethodHead, "http://example.com/path", nil))
+	assert.Equal(t, StatusHit, recorder.Header().Get(StatusHeader))
+	assert.Empty(t, recorder.Body.String())
+
+	// other URLs have their own responses
+	get(c, b, "http://example.com/path?query")
+	get(c, b, "http://other.com/path")
+	assert.Equal(t, 3, b.count())
+
+	testClock.advance(time.Minute)
+	recorder = get(c, b, "http://example.com/path")
+	assert.Equal(t, StatusMiss, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, 4, b.count())
+}
+
+func TestCacheNotStored(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		config   types.Cache
+		request  func(req *http.Request)
+		response func(rw http.ResponseWriter)
+	}{
+		{
+			desc:     "no freshness",
+			response: func(rw http.ResponseWriter) {},
+		},
+		{
+			desc:     "no-store",
+			response: func(rw http.ResponseWriter) { rw.Header().Set("Cache-Control", "max-age=60, no-store") },
+		},
+		{
+			desc:     "private",
+			response: func(rw http.ResponseWriter) { rw.Header().Set("Cache-Control", "private, max-age=60") },
+		},
+		{
+			desc:     "no-cache",
+			response: func(rw http.ResponseWriter) { rw.Header().Set("Cache-Control", "no-cache") },
+		},
+		{
+			desc: "cookie",
+			response: func(rw http.ResponseWriter) {
+				rw.Header().Set("Cache-Control", "max-age=60")
+				rw.Header().Set("Set-Cookie", "session=1")
+			},
+		},
+		{
+			desc: "vary all",
+			response: func(rw http.ResponseWriter) {
+				rw.Header().Set("Cache-Control", "max-age=60")
+				rw.Header().Set("Vary", "*")
+			},
+		},
+		{
+			desc: "expired",
+			response: func(rw http.ResponseWriter) {
+				rw.Header().Set("Expires", "0")
+			},
+		},
+		{
+			desc: "status code",
+			response: func(rw http.ResponseWriter) {
+				rw.Header().Set("Cache-Control", "max-age=60")
+				rw.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+		{
+			desc:    "authorization",
+			request: func(req *http.Request) { req.Header.Set("Authorization", "Basic dGVzdDp0ZXN0") },
+			response: func(rw http.ResponseWriter) {
+				rw.Header().Set("Cache-Control", "max-age=60")
+			},
+		},
+		{
+			desc:   "too large",
+			config: types.Cache{MaxEntrySize: 4},
+			response: func(rw http.ResponseWriter) {
+				rw.Header().Set("Cache-Control", "max-age=60")
+				rw.Write([]byte("response"))
+			},
+		},
+		{
+			desc:    "request no-store",
+			request: func(req *http.Request) { req.Header.Set("Cache-Control", "no-store") },
+			response: func(rw http.ResponseWriter) {
+				rw.Header().Set("Cache-Control", "max-age=60")
+			},
+		},
+		{
+			desc:    "range",
+			request: func(req *http.Request) { req.Header.Set("Range", "bytes=0-1") },
+			response: func(rw http.ResponseWriter) {
+				rw.Header().Set("Cache-Control", "max-age=60")
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+				test.response(rw)
+				rw.Write([]byte("response"))
+			}}
+			c, _ := newTestCache(t, &test.config)
+
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+				if test.request != nil {
+					test.request(req)
+				}
+				serve(c, b, req)
+			}
+			assert.Equal(t, 2, b.count())
+		})
+	}
+}
+
+func TestCacheDefaultTTL(t *testing.T) {
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("response"))
+	}}
+	c, testClock := newTestCache(t, &types.Cache{DefaultTTL: flaeg.Duration(time.Minute)})
+
+	get(c, b, "http://example.com/path")
+	testClock.advance(59 * time.Second)
+	assert.Equal(t, StatusHit, get(c, b, "http://example.com/path").Header().Get(StatusHeader))
+
+	testClock.advance(time.Second)
+	assert.Equal(t, StatusMiss, get(c, b, "http://example.com/path").Header().Get(StatusHeader))
+}
+
+func TestCacheExpires(t *testing.T) {
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Date", "Mon, 12 Mar 2018 10:00:00 GMT")
+		rw.Header().Set("Expires", "Mon, 12 Mar 2018 10:00:30 GMT")
+		rw.Header().Set("Age", "10")
+	}}
+	c, testClock := newTestCache(t, &types.Cache{})
+
+	get(c, b, "http://example.com/path")
+	testClock.advance(10 * time.Second)
+	recorder := get(c, b, "http://example.com/path")
+	assert.Equal(t, StatusHit, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "20", recorder.Header().Get("Age"))
+
+	testClock.advance(10 * time.Second)
+	assert.Equal(t, StatusMiss, get(c, b, "http://example.com/path").Header().Get(StatusHeader))
+}
+
+func TestCacheVary(t *testing.T) {
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Header().Set("Vary", "accept-language")
+		rw.Write([]byte(req.Header.Get("Accept-Language")))
+	}}
+	c, _ := newTestCache(t, &types.Cache{})
+
+	request := func(language string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+		req.Header.Set("Accept-Language", language)
+		return serve(c, b, req)
+	}
+
+	assert.Equal(t, StatusMiss, request("fr").Header().Get(StatusHeader))
+	assert.Equal(t, StatusMiss, request("en").Header().Get(StatusHeader))
+
+	recorder := request("fr")
+	assert.Equal(t, StatusHit, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "fr", recorder.Body.String())
+	recorder = request("en")
+	assert.Equal(t, StatusHit, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "en", recorder.Body.String())
+	assert.Equal(t, 2, b.count())
+
+	n, err := c.Purge("http://example.com/path")
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, StatusMiss, request("fr").Header().Get(StatusHeader))
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		rw.Header().Set("ETag", `"v1"`)
+		rw.Write([]byte("response"))
+	}}
+	c, testClock := newTestCache(t, &types.Cache{})
+
+	get(c, b, "http://example.com/path")
+	testClock.advance(2 * time.Minute)
+
+	// the validators of the client don't apply to the cached response
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+	req.Header.Set("If-None-Match", `"v0"`)
+	recorder := serve(c, b, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, StatusRevalidated, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "response", recorder.Body.String())
+	assert.Equal(t, `"v1"`, b.last().Header.Get("If-None-Match"))
+
+	// the response is fresh again
+	assert.Equal(t, StatusHit, get(c, b, "http://example.com/path").Header().Get(StatusHeader))
+	assert.Equal(t, 2, b.count())
+
+	// until the client asks for a validation
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	assert.Equal(t, StatusRevalidated, serve(c, b, req).Header().Get(StatusHeader))
+	assert.Equal(t, 3, b.count())
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	version := make(chan string, 1)
+	version <- "v1"
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60, stale-while-revalidate=30")
+		rw.Write([]byte(<-version))
+	}}
+	c, testClock := newTestCache(t, &types.Cache{})
+
+	get(c, b, "http://example.com/path")
+	testClock.advance(70 * time.Second)
+
+	version <- "v2"
+	recorder := get(c, b, "http://example.com/path")
+	assert.Equal(t, StatusStale, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "v1", recorder.Body.String())
+
+	// the response is revalidated in the background
+	deadline := time.Now().Add(time.Second)
+	for {
+		recorder = get(c, b, "http://example.com/path")
+		if recorder.Body.String() == "v2" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, StatusHit, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "v2", recorder.Body.String())
+	assert.Equal(t, 2, b.count())
+
+	// past the stale-while-revalidate window, the response is revalidated before answering
+	testClock.advance(2 * time.Minute)
+	version <- "v3"
+	recorder = get(c, b, "http://example.com/path")
+	assert.Equal(t, StatusMiss, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "v3", recorder.Body.String())
+}
+
+func TestCacheStaleIfError(t *testing.T) {
+	failing := false
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		if failing {
+			rw.WriteHeader(http.StatusBadGateway)
+			rw.Write([]byte("bad gateway"))
+			return
+		}
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Write([]byte("response"))
+	}}
+	c, testClock := newTestCache(t, &types.Cache{StaleIfError: flaeg.Duration(time.Minute)})
+
+	get(c, b, "http://example.com/path")
+	testClock.advance(90 * time.Second)
+	failing = true
+
+	recorder := get(c, b, "http://example.com/path")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, StatusStale, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "response", recorder.Body.String())
+
+	// past the stale-if-error window, the error is forwarded
+	testClock.advance(time.Minute)
+	recorder = get(c, b, "http://example.com/path")
+	assert.Equal(t, http.StatusBadGateway, recorder.Code)
+	assert.Equal(t, StatusMiss, recorder.Header().Get(StatusHeader))
+	assert.Equal(t, "bad gateway", recorder.Body.String())
+}
+
+func TestCacheBypass(t *testing.T) {
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+	}}
+	c, _ := newTestCache(t, &types.Cache{})
+
+	recorder := serve(c, b, httptest.NewRequest(http.MethodPost, "http://example.com/path", nil))
+	assert.Equal(t, StatusBypass, recorder.Header().Get(StatusHeader))
+	serve(c, b, httptest.NewRequest(http.MethodPost, "http://example.com/path", nil))
+	assert.Equal(t, 2, b.count())
+}
+
+func TestCacheAccessLog(t *testing.T) {
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+	}}
+	c, _ := newTestCache(t, &types.Cache{})
+
+	for _, expected := range []string{StatusMiss, StatusHit} {
+		logDataTable := &accesslog.LogData{Core: make(accesslog.CoreLogData)}
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+		req = req.WithContext(context.WithValue(req.Context(), accesslog.DataTableKey, logDataTable))
+
+		serve(c, b, req)
+		assert.Equal(t, expected, logDataTable.Core[accesslog.CacheStatus])
+	}
+}
+
+func TestCachesPurge(t *testing.T) {
+	b := &backend{handler: func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Cache-Control", "max-age=60")
+	}}
+	c, _ := newTestCache(t, &types.Cache{})
+	get(c, b, "http://example.com/path")
+	get(c, b, "http://example.com/path/sub")
+	get(c, b, "https://example.com/other")
+
+	caches := &Caches{}
+	n, err := caches.Purge("")
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	caches.Set(map[string]*Cache{"frontend": c})
+	_, ok := caches.Get("frontend")
+	assert.True(t, ok)
+
+	_, err = caches.Purge("/path")
+	assert.Error(t, err)
+
+	// only the exact URL is purged
+	n, err = caches.Purge("http://EXAMPLE.com/path")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, StatusHit, get(c, b, "http://example.com/path/sub").Header().Get(StatusHeader))
+
+	n, err = caches.Purge("")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestValidateConfig(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config types.Cache
+		valid  bool
+	}{
+		{
+			desc:  "defaults",
+			valid: true,
+		},
+		{
+			desc:   "disk",
+			config: types.Cache{Store: StoreDisk, Directory: "/var/cache/traefik"},
+			valid:  true,
+		},
+		{
+			desc:   "disk without directory",
+			config: types.Cache{Store: StoreDisk},
+		},
+		{
+			desc:   "unknown store",
+			config: types.Cache{Store: "redis"},
+		},
+		{
+			desc:   "negative size",
+			config: types.Cache{MaxSize: -1},
+		},
+		{
+			desc:   "negative duration",
+			config: types.Cache{StaleIfError: flaeg.Duration(-time.Second)},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateConfig(&test.config)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
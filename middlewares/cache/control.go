@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDeltaSeconds is the largest delta-seconds value, as a duration.
+const maxDeltaSeconds = math.MaxInt64 / int64(time.Second)
+
+// cacheControl holds the directives of the Cache-Control headers, by lower case name.
+// The directives without argument have an empty value.
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if len(directive) == 0 {
+				continue
+			}
+
+			name, argument := directive, ""
+			if i := strings.Index(directive, "="); i >= 0 {
+				name = strings.TrimSpace(directive[:i])
+				argument = strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			cc[strings.ToLower(name)] = argument
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+// duration returns the value of a delta-seconds directive.
+func (cc cacheControl) duration(name string) (time.Duration, bool) {
+	argument, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseInt(argument, 10, 64)
+	if err != nil {
+		// delta-seconds larger than the int64 range are valid, and capped
+		if numErr, ok := err.(*strconv.NumError); !ok || numErr.Err != strconv.ErrRange || strings.HasPrefix(argument, "-") {
+			return 0, false
+		}
+		seconds = maxDeltaSeconds
+	}
+	if seconds < 0 {
+		return 0, false
+	}
+	if seconds > maxDeltaSeconds {
+		seconds = maxDeltaSeconds
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// parseVary returns the canonical names of the request headers of the Vary headers.
+func parseVary(header http.Header) []string {
+	var names []string
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if len(name) > 0 {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// heuristicallyCacheable holds the status codes of the responses that can be stored,
+// the others never being cached.
+var heuristicallyCacheable = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// hopHeaders are the headers of a connection, never stored.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	header := http.Header{}
+	header.Add("Cache-Control", `public, Max-Age="60"`)
+	header.Add("Cache-Control", "stale-while-revalidate=30,, no-transform")
+
+	cc := parseCacheControl(header)
+	assert.Equal(t, cacheControl{
+		"public":                 "",
+		"max-age":                "60",
+		"stale-while-revalidate": "30",
+		"no-transform":           "",
+	}, cc)
+	assert.True(t, cc.has("public"))
+	assert.False(t, cc.has("private"))
+}
+
+func TestCacheControlDuration(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		valid    bool
+	}{
+		{value: "max-age=60", expected: time.Minute, valid: true},
+		{value: "max-age=0", expected: 0, valid: true},
+		{value: "max-age=99999999999999999999", expected: time.Duration(maxDeltaSeconds) * time.Second, valid: true},
+		{value: "max-age=-1"},
+		{value: "max-age=-99999999999999999999"},
+		{value: "max-age=soon"},
+		{value: "max-age"},
+		{value: "public"},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.value, func(t *testing.T) {
+			t.Parallel()
+
+			duration, ok := parseCacheControl(http.Header{"Cache-Control": {test.value}}).duration("max-age")
+			assert.Equal(t, test.valid, ok)
+			assert.Equal(t, test.expected, duration)
+		})
+	}
+}
+
+func TestParseVary(t *testing.T) {
+	header := http.Header{}
+	header.Add("Vary", "accept-encoding, Accept-Language")
+	header.Add("Vary", "x-custom")
+
+	assert.Equal(t, []string{"Accept-Encoding", "Accept-Language", "X-Custom"}, parseVary(header))
+}
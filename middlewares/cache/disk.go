@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/containous/traefik/log"
+)
+
+const (
+	diskEntryExtension = ".entry"
+	diskTempPrefix     = ".tmp-"
+)
+
+// diskEntry is the content of the files of the disk store.
+type diskEntry struct {
+	Key   string
+	Entry *Entry
+}
+
+var _ Store = (*DiskStore)(nil)
+
+// DiskStore keeps the entries in the files of a directory, they survive the restarts.
+// The index of the entries is kept in memory, and rebuilt from the files on creation.
+type DiskStore struct {
+	lock      sync.Mutex
+	directory string
+	index     *lru
+}
+
+// NewDiskStore creates a store in the directory, holding up to maxSize bytes of entries.
+// The entries already in the directory are kept, up to maxSize.
+func NewDiskStore(directory string, maxSize int64) (*DiskStore, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, err
+	}
+
+	s := &DiskStore{
+		directory: directory,
+		index:     newLRU(maxSize),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load indexes the entries of the directory, from the least recently modified one,
+// and deletes the files left by interrupted writes.
+func (s *DiskStore) load() error {
+	files, err := ioutil.ReadDir(s.directory)
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+
+	for _, file := range files {
+		path := filepath.Join(s.directory, file.Name())
+		switch {
+		case strings.HasPrefix(file.Name(), diskTempPrefix):
+			s.removeFile(path)
+		case strings.HasSuffix(file.Name(), diskEntryExtension):
+			stored, err := readDiskEntry(path)
+			if err != nil || s.path(stored.Key) != path {
+				log.Debugf("Deleting invalid cache file %s: %v", path, err)
+				s.removeFile(path)
+				continue
+			}
+			for _, evicted := range s.index.add(stored.Key, int64(len(stored.Key))+stored.Entry.size()) {
+				s.removeFile(s.path(evicted))
+			}
+		}
+	}
+	return nil
+}
+
+// Get returns the entry of the key.
+func (s *DiskStore) Get(key string) (*Entry, bool) {
+	s.lock.Lock()
+	known := s.index.touch(key)
+	s.lock.Unlock()
+	if !known {
+		return nil, false
+	}
+
+	stored, err := readDiskEntry(s.path(key))
+	if err != nil {
+		// the entry may have been evicted in the meantime
+		log.Debugf("Error reading the cache entry of %s: %v", key, err)
+		return nil, false
+	}
+	return stored.Entry, true
+}
+
+// Set stores the entry of the key.
+func (s *DiskStore) Set(key string, entry *Entry) {
+	file, err := ioutil.TempFile(s.directory, diskTempPrefix)
+	if err != nil {
+		log.Errorf("Error creating the cache entry of %s: %v", key, err)
+		return
+	}
+
+	err = gob.NewEncoder(file).Encode(&diskEntry{Key: key, Entry: entry})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Errorf("Error writing the cache entry of %s: %v", key, err)
+		s.removeFile(file.Name())
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := os.Rename(file.Name(), s.path(key)); err != nil {
+		log.Errorf("Error writing the cache entry of %s: %v", key, err)
+		s.removeFile(file.Name())
+		return
+	}
+	for _, evicted := range s.index.add(key, int64(len(key))+entry.size()) {
+		s.removeFile(s.path(evicted))
+	}
+}
+
+// Delete deletes the entry of the key along with its variants.
+func (s *DiskStore) Delete(key string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.delete(s.index.keys(func(k string) bool { return isVariantOf(k, key) }))
+}
+
+// Clear deletes all the entries.
+func (s *DiskStore) Clear() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.delete(s.index.keys(func(string) bool { return true }))
+}
+
+func (s *DiskStore) delete(keys []string) int {
+	for _, key := range keys {
+		s.index.remove(key)
+		s.removeFile(s.path(key))
+	}
+	return len(keys)
+}
+
+// path returns the path of the file of the key, named after its hash.
+func (s *DiskStore) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(s.directory, hex.EncodeToString(hash[:])+diskEntryExtension)
+}
+
+func (s *DiskStore) removeFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Error deleting the cache file %s: %v", path, err)
+	}
+}
+
+func readDiskEntry(path string) (*diskEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stored := &diskEntry{}
+	if err := gob.NewDecoder(file).Decode(stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
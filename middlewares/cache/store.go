@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// variantSeparator separates the key of a response from the request headers selecting its variant.
+// It can't be part of an URL.
+const variantSeparator = "\n"
+
+// Entry is a cached response, or the pointer to the variants of a response.
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Date is when the response was generated, its age being the time elapsed since.
+	Date                 time.Time
+	Lifetime             time.Duration
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+	// Vary holds the request headers selecting the variant of the response.
+	// An entry with Vary only points to the variants, stored under their variant keys.
+	Vary []string
+}
+
+func (e *Entry) size() int64 {
+	size := int64(len(e.Body))
+	for name, values := range e.Header {
+		for _, value := range values {
+			size += int64(len(name) + len(value))
+		}
+	}
+	for _, name := range e.Vary {
+		size += int64(len(name))
+	}
+	return size
+}
+
+// Store holds the cached entries, by key.
+// The entries must not be modified once stored.
+type Store interface {
+	// Get returns the entry of the key.
+	Get(key string) (*Entry, bool)
+	// Set stores the entry of the key, evicting the least recently used entries to stay under the size of the store.
+	Set(key string, entry *Entry)
+	// Delete deletes the entry of the key along with its variants, and returns the number of deleted entries.
+	Delete(key string) int
+	// Clear deletes all the entries, and returns their number.
+	Clear() int
+}
+
+// isVariantOf tells whether the key is the one of the response, or one of its variants.
+func isVariantOf(key, responseKey string) bool {
+	return key == responseKey || strings.HasPrefix(key, responseKey+variantSeparator)
+}
+
+// lru tracks the keys by recency of use, and the total size of their entries.
+type lru struct {
+	maxSize  int64
+	size     int64
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+type lruItem struct {
+	key  string
+	size int64
+}
+
+func newLRU(maxSize int64) *lru {
+	return &lru{
+		maxSize:  maxSize,
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// touch marks the key as the most recently used one, and tells whether it is known.
+func (l *lru) touch(key string) bool {
+	element, ok := l.elements[key]
+	if ok {
+		l.order.MoveToFront(element)
+	}
+	return ok
+}
+
+// add adds or updates the key, and returns the least recently used keys to evict to stay under the maximum size.
+func (l *lru) add(key string, size int64) []string {
+	l.remove(key)
+	l.elements[key] = l.order.PushFront(&lruItem{key: key, size: size})
+	l.size += size
+
+	var evicted []string
+	for l.size > l.maxSize && l.order.Len() > 0 {
+		oldest := l.order.Back().Value.(*lruItem)
+		l.remove(oldest.key)
+		evicted = append(evicted, oldest.key)
+	}
+	return evicted
+}
+
+func (l *lru) remove(key string) bool {
+	element, ok := l.elements[key]
+	if !ok {
+		return false
+	}
+	l.order.Remove(element)
+	delete(l.elements, key)
+	l.size -= element.Value.(*lruItem).size
+	return true
+}
+
+// keys returns the keys matching the filter.
+func (l *lru) keys(filter func(key string) bool) []string {
+	var keys []string
+	for key := range l.elements {
+		if filter(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps the entries in memory, they are local to the Traefik instance and lost on restart.
+type MemoryStore struct {
+	lock    sync.Mutex
+	index   *lru
+	entries map[string]*Entry
+}
+
+// NewMemoryStore creates an empty in-memory store, holding up to maxSize bytes of entries.
+func NewMemoryStore(maxSize int64) *MemoryStore {
+	return &MemoryStore{
+		index:   newLRU(maxSize),
+		entries: make(map[string]*Entry),
+	}
+}
+
+// Get returns the entry of the key.
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.index.touch(key) {
+		return nil, false
+	}
+	return s.entries[key], true
+}
+
+// Set stores the entry of the key.
+func (s *MemoryStore) Set(key string, entry *Entry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.entries[key] = entry
+	for _, evicted := range s.index.add(key, int64(len(key))+entry.size()) {
+		delete(s.entries, evicted)
+	}
+}
+
+// Delete deletes the entry of the key along with its variants.
+func (s *MemoryStore) Delete(key string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.delete(s.index.keys(func(k string) bool { return isVariantOf(k, key) }))
+}
+
+// Clear deletes all the entries.
+func (s *MemoryStore) Clear() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.delete(s.index.keys(func(string) bool { return true }))
+}
+
+func (s *MemoryStore) delete(keys []string) int {
+	for _, key := range keys {
+		s.index.remove(key)
+		delete(s.entries, key)
+	}
+	return len(keys)
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEntry(body string) *Entry {
+	return &Entry{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       []byte(body),
+		Date:       time.Date(2018, 3, 12, 10, 0, 0, 0, time.UTC),
+		Lifetime:   time.Minute,
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+
+	store.Set("http://example.com/a", newEntry("a"))
+	store.Set("http://example.com/a"+variantSeparator+"Accept: text/html", newEntry("html"))
+	store.Set("http://example.com/ab", newEntry("ab"))
+
+	entry, ok := store.Get("http://example.com/a")
+	require.True(t, ok)
+	assert.Equal(t, newEntry("a"), entry)
+
+	_, ok = store.Get("http://example.com/b")
+	assert.False(t, ok)
+
+	// the variants are deleted with the response, but not the other URLs
+	assert.Equal(t, 2, store.Delete("http://example.com/a"))
+	_, ok = store.Get("http://example.com/a")
+	assert.False(t, ok)
+	_, ok = store.Get("http://example.com/ab")
+	assert.True(t, ok)
+
+	assert.Equal(t, 1, store.Clear())
+	_, ok = store.Get("http://example.com/ab")
+	assert.False(t, ok)
+}
+
+func testStoreEviction(t *testing.T, store Store) {
+	t.Helper()
+
+	// each entry is 20 bytes large, key included
+	store.Set("key1", newEntry("0123456789abcdef"))
+	store.Set("key2", newEntry("0123456789abcdef"))
+	_, ok := store.Get("key1")
+	require.True(t, ok)
+
+	// key2 is the least recently used
+	store.Set("key3", newEntry("0123456789abcdef"))
+	_, ok = store.Get("key2")
+	assert.False(t, ok)
+	_, ok = store.Get("key1")
+	assert.True(t, ok)
+	_, ok = store.Get("key3")
+	assert.True(t, ok)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(DefaultMaxSize))
+	testStoreEviction(t, NewMemoryStore(50))
+}
+
+func TestDiskStore(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	store, err := NewDiskStore(filepath.Join(directory, "store"), DefaultMaxSize)
+	require.NoError(t, err)
+	testStore(t, store)
+
+	store, err = NewDiskStore(filepath.Join(directory, "eviction"), 50)
+	require.NoError(t, err)
+	testStoreEviction(t, store)
+}
+
+func TestDiskStoreReload(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	store, err := NewDiskStore(directory, DefaultMaxSize)
+	require.NoError(t, err)
+	store.Set("key1", newEntry("0123456789abcdef"))
+	store.Set("key2", newEntry("0123456789abcdef"))
+
+	// the files of interrupted writes and the invalid files are deleted
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, diskTempPrefix+"1"), []byte("partial"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "invalid"+diskEntryExtension), []byte("invalid"), 0600))
+
+	// the entries are kept by a new store, up to its size
+	store, err = NewDiskStore(directory, 30)
+	require.NoError(t, err)
+
+	entries := 0
+	for _, key := range []string{"key1", "key2"} {
+		if entry, ok := store.Get(key); ok {
+			assert.Equal(t, newEntry("0123456789abcdef"), entry)
+			entries++
+		}
+	}
+	assert.Equal(t, 1, entries)
+
+	files, err := ioutil.ReadDir(directory)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// responseCapture records the response of the backend, to store it.
+// Once the status code is known, the response is either forwarded to the client while it is recorded,
+// or only recorded, e.g. to answer with the cached response instead.
+// The body is recorded up to maxSize bytes.
+type responseCapture struct {
+	rw      http.ResponseWriter
+	header  http.Header
+	maxSize int64
+	// forward decides whether the response with the status code is forwarded to the client.
+	forward func(statusCode int) bool
+	// onForward is called before the forwarded response headers are written.
+	onForward func(header http.Header)
+
+	statusCode  int
+	wroteHeader bool
+	forwarded   bool
+	body        bytes.Buffer
+	overflow    bool
+}
+
+func newResponseCapture(rw http.ResponseWriter, maxSize int64, forward func(statusCode int) bool, onForward func(header http.Header)) *responseCapture {
+	return &responseCapture{
+		rw:        rw,
+		header:    make(http.Header),
+		maxSize:   maxSize,
+		forward:   forward,
+		onForward: onForward,
+	}
+}
+
+func (c *responseCapture) Header() http.Header {
+	return c.header
+}
+
+func (c *responseCapture) WriteHeader(statusCode int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.statusCode = statusCode
+
+	if c.rw == nil || !c.forward(statusCode) {
+		return
+	}
+	c.forwarded = true
+
+	header := c.rw.Header()
+	for name, values := range c.header {
+		header[name] = values
+	}
+	if c.onForward != nil {
+		c.onForward(header)
+	}
+	c.rw.WriteHeader(statusCode)
+}
+
+func (c *responseCapture) Write(data []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+
+	if !c.overflow {
+		if int64(c.body.Len()+len(data)) > c.maxSize {
+			c.overflow = true
+			c.body.Reset()
+		} else {
+			c.body.Write(data)
+		}
+	}
+
+	if c.forwarded {
+		return c.rw.Write(data)
+	}
+	return len(data), nil
+}
+
+// finish completes the responses without status code.
+func (c *responseCapture) finish() {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+}
+
+func (c *responseCapture) Flush() {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.forwarded {
+		return
+	}
+	if flusher, ok := c.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *responseCapture) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := c.rw.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", c.rw)
+	}
+	// the connection is not a cacheable HTTP response anymore
+	c.overflow = true
+	return hijacker.Hijack()
+}
+
+func (c *responseCapture) CloseNotify() <-chan bool {
+	if notifier, ok := c.rw.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(<-chan bool)
+}
@@ -1,25 +1,243 @@
 package middlewares
 
 import (
+	"errors"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/containous/traefik/types"
 	"github.com/vulcand/oxy/cbreaker"
 )
 
+// Circuit breaker states.
+const (
+	// CircuitBreakerStandby is the state of the circuit breakers forwarding the requests and watching the responses.
+	CircuitBreakerStandby = "standby"
+	// CircuitBreakerTripped is the state of the circuit breakers answering all the requests with their fallback.
+	CircuitBreakerTripped = "tripped"
+	// CircuitBreakerRecovering is the state of the circuit breakers forwarding a growing part of the requests,
+	// until they are back in standby.
+	CircuitBreakerRecovering = "recovering"
+)
+
+// defaultCircuitBreakerFallbackDuration is the oxy default fallback duration.
+const defaultCircuitBreakerFallbackDuration = 10 * time.Second
+
+// CircuitBreakerListener is used to inform about the state changes of a circuit breaker.
+type CircuitBreakerListener interface {
+	// StateChanged will be called when the circuit breaker enters the state.
+	StateChanged(state string)
+}
+
+// CircuitBreakerState is the state of a circuit breaker, and the time it was entered.
+type CircuitBreakerState struct {
+	State string    `json:"state"`
+	Since time.Time `json:"since"`
+}
+
 // CircuitBreaker holds the oxy circuit breaker.
 type CircuitBreaker struct {
-	circuitBreaker *cbreaker.CircuitBreaker
+	circuitBreaker   *cbreaker.CircuitBreaker
+	fallbackDuration time.Duration
+	listener         CircuitBreakerListener
+
+	mutex      sync.RWMutex
+	state      CircuitBreakerState
+	generation int
+	recovery   *time.Timer
+	// stopped is set once the circuit breaker is replaced, its state changes are then ignored
+	stopped bool
 }
 
 // NewCircuitBreaker returns a new CircuitBreaker.
-func NewCircuitBreaker(next http.Handler, expression string, options ...cbreaker.CircuitBreakerOption) (*CircuitBreaker, error) {
-	circuitBreaker, err := cbreaker.New(next, expression, options...)
+// While it is tripped, the requests are answered by the fallback handler if it is set,
+// or else by the static fallback response of the configuration, which defaults to a 503.
+// The listener may be nil.
+func NewCircuitBreaker(next http.Handler, config *types.CircuitBreaker, fallback http.Handler, listener CircuitBreakerListener, options ...cbreaker.CircuitBreakerOption) (*CircuitBreaker, error) {
+	cb := &CircuitBreaker{
+		fallbackDuration: defaultCircuitBreakerFallbackDuration,
+		listener:         listener,
+		state:            CircuitBreakerState{State: CircuitBreakerStandby, Since: time.Now()},
+	}
+
+	if config.FallbackDuration < 0 || config.RecoveryDuration < 0 || config.CheckPeriod < 0 {
+		return nil, errors.New("negative circuit breaker durations")
+	}
+	if config.FallbackDuration > 0 {
+		cb.fallbackDuration = time.Duration(config.FallbackDuration)
+		options = append(options, cbreaker.FallbackDuration(cb.fallbackDuration))
+	}
+	if config.RecoveryDuration > 0 {
+		options = append(options, cbreaker.RecoveryDuration(time.Duration(config.RecoveryDuration)))
+	}
+	if config.CheckPeriod > 0 {
+		options = append(options, cbreaker.CheckPeriod(time.Duration(config.CheckPeriod)))
+	}
+
+	if fallback == nil && config.Fallback != nil && len(config.Fallback.Backend) == 0 {
+		statusCode := config.Fallback.StatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusServiceUnavailable
+		}
+		if statusCode < 100 || statusCode > 599 {
+			return nil, errors.New("invalid circuit breaker fallback status code")
+		}
+
+		responseFallback, err := cbreaker.NewResponseFallback(cbreaker.Response{
+			StatusCode:  statusCode,
+			ContentType: config.Fallback.ContentType,
+			Body:        []byte(config.Fallback.Body),
+		})
+		if err != nil {
+			return nil, err
+		}
+		fallback = responseFallback
+	}
+	if fallback != nil {
+		options = append(options, cbreaker.Fallback(fallback))
+	}
+
+	options = append(options,
+		cbreaker.OnTripped(stateSideEffect{circuitBreaker: cb, state: CircuitBreakerTripped}),
+		cbreaker.OnStandby(stateSideEffect{circuitBreaker: cb, state: CircuitBreakerStandby}))
+
+	circuitBreaker, err := cbreaker.New(next, config.Expression, options...)
 	if err != nil {
 		return nil, err
 	}
-	return &CircuitBreaker{circuitBreaker}, nil
+	cb.circuitBreaker = circuitBreaker
+	return cb, nil
 }
 
 func (cb *CircuitBreaker) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	cb.circuitBreaker.ServeHTTP(rw, r)
 }
+
+// State returns the current state of the circuit breaker.
+func (cb *CircuitBreaker) State() CircuitBreakerState {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	return cb.state
+}
+
+// setState records the state entered by the oxy circuit breaker.
+// The oxy circuit breaker only tells when it is tripped or back in standby:
+// it is recovering once the fallback duration is elapsed.
+func (cb *CircuitBreaker) setState(state string) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.stopped {
+		return
+	}
+
+	// oxy executes each side effect in its own goroutine, so they may run out of order.
+	// It stays tripped for the fallback duration at least: a standby coming sooner predates the trip.
+	if state == CircuitBreakerStandby && cb.state.State == CircuitBreakerTripped && time.Since(cb.state.Since) < cb.fallbackDuration {
+		return
+	}
+
+	if cb.recovery != nil {
+		cb.recovery.Stop()
+		cb.recovery = nil
+	}
+	cb.generation++
+
+	if state == CircuitBreakerTripped {
+		generation := cb.generation
+		cb.recovery = time.AfterFunc(cb.fallbackDuration, func() {
+			cb.setRecovering(generation)
+		})
+	}
+
+	cb.enter(state)
+}
+
+// setRecovering records the recovering state, unless the state changed since the given generation.
+func (cb *CircuitBreaker) setRecovering(generation int) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.stopped || cb.generation != generation || cb.state.State != CircuitBreakerTripped {
+		return
+	}
+	cb.recovery = nil
+	cb.enter(CircuitBreakerRecovering)
+}
+
+// stop stops the recovery of a replaced circuit breaker, and ignores its next state changes,
+// so that it no longer reports the state of its backend.
+func (cb *CircuitBreaker) stop() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.recovery != nil {
+		cb.recovery.Stop()
+		cb.recovery = nil
+	}
+	cb.stopped = true
+}
+
+func (cb *CircuitBreaker) enter(state string) {
+	cb.state = CircuitBreakerState{State: state, Since: time.Now()}
+	if cb.listener != nil {
+		cb.listener.StateChanged(state)
+	}
+}
+
+// stateSideEffect is the oxy side effect recording the state changes of the circuit breaker.
+type stateSideEffect struct {
+	circuitBreaker *CircuitBreaker
+	state          string
+}
+
+func (s stateSideEffect) Exec() error {
+	s.circuitBreaker.setState(s.state)
+	return nil
+}
+
+// CircuitBreakers holds the circuit breakers of the backends, by backend name then by entry point name.
+// The zero value is ready to use.
+type CircuitBreakers struct {
+	mutex           sync.RWMutex
+	circuitBreakers map[string]map[string]*CircuitBreaker
+}
+
+// Set replaces the circuit breakers, e.g. after a configuration reload.
+// The replaced circuit breakers are stopped.
+func (c *CircuitBreakers) Set(circuitBreakers map[string]map[string]*CircuitBreaker) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	kept := make(map[*CircuitBreaker]bool)
+	for _, entryPoints := range circuitBreakers {
+		for _, cb := range entryPoints {
+			kept[cb] = true
+		}
+	}
+	for _, entryPoints := range c.circuitBreakers {
+		for _, cb := range entryPoints {
+			if !kept[cb] {
+				cb.stop()
+			}
+		}
+	}
+
+	c.circuitBreakers = circuitBreakers
+}
+
+// States returns the states of the circuit breakers, by backend name then by entry point name.
+func (c *CircuitBreakers) States() map[string]map[string]CircuitBreakerState {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	states := make(map[string]map[string]CircuitBreakerState)
+	for backendName, entryPoints := range c.circuitBreakers {
+		states[backendName] = make(map[string]CircuitBreakerState)
+		for entryPointName, cb := range entryPoints {
+			states[backendName][entryPointName] = cb.State()
+		}
+	}
+	return states
+}
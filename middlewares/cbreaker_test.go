@@ -0,0 +1,201 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectingStatesListener is a CircuitBreakerListener collecting the states.
+type collectingStatesListener struct {
+	mutex  sync.Mutex
+	states []string
+}
+
+func (l *collectingStatesListener) StateChanged(state string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.states = append(l.states, state)
+}
+
+func (l *collectingStatesListener) collected() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string(nil), l.states...)
+}
+
+func waitForState(t *testing.T, cb *CircuitBreaker, state string) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for cb.State().State != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("circuit breaker state is %s, want %s", cb.State().State, state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing int32 = 1
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	listener := &collectingStatesListener{}
+	cb, err := NewCircuitBreaker(next, &types.CircuitBreaker{
+		Expression:       "ResponseCodeRatio(500, 600, 0, 600) > 0.5",
+		FallbackDuration: flaeg.Duration(50 * time.Millisecond),
+		RecoveryDuration: flaeg.Duration(50 * time.Millisecond),
+		CheckPeriod:      flaeg.Duration(time.Millisecond),
+		Fallback:         &types.CircuitBreakerFallback{StatusCode: http.StatusTeapot, ContentType: "text/plain", Body: "fallback"},
+	}, nil, listener)
+	require.NoError(t, err)
+
+	serve := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		cb.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil), nil)
+		return recorder
+	}
+
+	assert.Equal(t, CircuitBreakerStandby, cb.State().State)
+	assert.Equal(t, http.StatusInternalServerError, serve().Code)
+
+	waitForState(t, cb, CircuitBreakerTripped)
+	recorder := serve()
+	assert.Equal(t, http.StatusTeapot, recorder.Code)
+	assert.Equal(t, "fallback", recorder.Body.String())
+	assert.Equal(t, "text/plain", recorder.Header().Get("Content-Type"))
+
+	atomic.StoreInt32(&failing, 0)
+	waitForState(t, cb, CircuitBreakerRecovering)
+
+	// the circuit breaker is back in standby after the recovery duration, on the next request
+	deadline := time.Now().Add(time.Second)
+	for cb.State().State != CircuitBreakerStandby && time.Now().Before(deadline) {
+		serve()
+		time.Sleep(5 * time.Millisecond)
+	}
+	waitForState(t, cb, CircuitBreakerStandby)
+
+	assert.Equal(t, []string{CircuitBreakerTripped, CircuitBreakerRecovering, CircuitBreakerStandby}, listener.collected())
+}
+
+func TestCircuitBreakerFallbackHandler(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+	})
+	fallback := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("fallback backend"))
+	})
+
+	cb, err := NewCircuitBreaker(next, &types.CircuitBreaker{
+		Expression:  "ResponseCodeRatio(500, 600, 0, 600) > 0.5",
+		CheckPeriod: flaeg.Duration(time.Millisecond),
+		Fallback:    &types.CircuitBreakerFallback{Backend: "fallback"},
+	}, fallback, nil)
+	require.NoError(t, err)
+
+	cb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	waitForState(t, cb, CircuitBreakerTripped)
+
+	recorder := httptest.NewRecorder()
+	cb.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "fallback backend", recorder.Body.String())
+}
+
+func TestCircuitBreakerSideEffectsOutOfOrder(t *testing.T) {
+	listener := &collectingStatesListener{}
+	cb, err := NewCircuitBreaker(http.NotFoundHandler(), &types.CircuitBreaker{
+		Expression:       "NetworkErrorRatio() > 0.5",
+		FallbackDuration: flaeg.Duration(time.Minute),
+	}, nil, listener)
+	require.NoError(t, err)
+
+	// the standby side effect of a previous recovery runs after the tripped one
+	stateSideEffect{circuitBreaker: cb, state: CircuitBreakerTripped}.Exec()
+	stateSideEffect{circuitBreaker: cb, state: CircuitBreakerStandby}.Exec()
+
+	assert.Equal(t, CircuitBreakerTripped, cb.State().State)
+	assert.Equal(t, []string{CircuitBreakerTripped}, listener.collected())
+}
+
+func TestNewCircuitBreakerErrors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *types.CircuitBreaker
+	}{
+		{
+			desc:   "invalid expression",
+			config: &types.CircuitBreaker{Expression: "NetworkErrorRatio() >"},
+		},
+		{
+			desc:   "negative duration",
+			config: &types.CircuitBreaker{Expression: "NetworkErrorRatio() > 0.5", FallbackDuration: flaeg.Duration(-time.Second)},
+		},
+		{
+			desc: "invalid fallback status code",
+			config: &types.CircuitBreaker{
+				Expression: "NetworkErrorRatio() > 0.5",
+				Fallback:   &types.CircuitBreakerFallback{StatusCode: 1000},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewCircuitBreaker(http.NotFoundHandler(), test.config, nil, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCircuitBreakersStates(t *testing.T) {
+	cb, err := NewCircuitBreaker(http.NotFoundHandler(), &types.CircuitBreaker{Expression: "NetworkErrorRatio() > 0.5"}, nil, nil)
+	require.NoError(t, err)
+
+	circuitBreakers := &CircuitBreakers{}
+	assert.Empty(t, circuitBreakers.States())
+
+	circuitBreakers.Set(map[string]map[string]*CircuitBreaker{"backend": {"http": cb}})
+	states := circuitBreakers.States()
+	assert.Equal(t, CircuitBreakerStandby, states["backend"]["http"].State)
+}
+
+func TestCircuitBreakersSetStopsReplaced(t *testing.T) {
+	listener := &collectingStatesListener{}
+	config := &types.CircuitBreaker{
+		Expression:       "NetworkErrorRatio() > 0.5",
+		FallbackDuration: flaeg.Duration(10 * time.Millisecond),
+	}
+	replaced, err := NewCircuitBreaker(http.NotFoundHandler(), config, nil, listener)
+	require.NoError(t, err)
+	kept, err := NewCircuitBreaker(http.NotFoundHandler(), config, nil, nil)
+	require.NoError(t, err)
+
+	circuitBreakers := &CircuitBreakers{}
+	circuitBreakers.Set(map[string]map[string]*CircuitBreaker{"backend": {"http": replaced, "https": kept}})
+	stateSideEffect{circuitBreaker: replaced, state: CircuitBreakerTripped}.Exec()
+
+	// the recovery of the replaced circuit breaker must not report a state for the backend
+	circuitBreakers.Set(map[string]map[string]*CircuitBreaker{"backend": {"https": kept}})
+	time.Sleep(50 * time.Millisecond)
+	stateSideEffect{circuitBreaker: replaced, state: CircuitBreakerStandby}.Exec()
+
+	assert.Equal(t, []string{CircuitBreakerTripped}, listener.collected())
+	assert.False(t, kept.stopped)
+}
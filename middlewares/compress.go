@@ -1,27 +1,401 @@
 package middlewares
 
 import (
+	"bufio"
 	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
-	"github.com/NYTimes/gziphandler"
+	"github.com/andybalholm/brotli"
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
 )
 
-// Compress is a middleware that allows redirection
-type Compress struct{}
+const (
+	brotliEncoding = "br"
+	gzipEncoding   = "gzip"
 
-// ServerHTTP is a function used by Negroni
+	// DefaultCompressMinSize is the size under which the responses are not compressed.
+	DefaultCompressMinSize = 512
+)
+
+// defaultEncodings are the supported encodings, in their order of preference.
+var defaultEncodings = []string{brotliEncoding, gzipEncoding}
+
+// defaultExcludedContentTypes are the content types of already compressed formats.
+var defaultExcludedContentTypes = []string{
+	"image/*",
+	"video/*",
+	"audio/*",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+}
+
+// Compress is a middleware that compresses the responses with brotli or gzip, depending on the Accept-Encoding request header
+type Compress struct {
+	encodings            []string
+	level                int
+	brotliLevel          int
+	minSize              int
+	contentTypes         []string
+	excludedContentTypes []string
+}
+
+// NewCompress creates a compress middleware from its configuration, the defaults being used for the zero values.
+func NewCompress(config *types.Compress) (*Compress, error) {
+	c := &Compress{}
+	if config == nil {
+		return c, nil
+	}
+
+	for _, encoding := range config.Encodings {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding != brotliEncoding && encoding != gzipEncoding {
+			return nil, fmt.Errorf("unsupported compression encoding %q", encoding)
+		}
+		c.encodings = append(c.encodings, encoding)
+	}
+
+	if config.Level != 0 && (config.Level < gzip.HuffmanOnly || config.Level > gzip.BestCompression) {
+		return nil, fmt.Errorf("invalid gzip compression level %d", config.Level)
+	}
+	c.level = config.Level
+
+	if config.BrotliLevel < 0 || config.BrotliLevel > brotli.BestCompression {
+		return nil, fmt.Errorf("invalid brotli compression level %d", config.BrotliLevel)
+	}
+	c.brotliLevel = config.BrotliLevel
+
+	if config.MinSize < 0 {
+		return nil, fmt.Errorf("invalid compression minimum size %d", config.MinSize)
+	}
+	c.minSize = config.MinSize
+
+	c.contentTypes = normalizeContentTypes(config.ContentTypes)
+	c.excludedContentTypes = normalizeContentTypes(config.ExcludedContentTypes)
+	return c, nil
+}
+
+// ServeHTTP is a function used by Negroni
 func (c *Compress) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	gzipHandler(next).ServeHTTP(rw, r)
+	addVaryHeader(rw.Header())
+
+	encoding := c.negotiateEncoding(r.Header)
+	if len(encoding) == 0 || r.Header.Get("Range") != "" {
+		next(rw, r)
+		return
+	}
+
+	crw := &compressResponseWriter{
+		ResponseWriter: rw,
+		compress:       c,
+		encoding:       encoding,
+	}
+	defer crw.close()
+
+	next(crw, r)
 }
 
-func gzipHandler(h http.Handler) http.Handler {
-	wrapper, err := gziphandler.GzipHandlerWithOpts(
-		gziphandler.CompressionLevel(gzip.DefaultCompression),
-		gziphandler.MinSize(gziphandler.DefaultMinSize))
+// negotiateEncoding returns the encoding accepted with the highest quality by the request,
+// the order of the encodings breaking the ties, or an empty string when no encoding is accepted.
+func (c *Compress) negotiateEncoding(header http.Header) string {
+	encodings := c.encodings
+	if len(encodings) == 0 {
+		encodings = defaultEncodings
+	}
+
+	qualities := make(map[string]float64)
+	for _, value := range header["Accept-Encoding"] {
+		for _, spec := range strings.Split(value, ",") {
+			parts := strings.Split(spec, ";")
+			name := strings.ToLower(strings.TrimSpace(parts[0]))
+			if len(name) == 0 {
+				continue
+			}
+
+			quality := 1.0
+			for _, param := range parts[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+						quality = q
+					}
+				}
+			}
+			qualities[name] = quality
+		}
+	}
+
+	var bestEncoding string
+	var bestQuality float64
+	for _, encoding := range encodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			bestEncoding = encoding
+			bestQuality = quality
+		}
+	}
+	return bestEncoding
+}
+
+// acceptContentType tells whether a response of the content type may be compressed.
+func (c *Compress) acceptContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		log.Error(err)
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	if len(c.contentTypes) > 0 && !matchContentType(c.contentTypes, mediaType) {
+		return false
+	}
+
+	excluded := c.excludedContentTypes
+	if len(excluded) == 0 {
+		excluded = defaultExcludedContentTypes
+	}
+	return !matchContentType(excluded, mediaType)
+}
+
+func (c *Compress) getMinSize() int {
+	if c.minSize == 0 {
+		return DefaultCompressMinSize
+	}
+	return c.minSize
+}
+
+func (c *Compress) newEncodingWriter(encoding string, w io.Writer) (io.WriteCloser, error) {
+	if encoding == brotliEncoding {
+		level := brotli.DefaultCompression
+		if c.brotliLevel != 0 {
+			level = c.brotliLevel
+		}
+		return brotli.NewWriterLevel(w, level), nil
+	}
+
+	level := gzip.DefaultCompression
+	if c.level != 0 {
+		level = c.level
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+// matchContentType tells whether the media type matches one of the content types, which may end with a /* wildcard.
+func matchContentType(contentTypes []string, mediaType string) bool {
+	for _, contentType := range contentTypes {
+		if contentType == mediaType {
+			return true
+		}
+		if strings.HasSuffix(contentType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(contentType, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeContentTypes(contentTypes []string) []string {
+	var normalized []string
+	for _, contentType := range contentTypes {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			normalized = append(normalized, mediaType)
+		} else {
+			normalized = append(normalized, strings.ToLower(strings.TrimSpace(contentType)))
+		}
+	}
+	return normalized
+}
+
+func addVaryHeader(header http.Header) {
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), "Accept-Encoding") {
+				return
+			}
+		}
+	}
+	header.Add("Vary", "Accept-Encoding")
+}
+
+// compressResponseWriter buffers the beginning of the response, until it knows whether the response must be compressed.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	compress *Compress
+	encoding string
+
+	status  int
+	buffer  []byte
+	decided bool
+	writer  io.WriteCloser
+}
+
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if status < http.StatusOK && status != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	if w.status == 0 {
+		w.status = status
+	}
+
+	// no body is expected, or the response may be hijacked
+	if !w.decided && (status == http.StatusSwitchingProtocols || status == http.StatusNoContent || status == http.StatusNotModified) {
+		if err := w.decide(false); err != nil {
+			log.Debugf("Error writing the response: %v", err)
+		}
+	}
+}
+
+func (w *compressResponseWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.writer != nil {
+			return w.writer.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	if !w.isCompressible() {
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buffer = append(w.buffer, data...)
+	if len(w.buffer) >= w.compress.getMinSize() {
+		if err := w.decide(w.acceptContentType()); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// isCompressible tells whether the response may still be compressed, from its status and headers.
+func (w *compressResponseWriter) isCompressible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+
+	if w.status == http.StatusPartialContent {
+		return false
+	}
+
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		return w.compress.acceptContentType(contentType)
+	}
+	return true
+}
+
+// acceptContentType checks the content type of the response, detecting it when it is not set.
+func (w *compressResponseWriter) acceptContentType() bool {
+	contentType := w.Header().Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buffer)
+		w.Header().Set("Content-Type", contentType)
+	}
+	return w.compress.acceptContentType(contentType)
+}
+
+// decide writes the headers, and the buffered data compressed or not.
+func (w *compressResponseWriter) decide(compress bool) error {
+	w.decided = true
+
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+
+		writer, err := w.compress.newEncodingWriter(w.encoding, w.ResponseWriter)
+		if err != nil {
+			log.Errorf("Error creating the %s writer: %v", w.encoding, err)
+			header.Del("Content-Encoding")
+		} else {
+			w.writer = writer
+		}
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	if len(w.buffer) == 0 {
+		return nil
+	}
+
+	buffer := w.buffer
+	w.buffer = nil
+	if w.writer != nil {
+		_, err := w.writer.Write(buffer)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffer)
+	return err
+}
+
+// close writes the buffered data of a short response, or terminates the compressed stream.
+func (w *compressResponseWriter) close() {
+	if !w.decided {
+		if err := w.decide(false); err != nil {
+			log.Debugf("Error writing the response: %v", err)
+		}
+		return
+	}
+
+	if w.writer != nil {
+		if err := w.writer.Close(); err != nil {
+			log.Debugf("Error closing the %s writer: %v", w.encoding, err)
+		}
+	}
+}
+
+// Flush sends the buffered data, a flushed response being compressed regardless of its size.
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		compress := w.isCompressible() && (len(w.buffer) > 0 || w.Header().Get("Content-Type") != "") && w.acceptContentType()
+		if err := w.decide(compress); err != nil {
+			log.Debugf("Error writing the response: %v", err)
+			return
+		}
+	}
+
+	if flusher, ok := w.writer.(interface {
+		Flush() error
+	}); ok {
+		if err := flusher.Flush(); err != nil {
+			log.Debugf("Error flushing the %s writer: %v", w.encoding, err)
+		}
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", w.ResponseWriter)
+	}
+	// the connection is not a HTTP response anymore
+	w.decided = true
+	return hijacker.Hijack()
+}
+
+func (w *compressResponseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
 	}
-	return wrapper(h)
+	return make(<-chan bool)
 }
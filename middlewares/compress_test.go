@@ -1,13 +1,16 @@
 package middlewares
 
 import (
+	"compress/gzip"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
-	"github.com/NYTimes/gziphandler"
+	"github.com/andybalholm/brotli"
 	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/negroni"
@@ -26,7 +29,7 @@ func TestShouldCompressWhenNoContentEncodingHeader(t *testing.T) {
 	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
 	req.Header.Add(acceptEncodingHeader, gzipValue)
 
-	baseBody := generateBytes(gziphandler.DefaultMinSize)
+	baseBody := generateBytes(DefaultCompressMinSize)
 	next := func(rw http.ResponseWriter, r *http.Request) {
 		rw.Write(baseBody)
 	}
@@ -48,7 +51,7 @@ func TestShouldNotCompressWhenContentEncodingHeader(t *testing.T) {
 	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
 	req.Header.Add(acceptEncodingHeader, gzipValue)
 
-	fakeCompressedBody := generateBytes(gziphandler.DefaultMinSize)
+	fakeCompressedBody := generateBytes(DefaultCompressMinSize)
 	next := func(rw http.ResponseWriter, r *http.Request) {
 		rw.Header().Add(contentEncodingHeader, gzipValue)
 		rw.Header().Add(varyHeader, acceptEncodingHeader)
@@ -69,7 +72,7 @@ func TestShouldNotCompressWhenNoAcceptEncodingHeader(t *testing.T) {
 
 	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
 
-	fakeBody := generateBytes(gziphandler.DefaultMinSize)
+	fakeBody := generateBytes(DefaultCompressMinSize)
 	next := func(rw http.ResponseWriter, r *http.Request) {
 		rw.Write(fakeBody)
 	}
@@ -192,6 +195,226 @@ func TestIntegrationShouldCompress(t *testing.T) {
 	}
 }
 
+func TestCompressNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		encodings        []string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		{
+			desc:             "brotli preferred",
+			acceptEncoding:   "gzip, deflate, br",
+			expectedEncoding: brotliEncoding,
+		},
+		{
+			desc:             "gzip only",
+			acceptEncoding:   "gzip",
+			expectedEncoding: gzipEncoding,
+		},
+		{
+			desc:             "quality values",
+			acceptEncoding:   "br;q=0.5, gzip;q=0.8",
+			expectedEncoding: gzipEncoding,
+		},
+		{
+			desc:             "refused encoding",
+			acceptEncoding:   "br;q=0, *",
+			expectedEncoding: gzipEncoding,
+		},
+		{
+			desc:             "wildcard",
+			acceptEncoding:   "*",
+			expectedEncoding: brotliEncoding,
+		},
+		{
+			desc:             "configured encodings",
+			encodings:        []string{"gzip"},
+			acceptEncoding:   "gzip, br",
+			expectedEncoding: gzipEncoding,
+		},
+		{
+			desc:           "unsupported encoding",
+			acceptEncoding: "deflate",
+		},
+		{
+			desc: "no accept encoding",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			compress, err := NewCompress(&types.Compress{Encodings: test.encodings})
+			require.NoError(t, err)
+
+			header := http.Header{}
+			if len(test.acceptEncoding) > 0 {
+				header.Set(acceptEncodingHeader, test.acceptEncoding)
+			}
+
+			assert.Equal(t, test.expectedEncoding, compress.negotiateEncoding(header))
+		})
+	}
+}
+
+func TestCompressBrotli(t *testing.T) {
+	compress, err := NewCompress(&types.Compress{BrotliLevel: 4})
+	require.NoError(t, err)
+
+	req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Add(acceptEncodingHeader, "gzip, br")
+
+	baseBody := generateBytes(10 * DefaultCompressMinSize)
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.Write(baseBody)
+	}
+
+	rw := httptest.NewRecorder()
+	compress.ServeHTTP(rw, req, next)
+
+	assert.Equal(t, brotliEncoding, rw.Header().Get(contentEncodingHeader))
+	assert.Equal(t, acceptEncodingHeader, rw.Header().Get(varyHeader))
+	assert.True(t, rw.Body.Len() < len(baseBody))
+
+	body, err := ioutil.ReadAll(brotli.NewReader(rw.Body))
+	require.NoError(t, err)
+	assert.Equal(t, baseBody, body)
+}
+
+func TestCompressPolicies(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		config           *types.Compress
+		contentType      string
+		bodySize         int
+		expectedEncoding string
+	}{
+		{
+			desc:             "default",
+			contentType:      "text/html; charset=utf-8",
+			bodySize:         DefaultCompressMinSize,
+			expectedEncoding: gzipEncoding,
+		},
+		{
+			desc:        "smaller than the default minimum size",
+			contentType: "text/html",
+			bodySize:    DefaultCompressMinSize - 1,
+		},
+		{
+			desc:             "configured minimum size",
+			config:           &types.Compress{MinSize: 10},
+			contentType:      "text/html",
+			bodySize:         10,
+			expectedEncoding: gzipEncoding,
+		},
+		{
+			desc:        "already compressed content type",
+			contentType: "image/png",
+			bodySize:    DefaultCompressMinSize,
+		},
+		{
+			desc:             "included content type",
+			config:           &types.Compress{ContentTypes: []string{"application/json", "text/*"}},
+			contentType:      "text/css",
+			bodySize:         DefaultCompressMinSize,
+			expectedEncoding: gzipEncoding,
+		},
+		{
+			desc:        "not included content type",
+			config:      &types.Compress{ContentTypes: []string{"application/json"}},
+			contentType: "text/css",
+			bodySize:    DefaultCompressMinSize,
+		},
+		{
+			desc:        "excluded content type",
+			config:      &types.Compress{ExcludedContentTypes: []string{"text/event-stream"}},
+			contentType: "text/event-stream",
+			bodySize:    DefaultCompressMinSize,
+		},
+		{
+			desc:             "detected content type",
+			bodySize:         DefaultCompressMinSize,
+			expectedEncoding: gzipEncoding,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			compress, err := NewCompress(test.config)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, "http://localhost", nil)
+			req.Header.Add(acceptEncodingHeader, gzipValue)
+
+			baseBody := []byte(strings.Repeat("a", test.bodySize))
+			next := func(rw http.ResponseWriter, r *http.Request) {
+				if len(test.contentType) > 0 {
+					rw.Header().Set("Content-Type", test.contentType)
+				}
+				// the body is written in several parts
+				rw.Write(baseBody[:test.bodySize/2])
+				rw.Write(baseBody[test.bodySize/2:])
+			}
+
+			rw := httptest.NewRecorder()
+			compress.ServeHTTP(rw, req, next)
+
+			assert.Equal(t, test.expectedEncoding, rw.Header().Get(contentEncodingHeader))
+			if len(test.expectedEncoding) == 0 {
+				assert.Equal(t, baseBody, rw.Body.Bytes())
+				return
+			}
+
+			reader, err := gzip.NewReader(rw.Body)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, baseBody, body)
+		})
+	}
+}
+
+func TestNewCompressErrors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *types.Compress
+	}{
+		{
+			desc:   "unsupported encoding",
+			config: &types.Compress{Encodings: []string{"deflate"}},
+		},
+		{
+			desc:   "invalid gzip level",
+			config: &types.Compress{Level: 10},
+		},
+		{
+			desc:   "invalid brotli level",
+			config: &types.Compress{BrotliLevel: 12},
+		},
+		{
+			desc:   "invalid minimum size",
+			config: &types.Compress{MinSize: -1},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewCompress(test.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
 func generateBytes(len int) []byte {
 	var value []byte
 	for i := 0; i < len; i++ {
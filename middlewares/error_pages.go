@@ -1,77 +1,469 @@
 package middlewares
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/types"
 	"github.com/vulcand/oxy/forward"
-	"github.com/vulcand/oxy/utils"
 )
 
+// errorPageHeaders are the headers of the original response which describe its content,
+// and are not kept on the error page.
+var errorPageHeaders = []string{
+	"Accept-Ranges",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Length",
+	"Content-Md5",
+	"Content-Range",
+	"Content-Type",
+	"Etag",
+	"Last-Modified",
+}
+
+type errorPagesStateKey struct{}
+
+// errorPagesState is shared by the error pages handlers of a request,
+// so that an error page is not replaced by the error page of an outer handler.
+type errorPagesState struct {
+	served bool
+}
+
 //ErrorPagesHandler is a middleware that provides the custom error pages
 type ErrorPagesHandler struct {
-	HTTPCodeRanges     [][2]int
-	BackendURL         string
-	errorPageForwarder *forward.Forwarder
+	HTTPCodeRanges [][2]int
+	BackendURL     string
+	page           errorPage
 }
 
-//NewErrorPagesHandler initializes the utils.ErrorHandler for the custom error pages
+//NewErrorPagesHandler initializes the utils.ErrorHandler for the custom error pages.
+//The error page is served from its file or content when set, and otherwise fetched from the backend URL.
 func NewErrorPagesHandler(errorPage types.ErrorPage, backendURL string) (*ErrorPagesHandler, error) {
-	fwd, err := forward.New()
+	blocks, err := parseHTTPCodeRanges(errorPage.Status)
 	if err != nil {
 		return nil, err
 	}
 
-	//Break out the http status code ranges into a low int and high int
-	//for ease of use at runtime
-	var blocks [][2]int
-	for _, block := range errorPage.Status {
-		codes := strings.Split(block, "-")
-		//if only a single HTTP code was configured, assume the best and create the correct configuration on the user's behalf
-		if len(codes) == 1 {
-			codes = append(codes, codes[0])
-		}
-		lowCode, err := strconv.Atoi(codes[0])
+	handler := &ErrorPagesHandler{HTTPCodeRanges: blocks}
+	switch {
+	case len(errorPage.File) > 0:
+		handler.page, err = newFileErrorPage(errorPage.File, errorPage.ContentType)
 		if err != nil {
 			return nil, err
 		}
-		highCode, err := strconv.Atoi(codes[1])
+	case len(errorPage.Content) > 0:
+		handler.page = newStaticErrorPage(errorPage.Content, errorPage.ContentType)
+	default:
+		if len(backendURL) == 0 {
+			return nil, errors.New("no backend URL, file or content for the error page")
+		}
+		fwd, err := forward.New()
 		if err != nil {
 			return nil, err
 		}
-		blocks = append(blocks, [2]int{lowCode, highCode})
+		handler.BackendURL = backendURL + errorPage.Query
+		handler.page = &backendErrorPage{url: handler.BackendURL, forwarder: fwd}
+	}
+	return handler, nil
+}
+
+// ValidateErrorPage checks the status code ranges of an error page, and that it has exactly one source:
+// a backend, a file or a content.
+func ValidateErrorPage(errorPage types.ErrorPage) error {
+	if len(errorPage.Status) == 0 {
+		return errors.New("no status code")
+	}
+	if _, err := parseHTTPCodeRanges(errorPage.Status); err != nil {
+		return err
 	}
-	return &ErrorPagesHandler{
-			HTTPCodeRanges:     blocks,
-			BackendURL:         backendURL + errorPage.Query,
-			errorPageForwarder: fwd},
-		nil
+
+	var sources int
+	for _, source := range []string{errorPage.Backend, errorPage.File, errorPage.Content} {
+		if len(source) > 0 {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return errors.New("exactly one of the backend, the file or the content must be set")
+	}
+	return nil
 }
 
 func (ep *ErrorPagesHandler) ServeHTTP(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
-	recorder := newRetryResponseRecorder()
-	recorder.responseWriter = w
-	next.ServeHTTP(recorder, req)
+	state, ok := req.Context().Value(errorPagesStateKey{}).(*errorPagesState)
+	if !ok {
+		state = &errorPagesState{}
+		req = req.WithContext(context.WithValue(req.Context(), errorPagesStateKey{}, state))
+	}
 
-	w.WriteHeader(recorder.Code)
-	//check the recorder code against the configured http status code ranges
+	interceptor := &errorPageInterceptor{ResponseWriter: w, handler: ep, state: state}
+	next.ServeHTTP(interceptor, req)
+
+	if interceptor.caughtCode == 0 {
+		return
+	}
+
+	log.Debugf("Caught HTTP Status Code %d, returning error page", interceptor.caughtCode)
+	for _, name := range errorPageHeaders {
+		w.Header().Del(name)
+	}
+	ep.page.serve(w, req, interceptor.caughtCode)
+}
+
+// catches tells whether the status code is in the ranges of the error page.
+func (ep *ErrorPagesHandler) catches(statusCode int) bool {
 	for _, block := range ep.HTTPCodeRanges {
-		if recorder.Code >= block[0] && recorder.Code <= block[1] {
-			log.Errorf("Caught HTTP Status Code %d, returning error page", recorder.Code)
-			finalURL := strings.Replace(ep.BackendURL, "{status}", strconv.Itoa(recorder.Code), -1)
-			if newReq, err := http.NewRequest(http.MethodGet, finalURL, nil); err != nil {
-				w.Write([]byte(http.StatusText(recorder.Code)))
-			} else {
-				ep.errorPageForwarder.ServeHTTP(w, newReq)
+		if statusCode >= block[0] && statusCode <= block[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// errorPageInterceptor forwards the response to the client, unless its status code is caught by the error page handler.
+// The body of a caught response is discarded, while its headers are kept for the error page.
+type errorPageInterceptor struct {
+	http.ResponseWriter
+	handler     *ErrorPagesHandler
+	state       *errorPagesState
+	wroteHeader bool
+	caughtCode  int
+}
+
+func (i *errorPageInterceptor) WriteHeader(statusCode int) {
+	if i.wroteHeader {
+		return
+	}
+	i.wroteHeader = true
+
+	if !i.state.served && i.handler.catches(statusCode) {
+		i.state.served = true
+		i.caughtCode = statusCode
+		return
+	}
+	i.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (i *errorPageInterceptor) Write(data []byte) (int, error) {
+	if !i.wroteHeader {
+		i.WriteHeader(http.StatusOK)
+	}
+	if i.caughtCode != 0 {
+		return len(data), nil
+	}
+	return i.ResponseWriter.Write(data)
+}
+
+func (i *errorPageInterceptor) Flush() {
+	if !i.wroteHeader {
+		i.WriteHeader(http.StatusOK)
+	}
+	if i.caughtCode != 0 {
+		return
+	}
+	if flusher, ok := i.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (i *errorPageInterceptor) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := i.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", i.ResponseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+func (i *errorPageInterceptor) CloseNotify() <-chan bool {
+	if notifier, ok := i.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(<-chan bool)
+}
+
+// errorPage serves the error page of a status code.
+type errorPage interface {
+	serve(rw http.ResponseWriter, req *http.Request, statusCode int)
+}
+
+// backendErrorPage fetches the error page from a backend, and serves it with the original status code.
+type backendErrorPage struct {
+	url       string
+	forwarder *forward.Forwarder
+}
+
+func (p *backendErrorPage) serve(rw http.ResponseWriter, req *http.Request, statusCode int) {
+	pageURL := errorPagePlaceholders(statusCode, req, url.QueryEscape).Replace(p.url)
+	pageReq, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		log.Debugf("Error creating the error page request %s: %v", pageURL, err)
+		writeDefaultErrorPage(rw, req, statusCode)
+		return
+	}
+	// the forwarder sends the request URI, including the query
+	pageReq.RequestURI = pageReq.URL.RequestURI()
+	for _, name := range []string{"Accept", "Accept-Language"} {
+		if value := req.Header.Get(name); len(value) > 0 {
+			pageReq.Header.Set(name, value)
+		}
+	}
+	if header, id := getRequestID(req); len(id) > 0 {
+		pageReq.Header.Set(header, id)
+	}
+
+	p.forwarder.ServeHTTP(&statusCodeWriter{ResponseWriter: rw, statusCode: statusCode}, pageReq)
+}
+
+// statusCodeWriter writes its status code instead of the status code of the response.
+type statusCodeWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusCodeWriter) WriteHeader(int) {
+	w.ResponseWriter.WriteHeader(w.statusCode)
+}
+
+// staticErrorPage is an error page template, with its content type.
+// The JSON clients are served the default JSON error page instead of the pages of other content types.
+type staticErrorPage struct {
+	body        string
+	contentType string
+}
+
+func newStaticErrorPage(body string, contentType string) *staticErrorPage {
+	if len(contentType) == 0 {
+		contentType = "text/html; charset=utf-8"
+	}
+	return &staticErrorPage{body: body, contentType: contentType}
+}
+
+func (p *staticErrorPage) serve(rw http.ResponseWriter, req *http.Request, statusCode int) {
+	if prefersJSON(req) && !isJSON(p.contentType) {
+		writeDefaultErrorPage(rw, req, statusCode)
+		return
+	}
+	p.write(rw, req, statusCode)
+}
+
+func (p *staticErrorPage) write(rw http.ResponseWriter, req *http.Request, statusCode int) {
+	rw.Header().Set("Content-Type", p.contentType)
+	rw.WriteHeader(statusCode)
+	io.WriteString(rw, errorPagePlaceholders(statusCode, req, escaper(p.contentType)).Replace(p.body))
+}
+
+// newFileErrorPage loads the error page of a file, or returns the error pages of a directory.
+func newFileErrorPage(path string, contentType string) (errorPage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return directoryErrorPage(path), nil
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(contentType) == 0 {
+		contentType = fileContentType(path, body)
+	}
+	return newStaticErrorPage(string(body), contentType), nil
+}
+
+// directoryErrorPage serves the error pages of a directory, read on each error.
+// The page of a status code is its <status>, <class>xx or error file, e.g. 503, 5xx or error,
+// with the .html or .json extension according to the preference of the client.
+type directoryErrorPage string
+
+func (p directoryErrorPage) serve(rw http.ResponseWriter, req *http.Request, statusCode int) {
+	extensions := []string{".html", ".json"}
+	if prefersJSON(req) {
+		extensions = []string{".json"}
+	}
+
+	for _, extension := range extensions {
+		for _, name := range []string{strconv.Itoa(statusCode), strconv.Itoa(statusCode/100) + "xx", "error"} {
+			path := filepath.Join(string(p), name+extension)
+			body, err := ioutil.ReadFile(path)
+			if err != nil {
+				if !os.IsNotExist(err) {
+					log.Debugf("Error reading the error page %s: %v", path, err)
+				}
+				continue
 			}
+			page := &staticErrorPage{body: string(body), contentType: fileContentType(path, body)}
+			page.write(rw, req, statusCode)
 			return
 		}
 	}
+	writeDefaultErrorPage(rw, req, statusCode)
+}
+
+// writeDefaultErrorPage writes the status text of the status code, as JSON for the clients preferring it.
+func writeDefaultErrorPage(rw http.ResponseWriter, req *http.Request, statusCode int) {
+	if !prefersJSON(req) {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.WriteHeader(statusCode)
+		io.WriteString(rw, http.StatusText(statusCode))
+		return
+	}
+
+	_, requestID := getRequestID(req)
+	body, err := json.Marshal(struct {
+		Status    int    `json:"status"`
+		Message   string `json:"message"`
+		RequestID string `json:"requestId,omitempty"`
+	}{
+		Status:    statusCode,
+		Message:   http.StatusText(statusCode),
+		RequestID: requestID,
+	})
+	if err != nil {
+		log.Debugf("Error encoding the error page: %v", err)
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(statusCode)
+	rw.Write(body)
+}
+
+// errorPagePlaceholders replaces the {status}, {statusText} and {requestId} placeholders of an error page,
+// with their values escaped by the escape function.
+func errorPagePlaceholders(statusCode int, req *http.Request, escape func(string) string) *strings.Replacer {
+	_, requestID := getRequestID(req)
+	return strings.NewReplacer(
+		"{status}", strconv.Itoa(statusCode),
+		"{statusText}", escape(http.StatusText(statusCode)),
+		"{requestId}", escape(requestID),
+	)
+}
+
+// escaper returns the escape function of the placeholder values for a content type.
+func escaper(contentType string) func(string) string {
+	switch {
+	case isJSON(contentType):
+		return func(value string) string {
+			quoted, err := json.Marshal(value)
+			if err != nil {
+				return ""
+			}
+			return string(quoted[1 : len(quoted)-1])
+		}
+	case strings.Contains(contentType, "html") || strings.Contains(contentType, "xml"):
+		return html.EscapeString
+	default:
+		return func(value string) string { return value }
+	}
+}
+
+// fileContentType returns the content type of a file, from its extension or its content.
+func fileContentType(path string, body []byte) string {
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension == ".json" {
+		return "application/json"
+	}
+	if contentType := mime.TypeByExtension(extension); len(contentType) > 0 {
+		return contentType
+	}
+	return http.DetectContentType(body)
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// prefersJSON tells whether the Accept header of the request prefers JSON over HTML.
+// With the same quality, the media type of the most specific media range is preferred, then HTML.
+func prefersJSON(req *http.Request) bool {
+	accept := req.Header.Get("Accept")
+	if len(accept) == 0 {
+		return false
+	}
+
+	jsonQuality, jsonSpecificity := acceptQuality(accept, "application/json")
+	htmlQuality, htmlSpecificity := acceptQuality(accept, "text/html")
+	if jsonQuality != htmlQuality {
+		return jsonQuality > htmlQuality
+	}
+	return jsonQuality > 0 && jsonSpecificity > htmlSpecificity
+}
+
+// acceptQuality returns the quality of a media type in an Accept header, from its most specific media range,
+// and the specificity of this range: 2 for the media type, 1 for its type/*, 0 for */*, and -1 when none matches.
+func acceptQuality(accept string, mediaType string) (float64, int) {
+	mainType := strings.SplitN(mediaType, "/", 2)[0]
+
+	quality, specificity := 0.0, -1
+	for _, mediaRange := range strings.Split(accept, ",") {
+		name, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		var rangeSpecificity int
+		switch name {
+		case mediaType:
+			rangeSpecificity = 2
+		case mainType + "/*":
+			rangeSpecificity = 1
+		case "*/*":
+			rangeSpecificity = 0
+		default:
+			continue
+		}
+		if rangeSpecificity <= specificity {
+			continue
+		}
+
+		specificity = rangeSpecificity
+		quality = 1
+		if q, ok := params["q"]; ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = value
+			}
+		}
+	}
+	return quality, specificity
+}
 
-	//did not catch a configured status code so proceed with the request
-	utils.CopyHeaders(w.Header(), recorder.Header())
-	w.Write(recorder.Body.Bytes())
+// parseHTTPCodeRanges breaks out the http status code ranges, like "500-599", into a low int and high int
+// for ease of use at runtime.
+func parseHTTPCodeRanges(ranges []string) ([][2]int, error) {
+	var blocks [][2]int
+	for _, block := range ranges {
+		codes := strings.Split(block, "-")
+		//if only a single HTTP code was configured, assume the best and create the correct configuration on the user's behalf
+		if len(codes) == 1 {
+			codes = append(codes, codes[0])
+		}
+		lowCode, err := strconv.Atoi(codes[0])
+		if err != nil {
+			return nil, err
+		}
+		highCode, err := strconv.Atoi(codes[1])
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, [2]int{lowCode, highCode})
+	}
+	return blocks, nil
 }
@@ -2,8 +2,11 @@ package middlewares
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -150,3 +153,269 @@ func TestErrorPageSingleCode(t *testing.T) {
 	assert.Contains(t, recorder.Body.String(), "503 Test Server")
 	assert.NotContains(t, recorder.Body.String(), "oops", "Should not return the oops page")
 }
+
+func TestErrorPageBackendHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path": %q, "accept": %q, "requestId": %q}`, r.URL.RequestURI(), r.Header.Get("Accept"), r.Header.Get("X-Request-Id"))
+	}))
+	defer ts.Close()
+
+	testHandler, err := NewErrorPagesHandler(types.ErrorPage{Status: []string{"503"}, Backend: "error", Query: "/{status}?id={requestId}"}, ts.URL)
+	require.NoError(t, err)
+
+	n := negroni.New(testHandler)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "oops")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Request-Id", "a b")
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "120", recorder.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"path": "/503?id=a+b", "accept": "application/json", "requestId": "a b"}`, recorder.Body.String())
+}
+
+func TestErrorPageContent(t *testing.T) {
+	directory, err := ioutil.TempDir("", "traefik-errors")
+	require.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	for name, content := range map[string]string{
+		"503.html":   "<h1>503 {requestId}</h1>",
+		"5xx.html":   "<h1>{status} {statusText}</h1>",
+		"error.json": `{"status": {status}, "requestId": "{requestId}"}`,
+		"page.html":  "<h1>page {status}</h1>",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(directory, name), []byte(content), 0644))
+	}
+
+	testCases := []struct {
+		desc                string
+		errorPage           types.ErrorPage
+		statusCode          int
+		accept              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			desc:                "inline content",
+			errorPage:           types.ErrorPage{Content: "<p>{status} {statusText} {requestId}</p>"},
+			statusCode:          http.StatusBadGateway,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "<p>502 Bad Gateway &lt;id&gt;</p>",
+		},
+		{
+			desc:                "inline JSON content",
+			errorPage:           types.ErrorPage{Content: `{"error": "{requestId}"}`, ContentType: "application/json"},
+			statusCode:          http.StatusBadGateway,
+			expectedContentType: "application/json",
+			expectedBody:        `{"error": "<id>"}`,
+		},
+		{
+			desc:                "JSON client",
+			errorPage:           types.ErrorPage{Content: "<p>{status}</p>"},
+			statusCode:          http.StatusGatewayTimeout,
+			accept:              "application/json, text/html;q=0.9",
+			expectedContentType: "application/json",
+			expectedBody:        `{"status": 504, "message": "Gateway Timeout", "requestId": "<id>"}`,
+		},
+		{
+			desc:                "file",
+			errorPage:           types.ErrorPage{File: filepath.Join(directory, "page.html")},
+			statusCode:          http.StatusNotFound,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "<h1>page 404</h1>",
+		},
+		{
+			desc:                "directory status page",
+			errorPage:           types.ErrorPage{File: directory},
+			statusCode:          http.StatusServiceUnavailable,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "<h1>503 &lt;id&gt;</h1>",
+		},
+		{
+			desc:                "directory class page",
+			errorPage:           types.ErrorPage{File: directory},
+			statusCode:          http.StatusBadGateway,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "<h1>502 Bad Gateway</h1>",
+		},
+		{
+			desc:                "directory JSON page",
+			errorPage:           types.ErrorPage{File: directory},
+			statusCode:          http.StatusBadGateway,
+			accept:              "application/json",
+			expectedContentType: "application/json",
+			expectedBody:        `{"status": 502, "requestId": "<id>"}`,
+		},
+		{
+			desc:                "directory default page",
+			errorPage:           types.ErrorPage{File: directory},
+			statusCode:          http.StatusNotFound,
+			accept:              "text/html",
+			expectedContentType: "application/json",
+			expectedBody:        `{"status": 404, "requestId": "<id>"}`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			test.errorPage.Status = []string{"400-599"}
+			testHandler, err := NewErrorPagesHandler(test.errorPage, "")
+			require.NoError(t, err)
+
+			n := negroni.New(testHandler)
+			n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Header().Set("Content-Length", "5")
+				w.Header().Set("WWW-Authenticate", "Basic")
+				w.WriteHeader(test.statusCode)
+				fmt.Fprint(w, "oops\n")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+			req.Header.Set("X-Request-Id", "<id>")
+			if len(test.accept) > 0 {
+				req.Header.Set("Accept", test.accept)
+			}
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.statusCode, recorder.Code)
+			assert.Equal(t, test.expectedContentType, recorder.Header().Get("Content-Type"))
+			assert.Empty(t, recorder.Header().Get("Content-Length"))
+			assert.Equal(t, "Basic", recorder.Header().Get("WWW-Authenticate"))
+			if test.expectedContentType == "application/json" {
+				assert.JSONEq(t, test.expectedBody, recorder.Body.String())
+			} else {
+				assert.Equal(t, test.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestErrorPageNested(t *testing.T) {
+	outer, err := NewErrorPagesHandler(types.ErrorPage{Status: []string{"400-599"}, Content: "outer {status}"}, "")
+	require.NoError(t, err)
+	inner, err := NewErrorPagesHandler(types.ErrorPage{Status: []string{"503"}, Content: "inner {status}"}, "")
+	require.NoError(t, err)
+
+	n := negroni.New(outer, inner)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.WriteHeader(status)
+	}))
+
+	for status, expected := range map[int]string{503: "inner 503", 502: "outer 502", 404: "outer 404"} {
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/?status="+strconv.Itoa(status), nil))
+
+		assert.Equal(t, status, recorder.Code)
+		assert.Equal(t, expected, recorder.Body.String())
+	}
+}
+
+func TestErrorPageStreaming(t *testing.T) {
+	testHandler, err := NewErrorPagesHandler(types.ErrorPage{Status: []string{"500-599"}, Content: "error"}, "")
+	require.NoError(t, err)
+
+	n := negroni.New(testHandler)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event")
+		w.(http.Flusher).Flush()
+		// the response is already forwarded
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/events", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, "event", recorder.Body.String())
+}
+
+func TestPrefersJSON(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected bool
+	}{
+		{accept: "", expected: false},
+		{accept: "*/*", expected: false},
+		{accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", expected: false},
+		{accept: "application/json", expected: true},
+		{accept: "application/json, text/plain, */*", expected: true},
+		{accept: "application/*", expected: true},
+		{accept: "text/html;q=0.5, application/json;q=0.8", expected: true},
+		{accept: "application/json;q=0.5, */*", expected: false},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.accept, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			req.Header.Set("Accept", test.accept)
+			assert.Equal(t, test.expected, prefersJSON(req))
+		})
+	}
+}
+
+func TestValidateErrorPage(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		errorPage types.ErrorPage
+		valid     bool
+	}{
+		{
+			desc:      "backend",
+			errorPage: types.ErrorPage{Status: []string{"500-599"}, Backend: "error"},
+			valid:     true,
+		},
+		{
+			desc:      "content",
+			errorPage: types.ErrorPage{Status: []string{"404"}, Content: "not found"},
+			valid:     true,
+		},
+		{
+			desc:      "no status",
+			errorPage: types.ErrorPage{File: "/etc/traefik/errors"},
+		},
+		{
+			desc:      "invalid status",
+			errorPage: types.ErrorPage{Status: []string{"5xx"}, Backend: "error"},
+		},
+		{
+			desc:      "no source",
+			errorPage: types.ErrorPage{Status: []string{"404"}},
+		},
+		{
+			desc:      "several sources",
+			errorPage: types.ErrorPage{Status: []string{"404"}, Backend: "error", Content: "not found"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateErrorPage(test.errorPage)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -2,10 +2,10 @@ package middlewares
 
 import (
 	"fmt"
-	"net"
 	"net/http"
 
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
 	"github.com/containous/traefik/whitelist"
 	"github.com/pkg/errors"
 	"github.com/urfave/negroni"
@@ -13,63 +13,90 @@ import (
 
 // IPWhiteLister is a middleware that provides Checks of the Requesting IP against a set of Whitelists
 type IPWhiteLister struct {
-	handler     negroni.Handler
-	whiteLister *whitelist.IP
+	handler          negroni.Handler
+	whiteLister      *whitelist.IP
+	strategy         whitelist.Strategy
+	deny             bool
+	rejectStatusCode int
+	rejectBody       string
 }
 
-// NewIPWhitelister builds a new IPWhiteLister given a list of CIDR-Strings to whitelist
-func NewIPWhitelister(whitelistStrings []string) (*IPWhiteLister, error) {
-
-	if len(whitelistStrings) == 0 {
+// NewIPWhitelister builds a new IPWhiteLister given a white list configuration,
+// the strategy extracting the client IP of the requests (the address of the connection when nil)
+func NewIPWhitelister(config *types.WhiteList, strategy whitelist.Strategy) (*IPWhiteLister, error) {
+	if config == nil || len(config.SourceRange) == 0 {
 		return nil, errors.New("no whitelists provided")
 	}
 
-	whiteLister := IPWhiteLister{}
+	if config.RejectStatusCode != 0 && (config.RejectStatusCode < 400 || config.RejectStatusCode > 599) {
+		return nil, fmt.Errorf("invalid whitelist reject status code %d", config.RejectStatusCode)
+	}
+
+	whiteLister := IPWhiteLister{
+		strategy:         strategy,
+		deny:             config.Deny,
+		rejectStatusCode: config.RejectStatusCode,
+		rejectBody:       config.RejectBody,
+	}
+	if whiteLister.strategy == nil {
+		whiteLister.strategy = &whitelist.RemoteAddrStrategy{}
+	}
+	if whiteLister.rejectStatusCode == 0 {
+		whiteLister.rejectStatusCode = http.StatusForbidden
+	}
 
-	ip, err := whitelist.NewIP(whitelistStrings, false)
+	ip, err := whitelist.NewIP(config.SourceRange, false)
 	if err != nil {
-		return nil, fmt.Errorf("parsing CIDR whitelist %s: %v", whitelistStrings, err)
+		return nil, fmt.Errorf("parsing CIDR whitelist %s: %v", config.SourceRange, err)
 	}
 	whiteLister.whiteLister = ip
 
 	whiteLister.handler = negroni.HandlerFunc(whiteLister.handle)
-	log.Debugf("configured %u IP whitelists: %s", len(whitelistStrings), whitelistStrings)
+	log.Debugf("configured %d IP whitelists: %s", len(config.SourceRange), config.SourceRange)
 
 	return &whiteLister, nil
 }
 
 func (wl *IPWhiteLister) handle(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
+	contained, ip, err := wl.whiteLister.ContainsRequest(r, wl.strategy)
 	if err != nil {
-		log.Warnf("unable to parse remote-address from header: %s - rejecting", r.RemoteAddr)
-		reject(w)
+		log.Debugf("unable to get the source-IP of the request: %v - rejecting", err)
+		wl.reject(w)
 		return
 	}
 
-	allowed, ip, err := wl.whiteLister.Contains(ipAddress)
-	if err != nil {
-		log.Debugf("source-IP %s matched none of the whitelists - rejecting", ipAddress)
-		reject(w)
+	if wl.deny {
+		if contained {
+			log.Debugf("source-IP %s matched the blacklists - rejecting", ip)
+			wl.reject(w)
+			return
+		}
+
+		log.Debugf("source-IP %s matched none of the blacklists - passing", ip)
+		next.ServeHTTP(w, r)
 		return
 	}
 
-	if allowed {
-		log.Debugf("source-IP %s matched whitelist %s - passing", ipAddress, wl.whiteLister)
+	if contained {
+		log.Debugf("source-IP %s matched the whitelists - passing", ip)
 		next.ServeHTTP(w, r)
 		return
 	}
 
 	log.Debugf("source-IP %s matched none of the whitelists - rejecting", ip)
-	reject(w)
+	wl.reject(w)
 }
 
 func (wl *IPWhiteLister) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	wl.handler.ServeHTTP(rw, r, next)
 }
 
-func reject(w http.ResponseWriter) {
-	statusCode := http.StatusForbidden
+func (wl *IPWhiteLister) reject(w http.ResponseWriter) {
+	body := wl.rejectBody
+	if len(body) == 0 {
+		body = http.StatusText(wl.rejectStatusCode)
+	}
 
-	w.WriteHeader(statusCode)
-	w.Write([]byte(http.StatusText(statusCode)))
+	w.WriteHeader(wl.rejectStatusCode)
+	w.Write([]byte(body))
 }
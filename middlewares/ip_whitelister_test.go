@@ -0,0 +1,135 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/containous/traefik/whitelist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIPWhitelisterErrors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *types.WhiteList
+	}{
+		{
+			desc: "nil configuration",
+		},
+		{
+			desc:   "no source range",
+			config: &types.WhiteList{},
+		},
+		{
+			desc:   "invalid source range",
+			config: &types.WhiteList{SourceRange: []string{"foo"}},
+		},
+		{
+			desc:   "invalid reject status code",
+			config: &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}, RejectStatusCode: 200},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewIPWhitelister(test.config, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestIPWhiteListerServeHTTP(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		config             *types.WhiteList
+		strategy           whitelist.Strategy
+		remoteAddr         string
+		xForwardedFor      string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			desc:               "allowed remote address",
+			config:             &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}},
+			remoteAddr:         "10.0.0.1:1234",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "traefik",
+		},
+		{
+			desc:               "rejected remote address",
+			config:             &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}},
+			remoteAddr:         "20.0.0.1:1234",
+			xForwardedFor:      "10.0.0.1",
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       "Forbidden",
+		},
+		{
+			desc:               "allowed forwarded address",
+			config:             &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}},
+			strategy:           &whitelist.DepthStrategy{Depth: 1},
+			remoteAddr:         "20.0.0.1:1234",
+			xForwardedFor:      "30.0.0.1, 10.0.0.1",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "traefik",
+		},
+		{
+			desc:               "missing forwarded address",
+			config:             &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}},
+			strategy:           &whitelist.DepthStrategy{Depth: 1},
+			remoteAddr:         "10.0.0.1:1234",
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       "Forbidden",
+		},
+		{
+			desc:               "blacklisted address",
+			config:             &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}, Deny: true},
+			remoteAddr:         "10.0.0.1:1234",
+			expectedStatusCode: http.StatusForbidden,
+			expectedBody:       "Forbidden",
+		},
+		{
+			desc:               "address not blacklisted",
+			config:             &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}, Deny: true},
+			remoteAddr:         "20.0.0.1:1234",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "traefik",
+		},
+		{
+			desc:               "custom rejection",
+			config:             &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}, RejectStatusCode: http.StatusNotFound, RejectBody: "nothing here"},
+			remoteAddr:         "20.0.0.1:1234",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "nothing here",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			whiteLister, err := NewIPWhitelister(test.config, test.strategy)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+			req.RemoteAddr = test.remoteAddr
+			if len(test.xForwardedFor) > 0 {
+				req.Header.Set("X-Forwarded-For", test.xForwardedFor)
+			}
+
+			recorder := httptest.NewRecorder()
+			whiteLister.ServeHTTP(recorder, req, func(rw http.ResponseWriter, r *http.Request) {
+				rw.Write([]byte("traefik"))
+			})
+
+			assert.Equal(t, test.expectedStatusCode, recorder.Code)
+			assert.Equal(t, test.expectedBody, recorder.Body.String())
+		})
+	}
+}
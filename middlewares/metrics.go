@@ -66,6 +66,34 @@ type MetricsRetryListener struct {
 }
 
 // Retried tracks the retry in the RequestMetrics implementation.
-func (m *MetricsRetryListener) Retried(req *http.Request, attempt int) {
-	m.retryMetrics.RetriesCounter().With("backend", m.backendName).Add(1)
+func (m *MetricsRetryListener) Retried(req *http.Request, attempt int, reason string) {
+	m.retryMetrics.RetriesCounter().With("service", m.backendName, "reason", reason).Add(1)
+}
+
+type circuitBreakerMetrics interface {
+	CircuitBreakerStateGauge() gokitmetrics.Gauge
+}
+
+// circuitBreakerStateValues are the values of the circuit breaker states in the state gauge.
+var circuitBreakerStateValues = map[string]float64{
+	CircuitBreakerStandby:    0,
+	CircuitBreakerTripped:    1,
+	CircuitBreakerRecovering: 2,
+}
+
+// NewMetricsCircuitBreakerListener instantiates a MetricsCircuitBreakerListener with the given circuitBreakerMetrics.
+func NewMetricsCircuitBreakerListener(circuitBreakerMetrics circuitBreakerMetrics, backendName string) CircuitBreakerListener {
+	return &MetricsCircuitBreakerListener{circuitBreakerMetrics: circuitBreakerMetrics, backendName: backendName}
+}
+
+// MetricsCircuitBreakerListener is an implementation of the CircuitBreakerListener interface to
+// record the state of the circuit breaker of a backend.
+type MetricsCircuitBreakerListener struct {
+	circuitBreakerMetrics circuitBreakerMetrics
+	backendName           string
+}
+
+// StateChanged sets the state gauge of the backend.
+func (m *MetricsCircuitBreakerListener) StateChanged(state string) {
+	m.circuitBreakerMetrics.CircuitBreakerStateGauge().With("service", m.backendName).Set(circuitBreakerStateValues[state])
 }
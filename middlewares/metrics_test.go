@@ -6,27 +6,60 @@ import (
 	"reflect"
 	"testing"
 
+	traefikmetrics "github.com/containous/traefik/metrics"
+	"github.com/containous/traefik/types"
 	"github.com/go-kit/kit/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMetricsRetryListener(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	retryMetrics := newCollectingRetryMetrics()
 	retryListener := NewMetricsRetryListener(retryMetrics, "backendName")
-	retryListener.Retried(req, 1)
-	retryListener.Retried(req, 2)
+	retryListener.Retried(req, 1, RetryReasonNetworkError)
+	retryListener.Retried(req, 2, "502")
 
 	wantCounterValue := float64(2)
 	if retryMetrics.retryCounter.counterValue != wantCounterValue {
 		t.Errorf("got counter value of %d, want %d", retryMetrics.retryCounter.counterValue, wantCounterValue)
 	}
 
-	wantLabelValues := []string{"backend", "backendName"}
+	wantLabelValues := []string{"service", "backendName", "reason", "502"}
 	if !reflect.DeepEqual(retryMetrics.retryCounter.lastLabelValues, wantLabelValues) {
 		t.Errorf("wrong label values %v used, want %v", retryMetrics.retryCounter.lastLabelValues, wantLabelValues)
 	}
 }
 
+// TestMetricsListenersPrometheus checks the labels of the listeners against the ones declared by the Prometheus metrics,
+// which panic on a mismatch.
+func TestMetricsListenersPrometheus(t *testing.T) {
+	registry := traefikmetrics.RegisterPrometheus(&types.Prometheus{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	NewMetricsRetryListener(registry, "backendName").Retried(req, 1, "502")
+	NewMetricsCircuitBreakerListener(registry, "backendName").StateChanged(CircuitBreakerTripped)
+
+	metricsFamilies, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, family := range metricsFamilies {
+		for _, metric := range family.Metric {
+			for _, label := range metric.Label {
+				if label.GetName() == "service" && label.GetValue() == "backendName" {
+					values[family.GetName()] = metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"traefik_backend_retries_total":         1,
+		"traefik_backend_circuit_breaker_state": 1,
+	}, values)
+}
+
 // collectingRetryMetrics is an implementation of the retryMetrics interface that can be used inside tests to collect the times Add() was called.
 type collectingRetryMetrics struct {
 	retryCounter *collectingCounter
@@ -53,3 +86,45 @@ func (c *collectingCounter) With(labelValues ...string) metrics.Counter {
 func (c *collectingCounter) Add(delta float64) {
 	c.counterValue += delta
 }
+
+func TestMetricsCircuitBreakerListener(t *testing.T) {
+	gauge := &collectingGauge{}
+	listener := NewMetricsCircuitBreakerListener(collectingCircuitBreakerMetrics{gauge}, "backendName")
+
+	listener.StateChanged(CircuitBreakerTripped)
+	if gauge.gaugeValue != 1 {
+		t.Errorf("got gauge value of %f, want %f", gauge.gaugeValue, 1.0)
+	}
+
+	listener.StateChanged(CircuitBreakerRecovering)
+	if gauge.gaugeValue != 2 {
+		t.Errorf("got gauge value of %f, want %f", gauge.gaugeValue, 2.0)
+	}
+
+	wantLabelValues := []string{"service", "backendName"}
+	if !reflect.DeepEqual(gauge.lastLabelValues, wantLabelValues) {
+		t.Errorf("wrong label values %v used, want %v", gauge.lastLabelValues, wantLabelValues)
+	}
+}
+
+type collectingCircuitBreakerMetrics struct {
+	gauge *collectingGauge
+}
+
+func (metrics collectingCircuitBreakerMetrics) CircuitBreakerStateGauge() metrics.Gauge {
+	return metrics.gauge
+}
+
+type collectingGauge struct {
+	gaugeValue      float64
+	lastLabelValues []string
+}
+
+func (g *collectingGauge) With(labelValues ...string) metrics.Gauge {
+	g.lastLabelValues = labelValues
+	return g
+}
+
+func (g *collectingGauge) Set(value float64) {
+	g.gaugeValue = value
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/vulcand/oxy/utils"
+)
+
+const (
+	cookiePrefix   = "request.cookie."
+	jwtClaimPrefix = "jwt.claim."
+)
+
+// NewExtractor creates the extractor of the source of the requests, from the variable:
+// client.ip, request.host, request.header.<name>, request.cookie.<name> or jwt.claim.<name>.
+// The requests without the cookie or the claim share the empty source.
+func NewExtractor(variable string) (utils.SourceExtractor, error) {
+	if strings.HasPrefix(variable, cookiePrefix) {
+		name := strings.TrimPrefix(variable, cookiePrefix)
+		if len(name) == 0 {
+			return nil, fmt.Errorf("wrong cookie name: %s", variable)
+		}
+		return utils.ExtractorFunc(func(req *http.Request) (string, int64, error) {
+			cookie, err := req.Cookie(name)
+			if err != nil {
+				return "", 1, nil
+			}
+			return cookie.Value, 1, nil
+		}), nil
+	}
+
+	if strings.HasPrefix(variable, jwtClaimPrefix) {
+		name := strings.TrimPrefix(variable, jwtClaimPrefix)
+		if len(name) == 0 {
+			return nil, fmt.Errorf("wrong JWT claim name: %s", variable)
+		}
+		return utils.ExtractorFunc(func(req *http.Request) (string, int64, error) {
+			return getJWTClaim(req, name), 1, nil
+		}), nil
+	}
+
+	return utils.NewExtractor(variable)
+}
+
+// getJWTClaim returns the claim of the bearer token of the request, or an empty string.
+// The signature of the token is not verified: the rate limiter only uses the claim as a counter key,
+// the tokens must be verified by the authentication of the frontend.
+func getJWTClaim(req *http.Request, name string) string {
+	authorization := req.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return ""
+	}
+
+	parts := strings.Split(strings.TrimSpace(authorization[7:]), ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := jwt.DecodeSegment(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	claims := make(map[string]interface{})
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	switch value := claims[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
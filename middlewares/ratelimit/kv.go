@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/safe"
+	"github.com/docker/libkv/store"
+)
+
+// maxAttempts is the number of compare-and-swap attempts of an increment, under contention.
+const maxAttempts = 10
+
+type kvCounter struct {
+	Value   int64 `json:"value"`
+	Expires int64 `json:"expires"`
+}
+
+var _ Store = (*KVStore)(nil)
+
+// KVStore keeps the counters in a KV store (Consul, etcd, Zookeeper...), shared between the Traefik instances.
+// The counters are updated with compare-and-swap operations, and their expiration is stored with their value
+// as not every KV store supports TTLs.
+type KVStore struct {
+	kv     store.Store
+	prefix string
+
+	lock      sync.Mutex
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewKVStore creates a store keeping the counters under the prefix of the KV store.
+func NewKVStore(kv store.Store, prefix string) *KVStore {
+	return &KVStore{
+		kv:     kv,
+		prefix: strings.TrimSuffix(prefix, "/"),
+		now:    time.Now,
+	}
+}
+
+// Increment adds the amount to the counter of the key.
+func (s *KVStore) Increment(key string, amount int64, ttl time.Duration) (int64, error) {
+	key = s.kvKey(key)
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		now := s.now()
+
+		previous, err := s.kv.Get(key)
+		if err != nil && err != store.ErrKeyNotFound {
+			return 0, err
+		}
+		if err == store.ErrKeyNotFound {
+			previous = nil
+		}
+
+		c := kvCounter{Expires: now.Add(ttl).UnixNano()}
+		if previous != nil {
+			var stored kvCounter
+			if err := json.Unmarshal(previous.Value, &stored); err == nil && now.UnixNano() < stored.Expires {
+				c = stored
+			}
+		}
+		c.Value += amount
+
+		value, err := json.Marshal(c)
+		if err != nil {
+			return 0, err
+		}
+
+		_, _, err = s.kv.AtomicPut(key, value, previous, &store.WriteOptions{TTL: ttl})
+		if err == store.ErrKeyModified || err == store.ErrKeyExists {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		s.sweep(now)
+		return c.Value, nil
+	}
+
+	return 0, fmt.Errorf("too many concurrent updates of %s", key)
+}
+
+// Get returns the value of the counter of the key.
+func (s *KVStore) Get(key string) (int64, error) {
+	pair, err := s.kv.Get(s.kvKey(key))
+	if err == store.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var c kvCounter
+	if err := json.Unmarshal(pair.Value, &c); err != nil {
+		return 0, fmt.Errorf("invalid counter %s: %v", pair.Key, err)
+	}
+	if s.now().UnixNano() >= c.Expires {
+		return 0, nil
+	}
+	return c.Value, nil
+}
+
+// kvKey flattens the key under the prefix, so that the counters can be listed at once.
+func (s *KVStore) kvKey(key string) string {
+	return s.prefix + "/" + strings.Replace(key, "/", ":", -1)
+}
+
+// sweep deletes the expired counters in the background, at most once per sweep interval.
+func (s *KVStore) sweep(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	safe.Go(func() {
+		pairs, err := s.kv.List(s.prefix)
+		if err != nil {
+			if err != store.ErrKeyNotFound {
+				log.Debugf("Error listing the rate limit counters: %v", err)
+			}
+			return
+		}
+
+		for _, pair := range pairs {
+			var c kvCounter
+			if err := json.Unmarshal(pair.Value, &c); err != nil || now.UnixNano() < c.Expires {
+				continue
+			}
+			// the counter may have been updated in the meantime, it is then kept
+			if _, err := s.kv.AtomicDelete(pair.Key, pair); err != nil {
+				log.Debugf("Error deleting the rate limit counter %s: %v", pair.Key, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/utils"
+)
+
+// Algorithms of the rate limiters.
+const (
+	// TokenBucket limits the requests with in-memory token buckets, local to the Traefik instance.
+	TokenBucket = "token-bucket"
+	// SlidingWindow counts the requests of the current window, plus the requests of the previous window weighted by its overlap with the sliding period.
+	SlidingWindow = "sliding-window"
+	// FixedWindow counts the requests of the current window only.
+	FixedWindow = "fixed-window"
+)
+
+// storeErrorLogInterval is the minimum interval between two logs of the errors of the store.
+const storeErrorLogInterval = time.Minute
+
+// ValidateAlgorithm checks that the algorithm is known, the empty string selecting the default one.
+func ValidateAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", TokenBucket, SlidingWindow, FixedWindow:
+		return nil
+	default:
+		return fmt.Errorf("unknown rate limit algorithm %q", algorithm)
+	}
+}
+
+// RateLimiter limits the requests of each source with window counters kept in a store,
+// which may be shared between Traefik instances.
+// While the store is unavailable, the requests are allowed, or rejected when the rate limiter fails closed.
+type RateLimiter struct {
+	next       http.Handler
+	name       string
+	extractor  utils.SourceExtractor
+	store      Store
+	failClosed bool
+	sliding    bool
+	rates      []*rate
+	now        func() time.Time
+
+	errorsLock       sync.Mutex
+	lastErrorLog     time.Time
+	suppressedErrors int
+}
+
+type rate struct {
+	name   string
+	period time.Duration
+	limit  int64
+}
+
+// decision is the outcome of a rate for a request.
+type decision struct {
+	rate       *rate
+	key        string
+	amount     int64
+	allowed    bool
+	remaining  int64
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// New creates a window rate limiter, named after the frontend it limits.
+// A local in-memory store is used when the store is nil.
+// With failClosed, the requests are rejected while the store is unavailable.
+func New(next http.Handler, name string, config *types.RateLimit, extractor utils.SourceExtractor, store Store, failClosed bool) (*RateLimiter, error) {
+	rl := &RateLimiter{
+		next:       next,
+		name:       name,
+		extractor:  extractor,
+		store:      store,
+		failClosed: failClosed,
+		now:        time.Now,
+	}
+
+	switch config.Algorithm {
+	case "", SlidingWindow:
+		rl.sliding = true
+	case FixedWindow:
+	default:
+		return nil, fmt.Errorf("unsupported window rate limit algorithm %q", config.Algorithm)
+	}
+
+	if rl.store == nil {
+		rl.store = NewMemoryStore()
+	}
+
+	var names []string
+	for rateName := range config.RateSet {
+		names = append(names, rateName)
+	}
+	sort.Strings(names)
+
+	for _, rateName := range names {
+		r := config.RateSet[rateName]
+		if r == nil || time.Duration(r.Period) <= 0 || r.Average <= 0 {
+			return nil, fmt.Errorf("invalid rate %s: period and average must be positive", rateName)
+		}
+		rl.rates = append(rl.rates, &rate{name: rateName, period: time.Duration(r.Period), limit: r.Average})
+	}
+
+	if len(rl.rates) == 0 {
+		return nil, fmt.Errorf("no rate defined for rate limiter %s", name)
+	}
+	return rl, nil
+}
+
+func (rl *RateLimiter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	source, amount, err := rl.extractor.Extract(req)
+	if err != nil {
+		log.Errorf("Error extracting the source of the request for rate limiter %s: %v", rl.name, err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	now := rl.now()
+
+	var taken []*decision
+	var restrictive *decision
+	for _, r := range rl.rates {
+		d, err := rl.take(r, source, amount, now)
+		if err != nil {
+			rl.logStoreError("Error counting the requests of rate limiter %s: %v", err)
+			if rl.failClosed {
+				rl.release(taken)
+				http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			// the requests are not limited while the store is unavailable
+			continue
+		}
+
+		if !d.allowed {
+			rl.release(taken)
+			rl.writeHeaders(rw, d)
+			rw.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(d.retryAfter), 10))
+			http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		taken = append(taken, d)
+		if restrictive == nil || d.remaining < restrictive.remaining {
+			restrictive = d
+		}
+	}
+
+	if restrictive != nil {
+		rl.writeHeaders(rw, restrictive)
+	}
+	rl.next.ServeHTTP(rw, req)
+}
+
+// take counts the request in the current window of the rate, and tells whether the request is allowed.
+// A denied request is not counted.
+func (rl *RateLimiter) take(r *rate, source string, amount int64, now time.Time) (*decision, error) {
+	window := now.UnixNano() / int64(r.period)
+	windowStart := time.Unix(0, window*int64(r.period))
+	ttl := 2 * r.period
+
+	d := &decision{
+		rate:   r,
+		key:    rl.key(r, source, window),
+		amount: amount,
+		reset:  windowStart.Add(r.period).Sub(now),
+	}
+
+	count, err := rl.store.Increment(d.key, amount, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	estimated := float64(count)
+	var previous int64
+	if rl.sliding {
+		previous, err = rl.store.Get(rl.key(r, source, window-1))
+		if err != nil {
+			rl.release([]*decision{d})
+			return nil, err
+		}
+		elapsed := float64(now.Sub(windowStart)) / float64(r.period)
+		estimated += float64(previous) * (1 - elapsed)
+	}
+
+	if int64(estimated) > r.limit {
+		rl.release([]*decision{d})
+		d.retryAfter = d.reset
+
+		// the weight of the previous window decreases until the request fits in the limit
+		current := count - amount
+		if rl.sliding && previous > 0 && r.limit-current-amount >= 0 {
+			fraction := 1 - float64(r.limit-current-amount)/float64(previous)
+			d.retryAfter = windowStart.Add(time.Duration(fraction * float64(r.period))).Sub(now)
+		}
+		return d, nil
+	}
+
+	d.allowed = true
+	d.remaining = r.limit - int64(estimated)
+	return d, nil
+}
+
+// release uncounts the requests of the decisions.
+func (rl *RateLimiter) release(decisions []*decision) {
+	for _, d := range decisions {
+		if _, err := rl.store.Increment(d.key, -d.amount, 2*d.rate.period); err != nil {
+			rl.logStoreError("Error releasing the requests of rate limiter %s: %v", err)
+		}
+	}
+}
+
+// logStoreError logs at most one error of the store per storeErrorLogInterval,
+// every request failing while the store is unavailable.
+func (rl *RateLimiter) logStoreError(format string, err error) {
+	rl.errorsLock.Lock()
+	now := rl.now()
+	if !rl.lastErrorLog.IsZero() && now.Sub(rl.lastErrorLog) < storeErrorLogInterval {
+		rl.suppressedErrors++
+		rl.errorsLock.Unlock()
+		return
+	}
+	suppressed := rl.suppressedErrors
+	rl.lastErrorLog = now
+	rl.suppressedErrors = 0
+	rl.errorsLock.Unlock()
+
+	log.Errorf(format, rl.name, err)
+	if suppressed > 0 {
+		log.Errorf("%d errors of the store of rate limiter %s were not logged", suppressed, rl.name)
+	}
+}
+
+func (rl *RateLimiter) key(r *rate, source string, window int64) string {
+	return fmt.Sprintf("%s/%s/%s/%d", url.QueryEscape(rl.name), url.QueryEscape(r.name), url.QueryEscape(source), window)
+}
+
+func (rl *RateLimiter) writeHeaders(rw http.ResponseWriter, d *decision) {
+	header := rw.Header()
+	header.Set("X-RateLimit-Limit", strconv.FormatInt(d.rate.limit, 10))
+	header.Set("X-RateLimit-Remaining", strconv.FormatInt(d.remaining, 10))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(d.reset), 10))
+}
+
+// ceilSeconds rounds up the duration to seconds, with a minimum of one second.
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Max(1, math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{}
+
+func (failingStore) Increment(key string, amount int64, ttl time.Duration) (int64, error) {
+	return 0, errors.New("unavailable")
+}
+
+func (failingStore) Get(key string) (int64, error) {
+	return 0, errors.New("unavailable")
+}
+
+func newRateLimiter(t *testing.T, algorithm string, rateSet map[string]*types.Rate, store Store, now *time.Time) *RateLimiter {
+	extractor, err := NewExtractor("request.header.X-Source")
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("traefik"))
+	})
+
+	rl, err := New(next, "frontend", &types.RateLimit{Algorithm: algorithm, RateSet: rateSet}, extractor, store, false)
+	require.NoError(t, err)
+	rl.now = func() time.Time { return *now }
+	if memoryStore, ok := rl.store.(*MemoryStore); ok {
+		memoryStore.now = rl.now
+	}
+	return rl
+}
+
+func serve(rl *RateLimiter, source string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+	req.Header.Set("X-Source", source)
+	recorder := httptest.NewRecorder()
+	rl.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestFixedWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	rl := newRateLimiter(t, FixedWindow, map[string]*types.Rate{
+		"default": {Period: flaeg.Duration(10 * time.Second), Average: 2},
+	}, nil, &now)
+
+	resp := serve(rl, "a")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "2", resp.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", resp.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "10", resp.Header().Get("X-RateLimit-Reset"))
+
+	now = now.Add(4 * time.Second)
+	resp = serve(rl, "a")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get("X-RateLimit-Remaining"))
+
+	resp = serve(rl, "a")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "6", resp.Header().Get("Retry-After"))
+
+	// the sources are limited separately
+	resp = serve(rl, "b")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	now = now.Add(6 * time.Second)
+	resp = serve(rl, "a")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	rl := newRateLimiter(t, SlidingWindow, map[string]*types.Rate{
+		"default": {Period: flaeg.Duration(10 * time.Second), Average: 4},
+	}, nil, &now)
+
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, http.StatusOK, serve(rl, "a").Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve(rl, "a").Code)
+
+	// a quarter of the next window: 3 requests of the previous window are still counted
+	now = now.Add(12500 * time.Millisecond)
+	resp := serve(rl, "a")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get("X-RateLimit-Remaining"))
+
+	resp = serve(rl, "a")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	// the previous window weighs less than 3 requests after half of the window
+	assert.Equal(t, "3", resp.Header().Get("Retry-After"))
+
+	now = now.Add(2500 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, serve(rl, "a").Code)
+}
+
+func TestSeveralRates(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryStore()
+	rl := newRateLimiter(t, FixedWindow, map[string]*types.Rate{
+		"second": {Period: flaeg.Duration(time.Second), Average: 1},
+		"minute": {Period: flaeg.Duration(time.Minute), Average: 10},
+	}, store, &now)
+
+	resp := serve(rl, "a")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// the most restrictive rate is reported
+	assert.Equal(t, "1", resp.Header().Get("X-RateLimit-Limit"))
+
+	resp = serve(rl, "a")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+
+	// the denied request is not counted by the other rates
+	count, err := store.Get(rl.key(rl.rates[0], "a", now.UnixNano()/int64(time.Minute)))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestFailingStore(t *testing.T) {
+	now := time.Unix(1000, 0)
+	rl := newRateLimiter(t, SlidingWindow, map[string]*types.Rate{
+		"default": {Period: flaeg.Duration(time.Second), Average: 1},
+	}, failingStore{}, &now)
+
+	for i := 0; i < 3; i++ {
+		resp := serve(rl, "a")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("X-RateLimit-Limit"))
+	}
+
+	// a single error is logged per interval
+	assert.Equal(t, 2, rl.suppressedErrors)
+
+	now = now.Add(storeErrorLogInterval)
+	serve(rl, "a")
+	assert.Equal(t, 0, rl.suppressedErrors)
+}
+
+func TestFailingStoreFailClosed(t *testing.T) {
+	now := time.Unix(1000, 0)
+	rl := newRateLimiter(t, SlidingWindow, map[string]*types.Rate{
+		"default": {Period: flaeg.Duration(time.Second), Average: 1},
+	}, failingStore{}, &now)
+	rl.failClosed = true
+
+	resp := serve(rl, "a")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.NotEqual(t, "traefik", resp.Body.String())
+}
+
+func TestNewErrors(t *testing.T) {
+	extractor, err := NewExtractor("client.ip")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc   string
+		config *types.RateLimit
+	}{
+		{
+			desc:   "token bucket",
+			config: &types.RateLimit{Algorithm: TokenBucket, RateSet: map[string]*types.Rate{"a": {Period: flaeg.Duration(time.Second), Average: 1}}},
+		},
+		{
+			desc:   "no rate",
+			config: &types.RateLimit{},
+		},
+		{
+			desc:   "no period",
+			config: &types.RateLimit{RateSet: map[string]*types.Rate{"a": {Average: 1}}},
+		},
+		{
+			desc:   "no average",
+			config: &types.RateLimit{RateSet: map[string]*types.Rate{"a": {Period: flaeg.Duration(time.Second)}}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := New(http.NotFoundHandler(), "frontend", test.config, extractor, nil, false)
+			assert.Error(t, err)
+		})
+	}
+}
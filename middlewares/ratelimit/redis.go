@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+const (
+	redisTimeout     = 5 * time.Second
+	redisMaxConns    = 16
+	redisIdleTimeout = 5 * time.Minute
+)
+
+// RedisOptions configures the connections to a server speaking the Redis protocol.
+type RedisOptions struct {
+	Address  string
+	Password string
+	Database int
+	Prefix   string
+}
+
+var _ Store = (*RedisStore)(nil)
+
+// RedisStore keeps the counters in a server speaking the Redis protocol, shared between the Traefik instances.
+// The connections are opened on demand and kept in a small pool.
+type RedisStore struct {
+	prefix string
+	pool   *redis.Pool
+}
+
+// NewRedisStore creates a store keeping the counters in the Redis server.
+func NewRedisStore(options RedisOptions) (*RedisStore, error) {
+	if len(options.Address) == 0 {
+		return nil, errors.New("no redis address provided")
+	}
+	prefix := options.Prefix
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, ":") {
+		prefix += ":"
+	}
+
+	return &RedisStore{
+		prefix: prefix,
+		pool: &redis.Pool{
+			MaxIdle:     redisMaxConns,
+			IdleTimeout: redisIdleTimeout,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", options.Address,
+					redis.DialConnectTimeout(redisTimeout),
+					redis.DialReadTimeout(redisTimeout),
+					redis.DialWriteTimeout(redisTimeout),
+					redis.DialPassword(options.Password),
+					redis.DialDatabase(options.Database))
+			},
+		},
+	}, nil
+}
+
+// Increment adds the amount to the counter of the key: the key is created with its expiration if needed, then incremented.
+func (s *RedisStore) Increment(key string, amount int64, ttl time.Duration) (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	key = s.prefix + key
+	if err := conn.Send("SET", key, 0, "PX", int64(ttl/time.Millisecond), "NX"); err != nil {
+		return 0, redisErr(err)
+	}
+	if err := conn.Send("INCRBY", key, amount); err != nil {
+		return 0, redisErr(err)
+	}
+
+	// the empty command flushes the pipeline, and receives its replies
+	replies, err := redis.Values(conn.Do(""))
+	if err != nil {
+		return 0, redisErr(err)
+	}
+	if len(replies) != 2 {
+		return 0, fmt.Errorf("redis: unexpected replies %v", replies)
+	}
+
+	value, err := redis.Int64(replies[1], nil)
+	if err != nil {
+		return 0, redisErr(err)
+	}
+	return value, nil
+}
+
+// Get returns the value of the counter of the key.
+func (s *RedisStore) Get(key string) (int64, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	value, err := redis.Int64(conn.Do("GET", s.prefix+key))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, redisErr(err)
+	}
+	return value, nil
+}
+
+func redisErr(err error) error {
+	return fmt.Errorf("redis: %v", err)
+}
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is a server implementing the few commands used by the store, without expiration.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+	lock     sync.Mutex
+	values   map[string]int64
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeRedis{listener: listener, password: password, values: make(map[string]int64)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := len(s.password) == 0
+
+	for {
+		command, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch {
+		case command[0] == "AUTH":
+			authenticated = command[1] == s.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-ERR invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		default:
+			reply = s.execute(command)
+		}
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) execute(command []string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch command[0] {
+	case "SET":
+		if _, ok := s.values[command[1]]; ok {
+			return "$-1\r\n"
+		}
+		value, _ := strconv.ParseInt(command[2], 10, 64)
+		s.values[command[1]] = value
+		return "+OK\r\n"
+	case "INCRBY":
+		amount, _ := strconv.ParseInt(command[2], 10, 64)
+		s.values[command[1]] += amount
+		return fmt.Sprintf(":%d\r\n", s.values[command[1]])
+	case "GET":
+		value, ok := s.values[command[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		formatted := strconv.FormatInt(value, 10)
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(formatted), formatted)
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	command := make([]string, size)
+	for i := range command {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		command[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return command, nil
+}
+
+func TestRedisStore(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	defer server.listener.Close()
+
+	s, err := NewRedisStore(RedisOptions{Address: server.listener.Addr().String(), Password: "secret", Prefix: "traefik"})
+	require.NoError(t, err)
+
+	value, err := s.Get("foo/bar")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, value)
+
+	value, err = s.Increment("foo/bar", 2, 10*time.Second)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, value)
+
+	value, err = s.Increment("foo/bar", -1, 10*time.Second)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	value, err = s.Get("foo/bar")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	assert.Contains(t, server.values, "traefik:foo/bar")
+}
+
+func TestRedisStoreErrors(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	defer server.listener.Close()
+
+	_, err := NewRedisStore(RedisOptions{})
+	assert.Error(t, err)
+
+	s, err := NewRedisStore(RedisOptions{Address: server.listener.Addr().String(), Password: "wrong"})
+	require.NoError(t, err)
+
+	_, err = s.Increment("foo", 1, time.Second)
+	assert.EqualError(t, err, "redis: ERR invalid password")
+}
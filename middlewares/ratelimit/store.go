@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is the minimum interval between two deletions of the expired counters.
+const sweepInterval = time.Minute
+
+// Store holds the request counters of the rate limiters.
+type Store interface {
+	// Increment adds the amount to the counter of the key, which expires after the ttl, and returns its new value.
+	Increment(key string, amount int64, ttl time.Duration) (int64, error)
+	// Get returns the value of the counter of the key, 0 when it does not exist or has expired.
+	Get(key string) (int64, error)
+}
+
+type counter struct {
+	value   int64
+	expires time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore keeps the counters in memory, they are local to the Traefik instance.
+type MemoryStore struct {
+	lock      sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*counter),
+		now:      time.Now,
+	}
+}
+
+// Increment adds the amount to the counter of the key.
+func (s *MemoryStore) Increment(key string, amount int64, ttl time.Duration) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &counter{expires: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	c.value += amount
+	return c.value, nil
+}
+
+// Get returns the value of the counter of the key.
+func (s *MemoryStore) Get(key string) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || !s.now().Before(c.expires) {
+		return 0, nil
+	}
+	return c.value, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, c := range s.counters {
+		if !now.Before(c.expires) {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/libkv/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kvMock is an in-memory KV store supporting the atomic operations
+type kvMock struct {
+	store.Store
+	lock  sync.Mutex
+	index uint64
+	pairs map[string]*store.KVPair
+}
+
+func (s *kvMock) Get(key string) (*store.KVPair, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	pair, ok := s.pairs[key]
+	if !ok {
+		return nil, store.ErrKeyNotFound
+	}
+	return pair, nil
+}
+
+func (s *kvMock) List(directory string) ([]*store.KVPair, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var pairs []*store.KVPair
+	for key, pair := range s.pairs {
+		if strings.HasPrefix(key, directory) {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil
+}
+
+func (s *kvMock) AtomicPut(key string, value []byte, previous *store.KVPair, options *store.WriteOptions) (bool, *store.KVPair, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current, ok := s.pairs[key]
+	if previous == nil && ok {
+		return false, nil, store.ErrKeyExists
+	}
+	if previous != nil && (!ok || current.LastIndex != previous.LastIndex) {
+		return false, nil, store.ErrKeyModified
+	}
+
+	s.index++
+	pair := &store.KVPair{Key: key, Value: value, LastIndex: s.index}
+	s.pairs[key] = pair
+	return true, pair, nil
+}
+
+func (s *kvMock) AtomicDelete(key string, previous *store.KVPair) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current, ok := s.pairs[key]
+	if !ok || current.LastIndex != previous.LastIndex {
+		return false, errors.New("key modified")
+	}
+	delete(s.pairs, key)
+	return true, nil
+}
+
+func testStore(t *testing.T, s Store, setNow func(time.Time)) {
+	now := time.Unix(1000, 0)
+	setNow(now)
+
+	value, err := s.Get("foo/bar")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, value)
+
+	value, err = s.Increment("foo/bar", 2, 10*time.Second)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, value)
+
+	value, err = s.Increment("foo/bar", -1, 10*time.Second)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	value, err = s.Get("foo/bar")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	// the counter expires
+	setNow(now.Add(10 * time.Second))
+
+	value, err = s.Get("foo/bar")
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, value)
+
+	value, err = s.Increment("foo/bar", 1, 10*time.Second)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	testStore(t, s, func(now time.Time) {
+		s.now = func() time.Time { return now }
+	})
+}
+
+func TestKVStore(t *testing.T) {
+	kv := &kvMock{pairs: make(map[string]*store.KVPair)}
+
+	s := NewKVStore(kv, "traefik/ratelimit/")
+	testStore(t, s, func(now time.Time) {
+		s.now = func() time.Time { return now }
+	})
+
+	_, err := kv.Get("traefik/ratelimit/foo:bar")
+	assert.NoError(t, err)
+}
+
+func TestKVStoreConcurrentIncrements(t *testing.T) {
+	kv := &kvMock{pairs: make(map[string]*store.KVPair)}
+	s := NewKVStore(kv, "traefik/ratelimit")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Increment("foo", 1, time.Minute)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	value, err := s.Get("foo")
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, value)
+}
@@ -0,0 +1,170 @@
+package middlewares
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/containous/traefik/server/uuid"
+	"github.com/containous/traefik/types"
+)
+
+const (
+	// DefaultRequestIDHeader is the default header of the request IDs.
+	DefaultRequestIDHeader = "X-Request-Id"
+	// RequestIDFormatUUID generates random UUIDs, e.g. 0ccb4a0c-2f4b-4bd9-a6e2-8c15ff3f9b87.
+	RequestIDFormatUUID = "uuid"
+	// RequestIDFormatHex generates 16 random bytes, hex encoded.
+	RequestIDFormatHex = "hex"
+
+	// maxRequestIDLength is the length of the longest trusted incoming request ID.
+	maxRequestIDLength = 200
+)
+
+type requestIDKey struct{}
+
+// requestID is the request ID of a request, with its header.
+type requestID struct {
+	header string
+	value  string
+}
+
+// RequestIDListener is used to inform about the request IDs.
+type RequestIDListener interface {
+	// RequestIdentified will be called with the request ID of each request.
+	RequestIdentified(req *http.Request, id string)
+}
+
+// RequestID is a middleware identifying the requests:
+// the request ID is forwarded to the backends, returned to the client, and passed to the listener.
+type RequestID struct {
+	header        string
+	generate      func() string
+	trustIncoming bool
+	listener      RequestIDListener
+}
+
+// NewRequestID creates the request ID middleware of an entry point, the listener being optional.
+func NewRequestID(config *types.RequestID, listener RequestIDListener) (*RequestID, error) {
+	header := DefaultRequestIDHeader
+	if len(config.Header) > 0 {
+		header = http.CanonicalHeaderKey(config.Header)
+	}
+
+	var generate func() string
+	switch config.Format {
+	case "", RequestIDFormatUUID:
+		generate = uuid.New
+	case RequestIDFormatHex:
+		generate = randomHex
+	default:
+		return nil, fmt.Errorf("unknown request ID format %q", config.Format)
+	}
+
+	return &RequestID{
+		header:        header,
+		generate:      generate,
+		trustIncoming: config.TrustIncoming,
+		listener:      listener,
+	}, nil
+}
+
+func (r *RequestID) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	id := req.Header.Get(r.header)
+	if !r.trustIncoming || !validRequestID(id) {
+		id = r.generate()
+	}
+
+	req.Header.Set(r.header, id)
+	if r.listener != nil {
+		r.listener.RequestIdentified(req, id)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), requestIDKey{}, requestID{header: r.header, value: id}))
+
+	// the response might have no body, nor status code written by the handlers
+	rw.Header().Set(r.header, id)
+	next(&requestIDWriter{ResponseWriter: rw, header: r.header, id: id}, req)
+}
+
+// getRequestID returns the header and the value of the request ID of a request,
+// the X-Request-Id header when the entry point doesn't identify its requests.
+func getRequestID(req *http.Request) (string, string) {
+	if id, ok := req.Context().Value(requestIDKey{}).(requestID); ok {
+		return id.header, id.value
+	}
+	return DefaultRequestIDHeader, req.Header.Get(DefaultRequestIDHeader)
+}
+
+// validRequestID tells whether an incoming request ID can be trusted:
+// it is made of at most maxRequestIDLength visible ASCII characters, so that it can be logged safely.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// the UUIDs are random as well
+		return uuid.New()
+	}
+	return hex.EncodeToString(id)
+}
+
+// requestIDWriter sets the request ID on the response, replacing the one of the backend, if any.
+type requestIDWriter struct {
+	http.ResponseWriter
+	header      string
+	id          string
+	wroteHeader bool
+}
+
+func (w *requestIDWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set(w.header, w.id)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *requestIDWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *requestIDWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *requestIDWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", w.ResponseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+func (w *requestIDWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(<-chan bool)
+}
@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+)
+
+type requestIDRecorder []string
+
+func (r *requestIDRecorder) RequestIdentified(req *http.Request, id string) {
+	*r = append(*r, id)
+}
+
+func TestRequestID(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	hexPattern := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	testCases := []struct {
+		desc       string
+		config     types.RequestID
+		incoming   string
+		header     string
+		expected   string
+		expectedRe *regexp.Regexp
+	}{
+		{
+			desc:       "generated uuid",
+			header:     "X-Request-Id",
+			expectedRe: uuidPattern,
+		},
+		{
+			desc:       "generated hex",
+			config:     types.RequestID{Format: RequestIDFormatHex},
+			header:     "X-Request-Id",
+			expectedRe: hexPattern,
+		},
+		{
+			desc:       "untrusted incoming",
+			incoming:   "abc",
+			header:     "X-Request-Id",
+			expectedRe: uuidPattern,
+		},
+		{
+			desc:     "trusted incoming",
+			config:   types.RequestID{TrustIncoming: true},
+			incoming: "abc",
+			header:   "X-Request-Id",
+			expected: "abc",
+		},
+		{
+			desc:       "invalid trusted incoming",
+			config:     types.RequestID{TrustIncoming: true},
+			incoming:   "a b",
+			header:     "X-Request-Id",
+			expectedRe: uuidPattern,
+		},
+		{
+			desc:       "too long trusted incoming",
+			config:     types.RequestID{TrustIncoming: true},
+			incoming:   strings.Repeat("a", maxRequestIDLength+1),
+			header:     "X-Request-Id",
+			expectedRe: uuidPattern,
+		},
+		{
+			desc:     "custom header",
+			config:   types.RequestID{Header: "x-correlation-id", TrustIncoming: true},
+			incoming: "abc",
+			header:   "X-Correlation-Id",
+			expected: "abc",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			listener := &requestIDRecorder{}
+			requestID, err := NewRequestID(&test.config, listener)
+			require.NoError(t, err)
+
+			var backendIDs []string
+			n := negroni.New(requestID)
+			n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				backendIDs = r.Header[test.header]
+				// the backend echoes the request ID
+				w.Header().Set(test.header, r.Header.Get(test.header))
+				fmt.Fprintln(w, "traefik")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+			if len(test.incoming) > 0 {
+				req.Header.Set(test.header, test.incoming)
+			}
+			recorder := httptest.NewRecorder()
+			n.ServeHTTP(recorder, req)
+
+			require.Len(t, backendIDs, 1)
+			id := backendIDs[0]
+			if test.expectedRe != nil {
+				assert.Regexp(t, test.expectedRe, id)
+			} else {
+				assert.Equal(t, test.expected, id)
+			}
+			assert.Equal(t, []string{id}, recorder.Header()[test.header])
+			assert.Equal(t, []string{id}, []string(*listener))
+		})
+	}
+}
+
+func TestRequestIDUnique(t *testing.T) {
+	requestID, err := NewRequestID(&types.RequestID{}, nil)
+	require.NoError(t, err)
+
+	n := negroni.New(requestID)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	ids := map[string]struct{}{}
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		n.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/test", nil))
+		ids[recorder.Header().Get(DefaultRequestIDHeader)] = struct{}{}
+	}
+	assert.Len(t, ids, 10)
+}
+
+func TestNewRequestIDInvalidFormat(t *testing.T) {
+	_, err := NewRequestID(&types.RequestID{Format: "sequential"}, nil)
+	assert.Error(t, err)
+}
+
+func TestRequestIDErrorPage(t *testing.T) {
+	requestID, err := NewRequestID(&types.RequestID{Header: "X-Correlation-Id", TrustIncoming: true}, nil)
+	require.NoError(t, err)
+
+	errorPages, err := NewErrorPagesHandler(types.ErrorPage{Status: []string{"404"}, Content: "{status} {requestId}"}, "")
+	require.NoError(t, err)
+
+	n := negroni.New(requestID, errorPages)
+	n.UseHandler(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+	req.Header.Set("X-Correlation-Id", "abc")
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "abc", recorder.Header().Get("X-Correlation-Id"))
+	assert.Equal(t, "404 abc", recorder.Body.String())
+}
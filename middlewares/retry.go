@@ -4,11 +4,20 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
+	"math/rand"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
 	"github.com/vulcand/oxy/utils"
 )
 
@@ -17,32 +26,202 @@ var (
 	_ Stateful = &retryResponseRecorder{}
 )
 
+// RetryReasonNetworkError is the retry reason of the requests failing with a network error.
+// The requests retried because of their response status code have this code as reason.
+const RetryReasonNetworkError = "network_error"
+
+// DefaultRetryMaxBodySize is the maximum size of the request bodies buffered by the backend retry policies,
+// unless configured.
+const DefaultRetryMaxBodySize = 1 << 20
+
+// idempotentMethods are the methods retried by the backend retry policies, unless configured.
+var idempotentMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodTrace,
+	http.MethodPut,
+	http.MethodDelete,
+}
+
+// RetryPolicy defines which requests are retried, and when.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts of a request, the first one included.
+	Attempts int
+	// StatusCodes are the ranges of the response status codes retried, besides the network errors.
+	StatusCodes [][2]int
+	// Methods are the retried request methods, all of them if empty.
+	Methods []string
+	// InitialInterval is the backoff before the first retry, doubled on each retry up to MaxInterval.
+	// The requests are retried at once if it is zero.
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// MaxDuration bounds the time spent on the attempts of a request.
+	MaxDuration time.Duration
+	// MaxBodySize is the maximum size of the request bodies buffered to be sent again: the larger requests are not retried.
+	// NewRetryPolicy sets it to DefaultRetryMaxBodySize when the retry configuration has no size.
+	// Without retry configuration it is zero: the bodies are not buffered, and the requests with a body
+	// are only retried on network errors.
+	MaxBodySize int64
+}
+
+// NewRetryPolicy builds the retry policy of a backend, attempts being the default number of attempts.
+func NewRetryPolicy(attempts int, config *types.Retry) (*RetryPolicy, error) {
+	policy := &RetryPolicy{Attempts: attempts}
+	if config == nil {
+		return policy, nil
+	}
+
+	if config.Attempts < 0 {
+		return nil, fmt.Errorf("invalid retry attempts %d", config.Attempts)
+	}
+	if config.Attempts > 0 {
+		policy.Attempts = config.Attempts
+	}
+
+	statusCodes, err := parseHTTPCodeRanges(config.StatusCodes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry status codes: %v", err)
+	}
+	policy.StatusCodes = statusCodes
+
+	policy.Methods = idempotentMethods
+	if len(config.Methods) > 0 {
+		policy.Methods = nil
+		for _, method := range config.Methods {
+			policy.Methods = append(policy.Methods, strings.ToUpper(strings.TrimSpace(method)))
+		}
+	}
+
+	policy.InitialInterval = time.Duration(config.InitialInterval)
+	policy.MaxInterval = time.Duration(config.MaxInterval)
+	policy.MaxDuration = time.Duration(config.MaxDuration)
+	if policy.InitialInterval < 0 || policy.MaxInterval < 0 || policy.MaxDuration < 0 {
+		return nil, errors.New("negative retry durations")
+	}
+
+	if config.MaxBodySize < 0 {
+		return nil, fmt.Errorf("invalid retry max body size %d", config.MaxBodySize)
+	}
+	policy.MaxBodySize = config.MaxBodySize
+	if policy.MaxBodySize == 0 {
+		policy.MaxBodySize = DefaultRetryMaxBodySize
+	}
+
+	return policy, nil
+}
+
+// retriesMethod returns whether the requests with this method may be retried.
+func (p *RetryPolicy) retriesMethod(method string) bool {
+	if len(p.Methods) == 0 {
+		return true
+	}
+	for _, m := range p.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// retriesStatusCode returns whether the responses with this status code are retried.
+func (p *RetryPolicy) retriesStatusCode(code int) bool {
+	for _, block := range p.StatusCodes {
+		if code >= block[0] && code <= block[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the exponential backoff after the attempt, with an equal jitter:
+// half of the interval is kept, the other half is random.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	if p.InitialInterval <= 0 {
+		return 0
+	}
+
+	interval := p.InitialInterval
+	for i := 1; i < attempt && interval < math.MaxInt64/2; i++ {
+		if p.MaxInterval > 0 && interval >= p.MaxInterval {
+			break
+		}
+		interval *= 2
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+
+	half := interval / 2
+	return half + time.Duration(rand.Int63n(int64(interval-half)+1))
+}
+
 // Retry is a middleware that retries requests
 type Retry struct {
-	attempts int
+	policy   RetryPolicy
 	next     http.Handler
 	listener RetryListener
 }
 
-// NewRetry returns a new Retry instance
+// NewRetry returns a new Retry instance, retrying the requests failing with a network error.
 func NewRetry(attempts int, next http.Handler, listener RetryListener) *Retry {
+	return NewRetryWithPolicy(&RetryPolicy{Attempts: attempts}, next, listener)
+}
+
+// NewRetryWithPolicy returns a new Retry instance, retrying the requests according to the policy.
+func NewRetryWithPolicy(policy *RetryPolicy, next http.Handler, listener RetryListener) *Retry {
 	return &Retry{
-		attempts: attempts,
+		policy:   *policy,
 		next:     next,
 		listener: listener,
 	}
 }
 
 func (retry *Retry) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	// if we might make multiple attempts, swap the body for an ioutil.NopCloser
-	// cf https://github.com/containous/traefik/issues/1008
-	if retry.attempts > 1 {
-		body := r.Body
-		defer body.Close()
-		r.Body = ioutil.NopCloser(body)
-	}
-	attempts := 1
+	attempts := retry.policy.Attempts
+	if !retry.policy.retriesMethod(r.Method) {
+		attempts = 1
+	}
+
+	// whether the body can be sent again once read by the backend
+	replayable := r.Body == nil || r.Body == http.NoBody
+	var body []byte
+
+	if attempts > 1 && !replayable {
+		originalBody := r.Body
+		defer originalBody.Close()
+
+		if retry.policy.MaxBodySize > 0 {
+			var err error
+			body, err = ioutil.ReadAll(io.LimitReader(originalBody, retry.policy.MaxBodySize+1))
+			if err != nil {
+				log.Debugf("Error reading the body of the request %v: %v", r.URL, err)
+				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			if int64(len(body)) > retry.policy.MaxBodySize {
+				// the body is too large to be buffered, the request is sent once
+				r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), originalBody))
+				attempts = 1
+				body = nil
+			} else {
+				replayable = true
+			}
+		} else {
+			// if we might make multiple attempts, swap the body for an ioutil.NopCloser
+			// cf https://github.com/containous/traefik/issues/1008
+			r.Body = ioutil.NopCloser(originalBody)
+		}
+	}
+
+	start := time.Now()
+	attempt := 1
 	for {
+		if body != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
 		netErrorOccurred := false
 		// We pass in a pointer to netErrorOccurred so that we can set it to true on network errors
 		// when proxying the HTTP requests to the backends. This happens in the custom RecordingErrorHandler.
@@ -59,15 +238,44 @@ func (retry *Retry) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			break
 		}
 
-		if !netErrorOccurred || attempts >= retry.attempts {
+		var reason string
+		if netErrorOccurred {
+			reason = RetryReasonNetworkError
+		} else if replayable && retry.policy.retriesStatusCode(recorder.Code) {
+			reason = strconv.Itoa(recorder.Code)
+		}
+
+		if len(reason) == 0 || attempt >= attempts || !retry.wait(r, attempt, start) {
 			utils.CopyHeaders(rw.Header(), recorder.Header())
 			rw.WriteHeader(recorder.Code)
 			rw.Write(recorder.Body.Bytes())
 			break
 		}
-		attempts++
-		log.Debugf("New attempt %d for request: %v", attempts, r.URL)
-		retry.listener.Retried(r, attempts)
+		attempt++
+		log.Debugf("New attempt %d for request: %v, retry reason: %s", attempt, r.URL, reason)
+		retry.listener.Retried(r, attempt, reason)
+	}
+}
+
+// wait sleeps for the backoff following the attempt.
+// It returns false when the request must not be retried anymore, because of its maximum duration or of its cancellation.
+func (retry *Retry) wait(req *http.Request, attempt int, start time.Time) bool {
+	backoff := retry.policy.backoff(attempt)
+	if retry.policy.MaxDuration > 0 && time.Since(start)+backoff > retry.policy.MaxDuration {
+		return false
+	}
+	if backoff <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
 	}
 }
 
@@ -100,7 +308,8 @@ func (DefaultNetErrorRecorder) Record(ctx context.Context) {
 type RetryListener interface {
 	// Retried will be called when a retry happens, with the request attempt passed to it.
 	// For the first retry this will be attempt 2.
-	Retried(req *http.Request, attempt int)
+	// The reason is RetryReasonNetworkError, or the retried response status code.
+	Retried(req *http.Request, attempt int, reason string)
 }
 
 // RetryListeners is a convenience type to construct a list of RetryListener and notify
@@ -108,9 +317,9 @@ type RetryListener interface {
 type RetryListeners []RetryListener
 
 // Retried exists to implement the RetryListener interface. It calls Retried on each of its slice entries.
-func (l RetryListeners) Retried(req *http.Request, attempt int) {
+func (l RetryListeners) Retried(req *http.Request, attempt int, reason string) {
 	for _, retryListener := range l {
-		retryListener.Retried(req, attempt)
+		retryListener.Retried(req, attempt, reason)
 	}
 }
 
@@ -6,7 +6,14 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRetry(t *testing.T) {
@@ -93,8 +100,8 @@ func TestRetryListeners(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	retryListeners := RetryListeners{&countingRetryListener{}, &countingRetryListener{}}
 
-	retryListeners.Retried(req, 1)
-	retryListeners.Retried(req, 1)
+	retryListeners.Retried(req, 1, RetryReasonNetworkError)
+	retryListeners.Retried(req, 1, RetryReasonNetworkError)
 
 	for _, retryListener := range retryListeners {
 		listener := retryListener.(*countingRetryListener)
@@ -129,8 +136,193 @@ func (handler *networkFailingHTTPHandler) ServeHTTP(w http.ResponseWriter, r *ht
 // countingRetryListener is a RetryListener implementation to count the times the Retried fn is called.
 type countingRetryListener struct {
 	timesCalled int
+	reasons     []string
 }
 
-func (l *countingRetryListener) Retried(req *http.Request, attempt int) {
+func (l *countingRetryListener) Retried(req *http.Request, attempt int, reason string) {
 	l.timesCalled++
+	l.reasons = append(l.reasons, reason)
+}
+
+// statusHandler answers with the status codes in turn, then with 200, and records the bodies of the requests.
+type statusHandler struct {
+	statusCodes []int
+	bodies      []string
+}
+
+func (handler *statusHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	handler.bodies = append(handler.bodies, string(body))
+
+	if len(handler.bodies) <= len(handler.statusCodes) {
+		rw.WriteHeader(handler.statusCodes[len(handler.bodies)-1])
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+func TestRetryStatusCodes(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		method          string
+		statusCodes     []int
+		expectedStatus  int
+		expectedReasons []string
+	}{
+		{
+			desc:            "retried status codes",
+			method:          http.MethodGet,
+			statusCodes:     []int{http.StatusServiceUnavailable, http.StatusBadGateway},
+			expectedStatus:  http.StatusOK,
+			expectedReasons: []string{"503", "502"},
+		},
+		{
+			desc:            "too many failures",
+			method:          http.MethodGet,
+			statusCodes:     []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+			expectedStatus:  http.StatusGatewayTimeout,
+			expectedReasons: []string{"503", "503"},
+		},
+		{
+			desc:           "status code not retried",
+			method:         http.MethodGet,
+			statusCodes:    []int{http.StatusInternalServerError},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			desc:           "method not retried",
+			method:         http.MethodPost,
+			statusCodes:    []int{http.StatusServiceUnavailable},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := NewRetryPolicy(2, &types.Retry{Attempts: 3, StatusCodes: []string{"502-504"}})
+			require.NoError(t, err)
+
+			listener := &countingRetryListener{}
+			handler := NewRetryWithPolicy(policy, &statusHandler{statusCodes: test.statusCodes}, listener)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(test.method, "/", nil))
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedReasons, listener.reasons)
+		})
+	}
+}
+
+func TestRetryBody(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		body           string
+		expectedBodies []string
+	}{
+		{
+			desc:           "buffered body",
+			body:           "0123456789",
+			expectedBodies: []string{"0123456789", "0123456789"},
+		},
+		{
+			desc:           "body too large",
+			body:           "0123456789abcdef",
+			expectedBodies: []string{"0123456789abcdef"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := NewRetryPolicy(2, &types.Retry{
+				StatusCodes: []string{"503"},
+				Methods:     []string{"post"},
+				MaxBodySize: 10,
+			})
+			require.NoError(t, err)
+
+			backend := &statusHandler{statusCodes: []int{http.StatusServiceUnavailable}}
+			handler := NewRetryWithPolicy(policy, backend, &countingRetryListener{})
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body)))
+
+			assert.Equal(t, test.expectedBodies, backend.bodies)
+		})
+	}
+}
+
+func TestRetryMaxDuration(t *testing.T) {
+	policy, err := NewRetryPolicy(3, &types.Retry{
+		StatusCodes:     []string{"503"},
+		InitialInterval: flaeg.Duration(40 * time.Millisecond),
+		MaxDuration:     flaeg.Duration(50 * time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	listener := &countingRetryListener{}
+	backend := &statusHandler{statusCodes: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}}
+	handler := NewRetryWithPolicy(policy, backend, listener)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// the second backoff, of 40 to 80ms, would exceed the maximum duration
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, 1, listener.timesCalled)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialInterval: 100 * time.Millisecond, MaxInterval: 300 * time.Millisecond}
+
+	testCases := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{attempt: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 3, min: 150 * time.Millisecond, max: 300 * time.Millisecond},
+		{attempt: 100, min: 150 * time.Millisecond, max: 300 * time.Millisecond},
+	}
+
+	for _, test := range testCases {
+		for i := 0; i < 10; i++ {
+			backoff := policy.backoff(test.attempt)
+			assert.True(t, backoff >= test.min && backoff <= test.max, "attempt %d: backoff %s", test.attempt, backoff)
+		}
+	}
+
+	assert.Zero(t, (&RetryPolicy{}).backoff(1))
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy, err := NewRetryPolicy(2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &RetryPolicy{Attempts: 2}, policy)
+
+	policy, err = NewRetryPolicy(2, &types.Retry{StatusCodes: []string{"502-504"}})
+	require.NoError(t, err)
+	assert.Equal(t, &RetryPolicy{
+		Attempts:    2,
+		StatusCodes: [][2]int{{502, 504}},
+		Methods:     idempotentMethods,
+		MaxBodySize: DefaultRetryMaxBodySize,
+	}, policy)
+
+	for _, config := range []*types.Retry{
+		{Attempts: -1},
+		{StatusCodes: []string{"5xx"}},
+		{InitialInterval: flaeg.Duration(-time.Second)},
+		{MaxBodySize: -1},
+	} {
+		_, err := NewRetryPolicy(2, config)
+		assert.Error(t, err, "%+v", config)
+	}
 }
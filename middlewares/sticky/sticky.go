@@ -0,0 +1,258 @@
+package sticky
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/containous/traefik/types"
+	"github.com/vulcand/oxy/utils"
+)
+
+// Affinity modes.
+const (
+	// ModeCookie sticks the clients to the server stored in a cookie.
+	ModeCookie = "cookie"
+	// ModeHash sticks the clients to the server chosen by a consistent hash of a request value.
+	ModeHash = "hash"
+)
+
+// Obfuscations of the server stored in the cookie.
+const (
+	// ObfuscationHash stores a SHA-256 hash of the server URL.
+	ObfuscationHash = "hash"
+	// ObfuscationHMAC stores an HMAC-SHA256 of the server URL, keyed with the secret.
+	ObfuscationHMAC = "hmac"
+)
+
+// obfuscatedLength is the length of the hex encoded obfuscated server values.
+const obfuscatedLength = 32
+
+// Balancer is a load balancer whose servers may change over time, e.g. after health checks.
+type Balancer interface {
+	http.Handler
+	Servers() []*url.URL
+}
+
+// weighted is implemented by the balancers exposing the weights of their servers.
+type weighted interface {
+	ServerWeight(u *url.URL) (int, bool)
+}
+
+// Sticky keeps the requests of a client on the same server of a backend.
+type Sticky struct {
+	next       http.Handler
+	cookieName string
+	config     types.Stickiness
+	sameSite   string
+	extractor  utils.SourceExtractor
+}
+
+// New creates the stickiness of a backend, next being the handler forwarding the requests to the server in their URL.
+// The extractor gives the value hashed by the hash mode.
+func New(next http.Handler, cookieName string, config *types.Stickiness, extractor utils.SourceExtractor) (*Sticky, error) {
+	s := &Sticky{
+		next:       next,
+		cookieName: cookieName,
+		config:     *config,
+		extractor:  extractor,
+	}
+
+	switch strings.ToLower(config.Mode) {
+	case "", ModeCookie:
+		s.config.Mode = ModeCookie
+	case ModeHash:
+		s.config.Mode = ModeHash
+		if extractor == nil {
+			return nil, errors.New("no value to hash provided")
+		}
+	default:
+		return nil, fmt.Errorf("unknown stickiness mode %q", config.Mode)
+	}
+
+	switch strings.ToLower(config.SameSite) {
+	case "":
+	case "none":
+		s.sameSite = "None"
+	case "lax":
+		s.sameSite = "Lax"
+	case "strict":
+		s.sameSite = "Strict"
+	default:
+		return nil, fmt.Errorf("unknown cookie SameSite attribute %q", config.SameSite)
+	}
+
+	switch strings.ToLower(config.Obfuscation) {
+	case "":
+	case ObfuscationHash:
+		s.config.Obfuscation = ObfuscationHash
+	case ObfuscationHMAC:
+		if len(config.Secret) == 0 {
+			return nil, errors.New("the hmac obfuscation requires a secret")
+		}
+		s.config.Obfuscation = ObfuscationHMAC
+	default:
+		return nil, fmt.Errorf("unknown cookie obfuscation %q", config.Obfuscation)
+	}
+
+	if config.MaxAge < 0 {
+		return nil, fmt.Errorf("invalid cookie max age %d", config.MaxAge)
+	}
+
+	return s, nil
+}
+
+// Forwarder returns the handler the load balancer forwards the requests to:
+// in the cookie mode, it sticks the clients to the server chosen by the load balancer.
+func (s *Sticky) Forwarder() http.Handler {
+	if s.config.Mode != ModeCookie {
+		return s.next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		s.setCookie(rw, req.URL)
+		s.next.ServeHTTP(rw, req)
+	})
+}
+
+// Handler returns the handler sending the requests of the clients to their server,
+// and the other requests to the load balancer.
+func (s *Sticky) Handler(balancer Balancer) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var server *url.URL
+		if s.config.Mode == ModeHash {
+			server = s.hashServer(req, balancer)
+		} else {
+			server = s.cookieServer(req, balancer.Servers())
+		}
+
+		if server == nil {
+			balancer.ServeHTTP(rw, req)
+			return
+		}
+
+		// the expiration of the cookie is renewed as long as the client uses it
+		if s.config.Mode == ModeCookie && s.config.MaxAge > 0 {
+			s.setCookie(rw, server)
+		}
+
+		newReq := *req
+		newReq.URL = utils.CopyURL(server)
+		s.next.ServeHTTP(rw, &newReq)
+	})
+}
+
+// cookieServer returns the server stored in the cookie of the request, if it is still one of the servers.
+func (s *Sticky) cookieServer(req *http.Request, servers []*url.URL) *url.URL {
+	cookie, err := req.Cookie(s.cookieName)
+	if err != nil || len(cookie.Value) == 0 {
+		return nil
+	}
+
+	for _, server := range servers {
+		if hmac.Equal([]byte(cookie.Value), []byte(s.cookieValue(server))) {
+			return server
+		}
+	}
+	return nil
+}
+
+// cookieValue is the server stored in the cookie, obfuscated or not.
+func (s *Sticky) cookieValue(server *url.URL) string {
+	value := server.String()
+
+	switch s.config.Obfuscation {
+	case ObfuscationHash:
+		sum := sha256.Sum256([]byte(value))
+		return hex.EncodeToString(sum[:])[:obfuscatedLength]
+	case ObfuscationHMAC:
+		mac := hmac.New(sha256.New, []byte(s.config.Secret))
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))[:obfuscatedLength]
+	default:
+		return value
+	}
+}
+
+func (s *Sticky) setCookie(rw http.ResponseWriter, server *url.URL) {
+	cookie := &http.Cookie{
+		Name:     s.cookieName,
+		Value:    s.cookieValue(server),
+		Path:     "/",
+		Domain:   s.config.Domain,
+		MaxAge:   s.config.MaxAge,
+		Secure:   s.config.Secure,
+		HttpOnly: s.config.HTTPOnly,
+	}
+
+	value := cookie.String()
+	if len(value) == 0 {
+		return
+	}
+	if len(s.sameSite) > 0 {
+		value += "; SameSite=" + s.sameSite
+	}
+	rw.Header().Add("Set-Cookie", value)
+}
+
+// hashServer returns the server chosen by the rendezvous hashing of the value of the request:
+// when a server is added or removed, only the values of this server are remapped.
+// The requests without value are left to the load balancer.
+func (s *Sticky) hashServer(req *http.Request, balancer Balancer) *url.URL {
+	key, _, err := s.extractor.Extract(req)
+	if err != nil || len(key) == 0 {
+		return nil
+	}
+
+	weights, _ := balancer.(weighted)
+
+	var chosen *url.URL
+	bestScore := math.Inf(-1)
+	for _, server := range balancer.Servers() {
+		weight := 1
+		if weights != nil {
+			if w, ok := weights.ServerWeight(server); ok {
+				weight = w
+			}
+		}
+		if weight <= 0 {
+			continue
+		}
+
+		if score := rendezvousScore(key, server.String(), weight); chosen == nil || score > bestScore {
+			chosen = server
+			bestScore = score
+		}
+	}
+	return chosen
+}
+
+// rendezvousScore is the weighted score of the server for the key, the highest score wins.
+func rendezvousScore(key, server string, weight int) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(server))
+
+	// the hash is mapped to ]0, 1[ to weight its logarithm
+	sum := mix(h.Sum64())
+	unit := (float64(sum>>11) + 0.5) / (1 << 53)
+	return -float64(weight) / math.Log(unit)
+}
+
+// mix spreads the bits of the FNV hash, whose high bits vary little for close inputs.
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,247 @@
+package sticky
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/containous/traefik/testhelpers"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vulcand/oxy/roundrobin"
+	"github.com/vulcand/oxy/utils"
+)
+
+// serverNameHandler answers with the host of the server the request is forwarded to.
+var serverNameHandler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	rw.Write([]byte(req.URL.Host))
+})
+
+func newBalancer(t *testing.T, config *types.Stickiness, extractor utils.SourceExtractor, servers ...string) (*roundrobin.RoundRobin, http.Handler) {
+	t.Helper()
+
+	s, err := New(serverNameHandler, "sticky", config, extractor)
+	require.NoError(t, err)
+
+	rr, err := roundrobin.New(s.Forwarder())
+	require.NoError(t, err)
+
+	for _, server := range servers {
+		require.NoError(t, rr.UpsertServer(testhelpers.MustParseURL(server)))
+	}
+	return rr, s.Handler(rr)
+}
+
+func serve(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestCookie(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		config         *types.Stickiness
+		expectedCookie string
+	}{
+		{
+			desc:           "server URL",
+			config:         &types.Stickiness{},
+			expectedCookie: "sticky=http://127.0.0.1:8081; Path=/",
+		},
+		{
+			desc: "attributes",
+			config: &types.Stickiness{
+				Secure:   true,
+				HTTPOnly: true,
+				SameSite: "lax",
+				MaxAge:   3600,
+				Domain:   "example.com",
+			},
+			expectedCookie: "sticky=http://127.0.0.1:8081; Path=/; Domain=example.com; Max-Age=3600; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			desc:           "hash obfuscation",
+			config:         &types.Stickiness{Obfuscation: "hash"},
+			expectedCookie: "sticky=08d5f409490f61fc00aed4d165513bd2; Path=/",
+		},
+		{
+			desc:           "hmac obfuscation",
+			config:         &types.Stickiness{Obfuscation: "hmac", Secret: "secret"},
+			expectedCookie: "sticky=81f5c1437bfa0c3961e130b9a3386f2c; Path=/",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, handler := newBalancer(t, test.config, nil, "http://127.0.0.1:8081", "http://127.0.0.1:8082")
+
+			recorder := serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, "127.0.0.1:8081", recorder.Body.String())
+			assert.Equal(t, test.expectedCookie, recorder.Header().Get("Set-Cookie"))
+
+			cookie := recorder.Result().Cookies()[0]
+			for i := 0; i < 3; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.AddCookie(cookie)
+
+				recorder = serve(handler, req)
+				assert.Equal(t, "127.0.0.1:8081", recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestCookieRemovedServer(t *testing.T) {
+	rr, handler := newBalancer(t, &types.Stickiness{Obfuscation: "hash"}, nil, "http://127.0.0.1:8081", "http://127.0.0.1:8082")
+
+	recorder := serve(handler, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, "127.0.0.1:8081", recorder.Body.String())
+	cookie := recorder.Result().Cookies()[0]
+
+	require.NoError(t, rr.RemoveServer(testhelpers.MustParseURL("http://127.0.0.1:8081")))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	recorder = serve(handler, req)
+	assert.Equal(t, "127.0.0.1:8082", recorder.Body.String())
+	assert.Contains(t, recorder.Header().Get("Set-Cookie"), "sticky=")
+}
+
+func TestCookieMaxAgeRenewal(t *testing.T) {
+	_, handler := newBalancer(t, &types.Stickiness{MaxAge: 60}, nil, "http://127.0.0.1:8081", "http://127.0.0.1:8082")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sticky", Value: "http://127.0.0.1:8082"})
+
+	recorder := serve(handler, req)
+	assert.Equal(t, "127.0.0.1:8082", recorder.Body.String())
+	assert.Equal(t, "sticky=http://127.0.0.1:8082; Path=/; Max-Age=60", recorder.Header().Get("Set-Cookie"))
+}
+
+func TestHash(t *testing.T) {
+	servers := []string{"http://127.0.0.1:8081", "http://127.0.0.1:8082", "http://127.0.0.1:8083", "http://127.0.0.1:8084"}
+	extractor, err := utils.NewExtractor("request.header.X-User")
+	require.NoError(t, err)
+
+	rr, handler := newBalancer(t, &types.Stickiness{Mode: "hash"}, extractor, servers...)
+
+	route := func(user string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if len(user) > 0 {
+			req.Header.Set("X-User", user)
+		}
+		recorder := serve(handler, req)
+		assert.Empty(t, recorder.Header().Get("Set-Cookie"))
+		return recorder.Body.String()
+	}
+
+	before := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		user := fmt.Sprintf("user%d", i)
+		before[user] = route(user)
+		counts[before[user]]++
+
+		assert.Equal(t, before[user], route(user))
+	}
+	for _, server := range servers {
+		assert.InDelta(t, 100, counts[testhelpers.MustParseURL(server).Host], 40, server)
+	}
+
+	// only the users of the removed server are remapped
+	removed := testhelpers.MustParseURL(servers[0])
+	require.NoError(t, rr.RemoveServer(removed))
+	for user, server := range before {
+		if server == removed.Host {
+			assert.NotEqual(t, removed.Host, route(user))
+		} else {
+			assert.Equal(t, server, route(user))
+		}
+	}
+
+	// the requests without the value are load balanced
+	assert.NotEqual(t, route(""), route(""))
+}
+
+func TestHashWeights(t *testing.T) {
+	extractor, err := utils.NewExtractor("request.header.X-User")
+	require.NoError(t, err)
+
+	s, err := New(serverNameHandler, "sticky", &types.Stickiness{Mode: "hash"}, extractor)
+	require.NoError(t, err)
+	rr, err := roundrobin.New(s.Forwarder())
+	require.NoError(t, err)
+	require.NoError(t, rr.UpsertServer(testhelpers.MustParseURL("http://127.0.0.1:8081"), roundrobin.Weight(3)))
+	require.NoError(t, rr.UpsertServer(testhelpers.MustParseURL("http://127.0.0.1:8082"), roundrobin.Weight(1)))
+	handler := s.Handler(rr)
+
+	counts := make(map[string]int)
+	for i := 0; i < 400; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", fmt.Sprintf("user%d", i))
+		counts[serve(handler, req).Body.String()]++
+	}
+	assert.InDelta(t, 300, counts["127.0.0.1:8081"], 40)
+}
+
+func TestNewErrors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *types.Stickiness
+	}{
+		{
+			desc:   "unknown mode",
+			config: &types.Stickiness{Mode: "random"},
+		},
+		{
+			desc:   "hash mode without extractor",
+			config: &types.Stickiness{Mode: "hash"},
+		},
+		{
+			desc:   "unknown SameSite",
+			config: &types.Stickiness{SameSite: "always"},
+		},
+		{
+			desc:   "unknown obfuscation",
+			config: &types.Stickiness{Obfuscation: "base64"},
+		},
+		{
+			desc:   "hmac without secret",
+			config: &types.Stickiness{Obfuscation: "hmac"},
+		},
+		{
+			desc:   "negative max age",
+			config: &types.Stickiness{MaxAge: -1},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := New(serverNameHandler, "sticky", test.config, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCookieValueMatchesLegacyCookies(t *testing.T) {
+	s, err := New(serverNameHandler, "sticky", &types.Stickiness{}, nil)
+	require.NoError(t, err)
+
+	server, err := url.Parse("http://10.0.0.1:80")
+	require.NoError(t, err)
+
+	// the cookies set by the previous sticky sessions keep working
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sticky", Value: "http://10.0.0.1:80"})
+	assert.Equal(t, server, s.cookieServer(req, []*url.URL{server}))
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/hashicorp/consul/api"
@@ -326,10 +327,6 @@ func (p *CatalogProvider) getPrefixedName(name string) string {
 	return name
 }
 
-func (p *CatalogProvider) getEntryPoints(list string) []string {
-	return strings.Split(list, ",")
-}
-
 func (p *CatalogProvider) getBackend(node *api.ServiceEntry) string {
 	return strings.ToLower(node.Service.Service)
 }
@@ -389,31 +386,32 @@ func (p *CatalogProvider) getBackendName(node *api.ServiceEntry, index int) stri
 	return serviceName
 }
 
-func (p *CatalogProvider) getBasicAuth(tags []string) []string {
-	list := p.getAttribute("frontend.auth.basic", tags, "")
-	if list != "" {
-		return strings.Split(list, ",")
-	}
-	return []string{}
-}
+// getLabels converts the prefixed key=value tags to traefik.* labels.
+// The legacy traefik.backend.loadbalancer tag sets the load balancer method.
+func (p *CatalogProvider) getLabels(tags []string) map[string]string {
+	prefix := p.getPrefixedName("")
+	labels := make(map[string]string)
+	for _, tag := range tags {
+		kv := strings.SplitN(tag, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(strings.ToLower(kv[0]), strings.ToLower(prefix)) {
+			continue
+		}
 
-func (p *CatalogProvider) getSticky(tags []string) string {
-	stickyTag := p.getTag(types.LabelBackendLoadbalancerSticky, tags, "")
-	if len(stickyTag) > 0 {
-		log.Warnf("Deprecated configuration found: %s. Please use %s.", types.LabelBackendLoadbalancerSticky, types.LabelBackendLoadbalancerStickiness)
-	} else {
-		stickyTag = "false"
+		name := types.LabelPrefix + kv[0][len(prefix):]
+		if name == types.LabelPrefix+"backend.loadbalancer" {
+			name = types.LabelBackendLoadbalancerMethod
+		}
+		labels[name] = kv[1]
 	}
-	return stickyTag
+	return labels
 }
 
-func (p *CatalogProvider) hasStickinessLabel(tags []string) bool {
-	stickinessTag := p.getTag(types.LabelBackendLoadbalancerStickiness, tags, "")
-	return len(stickinessTag) > 0 && strings.EqualFold(strings.TrimSpace(stickinessTag), "true")
+func (p *CatalogProvider) getFrontendOptions(service serviceUpdate) *types.Frontend {
+	return label.GetFrontend(p.getLabels(service.Attributes))
 }
 
-func (p *CatalogProvider) getStickinessCookieName(tags []string) string {
-	return p.getTag(types.LabelBackendLoadbalancerStickinessCookieName, tags, "")
+func (p *CatalogProvider) getBackendOptions(service serviceUpdate) *types.Backend {
+	return label.GetBackend(p.getLabels(service.Attributes))
 }
 
 func (p *CatalogProvider) getAttribute(name string, tags []string, defaultValue string) string {
@@ -462,19 +460,15 @@ func (p *CatalogProvider) getConstraintTags(tags []string) []string {
 
 func (p *CatalogProvider) buildConfig(catalog []catalogUpdate) *types.Configuration {
 	var FuncMap = template.FuncMap{
-		"getBackend":              p.getBackend,
-		"getFrontendRule":         p.getFrontendRule,
-		"getBackendName":          p.getBackendName,
-		"getBackendAddress":       p.getBackendAddress,
-		"getBasicAuth":            p.getBasicAuth,
-		"getSticky":               p.getSticky,
-		"hasStickinessLabel":      p.hasStickinessLabel,
-		"getStickinessCookieName": p.getStickinessCookieName,
-		"getAttribute":            p.getAttribute,
-		"getTag":                  p.getTag,
-		"hasTag":                  p.hasTag,
-		"getEntryPoints":          p.getEntryPoints,
-		"hasMaxconnAttributes":    p.hasMaxconnAttributes,
+		"getBackend":         p.getBackend,
+		"getFrontendRule":    p.getFrontendRule,
+		"getBackendName":     p.getBackendName,
+		"getBackendAddress":  p.getBackendAddress,
+		"getAttribute":       p.getAttribute,
+		"getTag":             p.getTag,
+		"hasTag":             p.hasTag,
+		"getFrontendOptions": p.getFrontendOptions,
+		"getBackendOptions":  p.getBackendOptions,
 	}
 
 	allNodes := []*api.ServiceEntry{}
@@ -504,15 +498,6 @@ func (p *CatalogProvider) buildConfig(catalog []catalogUpdate) *types.Configurat
 	return configuration
 }
 
-func (p *CatalogProvider) hasMaxconnAttributes(attributes []string) bool {
-	amount := p.getAttribute("backend.maxconn.amount", attributes, "")
-	extractorfunc := p.getAttribute("backend.maxconn.extractorfunc", attributes, "")
-	if amount != "" && extractorfunc != "" {
-		return true
-	}
-	return false
-}
-
 func (p *CatalogProvider) getNodes(index map[string][]string) ([]catalogUpdate, error) {
 	visited := make(map[string]bool)
 
@@ -561,7 +546,7 @@ func (p *CatalogProvider) watch(configurationChan chan<- types.ConfigMessage, st
 			}
 			configuration := p.buildConfig(nodes)
 			configurationChan <- types.ConfigMessage{
-				ProviderName:  "consul_catalog",
+				ProviderName:  p.GetProviderName("consul_catalog"),
 				Configuration: configuration,
 			}
 		case err := <-errorCh:
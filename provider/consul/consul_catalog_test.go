@@ -377,6 +377,7 @@ func TestConsulCatalogBuildConfig(t *testing.T) {
 				"frontend-test": {
 					Backend:        "backend-test",
 					PassHostHeader: true,
+					EntryPoints:    []string{},
 					Routes: map[string]types.Route{
 						"route-host-test": {
 							Rule: "Host:test.localhost",
@@ -858,79 +859,65 @@ func TestConsulCatalogFilterEnabled(t *testing.T) {
 	}
 }
 
-func TestConsulCatalogGetBasicAuth(t *testing.T) {
-	cases := []struct {
+func TestConsulCatalogGetLabels(t *testing.T) {
+	testCases := []struct {
 		desc     string
+		prefix   string
 		tags     []string
-		expected []string
+		expected map[string]string
 	}{
 		{
-			desc:     "label missing",
-			tags:     []string{},
-			expected: []string{},
+			desc:     "no tags",
+			prefix:   "traefik",
+			expected: map[string]string{},
 		},
 		{
-			desc: "label existing",
+			desc:   "prefixed tags",
+			prefix: "traefik",
 			tags: []string{
-				"traefik.frontend.auth.basic=user:password",
+				"traefik.frontend.passHostHeader=false",
+				"traefik.backend.loadbalancer=drr",
+				"traefik.backend.loadbalancer.stickiness=true",
+				"traefik.enable",
+				"random.foo=bar",
+			},
+			expected: map[string]string{
+				types.LabelFrontendPassHostHeader:        "false",
+				types.LabelBackendLoadbalancerMethod:     "drr",
+				types.LabelBackendLoadbalancerStickiness: "true",
 			},
-			expected: []string{"user:password"},
-		},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &CatalogProvider{
-				Prefix: "traefik",
-			}
-			actual := provider.getBasicAuth(c.tags)
-			if !reflect.DeepEqual(actual, c.expected) {
-				t.Errorf("actual %q, expected %q", actual, c.expected)
-			}
-		})
-	}
-}
-
-func TestConsulCatalogHasStickinessLabel(t *testing.T) {
-	testCases := []struct {
-		desc     string
-		tags     []string
-		expected bool
-	}{
-		{
-			desc:     "label missing",
-			tags:     []string{},
-			expected: false,
 		},
 		{
-			desc: "stickiness=true",
+			desc:   "custom prefix",
+			prefix: "foo",
 			tags: []string{
-				types.LabelBackendLoadbalancerStickiness + "=true",
+				"foo.frontend.priority=10",
+				"traefik.frontend.priority=20",
+			},
+			expected: map[string]string{
+				types.LabelFrontendPriority: "10",
 			},
-			expected: true,
 		},
 		{
-			desc: "stickiness=false",
+			desc: "empty prefix",
 			tags: []string{
-				types.LabelBackendLoadbalancerStickiness + "=false",
+				"frontend.priority=10",
+			},
+			expected: map[string]string{
+				types.LabelFrontendPriority: "10",
 			},
-			expected: false,
 		},
 	}
 
-	provider := &CatalogProvider{
-		Prefix: "traefik",
-	}
-
 	for _, test := range testCases {
 		test := test
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			actual := provider.hasStickinessLabel(test.tags)
-			assert.Equal(t, actual, test.expected)
+			provider := &CatalogProvider{
+				Prefix: test.prefix,
+			}
+			assert.Equal(t, test.expected, provider.getLabels(test.tags))
 		})
 	}
 }
@@ -3,7 +3,6 @@ package docker
 import (
 	"context"
 	"fmt"
-	"math"
 	"net"
 	"net/http"
 	"regexp"
@@ -17,6 +16,7 @@ import (
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/containous/traefik/version"
@@ -157,7 +157,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 
 			configuration := p.loadDockerConfig(dockerDataList)
 			configurationChan <- types.ConfigMessage{
-				ProviderName:  "docker",
+				ProviderName:  p.GetProviderName("docker"),
 				Configuration: configuration,
 			}
 			if p.Watch {
@@ -180,7 +180,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 								configuration := p.loadDockerConfig(services)
 								if configuration != nil {
 									configurationChan <- types.ConfigMessage{
-										ProviderName:  "docker",
+										ProviderName:  p.GetProviderName("docker"),
 										Configuration: configuration,
 									}
 								}
@@ -225,7 +225,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 						configuration := p.loadDockerConfig(containers)
 						if configuration != nil {
 							configurationChan <- types.ConfigMessage{
-								ProviderName:  "docker",
+								ProviderName:  p.GetProviderName("docker"),
 								Configuration: configuration,
 							}
 						}
@@ -260,44 +260,24 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 
 func (p *Provider) loadDockerConfig(containersInspected []dockerData) *types.Configuration {
 	var DockerFuncMap = template.FuncMap{
-		"getBackend":                  p.getBackend,
-		"getIPAddress":                p.getIPAddress,
-		"getPort":                     p.getPort,
-		"getWeight":                   p.getWeight,
-		"getDomain":                   p.getDomain,
-		"getProtocol":                 p.getProtocol,
-		"getPassHostHeader":           p.getPassHostHeader,
-		"getPriority":                 p.getPriority,
-		"getEntryPoints":              p.getEntryPoints,
-		"getBasicAuth":                p.getBasicAuth,
-		"getFrontendRule":             p.getFrontendRule,
-		"hasCircuitBreakerLabel":      p.hasCircuitBreakerLabel,
-		"getCircuitBreakerExpression": p.getCircuitBreakerExpression,
-		"hasLoadBalancerLabel":        p.hasLoadBalancerLabel,
-		"getLoadBalancerMethod":       p.getLoadBalancerMethod,
-		"hasMaxConnLabels":            p.hasMaxConnLabels,
-		"getMaxConnAmount":            p.getMaxConnAmount,
-		"getMaxConnExtractorFunc":     p.getMaxConnExtractorFunc,
-		"getSticky":                   p.getSticky,
-		"getStickinessCookieName":     p.getStickinessCookieName,
-		"hasStickinessLabel":          p.hasStickinessLabel,
-		"getIsBackendLBSwarm":         p.getIsBackendLBSwarm,
-		"hasServices":                 p.hasServices,
-		"getServiceNames":             p.getServiceNames,
-		"getServicePort":              p.getServicePort,
-		"getServiceWeight":            p.getServiceWeight,
-		"getServiceProtocol":          p.getServiceProtocol,
-		"getServiceEntryPoints":       p.getServiceEntryPoints,
-		"getServiceBasicAuth":         p.getServiceBasicAuth,
-		"getServiceFrontendRule":      p.getServiceFrontendRule,
-		"getServicePassHostHeader":    p.getServicePassHostHeader,
-		"getServicePriority":          p.getServicePriority,
-		"getServiceBackend":           p.getServiceBackend,
-		"getWhitelistSourceRange":     p.getWhitelistSourceRange,
-		"getRequestHeaders":           p.getRequestHeaders,
-		"getResponseHeaders":          p.getResponseHeaders,
-		"hasRequestHeaders":           p.hasRequestHeaders,
-		"hasResponseHeaders":          p.hasResponseHeaders,
+		"getBackend":                p.getBackend,
+		"getIPAddress":              p.getIPAddress,
+		"getPort":                   p.getPort,
+		"getWeight":                 p.getWeight,
+		"getDomain":                 p.getDomain,
+		"getProtocol":               p.getProtocol,
+		"getFrontendRule":           p.getFrontendRule,
+		"getIsBackendLBSwarm":       p.getIsBackendLBSwarm,
+		"hasServices":               p.hasServices,
+		"getServiceNames":           p.getServiceNames,
+		"getServicePort":            p.getServicePort,
+		"getServiceWeight":          p.getServiceWeight,
+		"getServiceProtocol":        p.getServiceProtocol,
+		"getServiceFrontendRule":    p.getServiceFrontendRule,
+		"getServiceBackend":         p.getServiceBackend,
+		"getFrontendOptions":        p.getFrontendOptions,
+		"getServiceFrontendOptions": p.getServiceFrontendOptions,
+		"getBackendOptions":         p.getBackendOptions,
 	}
 	// filter containers
 	filteredContainers := fun.Filter(func(container dockerData) bool {
@@ -336,11 +316,6 @@ func (p *Provider) loadDockerConfig(containersInspected []dockerData) *types.Con
 	return configuration
 }
 
-func (p *Provider) hasCircuitBreakerLabel(container dockerData) bool {
-	_, err := getLabel(container, types.LabelBackendCircuitbreakerExpression)
-	return err == nil
-}
-
 // Regexp used to extract the name of the service and the name of the property for this service
 // All properties are under the format traefik.<servicename>.frontent.*= except the port/weight/protocol directly after traefik.<servicename>.
 var servicesPropertiesRegexp = regexp.MustCompile(`^traefik\.(?P<service_name>.+?)\.(?P<property_name>port|weight|protocol|frontend\.(.*))$`)
@@ -394,41 +369,6 @@ func (p *Provider) getServiceNames(container dockerData) []string {
 	return keys
 }
 
-// Extract entrypoints from labels for a given service and a given docker container
-func (p *Provider) getServiceEntryPoints(container dockerData, serviceName string) []string {
-	if entryPoints, ok := getContainerServiceLabel(container, serviceName, "frontend.entryPoints"); ok {
-		return strings.Split(entryPoints, ",")
-	}
-	return p.getEntryPoints(container)
-
-}
-
-// Extract basic auth from labels for a given service and a given docker container
-func (p *Provider) getServiceBasicAuth(container dockerData, serviceName string) []string {
-	if basicAuth, ok := getContainerServiceLabel(container, serviceName, "frontend.auth.basic"); ok {
-		return strings.Split(basicAuth, ",")
-	}
-	return p.getBasicAuth(container)
-
-}
-
-// Extract passHostHeader from labels for a given service and a given docker container
-func (p *Provider) getServicePassHostHeader(container dockerData, serviceName string) string {
-	if servicePassHostHeader, ok := getContainerServiceLabel(container, serviceName, "frontend.passHostHeader"); ok {
-		return servicePassHostHeader
-	}
-	return p.getPassHostHeader(container)
-}
-
-// Extract priority from labels for a given service and a given docker container
-func (p *Provider) getServicePriority(container dockerData, serviceName string) string {
-	if value, ok := getContainerServiceLabel(container, serviceName, "frontend.priority"); ok {
-		return value
-	}
-	return p.getPriority(container)
-
-}
-
 // Extract backend from labels for a given service and a given docker container
 func (p *Provider) getServiceBackend(container dockerData, serviceName string) string {
 	if value, ok := getContainerServiceLabel(container, serviceName, "frontend.backend"); ok {
@@ -470,56 +410,19 @@ func (p *Provider) getServiceProtocol(container dockerData, serviceName string)
 	return p.getProtocol(container)
 }
 
-func (p *Provider) hasLoadBalancerLabel(container dockerData) bool {
-	_, errMethod := getLabel(container, types.LabelBackendLoadbalancerMethod)
-	_, errSticky := getLabel(container, types.LabelBackendLoadbalancerSticky)
-	_, errStickiness := getLabel(container, types.LabelBackendLoadbalancerStickiness)
-	_, errCookieName := getLabel(container, types.LabelBackendLoadbalancerStickinessCookieName)
-
-	return errMethod == nil || errSticky == nil || errStickiness == nil || errCookieName == nil
-}
-
-func (p *Provider) hasMaxConnLabels(container dockerData) bool {
-	if _, err := getLabel(container, types.LabelBackendMaxconnAmount); err != nil {
-		return false
-	}
-	if _, err := getLabel(container, types.LabelBackendMaxconnExtractorfunc); err != nil {
-		return false
-	}
-	return true
+// getFrontendOptions returns the frontend options set by the container labels.
+func (p *Provider) getFrontendOptions(container dockerData) *types.Frontend {
+	return label.GetFrontend(container.Labels)
 }
 
-func (p *Provider) getCircuitBreakerExpression(container dockerData) string {
-	if label, err := getLabel(container, types.LabelBackendCircuitbreakerExpression); err == nil {
-		return label
-	}
-	return "NetworkErrorRatio() > 1"
+// getServiceFrontendOptions returns the frontend options of a given service, the service labels overriding the container ones.
+func (p *Provider) getServiceFrontendOptions(container dockerData, serviceName string) *types.Frontend {
+	return label.GetFrontend(label.GetServiceLabels(container.Labels, serviceName))
 }
 
-func (p *Provider) getLoadBalancerMethod(container dockerData) string {
-	if label, err := getLabel(container, types.LabelBackendLoadbalancerMethod); err == nil {
-		return label
-	}
-	return "wrr"
-}
-
-func (p *Provider) getMaxConnAmount(container dockerData) int64 {
-	if label, err := getLabel(container, types.LabelBackendMaxconnAmount); err == nil {
-		i, errConv := strconv.ParseInt(label, 10, 64)
-		if errConv != nil {
-			log.Errorf("Unable to parse traefik.backend.maxconn.amount %s", label)
-			return math.MaxInt64
-		}
-		return i
-	}
-	return math.MaxInt64
-}
-
-func (p *Provider) getMaxConnExtractorFunc(container dockerData) string {
-	if label, err := getLabel(container, types.LabelBackendMaxconnExtractorfunc); err == nil {
-		return label
-	}
-	return "request.host"
+// getBackendOptions returns the backend options set by the container labels.
+func (p *Provider) getBackendOptions(container dockerData) *types.Backend {
+	return label.GetBackend(container.Labels)
 }
 
 func (p *Provider) containerFilter(container dockerData) bool {
@@ -715,28 +618,6 @@ func (p *Provider) getWeight(container dockerData) string {
 	return "0"
 }
 
-func (p *Provider) hasStickinessLabel(container dockerData) bool {
-	labelStickiness, errStickiness := getLabel(container, types.LabelBackendLoadbalancerStickiness)
-	return errStickiness == nil && len(labelStickiness) > 0 && strings.EqualFold(strings.TrimSpace(labelStickiness), "true")
-}
-
-func (p *Provider) getSticky(container dockerData) string {
-	if label, err := getLabel(container, types.LabelBackendLoadbalancerSticky); err == nil {
-		if len(label) > 0 {
-			log.Warnf("Deprecated configuration found: %s. Please use %s.", types.LabelBackendLoadbalancerSticky, types.LabelBackendLoadbalancerStickiness)
-		}
-		return label
-	}
-	return "false"
-}
-
-func (p *Provider) getStickinessCookieName(container dockerData) string {
-	if label, err := getLabel(container, types.LabelBackendLoadbalancerStickinessCookieName); err == nil {
-		return label
-	}
-	return ""
-}
-
 func (p *Provider) getIsBackendLBSwarm(container dockerData) string {
 	if label, err := getLabel(container, labelBackendLoadbalancerSwarm); err == nil {
 		return label
@@ -758,79 +639,6 @@ func (p *Provider) getProtocol(container dockerData) string {
 	return "http"
 }
 
-func (p *Provider) getPassHostHeader(container dockerData) string {
-	if passHostHeader, err := getLabel(container, types.LabelFrontendPassHostHeader); err == nil {
-		return passHostHeader
-	}
-	return "true"
-}
-
-func (p *Provider) getWhitelistSourceRange(container dockerData) []string {
-	var whitelistSourceRange []string
-
-	if whitelistSourceRangeLabel, err := getLabel(container, types.LabelTraefikFrontendWhitelistSourceRange); err == nil {
-		whitelistSourceRange = provider.SplitAndTrimString(whitelistSourceRangeLabel)
-	}
-	return whitelistSourceRange
-}
-
-func (p *Provider) getPriority(container dockerData) string {
-	if priority, err := getLabel(container, types.LabelFrontendPriority); err == nil {
-		return priority
-	}
-	return "0"
-}
-
-func (p *Provider) getEntryPoints(container dockerData) []string {
-	if entryPoints, err := getLabel(container, types.LabelFrontendEntryPoints); err == nil {
-		return strings.Split(entryPoints, ",")
-	}
-	return []string{}
-}
-
-func (p *Provider) getBasicAuth(container dockerData) []string {
-	if basicAuth, err := getLabel(container, types.LabelFrontendAuthBasic); err == nil {
-		return strings.Split(basicAuth, ",")
-	}
-
-	return []string{}
-}
-
-func (p *Provider) hasRequestHeaders(container dockerData) bool {
-	label, err := getLabel(container, types.LabelFrontendRequestHeader)
-	return err == nil && len(label) > 0
-}
-
-func (p *Provider) hasResponseHeaders(container dockerData) bool {
-	label, err := getLabel(container, types.LabelFrontendResponseHeader)
-	return err == nil && len(label) > 0
-}
-
-func (p *Provider) getRequestHeaders(container dockerData) map[string]string {
-	return parseCustomHeaders(container, types.LabelFrontendRequestHeader)
-}
-
-func (p *Provider) getResponseHeaders(container dockerData) map[string]string {
-	return parseCustomHeaders(container, types.LabelFrontendResponseHeader)
-}
-
-func parseCustomHeaders(container dockerData, containerType string) map[string]string {
-	customHeaders := make(map[string]string)
-	if label, err := getLabel(container, containerType); err == nil {
-		for _, headers := range strings.Split(label, ",") {
-			pair := strings.Split(headers, ":")
-			if len(pair) != 2 {
-				log.Warnf("Could not load header %v, skipping...", pair)
-			} else {
-				customHeaders[pair[0]] = pair[1]
-			}
-		}
-	}
-	if len(customHeaders) == 0 {
-		log.Errorf("Could not load any custom headers")
-	}
-	return customHeaders
-}
 func isContainerEnabled(container dockerData, exposedByDefault bool) bool {
 	return exposedByDefault && container.Labels[types.LabelEnable] != "false" || container.Labels[types.LabelEnable] == "true"
 }
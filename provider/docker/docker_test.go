@@ -5,12 +5,13 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/containous/flaeg"
 	"github.com/containous/traefik/types"
 	docker "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/go-connections/nat"
-	"github.com/stretchr/testify/assert"
 )
 
 func TestDockerGetFrontendName(t *testing.T) {
@@ -383,99 +384,6 @@ func TestDockerGetProtocol(t *testing.T) {
 	}
 }
 
-func TestDockerGetPassHostHeader(t *testing.T) {
-	containers := []struct {
-		container docker.ContainerJSON
-		expected  string
-	}{
-		{
-			container: containerJSON(),
-			expected:  "true",
-		},
-		{
-			container: containerJSON(labels(map[string]string{
-				types.LabelFrontendPassHostHeader: "false",
-			})),
-			expected: "false",
-		},
-	}
-
-	for containerID, e := range containers {
-		e := e
-		t.Run(strconv.Itoa(containerID), func(t *testing.T) {
-			t.Parallel()
-			dockerData := parseContainer(e.container)
-			provider := &Provider{}
-			actual := provider.getPassHostHeader(dockerData)
-			if actual != e.expected {
-				t.Errorf("expected %q, got %q", e.expected, actual)
-			}
-		})
-	}
-}
-
-func TestDockerGetWhitelistSourceRange(t *testing.T) {
-	containers := []struct {
-		desc      string
-		container docker.ContainerJSON
-		expected  []string
-	}{
-		{
-			desc:      "no whitelist-label",
-			container: containerJSON(),
-			expected:  nil,
-		},
-		{
-			desc: "whitelist-label with empty string",
-			container: containerJSON(labels(map[string]string{
-				types.LabelTraefikFrontendWhitelistSourceRange: "",
-			})),
-			expected: nil,
-		},
-		{
-			desc: "whitelist-label with IPv4 mask",
-			container: containerJSON(labels(map[string]string{
-				types.LabelTraefikFrontendWhitelistSourceRange: "1.2.3.4/16",
-			})),
-			expected: []string{
-				"1.2.3.4/16",
-			},
-		},
-		{
-			desc: "whitelist-label with IPv6 mask",
-			container: containerJSON(labels(map[string]string{
-				types.LabelTraefikFrontendWhitelistSourceRange: "fe80::/16",
-			})),
-			expected: []string{
-				"fe80::/16",
-			},
-		},
-		{
-			desc: "whitelist-label with multiple masks",
-			container: containerJSON(labels(map[string]string{
-				types.LabelTraefikFrontendWhitelistSourceRange: "1.1.1.1/24, 1234:abcd::42/32",
-			})),
-			expected: []string{
-				"1.1.1.1/24",
-				"1234:abcd::42/32",
-			},
-		},
-	}
-
-	for _, e := range containers {
-		e := e
-		t.Run(e.desc, func(t *testing.T) {
-			t.Parallel()
-			dockerData := parseContainer(e.container)
-			provider := &Provider{}
-			actual := provider.getWhitelistSourceRange(dockerData)
-			if !reflect.DeepEqual(actual, e.expected) {
-				t.Errorf("expected %q, got %q", e.expected, actual)
-			}
-		})
-	}
-}
-
 func TestDockerGetLabel(t *testing.T) {
 	containers := []struct {
 		container docker.ContainerJSON
@@ -938,7 +846,11 @@ func TestDockerLoadDockerConfig(t *testing.T) {
 				containerJSON(
 					name("test2"),
 					labels(map[string]string{
-						types.LabelBackend: "foobar",
+						types.LabelBackend:                       "foobar",
+						types.LabelFrontendAuthBasicUsersFile:    "/etc/traefik/users",
+						types.LabelFrontendAuthBasicRealm:        "myrealm",
+						types.LabelFrontendAuthBasicRemoveHeader: "true",
+						types.LabelFrontendAuthHeaderField:       "X-WebAuth-User",
 					}),
 					ports(nat.PortMap{
 						"80/tcp": {},
@@ -963,6 +875,15 @@ func TestDockerLoadDockerConfig(t *testing.T) {
 					PassHostHeader: true,
 					EntryPoints:    []string{},
 					BasicAuth:      []string{},
+					Auth: &types.Auth{
+						HeaderField: "X-WebAuth-User",
+						Basic: &types.Basic{
+							Users:        types.Users{},
+							UsersFile:    "/etc/traefik/users",
+							Realm:        "myrealm",
+							RemoveHeader: true,
+						},
+					},
 					Routes: map[string]types.Route{
 						"route-frontend-Host-test2-docker-localhost-1": {
 							Rule: "Host:test2.docker.localhost",
@@ -1038,6 +959,199 @@ func TestDockerLoadDockerConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test1"),
+					labels(map[string]string{
+						types.LabelBackend:                             "foobar",
+						types.LabelProtocol:                            "https",
+						types.LabelBackendServersTransportServerName:   "backend.internal",
+						types.LabelBackendServersTransportRootCAs:      "/etc/ca.pem",
+						types.LabelBackendServersTransportCert:         "/etc/client.pem",
+						types.LabelBackendServersTransportKey:          "/etc/client.key",
+						types.LabelBackendServersTransportMaxIdleConns: "42",
+					}),
+					ports(nat.PortMap{
+						"443/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test1-docker-localhost-0": {
+					Backend:        "backend-foobar",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test1-docker-localhost-0": {
+							Rule: "Host:test1.docker.localhost",
+						},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-foobar": {
+					Servers: map[string]types.Server{
+						"server-test1": {
+							URL:    "https://127.0.0.1:443",
+							Weight: 0,
+						},
+					},
+					ServersTransport: &types.ServersTransport{
+						ServerName:          "backend.internal",
+						RootCAs:             []string{"/etc/ca.pem"},
+						Cert:                "/etc/client.pem",
+						Key:                 "/etc/client.key",
+						MaxIdleConnsPerHost: 42,
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test1"),
+					labels(map[string]string{
+						types.LabelBackend:                           "foobar",
+						types.LabelFrontendCacheStore:                "disk",
+						types.LabelFrontendCacheDirectory:            "/var/cache/traefik",
+						types.LabelFrontendCacheMaxSize:              "1048576",
+						types.LabelFrontendCacheStaleWhileRevalidate: "30s",
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test1-docker-localhost-0": {
+					Backend:        "backend-foobar",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Cache: &types.Cache{
+						Store:                "disk",
+						Directory:            "/var/cache/traefik",
+						MaxSize:              1048576,
+						StaleWhileRevalidate: flaeg.Duration(30 * time.Second),
+					},
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test1-docker-localhost-0": {
+							Rule: "Host:test1.docker.localhost",
+						},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-foobar": {
+					Servers: map[string]types.Server{
+						"server-test1": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test1"),
+					labels(map[string]string{
+						types.LabelBackend:                      "foobar",
+						types.LabelFrontendCompressEncodings:    "br",
+						types.LabelFrontendCompressBrotliLevel:  "4",
+						types.LabelFrontendCompressContentTypes: "text/html,application/json",
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test1-docker-localhost-0": {
+					Backend:        "backend-foobar",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					Compress: &types.Compress{
+						Encodings:            []string{"br"},
+						BrotliLevel:          4,
+						ContentTypes:         []string{"text/html", "application/json"},
+						ExcludedContentTypes: []string{},
+					},
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test1-docker-localhost-0": {
+							Rule: "Host:test1.docker.localhost",
+						},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-foobar": {
+					Servers: map[string]types.Server{
+						"server-test1": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+				},
+			},
+		},
+		{
+			containers: []docker.ContainerJSON{
+				containerJSON(
+					name("test1"),
+					labels(map[string]string{
+						types.LabelBackend:                           "foobar",
+						types.LabelFrontendWhiteListSourceRange:      "10.0.0.0/8",
+						types.LabelFrontendWhiteListIPStrategyDepth:  "1",
+						types.LabelFrontendWhiteListRejectStatusCode: "404",
+						types.LabelFrontendWhiteListRejectBody:       `"not found"`,
+					}),
+					ports(nat.PortMap{
+						"80/tcp": {},
+					}),
+					withNetwork("bridge", ipv4("127.0.0.1")),
+				),
+			},
+			expectedFrontends: map[string]*types.Frontend{
+				"frontend-Host-test1-docker-localhost-0": {
+					Backend:        "backend-foobar",
+					PassHostHeader: true,
+					EntryPoints:    []string{},
+					BasicAuth:      []string{},
+					WhiteList: &types.WhiteList{
+						SourceRange: []string{"10.0.0.0/8"},
+						IPStrategy: &types.IPStrategy{
+							Depth:          1,
+							TrustedProxies: []string{},
+						},
+						RejectStatusCode: 404,
+						RejectBody:       `"not found"`,
+					},
+					Routes: map[string]types.Route{
+						"route-frontend-Host-test1-docker-localhost-0": {
+							Rule: "Host:test1.docker.localhost",
+						},
+					},
+				},
+			},
+			expectedBackends: map[string]*types.Backend{
+				"backend-foobar": {
+					Servers: map[string]types.Server{
+						"server-test1": {
+							URL:    "http://127.0.0.1:80",
+							Weight: 0,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for caseID, c := range cases {
@@ -1066,45 +1180,6 @@ func TestDockerLoadDockerConfig(t *testing.T) {
 	}
 }
 
-func TestDockerHasStickinessLabel(t *testing.T) {
-	testCases := []struct {
-		desc      string
-		container docker.ContainerJSON
-		expected  bool
-	}{
-		{
-			desc:      "no stickiness-label",
-			container: containerJSON(),
-			expected:  false,
-		},
-		{
-			desc: "stickiness true",
-			container: containerJSON(labels(map[string]string{
-				types.LabelBackendLoadbalancerStickiness: "true",
-			})),
-			expected: true,
-		},
-		{
-			desc: "stickiness false",
-			container: containerJSON(labels(map[string]string{
-				types.LabelBackendLoadbalancerStickiness: "false",
-			})),
-			expected: false,
-		},
-	}
-
-	for _, test := range testCases {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
-			dockerData := parseContainer(test.container)
-			provider := &Provider{}
-			actual := provider.hasStickinessLabel(dockerData)
-			assert.Equal(t, actual, test.expected)
-		})
-	}
-}
-
 func TestDockerCheckPortLabels(t *testing.T) {
 	testCases := []struct {
 		container     docker.ContainerJSON
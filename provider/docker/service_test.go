@@ -200,104 +200,41 @@ func TestDockerGetServiceBackend(t *testing.T) {
 	}
 }
 
-func TestDockerGetServicePriority(t *testing.T) {
+func TestDockerGetServiceFrontendOptions(t *testing.T) {
 	provider := &Provider{}
 
 	containers := []struct {
 		container docker.ContainerJSON
-		expected  string
-	}{
-		{
-			container: containerJSON(),
-			expected:  "0",
-		},
-		{
-			container: containerJSON(labels(map[string]string{
-				types.LabelFrontendPriority: "33",
-			})),
-			expected: "33",
-		},
-		{
-			container: containerJSON(labels(map[string]string{
-				"traefik.myservice.frontend.priority": "2503",
-			})),
-			expected: "2503",
-		},
-	}
-
-	for containerID, e := range containers {
-		e := e
-		t.Run(strconv.Itoa(containerID), func(t *testing.T) {
-			t.Parallel()
-			dockerData := parseContainer(e.container)
-			actual := provider.getServicePriority(dockerData, "myservice")
-			if actual != e.expected {
-				t.Fatalf("expected %q, got %q", e.expected, actual)
-			}
-		})
-	}
-}
-
-func TestDockerGetServicePassHostHeader(t *testing.T) {
-	provider := &Provider{}
-
-	containers := []struct {
-		container docker.ContainerJSON
-		expected  string
+		expected  *types.Frontend
 	}{
 		{
 			container: containerJSON(),
-			expected:  "true",
+			expected:  &types.Frontend{PassHostHeader: true},
 		},
 		{
 			container: containerJSON(labels(map[string]string{
+				types.LabelFrontendPriority:       "33",
 				types.LabelFrontendPassHostHeader: "false",
+				types.LabelFrontendEntryPoints:    "http,https",
 			})),
-			expected: "false",
-		},
-		{
-			container: containerJSON(labels(map[string]string{
-				"traefik.myservice.frontend.passHostHeader": "false",
-			})),
-			expected: "false",
-		},
-	}
-
-	for containerID, e := range containers {
-		e := e
-		t.Run(strconv.Itoa(containerID), func(t *testing.T) {
-			t.Parallel()
-			dockerData := parseContainer(e.container)
-			actual := provider.getServicePassHostHeader(dockerData, "myservice")
-			if actual != e.expected {
-				t.Fatalf("expected %q, got %q", e.expected, actual)
-			}
-		})
-	}
-}
-
-func TestDockerGetServiceEntryPoints(t *testing.T) {
-	provider := &Provider{}
-
-	containers := []struct {
-		container docker.ContainerJSON
-		expected  []string
-	}{
-		{
-			container: containerJSON(),
-			expected:  []string{},
-		},
-		{
-			container: containerJSON(labels(map[string]string{
-				types.LabelFrontendEntryPoints: "http,https",
-			})),
-			expected: []string{"http", "https"},
+			expected: &types.Frontend{
+				Priority:    33,
+				EntryPoints: []string{"http", "https"},
+			},
 		},
 		{
 			container: containerJSON(labels(map[string]string{
-				"traefik.myservice.frontend.entryPoints": "http,https",
+				types.LabelFrontendPriority:                      "33",
+				"traefik.myservice.frontend.priority":            "2503",
+				"traefik.myservice.frontend.passHostHeader":      "false",
+				"traefik.myservice.frontend.entryPoints":         "https",
+				"traefik.myservice.frontend.redirect.entryPoint": "https",
 			})),
-			expected: []string{"http", "https"},
+			expected: &types.Frontend{
+				Priority:    2503,
+				EntryPoints: []string{"https"},
+				Redirect:    &types.Redirect{EntryPoint: "https"},
+			},
 		},
 	}
 
@@ -306,9 +243,9 @@ func TestDockerGetServiceEntryPoints(t *testing.T) {
 		t.Run(strconv.Itoa(containerID), func(t *testing.T) {
 			t.Parallel()
 			dockerData := parseContainer(e.container)
-			actual := provider.getServiceEntryPoints(dockerData, "myservice")
+			actual := provider.getServiceFrontendOptions(dockerData, "myservice")
 			if !reflect.DeepEqual(actual, e.expected) {
-				t.Fatalf("expected %q, got %q for container %q", e.expected, actual, dockerData.Name)
+				t.Fatalf("expected %+v, got %+v", e.expected, actual)
 			}
 		})
 	}
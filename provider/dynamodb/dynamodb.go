@@ -173,7 +173,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 			}
 
 			configurationChan <- types.ConfigMessage{
-				ProviderName:  "dynamodb",
+				ProviderName:  p.GetProviderName("dynamodb"),
 				Configuration: configuration,
 			}
 
@@ -190,7 +190,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 						}
 
 						configurationChan <- types.ConfigMessage{
-							ProviderName:  "dynamodb",
+							ProviderName:  p.GetProviderName("dynamodb"),
 							Configuration: configuration,
 						}
 					case <-ctx.Done():
@@ -21,6 +21,7 @@ import (
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 )
@@ -133,7 +134,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 			}
 
 			configurationChan <- types.ConfigMessage{
-				ProviderName:  "ecs",
+				ProviderName:  p.GetProviderName("ecs"),
 				Configuration: configuration,
 			}
 
@@ -149,7 +150,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 						}
 
 						configurationChan <- types.ConfigMessage{
-							ProviderName:  "ecs",
+							ProviderName:  p.GetProviderName("ecs"),
 							Configuration: configuration,
 						}
 					case <-ctx.Done():
@@ -181,20 +182,14 @@ func wrapAws(ctx context.Context, req *request.Request) error {
 // generateECSConfig fills the config template with the given instances
 func (p *Provider) generateECSConfig(services map[string][]ecsInstance) (*types.Configuration, error) {
 	var ecsFuncMap = template.FuncMap{
-		"filterFrontends":         p.filterFrontends,
-		"getFrontendRule":         p.getFrontendRule,
-		"getBasicAuth":            p.getBasicAuth,
-		"getLoadBalancerMethod":   p.getLoadBalancerMethod,
-		"getLoadBalancerSticky":   p.getLoadBalancerSticky,
-		"hasStickinessLabel":      p.hasStickinessLabel,
-		"getStickinessCookieName": p.getStickinessCookieName,
-		"getProtocol":             p.getProtocol,
-		"getHost":                 p.getHost,
-		"getPort":                 p.getPort,
-		"getWeight":               p.getWeight,
-		"getPassHostHeader":       p.getPassHostHeader,
-		"getPriority":             p.getPriority,
-		"getEntryPoints":          p.getEntryPoints,
+		"filterFrontends":    p.filterFrontends,
+		"getFrontendRule":    p.getFrontendRule,
+		"getFrontendOptions": p.getFrontendOptions,
+		"getBackendOptions":  p.getBackendOptions,
+		"getProtocol":        p.getProtocol,
+		"getHost":            p.getHost,
+		"getPort":            p.getPort,
+		"getWeight":          p.getWeight,
 	}
 	return p.GetConfiguration("templates/ecs.tmpl", ecsFuncMap, struct {
 		Services map[string][]ecsInstance
@@ -428,6 +423,16 @@ func (p *Provider) label(i ecsInstance, k string) string {
 	return ""
 }
 
+func (p *Provider) getLabels(i ecsInstance) map[string]string {
+	labels := make(map[string]string, len(i.containerDefinition.DockerLabels))
+	for k, v := range i.containerDefinition.DockerLabels {
+		if v != nil {
+			labels[k] = *v
+		}
+	}
+	return labels
+}
+
 func (p *Provider) filterInstance(i ecsInstance) bool {
 	if labelPort := p.label(i, types.LabelPort); len(i.container.NetworkBindings) == 0 && labelPort == "" {
 		log.Debugf("Filtering ecs instance without port %s (%s)", i.Name, i.ID)
@@ -481,49 +486,16 @@ func (p *Provider) getFrontendRule(i ecsInstance) string {
 	return "Host:" + strings.ToLower(strings.Replace(i.Name, "_", "-", -1)) + "." + p.Domain
 }
 
-func (p *Provider) getBasicAuth(i ecsInstance) []string {
-	label := p.label(i, types.LabelFrontendAuthBasic)
-	if label != "" {
-		return strings.Split(label, ",")
-	}
-	return []string{}
-}
-
-func (p *Provider) getFirstInstanceLabel(instances []ecsInstance, labelName string) string {
-	if len(instances) > 0 {
-		return p.label(instances[0], labelName)
-	}
-	return ""
-}
-
-func (p *Provider) getLoadBalancerSticky(instances []ecsInstance) string {
-	if len(instances) > 0 {
-		label := p.getFirstInstanceLabel(instances, types.LabelBackendLoadbalancerSticky)
-		if label != "" {
-			log.Warnf("Deprecated configuration found: %s. Please use %s.", types.LabelBackendLoadbalancerSticky, types.LabelBackendLoadbalancerStickiness)
-			return label
-		}
-	}
-	return "false"
-}
-
-func (p *Provider) hasStickinessLabel(instances []ecsInstance) bool {
-	stickinessLabel := p.getFirstInstanceLabel(instances, types.LabelBackendLoadbalancerStickiness)
-	return len(stickinessLabel) > 0 && strings.EqualFold(strings.TrimSpace(stickinessLabel), "true")
+func (p *Provider) getFrontendOptions(i ecsInstance) *types.Frontend {
+	return label.GetFrontend(p.getLabels(i))
 }
 
-func (p *Provider) getStickinessCookieName(instances []ecsInstance) string {
-	return p.getFirstInstanceLabel(instances, types.LabelBackendLoadbalancerStickinessCookieName)
-}
-
-func (p *Provider) getLoadBalancerMethod(instances []ecsInstance) string {
-	if len(instances) > 0 {
-		label := p.label(instances[0], types.LabelBackendLoadbalancerMethod)
-		if label != "" {
-			return label
-		}
+// getBackendOptions returns the backend options set by the labels of the first instance.
+func (p *Provider) getBackendOptions(instances []ecsInstance) *types.Backend {
+	if len(instances) == 0 {
+		return &types.Backend{}
 	}
-	return "wrr"
+	return label.GetBackend(p.getLabels(instances[0]))
 }
 
 // Provider expects no more than 100 parameters be passed to a DescribeTask call; thus, pack
@@ -566,24 +538,3 @@ func (p *Provider) getWeight(i ecsInstance) string {
 	}
 	return "0"
 }
-
-func (p *Provider) getPassHostHeader(i ecsInstance) string {
-	if label := p.label(i, types.LabelFrontendPassHostHeader); label != "" {
-		return label
-	}
-	return "true"
-}
-
-func (p *Provider) getPriority(i ecsInstance) string {
-	if label := p.label(i, types.LabelFrontendPriority); label != "" {
-		return label
-	}
-	return "0"
-}
-
-func (p *Provider) getEntryPoints(i ecsInstance) []string {
-	if label := p.label(i, types.LabelFrontendEntryPoints); label != "" {
-		return strings.Split(label, ",")
-	}
-	return []string{}
-}
@@ -205,129 +205,6 @@ func TestEcsWeight(t *testing.T) {
 	}
 }
 
-func TestEcsPassHostHeader(t *testing.T) {
-	tests := []struct {
-		desc         string
-		expected     string
-		instanceInfo ecsInstance
-		provider     *Provider
-	}{
-		{
-			desc:         "Frontend pass host header label not set should return a string equals to true",
-			expected:     "true",
-			instanceInfo: simpleEcsInstance(map[string]*string{}),
-			provider:     &Provider{},
-		},
-		{
-			desc:     "Frontend pass host header label set to false should return a string equals to false",
-			expected: "false",
-			instanceInfo: simpleEcsInstance(map[string]*string{
-				types.LabelFrontendPassHostHeader: aws.String("false"),
-			}),
-			provider: &Provider{},
-		},
-		{
-			desc:     "Frontend pass host header label set to true should return a string equals to true",
-			expected: "true",
-			instanceInfo: simpleEcsInstance(map[string]*string{
-				types.LabelFrontendPassHostHeader: aws.String("true"),
-			}),
-			provider: &Provider{},
-		},
-	}
-
-	for _, test := range tests {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
-			actual := test.provider.getPassHostHeader(test.instanceInfo)
-			assert.Equal(t, test.expected, actual)
-		})
-	}
-}
-
-func TestEcsPriority(t *testing.T) {
-	tests := []struct {
-		desc         string
-		expected     string
-		instanceInfo ecsInstance
-		provider     *Provider
-	}{
-		{
-			desc:         "Frontend priority label not set should return a string equals to 0",
-			expected:     "0",
-			instanceInfo: simpleEcsInstance(map[string]*string{}),
-			provider:     &Provider{},
-		},
-		{
-			desc:     "Frontend priority label set to 10 should return a string equals to 10",
-			expected: "10",
-			instanceInfo: simpleEcsInstance(map[string]*string{
-				types.LabelFrontendPriority: aws.String("10"),
-			}),
-			provider: &Provider{},
-		},
-		{
-			desc:     "Frontend priority label set to -1 should return a string equals to -1",
-			expected: "-1",
-			instanceInfo: simpleEcsInstance(map[string]*string{
-				types.LabelFrontendPriority: aws.String("-1"),
-			}),
-			provider: &Provider{},
-		},
-	}
-
-	for _, test := range tests {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
-			actual := test.provider.getPriority(test.instanceInfo)
-			assert.Equal(t, test.expected, actual)
-		})
-	}
-}
-
-func TestEcsEntryPoints(t *testing.T) {
-	tests := []struct {
-		desc         string
-		expected     []string
-		instanceInfo ecsInstance
-		provider     *Provider
-	}{
-		{
-			desc:         "Frontend entrypoints label not set should return empty array",
-			expected:     []string{},
-			instanceInfo: simpleEcsInstance(map[string]*string{}),
-			provider:     &Provider{},
-		},
-		{
-			desc:     "Frontend entrypoints label set to http should return a string array of 1 element",
-			expected: []string{"http"},
-			instanceInfo: simpleEcsInstance(map[string]*string{
-				types.LabelFrontendEntryPoints: aws.String("http"),
-			}),
-			provider: &Provider{},
-		},
-		{
-			desc:     "Frontend entrypoints label set to http,https should return a string array of 2 elements",
-			expected: []string{"http", "https"},
-			instanceInfo: simpleEcsInstance(map[string]*string{
-				types.LabelFrontendEntryPoints: aws.String("http,https"),
-			}),
-			provider: &Provider{},
-		},
-	}
-
-	for _, test := range tests {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
-			actual := test.provider.getEntryPoints(test.instanceInfo)
-			assert.Equal(t, test.expected, actual)
-		})
-	}
-}
-
 func TestFilterInstance(t *testing.T) {
 
 	nilPrivateIP := simpleEcsInstance(map[string]*string{})
@@ -552,37 +429,6 @@ func TestTaskChunking(t *testing.T) {
 	}
 }
 
-func TestEcsGetBasicAuth(t *testing.T) {
-	cases := []struct {
-		desc     string
-		instance ecsInstance
-		expected []string
-	}{
-		{
-			desc:     "label missing",
-			instance: simpleEcsInstance(map[string]*string{}),
-			expected: []string{},
-		},
-		{
-			desc: "label existing",
-			instance: simpleEcsInstance(map[string]*string{
-				types.LabelFrontendAuthBasic: aws.String("user:password"),
-			}),
-			expected: []string{"user:password"},
-		},
-	}
-
-	for _, test := range cases {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getBasicAuth(test.instance)
-			assert.Equal(t, test.expected, actual)
-		})
-	}
-}
-
 func TestGenerateECSConfig(t *testing.T) {
 	provider := &Provider{}
 	tests := []struct {
@@ -613,6 +459,60 @@ func TestGenerateECSConfig(t *testing.T) {
 					},
 				},
 			},
+			exp: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend-instance-1": {
+						Servers: map[string]types.Server{
+							"server-instance-1": {
+								URL: "http://10.0.0.1:1337",
+							},
+						},
+					},
+					"backend-testing": {},
+				},
+				Frontends: map[string]*types.Frontend{
+					"frontend-testing": {
+						EntryPoints: []string{},
+						Backend:     "backend-testing",
+						Routes: map[string]types.Route{
+							"route-frontend-testing": {
+								Rule: "Host:instance-1.",
+							},
+						},
+						PassHostHeader: true,
+						BasicAuth:      []string{},
+					},
+				},
+			},
+		},
+		{
+			desc: "config with labels",
+			services: map[string][]ecsInstance{
+				"testing": {
+					{
+						Name: "instance-1",
+						containerDefinition: &ecs.ContainerDefinition{
+							DockerLabels: map[string]*string{
+								types.LabelFrontendEntryPoints:                 aws.String("http,https"),
+								types.LabelFrontendSSLRedirect:                 aws.String("true"),
+								types.LabelTraefikFrontendWhitelistSourceRange: aws.String("10.0.0.0/8"),
+								types.LabelBackendLoadbalancerMethod:           aws.String("drr"),
+								types.LabelBackendHealthcheckPath:              aws.String("/health"),
+							},
+						},
+						machine: &ec2.Instance{
+							PrivateIpAddress: func(s string) *string { return &s }("10.0.0.1"),
+						},
+						container: &ecs.Container{
+							NetworkBindings: []*ecs.NetworkBinding{
+								{
+									HostPort: func(i int64) *int64 { return &i }(1337),
+								},
+							},
+						},
+					},
+				},
+			},
 			exp: &types.Configuration{
 				Backends: map[string]*types.Backend{
 					"backend-instance-1": {
@@ -624,21 +524,28 @@ func TestGenerateECSConfig(t *testing.T) {
 					},
 					"backend-testing": {
 						LoadBalancer: &types.LoadBalancer{
-							Method: "wrr",
+							Method: "drr",
+						},
+						HealthCheck: &types.HealthCheck{
+							Path: "/health",
 						},
 					},
 				},
 				Frontends: map[string]*types.Frontend{
 					"frontend-testing": {
-						EntryPoints: []string{},
+						EntryPoints: []string{"http", "https"},
 						Backend:     "backend-testing",
 						Routes: map[string]types.Route{
 							"route-frontend-testing": {
 								Rule: "Host:instance-1.",
 							},
 						},
-						PassHostHeader: true,
-						BasicAuth:      []string{},
+						PassHostHeader:       true,
+						BasicAuth:            []string{},
+						WhitelistSourceRange: []string{"10.0.0.0/8"},
+						Headers: types.Headers{
+							SSLRedirect: true,
+						},
 					},
 				},
 			},
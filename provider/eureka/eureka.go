@@ -12,6 +12,7 @@ import (
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 )
@@ -35,7 +36,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 		}
 
 		configurationChan <- types.ConfigMessage{
-			ProviderName:  "eureka",
+			ProviderName:  p.GetProviderName("eureka"),
 			Configuration: configuration,
 		}
 
@@ -64,7 +65,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 				}
 
 				configurationChan <- types.ConfigMessage{
-					ProviderName:  "eureka",
+					ProviderName:  p.GetProviderName("eureka"),
 					Configuration: configuration,
 				}
 			}
@@ -86,10 +87,12 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 // Build the configuration from Provider server
 func (p *Provider) buildConfiguration() (*types.Configuration, error) {
 	var EurekaFuncMap = template.FuncMap{
-		"getPort":       p.getPort,
-		"getProtocol":   p.getProtocol,
-		"getWeight":     p.getWeight,
-		"getInstanceID": p.getInstanceID,
+		"getPort":            p.getPort,
+		"getProtocol":        p.getProtocol,
+		"getWeight":          p.getWeight,
+		"getInstanceID":      p.getInstanceID,
+		"getFrontendOptions": p.getFrontendOptions,
+		"getBackendOptions":  p.getBackendOptions,
 	}
 
 	eureka.GetLogger().SetOutput(ioutil.Discard)
@@ -143,3 +146,26 @@ func (p *Provider) getInstanceID(instance eureka.InstanceInfo) string {
 	}
 	return strings.Replace(instance.IpAddr, ".", "-", -1) + "-" + p.getPort(instance)
 }
+
+// getLabels returns the metadata of the first instance of the application.
+func (p *Provider) getLabels(application eureka.Application) map[string]string {
+	if len(application.Instances) == 0 || application.Instances[0].Metadata == nil {
+		return nil
+	}
+	return application.Instances[0].Metadata.Map
+}
+
+func (p *Provider) getFrontendOptions(application eureka.Application) *types.Frontend {
+	labels := p.getLabels(application)
+	frontend := label.GetFrontend(labels)
+	// The Host header was never forwarded by this provider
+	frontend.PassHostHeader = label.GetBoolValue(labels, types.LabelFrontendPassHostHeader, false)
+	if len(frontend.EntryPoints) == 0 {
+		frontend.EntryPoints = []string{"http"}
+	}
+	return frontend
+}
+
+func (p *Provider) getBackendOptions(application eureka.Application) *types.Backend {
+	return label.GetBackend(p.getLabels(application))
+}
@@ -1,6 +1,7 @@
 package eureka
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/ArthurHlt/go-eureka-client/eureka"
@@ -170,3 +171,73 @@ func TestEurekaGetInstanceId(t *testing.T) {
 		}
 	}
 }
+
+func TestEurekaGetFrontendOptions(t *testing.T) {
+	cases := []struct {
+		expected    *types.Frontend
+		application eureka.Application
+	}{
+		{
+			expected: &types.Frontend{
+				EntryPoints: []string{"http"},
+			},
+			application: eureka.Application{
+				Instances: []eureka.InstanceInfo{{}},
+			},
+		},
+		{
+			expected: &types.Frontend{
+				EntryPoints:    []string{"https"},
+				PassHostHeader: true,
+				Priority:       10,
+			},
+			application: eureka.Application{
+				Instances: []eureka.InstanceInfo{
+					{
+						Metadata: &eureka.MetaData{
+							Map: map[string]string{
+								types.LabelFrontendEntryPoints:    "https",
+								types.LabelFrontendPassHostHeader: "true",
+								types.LabelFrontendPriority:       "10",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	eurekaProvider := &Provider{}
+	for _, c := range cases {
+		frontend := eurekaProvider.getFrontendOptions(c.application)
+		if !reflect.DeepEqual(frontend, c.expected) {
+			t.Fatalf("Should have been %#v, got %#v", c.expected, frontend)
+		}
+	}
+}
+
+func TestEurekaGetBackendOptions(t *testing.T) {
+	application := eureka.Application{
+		Instances: []eureka.InstanceInfo{
+			{
+				Metadata: &eureka.MetaData{
+					Map: map[string]string{
+						types.LabelBackendLoadbalancerMethod: "drr",
+						types.LabelBackendHealthcheckPath:    "/health",
+					},
+				},
+			},
+		},
+	}
+
+	expected := &types.Backend{
+		LoadBalancer: &types.LoadBalancer{Method: "drr"},
+		HealthCheck:  &types.HealthCheck{Path: "/health"},
+	}
+
+	eurekaProvider := &Provider{}
+	backend := eurekaProvider.getBackendOptions(application)
+	if !reflect.DeepEqual(backend, expected) {
+		t.Fatalf("Should have been %#v, got %#v", expected, backend)
+	}
+}
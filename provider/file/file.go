@@ -1,12 +1,15 @@
 package file
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"text/template"
 
 	"github.com/BurntSushi/toml"
 	"github.com/containous/traefik/log"
@@ -14,15 +17,24 @@ import (
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
+	"github.com/ghodss/yaml"
 	"gopkg.in/fsnotify.v1"
 )
 
+const (
+	// kubernetesDataDir is the symlink swapped by Kubernetes when a mounted ConfigMap or Secret is updated.
+	kubernetesDataDir = "..data"
+	// templateExtension is the extension of the files rendered as templates, e.g. rules.toml.tmpl.
+	templateExtension = ".tmpl"
+)
+
 var _ provider.Provider = (*Provider)(nil)
 
 // Provider holds configurations of the provider.
 type Provider struct {
 	provider.BaseProvider `mapstructure:",squash" export:"true"`
-	Directory             string `description:"Load configuration from one or more .toml files in a directory" export:"true"`
+	Directory             string `description:"Load configuration from the .toml, .yaml, .yml and .json files, and their .tmpl templates, of a directory and its subdirectories" export:"true"`
+	TraefikFile           string // The Traefik configuration file, which is not rendered as a template
 }
 
 // Provide allows the file provider to provide configurations to traefik
@@ -48,7 +60,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 		}
 	}
 
-	sendConfigToChannel(configurationChan, configuration)
+	p.sendConfigToChannel(configurationChan, configuration)
 	return nil
 }
 
@@ -69,10 +81,16 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 				if p.Directory == "" {
 					_, evtFileName := filepath.Split(evt.Name)
 					_, confFileName := filepath.Split(p.Filename)
-					if evtFileName == confFileName {
+					if evtFileName == confFileName || evtFileName == kubernetesDataDir {
 						callback(configurationChan, evt)
 					}
 				} else {
+					// fsnotify does not watch the subdirectories
+					if evt.Op&fsnotify.Create == fsnotify.Create {
+						if err := watchSubDirectories(watcher, evt.Name); err != nil {
+							log.Errorf("Error adding file watcher: %s", err)
+						}
+					}
 					callback(configurationChan, evt)
 				}
 			case err := <-watcher.Errors:
@@ -80,7 +98,12 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 			}
 		}
 	})
-	err = watcher.Add(directory)
+
+	if p.Directory == "" {
+		err = watcher.Add(directory)
+	} else {
+		err = watchSubDirectories(watcher, directory)
+	}
 	if err != nil {
 		return fmt.Errorf("error adding file watcher: %s", err)
 	}
@@ -88,83 +111,175 @@ func (p *Provider) addWatcher(pool *safe.Pool, directory string, configurationCh
 	return nil
 }
 
-func sendConfigToChannel(configurationChan chan<- types.ConfigMessage, configuration *types.Configuration) {
+// watchSubDirectories adds the directory and its subdirectories to the watcher, the hidden ones excepted.
+func watchSubDirectories(watcher *fsnotify.Watcher, directory string) error {
+	return filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != directory && isHidden(info.Name()) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+func (p *Provider) sendConfigToChannel(configurationChan chan<- types.ConfigMessage, configuration *types.Configuration) {
 	configurationChan <- types.ConfigMessage{
-		ProviderName:  "file",
+		ProviderName:  p.GetProviderName("file"),
 		Configuration: configuration,
 	}
 }
 
-func loadFileConfig(filename string) (*types.Configuration, error) {
-	configuration := new(types.Configuration)
-	if _, err := toml.DecodeFile(filename, configuration); err != nil {
+// loadFileConfig loads a TOML, YAML or JSON configuration file, depending on its extension.
+// The files with the .tmpl extension, e.g. rules.toml.tmpl, are first rendered as templates,
+// with the same functions as the providers templates,
+// unless it is the Traefik configuration file, which may hold the templates of the other providers.
+func (p *Provider) loadFileConfig(filename string) (*types.Configuration, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
 		return nil, fmt.Errorf("error reading configuration file: %s", err)
 	}
+
+	format := filename
+	if isTemplate(filename) {
+		format = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+
+	buffer := bytes.NewBuffer(content)
+	if isTemplate(filename) && filename != p.TraefikFile {
+		tmpl, err := template.New(filename).Funcs(provider.GetDefaultFuncMap()).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing configuration file %s: %s", filename, err)
+		}
+
+		buffer = new(bytes.Buffer)
+		if err = tmpl.Execute(buffer, nil); err != nil {
+			return nil, fmt.Errorf("error rendering configuration file %s: %s", filename, err)
+		}
+
+		if p.DebugLogGeneratedTemplate {
+			log.Debugf("Rendering results of %s:\n%s", filename, buffer.String())
+		}
+	}
+
+	configuration := new(types.Configuration)
+	switch strings.ToLower(filepath.Ext(format)) {
+	case ".yaml", ".yml":
+		// the servers are map values, which the YAML decoder is not able to set directly
+		var content []byte
+		if content, err = yaml.YAMLToJSON(buffer.Bytes()); err == nil {
+			err = json.Unmarshal(content, configuration)
+		}
+	case ".json":
+		err = json.Unmarshal(buffer.Bytes(), configuration)
+	default:
+		_, err = toml.Decode(buffer.String(), configuration)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading configuration file %s: %s", filename, err)
+	}
 	return configuration, nil
 }
 
-func loadFileConfigFromDirectory(directory string, configuration *types.Configuration) (*types.Configuration, error) {
+// listConfigFiles returns the configuration files of the directory and its subdirectories, sorted by path.
+// The hidden files and directories, like the ones created by Kubernetes for the mounted ConfigMaps, are ignored.
+func listConfigFiles(directory string) ([]string, error) {
 	fileList, err := ioutil.ReadDir(directory)
-
 	if err != nil {
-		return configuration, fmt.Errorf("unable to read directory %s: %v", directory, err)
+		return nil, fmt.Errorf("unable to read directory %s: %v", directory, err)
 	}
 
-	if configuration == nil {
-		configuration = &types.Configuration{
-			Frontends:        make(map[string]*types.Frontend),
-			Backends:         make(map[string]*types.Backend),
-			TLSConfiguration: make([]*tls.Configuration, 0),
+	var files []string
+	for _, item := range fileList {
+		if isHidden(item.Name()) {
+			continue
 		}
-	}
 
-	configTLSMaps := make(map[*tls.Configuration]struct{})
-	for _, item := range fileList {
+		itemPath := filepath.Join(directory, item.Name())
 
 		if item.IsDir() {
-			configuration, err = loadFileConfigFromDirectory(filepath.Join(directory, item.Name()), configuration)
+			subFiles, err := listConfigFiles(itemPath)
 			if err != nil {
-				return configuration, fmt.Errorf("unable to load content configuration from subdirectory %s: %v", item, err)
+				return nil, fmt.Errorf("unable to load content configuration from subdirectory %s: %v", item.Name(), err)
 			}
+			files = append(files, subFiles...)
 			continue
-		} else if !strings.HasSuffix(item.Name(), ".toml") {
-			continue
 		}
 
-		var c *types.Configuration
-		c, err = loadFileConfig(path.Join(directory, item.Name()))
+		// the files of the mounted ConfigMaps are symlinks
+		if item.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(itemPath); err != nil || target.IsDir() {
+				continue
+			}
+		}
+
+		if isConfigFile(item.Name()) {
+			files = append(files, itemPath)
+		}
+	}
+	return files, nil
+}
+
+// loadFileConfigFromDirectory merges the configuration files of the directory, in the order of their paths.
+// A backend, frontend or TLS configuration defined by several files is taken from the first one.
+func (p *Provider) loadFileConfigFromDirectory(directory string) (*types.Configuration, error) {
+	files, err := listConfigFiles(directory)
+	if err != nil {
+		return nil, err
+	}
 
+	configuration := &types.Configuration{
+		Frontends:        make(map[string]*types.Frontend),
+		Backends:         make(map[string]*types.Backend),
+		TLSConfiguration: make([]*tls.Configuration, 0),
+	}
+
+	backendsFiles := make(map[string]string)
+	frontendsFiles := make(map[string]string)
+	var tlsFiles []string
+
+	for _, file := range files {
+		c, err := p.loadFileConfig(file)
 		if err != nil {
-			return configuration, err
+			return nil, err
 		}
 
 		for backendName, backend := range c.Backends {
-			if _, exists := configuration.Backends[backendName]; exists {
-				log.Warnf("Backend %s already configured, skipping", backendName)
+			if previousFile, exists := backendsFiles[backendName]; exists {
+				log.Warnf("Backend %s of %s already configured in %s, skipping", backendName, file, previousFile)
 			} else {
+				backendsFiles[backendName] = file
 				configuration.Backends[backendName] = backend
 			}
 		}
 
 		for frontendName, frontend := range c.Frontends {
-			if _, exists := configuration.Frontends[frontendName]; exists {
-				log.Warnf("Frontend %s already configured, skipping", frontendName)
+			if previousFile, exists := frontendsFiles[frontendName]; exists {
+				log.Warnf("Frontend %s of %s already configured in %s, skipping", frontendName, file, previousFile)
 			} else {
+				frontendsFiles[frontendName] = file
 				configuration.Frontends[frontendName] = frontend
 			}
 		}
 
+	tlsConfigurations:
 		for _, conf := range c.TLSConfiguration {
-			if _, exists := configTLSMaps[conf]; exists {
-				log.Warnf("TLS Configuration %v already configured, skipping", conf)
-			} else {
-				configTLSMaps[conf] = struct{}{}
+			for i, existing := range configuration.TLSConfiguration {
+				if reflect.DeepEqual(existing, conf) {
+					log.Warnf("TLS Configuration %v of %s already configured in %s, skipping", conf, file, tlsFiles[i])
+					continue tlsConfigurations
+				}
 			}
+			tlsFiles = append(tlsFiles, file)
+			configuration.TLSConfiguration = append(configuration.TLSConfiguration, conf)
 		}
-
-	}
-	for conf := range configTLSMaps {
-		configuration.TLSConfiguration = append(configuration.TLSConfiguration, conf)
 	}
 	return configuration, nil
 }
@@ -187,13 +302,32 @@ func (p *Provider) watcherCallback(configurationChan chan<- types.ConfigMessage,
 		return
 	}
 
-	sendConfigToChannel(configurationChan, configuration)
+	p.sendConfigToChannel(configurationChan, configuration)
 }
 
 func (p *Provider) loadConfig() (*types.Configuration, error) {
 	if p.Directory != "" {
-		return loadFileConfigFromDirectory(p.Directory, nil)
+		return p.loadFileConfigFromDirectory(p.Directory)
 	}
 
-	return loadFileConfig(p.Filename)
+	return p.loadFileConfig(p.Filename)
+}
+
+func isConfigFile(name string) bool {
+	if isTemplate(name) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".toml", ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+func isTemplate(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), templateExtension)
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
 }
@@ -6,12 +6,14 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestProvideSingleFileAndWatch(t *testing.T) {
@@ -167,6 +169,242 @@ func TestProvideDirectoryAndNotWatch(t *testing.T) {
 
 }
 
+func TestProvideDirectoryAndWatchSubDirectory(t *testing.T) {
+	tempDir := createTempDir(t, "testdir")
+	defer os.RemoveAll(tempDir)
+
+	expectedNumFrontends := 2
+	expectedNumBackends := 0
+	expectedNumTLSConf := 0
+
+	createRandomFile(t, tempDir, createFrontendConfiguration(expectedNumFrontends))
+
+	configurationChan, signal := createConfigurationRoutine(t, &expectedNumFrontends, &expectedNumBackends, &expectedNumTLSConf)
+
+	provide(configurationChan, watch, withDirectory(tempDir))
+
+	// Wait for initial config message to be tested
+	err := waitForSignal(signal, 2*time.Second, "initial config")
+	assert.NoError(t, err)
+
+	// Now add a file in a new subdirectory
+	tempSubDir := createSubDir(t, tempDir, "backends")
+	err = waitForSignal(signal, 2*time.Second, "create the subdirectory")
+	assert.NoError(t, err)
+
+	expectedNumBackends = 2
+	createRandomFile(t, tempSubDir, createBackendConfiguration(expectedNumBackends))
+	err = waitForSignal(signal, 2*time.Second, "add the backends file in the subdirectory")
+	assert.NoError(t, err)
+}
+
+func TestProvideSingleFileAndWatchConfigMapUpdate(t *testing.T) {
+	tempDir := createTempDir(t, "testconfigmap")
+	defer os.RemoveAll(tempDir)
+
+	// Kubernetes mounts the ConfigMap files as symlinks to the ..data symlink to the current version directory
+	expectedNumFrontends := 2
+	expectedNumBackends := 2
+	expectedNumTLSConf := 0
+
+	createSubDir(t, tempDir, "..v1")
+	createFile(t, filepath.Join(tempDir, "..v1"), "simple.toml",
+		createFrontendConfiguration(expectedNumFrontends),
+		createBackendConfiguration(expectedNumBackends))
+	require.NoError(t, os.Symlink("..v1", filepath.Join(tempDir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "simple.toml"), filepath.Join(tempDir, "simple.toml")))
+
+	configurationChan, signal := createConfigurationRoutine(t, &expectedNumFrontends, &expectedNumBackends, &expectedNumTLSConf)
+
+	provide(configurationChan, watch, func(p *Provider) {
+		p.Filename = filepath.Join(tempDir, "simple.toml")
+	})
+
+	// Wait for initial config message to be tested
+	err := waitForSignal(signal, 2*time.Second, "initial config")
+	assert.NoError(t, err)
+
+	// Now swap the ..data symlink to a new version
+	expectedNumFrontends = 1
+	expectedNumBackends = 1
+
+	createSubDir(t, tempDir, "..v2")
+	createFile(t, filepath.Join(tempDir, "..v2"), "simple.toml",
+		createFrontendConfiguration(expectedNumFrontends),
+		createBackendConfiguration(expectedNumBackends))
+	require.NoError(t, os.Symlink("..v2", filepath.Join(tempDir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(tempDir, "..data_tmp"), filepath.Join(tempDir, "..data")))
+
+	err = waitForSignal(signal, 2*time.Second, "swap the ConfigMap version")
+	assert.NoError(t, err)
+}
+
+func TestLoadFileConfig(t *testing.T) {
+	os.Setenv("TRAEFIK_FILE_TEST_URL", "http://172.17.0.1:80")
+	defer os.Unsetenv("TRAEFIK_FILE_TEST_URL")
+
+	testCases := []struct {
+		desc        string
+		fileName    string
+		content     string
+		traefikFile bool
+		expectedURL string
+		expectedErr bool
+	}{
+		{
+			desc:     "TOML",
+			fileName: "simple.toml",
+			content: `
+[backends.backend1.servers.server1]
+url = "http://172.17.0.1:80"
+`,
+			expectedURL: "http://172.17.0.1:80",
+		},
+		{
+			desc:     "YAML",
+			fileName: "simple.yml",
+			content: `
+backends:
+  backend1:
+    servers:
+      server1:
+        url: http://172.17.0.1:80
+`,
+			expectedURL: "http://172.17.0.1:80",
+		},
+		{
+			desc:        "JSON",
+			fileName:    "simple.json",
+			content:     `{"backends": {"backend1": {"servers": {"server1": {"url": "http://172.17.0.1:80"}}}}}`,
+			expectedURL: "http://172.17.0.1:80",
+		},
+		{
+			desc:     "template",
+			fileName: "simple.toml.tmpl",
+			content: `
+[backends.backend1.servers.server1]
+url = "{{ env "TRAEFIK_FILE_TEST_URL" }}"
+`,
+			expectedURL: "http://172.17.0.1:80",
+		},
+		{
+			desc:     "Traefik configuration file is not a template",
+			fileName: "traefik.toml.tmpl",
+			content: `
+[consulCatalog]
+frontEndRule = "Host:{{getTag \"foo\" .Attributes \"\"}}"
+
+[backends.backend1.servers.server1]
+url = "http://172.17.0.1:80"
+`,
+			traefikFile: true,
+			expectedURL: "http://172.17.0.1:80",
+		},
+		{
+			desc:     "YAML template",
+			fileName: "simple.yml.tmpl",
+			content: `
+backends:
+  backend1:
+    servers:
+      server1:
+        url: {{ env "TRAEFIK_FILE_TEST_URL" }}
+`,
+			expectedURL: "http://172.17.0.1:80",
+		},
+		{
+			desc:     "literal braces without the template extension",
+			fileName: "simple.toml",
+			content: `
+[backends.backend1.servers.server1]
+url = "http://172.17.0.1:80"
+
+[frontends.frontend1]
+backend = "backend1"
+  [frontends.frontend1.routes.route1]
+  rule = "PathPrefix:/{id:[0-9]{{1,3}}}"
+`,
+			expectedURL: "http://172.17.0.1:80",
+		},
+		{
+			desc:     "invalid template",
+			fileName: "simple.toml.tmpl",
+			content: `
+[backends.backend1.servers.server1]
+url = "{{ foo }}"
+`,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			tempDir := createTempDir(t, "testfile")
+			defer os.RemoveAll(tempDir)
+
+			tempFile := createFile(t, tempDir, test.fileName, test.content)
+
+			p := &Provider{}
+			if test.traefikFile {
+				p.TraefikFile = tempFile.Name()
+			}
+
+			configuration, err := p.loadFileConfig(tempFile.Name())
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Contains(t, configuration.Backends, "backend1")
+			assert.Equal(t, test.expectedURL, configuration.Backends["backend1"].Servers["server1"].URL)
+		})
+	}
+}
+
+func TestLoadFileConfigFromDirectory(t *testing.T) {
+	tempDir := createTempDir(t, "testdir")
+	defer os.RemoveAll(tempDir)
+
+	createFile(t, tempDir, "a.toml", `
+[backends.backend1.servers.server1]
+url = "http://172.17.0.1:80"
+`)
+	createFile(t, createSubDir(t, tempDir, "b"), "backends.yaml", `
+backends:
+  backend1:
+    servers:
+      server1:
+        url: http://172.17.0.2:80
+  backend2:
+    servers:
+      server1:
+        url: http://172.17.0.3:80
+`)
+	createFile(t, tempDir, "c.json", `{"frontends": {"frontend1": {"backend": "backend1"}}}`)
+	createFile(t, tempDir, "d.toml.tmpl", `
+[backends.backend4.servers.server1]
+url = "{{ "http://172.17.0.5:80" }}"
+`)
+	createFile(t, tempDir, "README.md", "not a configuration")
+	createFile(t, createSubDir(t, tempDir, "..v1"), "d.toml", `
+[backends.backend3.servers.server1]
+url = "http://172.17.0.4:80"
+`)
+
+	p := &Provider{}
+	configuration, err := p.loadFileConfigFromDirectory(tempDir)
+	require.NoError(t, err)
+
+	assert.Len(t, configuration.Frontends, 1)
+	require.Len(t, configuration.Backends, 3)
+	// the first file in the paths order wins
+	assert.Equal(t, "http://172.17.0.1:80", configuration.Backends["backend1"].Servers["server1"].URL)
+	assert.Equal(t, "http://172.17.0.3:80", configuration.Backends["backend2"].Servers["server1"].URL)
+	assert.Equal(t, "http://172.17.0.5:80", configuration.Backends["backend4"].Servers["server1"].URL)
+}
+
 func createConfigurationRoutine(t *testing.T, expectedNumFrontends *int, expectedNumBackends *int, expectedNumTLSConfigurations *int) (chan types.ConfigMessage, chan interface{}) {
 	configurationChan := make(chan types.ConfigMessage)
 	signal := make(chan interface{})
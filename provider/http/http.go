@@ -0,0 +1,279 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+)
+
+var _ provider.Provider = (*Provider)(nil)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	// maxConfigurationSize is the size of the largest configuration fetched from an endpoint.
+	maxConfigurationSize = 10 << 20
+)
+
+// Provider holds configurations of the provider.
+type Provider struct {
+	provider.BaseProvider `mapstructure:",squash" export:"true"`
+
+	Endpoints    Endpoints         `description:"URLs of the configurations to fetch"`
+	PollInterval flaeg.Duration    `description:"Polling interval" export:"true"`
+	PollTimeout  flaeg.Duration    `description:"Timeout of a polling request" export:"true"`
+	TLS          *types.ClientTLS  `description:"Enable TLS client authentication" export:"true"`
+	Headers      map[string]string `description:"Headers sent with every request, such as credentials"`
+
+	client            *http.Client
+	sources           map[string]*source
+	lastConfiguration *types.Configuration
+}
+
+// source holds the last configuration fetched from an endpoint, with its cache validators.
+type source struct {
+	etag          string
+	lastModified  string
+	configuration *types.Configuration
+}
+
+// Provide allows the http provider to provide configurations to traefik
+// using the given configuration channel.
+func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, constraints types.Constraints) error {
+	if len(p.Endpoints) == 0 {
+		return fmt.Errorf("no endpoint defined")
+	}
+
+	client, err := p.createClient()
+	if err != nil {
+		return err
+	}
+	p.client = client
+	p.sources = make(map[string]*source)
+
+	if p.PollInterval <= 0 {
+		p.PollInterval = flaeg.Duration(defaultPollInterval)
+	}
+
+	pool.Go(func(stop chan bool) {
+		p.refresh(configurationChan)
+		if !p.Watch {
+			return
+		}
+
+		reload := time.NewTicker(time.Duration(p.PollInterval))
+		defer reload.Stop()
+		for {
+			select {
+			case <-reload.C:
+				p.refresh(configurationChan)
+			case <-stop:
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func (p *Provider) createClient() (*http.Client, error) {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if p.TLS != nil {
+		tlsConfig, err := p.TLS.CreateTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{
+		Timeout:   time.Duration(p.PollTimeout),
+		Transport: transport,
+	}, nil
+}
+
+// refresh fetches the configurations and sends them on the configuration channel when they changed.
+func (p *Provider) refresh(configurationChan chan<- types.ConfigMessage) {
+	configuration, err := p.loadConfiguration()
+	if err != nil {
+		log.Errorf("Failed to load the HTTP provider configuration: %v", err)
+		return
+	}
+
+	if reflect.DeepEqual(p.lastConfiguration, configuration) {
+		return
+	}
+	p.lastConfiguration = configuration
+
+	configurationChan <- types.ConfigMessage{
+		ProviderName:  p.GetProviderName("http"),
+		Configuration: configuration,
+	}
+}
+
+// loadConfiguration fetches every endpoint, and merges and validates their configurations.
+func (p *Provider) loadConfiguration() (*types.Configuration, error) {
+	var configurations []*types.Configuration
+	for _, endpoint := range p.Endpoints {
+		configuration, err := p.fetch(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", endpoint, err)
+		}
+		configurations = append(configurations, configuration)
+	}
+
+	configuration, err := mergeConfigurations(configurations)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateConfiguration(configuration); err != nil {
+		return nil, err
+	}
+	return configuration, nil
+}
+
+// fetch gets the configuration of an endpoint.
+// The configuration previously fetched is reused when the endpoint reports it was not modified.
+func (p *Provider) fetch(endpoint string) (*types.Configuration, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range p.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Accept", "application/json, application/toml")
+
+	src, ok := p.sources[endpoint]
+	if ok {
+		if len(src.etag) > 0 {
+			req.Header.Set("If-None-Match", src.etag)
+		}
+		if len(src.lastModified) > 0 {
+			req.Header.Set("If-Modified-Since", src.lastModified)
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && ok {
+		return src.configuration, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxConfigurationSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxConfigurationSize {
+		return nil, fmt.Errorf("configuration larger than %d bytes", maxConfigurationSize)
+	}
+
+	configuration, err := decodeConfiguration(resp.Header.Get("Content-Type"), body)
+	if err != nil {
+		return nil, err
+	}
+
+	p.sources[endpoint] = &source{
+		etag:          resp.Header.Get("ETag"),
+		lastModified:  resp.Header.Get("Last-Modified"),
+		configuration: configuration,
+	}
+	return configuration, nil
+}
+
+// decodeConfiguration decodes a JSON or TOML configuration.
+// The format is given by the content type, or guessed from the content when the content type is not specific.
+func decodeConfiguration(contentType string, content []byte) (*types.Configuration, error) {
+	configuration := new(types.Configuration)
+
+	isJSON := strings.Contains(contentType, "json")
+	if !isJSON && !strings.Contains(contentType, "toml") {
+		isJSON = bytes.HasPrefix(bytes.TrimSpace(content), []byte("{"))
+	}
+
+	if isJSON {
+		if err := json.Unmarshal(content, configuration); err != nil {
+			return nil, fmt.Errorf("invalid JSON configuration: %v", err)
+		}
+		return configuration, nil
+	}
+
+	if _, err := toml.Decode(string(content), configuration); err != nil {
+		return nil, fmt.Errorf("invalid TOML configuration: %v", err)
+	}
+	return configuration, nil
+}
+
+// mergeConfigurations merges the configurations of the endpoints.
+// The backends and frontends names must be unique across the endpoints.
+func mergeConfigurations(configurations []*types.Configuration) (*types.Configuration, error) {
+	merged := &types.Configuration{
+		Backends:  make(map[string]*types.Backend),
+		Frontends: make(map[string]*types.Frontend),
+	}
+
+	for _, configuration := range configurations {
+		for name, backend := range configuration.Backends {
+			if _, exists := merged.Backends[name]; exists {
+				return nil, fmt.Errorf("backend %q is defined by several endpoints", name)
+			}
+			merged.Backends[name] = backend
+		}
+		for name, frontend := range configuration.Frontends {
+			if _, exists := merged.Frontends[name]; exists {
+				return nil, fmt.Errorf("frontend %q is defined by several endpoints", name)
+			}
+			merged.Frontends[name] = frontend
+		}
+		merged.TLSConfiguration = append(merged.TLSConfiguration, configuration.TLSConfiguration...)
+	}
+	return merged, nil
+}
+
+// validateConfiguration checks that the frontends use defined backends, and that the servers URLs are valid.
+func validateConfiguration(configuration *types.Configuration) error {
+	for name, frontend := range configuration.Frontends {
+		if frontend == nil {
+			return fmt.Errorf("frontend %q is empty", name)
+		}
+		if _, ok := configuration.Backends[frontend.Backend]; !ok {
+			return fmt.Errorf("frontend %q uses the undefined backend %q", name, frontend.Backend)
+		}
+	}
+
+	for name, backend := range configuration.Backends {
+		if backend == nil {
+			return fmt.Errorf("backend %q is empty", name)
+		}
+		for serverName, server := range backend.Servers {
+			u, err := url.Parse(server.URL)
+			if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+				return fmt.Errorf("server %q of backend %q has an invalid URL %q", serverName, name, server.URL)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tomlConfiguration = `
+[backends]
+  [backends.backend1]
+    [backends.backend1.servers.server1]
+    url = "http://127.0.0.1:8080"
+
+[frontends]
+  [frontends.frontend1]
+  backend = "backend1"
+`
+
+const jsonConfiguration = `{
+  "backends": {
+    "backend2": {
+      "servers": {
+        "server1": {"url": "http://127.0.0.1:8081"}
+      }
+    }
+  },
+  "frontends": {
+    "frontend2": {"backend": "backend2"}
+  }
+}`
+
+func TestDecodeConfiguration(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		contentType     string
+		content         string
+		expectedBackend string
+		expectedErr     bool
+	}{
+		{
+			desc:            "TOML content type",
+			contentType:     "application/toml",
+			content:         tomlConfiguration,
+			expectedBackend: "backend1",
+		},
+		{
+			desc:            "JSON content type",
+			contentType:     "application/json; charset=utf-8",
+			content:         jsonConfiguration,
+			expectedBackend: "backend2",
+		},
+		{
+			desc:            "guessed TOML",
+			contentType:     "text/plain",
+			content:         tomlConfiguration,
+			expectedBackend: "backend1",
+		},
+		{
+			desc:            "guessed JSON",
+			content:         jsonConfiguration,
+			expectedBackend: "backend2",
+		},
+		{
+			desc:        "invalid JSON",
+			contentType: "application/json",
+			content:     tomlConfiguration,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			configuration, err := decodeConfiguration(test.contentType, []byte(test.content))
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Contains(t, configuration.Backends, test.expectedBackend)
+		})
+	}
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		configuration *types.Configuration
+		expectedErr   bool
+	}{
+		{
+			desc: "valid",
+			configuration: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend1": {Servers: map[string]types.Server{"server1": {URL: "http://127.0.0.1:8080"}}},
+				},
+				Frontends: map[string]*types.Frontend{
+					"frontend1": {Backend: "backend1"},
+				},
+			},
+		},
+		{
+			desc: "undefined backend",
+			configuration: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend1": {Backend: "backend1"},
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "invalid server URL",
+			configuration: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend1": {Servers: map[string]types.Server{"server1": {URL: "127.0.0.1:8080"}}},
+				},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateConfiguration(test.configuration)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMergeConfigurations(t *testing.T) {
+	first := &types.Configuration{
+		Backends:  map[string]*types.Backend{"backend1": {}},
+		Frontends: map[string]*types.Frontend{"frontend1": {Backend: "backend1"}},
+	}
+	second := &types.Configuration{
+		Backends:  map[string]*types.Backend{"backend2": {}},
+		Frontends: map[string]*types.Frontend{"frontend2": {Backend: "backend2"}},
+	}
+
+	merged, err := mergeConfigurations([]*types.Configuration{first, second})
+	require.NoError(t, err)
+	assert.Len(t, merged.Backends, 2)
+	assert.Len(t, merged.Frontends, 2)
+
+	_, err = mergeConfigurations([]*types.Configuration{first, first})
+	assert.Error(t, err)
+}
+
+func TestProviderRefresh(t *testing.T) {
+	var requests, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		if req.Header.Get("X-Token") != "secret" {
+			rw.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		rw.Header().Set("ETag", `"v1"`)
+		rw.Header().Set("Content-Type", "application/toml")
+		fmt.Fprint(rw, tomlConfiguration)
+	}))
+	defer ts.Close()
+
+	p := &Provider{
+		Endpoints: Endpoints{ts.URL},
+		Headers:   map[string]string{"X-Token": "secret"},
+		sources:   make(map[string]*source),
+	}
+	client, err := p.createClient()
+	require.NoError(t, err)
+	p.client = client
+
+	configurationChan := make(chan types.ConfigMessage, 10)
+
+	p.refresh(configurationChan)
+	require.Len(t, configurationChan, 1)
+	configMsg := <-configurationChan
+	assert.Equal(t, "http", configMsg.ProviderName)
+	assert.Contains(t, configMsg.Configuration.Frontends, "frontend1")
+
+	// not modified, nothing is sent
+	p.refresh(configurationChan)
+	assert.Len(t, configurationChan, 0)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, notModified)
+
+	// the configuration is not sent on error
+	p.Headers = nil
+	p.lastConfiguration = nil
+	p.refresh(configurationChan)
+	assert.Len(t, configurationChan, 0)
+}
+
+func TestProviderFetchTooLarge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/toml")
+		fmt.Fprint(rw, tomlConfiguration)
+		rw.Write(bytes.Repeat([]byte("#"), maxConfigurationSize))
+	}))
+	defer ts.Close()
+
+	p := &Provider{sources: make(map[string]*source)}
+	client, err := p.createClient()
+	require.NoError(t, err)
+	p.client = client
+
+	_, err = p.fetch(ts.URL)
+	assert.Error(t, err)
+}
+
+func TestProvideDefaultPollInterval(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/toml")
+		fmt.Fprint(rw, tomlConfiguration)
+	}))
+	defer ts.Close()
+
+	p := &Provider{Endpoints: Endpoints{ts.URL}}
+	p.Watch = true
+
+	configurationChan := make(chan types.ConfigMessage, 10)
+	pool := safe.NewPool(context.Background())
+	defer pool.Stop()
+
+	require.NoError(t, p.Provide(configurationChan, pool, nil))
+	assert.Equal(t, flaeg.Duration(defaultPollInterval), p.PollInterval)
+
+	select {
+	case <-configurationChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no configuration received")
+	}
+}
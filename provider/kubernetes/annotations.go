@@ -0,0 +1,201 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/types"
+	"github.com/ghodss/yaml"
+	"github.com/vulcand/oxy/utils"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+// The frontend options below are parsed from the Ingress annotations.
+// An invalid annotation is logged and ignored, the rest of the Ingress is still processed.
+
+func getHeaders(i *v1beta1.Ingress) types.Headers {
+	return types.Headers{
+		CustomRequestHeaders:    getMapAnnotation(i, annotationKubernetesCustomRequestHeaders),
+		CustomResponseHeaders:   getMapAnnotation(i, annotationKubernetesCustomResponseHeaders),
+		AllowedHosts:            getSliceAnnotation(i, annotationKubernetesAllowedHosts),
+		HostsProxyHeaders:       getSliceAnnotation(i, annotationKubernetesProxyHeaders),
+		SSLRedirect:             getBoolAnnotation(i, annotationKubernetesSSLRedirect),
+		SSLTemporaryRedirect:    getBoolAnnotation(i, annotationKubernetesSSLTemporaryRedirect),
+		SSLHost:                 i.Annotations[annotationKubernetesSSLHost],
+		SSLProxyHeaders:         getMapAnnotation(i, annotationKubernetesSSLProxyHeaders),
+		STSSeconds:              getInt64Annotation(i, annotationKubernetesHSTSMaxAge),
+		STSIncludeSubdomains:    getBoolAnnotation(i, annotationKubernetesHSTSIncludeSubdomains),
+		STSPreload:              getBoolAnnotation(i, annotationKubernetesHSTSPreload),
+		ForceSTSHeader:          getBoolAnnotation(i, annotationKubernetesForceHSTSHeader),
+		FrameDeny:               getBoolAnnotation(i, annotationKubernetesFrameDeny),
+		CustomFrameOptionsValue: i.Annotations[annotationKubernetesCustomFrameOptionsValue],
+		ContentTypeNosniff:      getBoolAnnotation(i, annotationKubernetesContentTypeNosniff),
+		BrowserXSSFilter:        getBoolAnnotation(i, annotationKubernetesBrowserXSSFilter),
+		ContentSecurityPolicy:   i.Annotations[annotationKubernetesContentSecurityPolicy],
+		PublicKey:               i.Annotations[annotationKubernetesPublicKey],
+		ReferrerPolicy:          i.Annotations[annotationKubernetesReferrerPolicy],
+		IsDevelopment:           getBoolAnnotation(i, annotationKubernetesIsDevelopment),
+	}
+}
+
+func getRedirect(i *v1beta1.Ingress) *types.Redirect {
+	redirect := &types.Redirect{
+		EntryPoint:  i.Annotations[annotationKubernetesRedirectEntryPoint],
+		Regex:       i.Annotations[annotationKubernetesRedirectRegex],
+		Replacement: i.Annotations[annotationKubernetesRedirectReplacement],
+	}
+
+	if err := checkRedirect(redirect); err != nil {
+		log.Errorf("Error in ingress %s/%s: invalid redirect: %s", i.Namespace, i.Name, err)
+		return nil
+	}
+	if len(redirect.EntryPoint) == 0 && len(redirect.Regex) == 0 {
+		return nil
+	}
+	return redirect
+}
+
+func checkRedirect(redirect *types.Redirect) error {
+	if len(redirect.EntryPoint) > 0 {
+		if len(redirect.Regex) > 0 || len(redirect.Replacement) > 0 {
+			return fmt.Errorf("%s can't be used with %s or %s", annotationKubernetesRedirectEntryPoint, annotationKubernetesRedirectRegex, annotationKubernetesRedirectReplacement)
+		}
+		return nil
+	}
+
+	if len(redirect.Regex) == 0 && len(redirect.Replacement) == 0 {
+		return nil
+	}
+	if len(redirect.Regex) == 0 || len(redirect.Replacement) == 0 {
+		return fmt.Errorf("%s and %s must be set together", annotationKubernetesRedirectRegex, annotationKubernetesRedirectReplacement)
+	}
+	if _, err := regexp.Compile(redirect.Regex); err != nil {
+		return fmt.Errorf("%s: %s", annotationKubernetesRedirectRegex, err)
+	}
+	return nil
+}
+
+func getRateLimit(i *v1beta1.Ingress) *types.RateLimit {
+	value, ok := i.Annotations[annotationKubernetesRateLimit]
+	if !ok {
+		return nil
+	}
+
+	rateLimit := &types.RateLimit{}
+	if err := unmarshalYAML(value, rateLimit); err != nil {
+		log.Errorf("Error in ingress %s/%s: failed to parse %q: %s", i.Namespace, i.Name, annotationKubernetesRateLimit, err)
+		return nil
+	}
+
+	if err := checkRateLimit(rateLimit); err != nil {
+		log.Errorf("Error in ingress %s/%s: invalid %q: %s", i.Namespace, i.Name, annotationKubernetesRateLimit, err)
+		return nil
+	}
+	return rateLimit
+}
+
+func checkRateLimit(rateLimit *types.RateLimit) error {
+	if _, err := utils.NewExtractor(rateLimit.ExtractorFunc); err != nil {
+		return fmt.Errorf("extractorFunc: %s", err)
+	}
+	if len(rateLimit.RateSet) == 0 {
+		return errors.New("at least one rate set is required")
+	}
+	for name, rate := range rateLimit.RateSet {
+		if rate == nil || rate.Period <= 0 || rate.Average <= 0 {
+			return fmt.Errorf("rate set %q requires a period and an average", name)
+		}
+	}
+	return nil
+}
+
+func getErrorPages(i *v1beta1.Ingress) map[string]types.ErrorPage {
+	value, ok := i.Annotations[annotationKubernetesErrorPages]
+	if !ok {
+		return nil
+	}
+
+	errorPages := make(map[string]types.ErrorPage)
+	if err := unmarshalYAML(value, &errorPages); err != nil {
+		log.Errorf("Error in ingress %s/%s: failed to parse %q: %s", i.Namespace, i.Name, annotationKubernetesErrorPages, err)
+		return nil
+	}
+
+	for name, errorPage := range errorPages {
+		if len(errorPage.Status) == 0 || len(errorPage.Backend) == 0 {
+			log.Errorf("Error in ingress %s/%s: invalid %q: error page %q requires a status and a backend", i.Namespace, i.Name, annotationKubernetesErrorPages, name)
+			return nil
+		}
+	}
+	return errorPages
+}
+
+// unmarshalYAML decodes the YAML value through its JSON form, so the json tags of the types apply.
+func unmarshalYAML(value string, out interface{}) error {
+	data, err := yaml.YAMLToJSON([]byte(value))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func getEntryPoints(i *v1beta1.Ingress) []string {
+	return provider.SplitAndTrimString(i.Annotations[types.LabelFrontendEntryPoints])
+}
+
+func getBoolAnnotation(i *v1beta1.Ingress, annotation string) bool {
+	value, ok := i.Annotations[annotation]
+	if !ok {
+		return false
+	}
+
+	v, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		log.Errorf("Error in ingress %s/%s: failed to parse %q value %q.", i.Namespace, i.Name, annotation, value)
+		return false
+	}
+	return v
+}
+
+func getInt64Annotation(i *v1beta1.Ingress, annotation string) int64 {
+	value, ok := i.Annotations[annotation]
+	if !ok {
+		return 0
+	}
+
+	v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		log.Errorf("Error in ingress %s/%s: failed to parse %q value %q.", i.Namespace, i.Name, annotation, value)
+		return 0
+	}
+	return v
+}
+
+func getSliceAnnotation(i *v1beta1.Ingress, annotation string) []string {
+	return provider.SplitAndTrimString(i.Annotations[annotation])
+}
+
+// getMapAnnotation parses an annotation with the format "Name1:Value1||Name2:Value2".
+func getMapAnnotation(i *v1beta1.Ingress, annotation string) map[string]string {
+	value, ok := i.Annotations[annotation]
+	if !ok || len(strings.TrimSpace(value)) == 0 {
+		return nil
+	}
+
+	mapValue := make(map[string]string)
+	for _, part := range strings.Split(value, "||") {
+		pair := strings.SplitN(part, ":", 2)
+		if len(pair) != 2 || len(strings.TrimSpace(pair[0])) == 0 {
+			log.Errorf("Error in ingress %s/%s: invalid %q entry %q, the format is Name:Value.", i.Namespace, i.Name, annotation, part)
+			return nil
+		}
+		mapValue[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+	}
+	return mapValue
+}
@@ -0,0 +1,271 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/pkg/util/intstr"
+)
+
+func TestIngressFrontendOptionsAnnotations(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		annotations map[string]string
+		expected    *types.Frontend
+	}{
+		{
+			desc: "no annotation",
+			expected: &types.Frontend{
+				Backend:        "foo.com/bar",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"/bar":    {Rule: "PathPrefix:/bar"},
+					"foo.com": {Rule: "Host:foo.com"},
+				},
+			},
+		},
+		{
+			desc: "headers",
+			annotations: map[string]string{
+				annotationKubernetesCustomRequestHeaders:  "X-Foo:bar || X-Bar:foo:bar",
+				annotationKubernetesCustomResponseHeaders: "X-Response:true",
+				annotationKubernetesAllowedHosts:          "foo.com, bar.com",
+				annotationKubernetesSSLRedirect:           "true",
+				annotationKubernetesSSLProxyHeaders:       "X-Forwarded-Proto:https",
+				annotationKubernetesHSTSMaxAge:            "31536000",
+				annotationKubernetesHSTSIncludeSubdomains: "true",
+				annotationKubernetesFrameDeny:             "true",
+				annotationKubernetesContentSecurityPolicy: "default-src 'self'",
+				annotationKubernetesReferrerPolicy:        "same-origin",
+			},
+			expected: &types.Frontend{
+				Backend:        "foo.com/bar",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"/bar":    {Rule: "PathPrefix:/bar"},
+					"foo.com": {Rule: "Host:foo.com"},
+				},
+				Headers: types.Headers{
+					CustomRequestHeaders:  map[string]string{"X-Foo": "bar", "X-Bar": "foo:bar"},
+					CustomResponseHeaders: map[string]string{"X-Response": "true"},
+					AllowedHosts:          []string{"foo.com", "bar.com"},
+					HostsProxyHeaders:     []string{},
+					SSLRedirect:           true,
+					SSLProxyHeaders:       map[string]string{"X-Forwarded-Proto": "https"},
+					STSSeconds:            31536000,
+					STSIncludeSubdomains:  true,
+					FrameDeny:             true,
+					ContentSecurityPolicy: "default-src 'self'",
+					ReferrerPolicy:        "same-origin",
+				},
+			},
+		},
+		{
+			desc: "invalid headers are ignored",
+			annotations: map[string]string{
+				annotationKubernetesCustomRequestHeaders: "X-Foo",
+				annotationKubernetesSSLRedirect:          "yes please",
+				annotationKubernetesHSTSMaxAge:           "1y",
+			},
+			expected: &types.Frontend{
+				Backend:        "foo.com/bar",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"/bar":    {Rule: "PathPrefix:/bar"},
+					"foo.com": {Rule: "Host:foo.com"},
+				},
+			},
+		},
+		{
+			desc: "entry points, pass TLS cert and redirect",
+			annotations: map[string]string{
+				types.LabelFrontendEntryPoints:         "http, https",
+				annotationKubernetesPassTLSCert:        "true",
+				annotationKubernetesRedirectEntryPoint: "https",
+			},
+			expected: &types.Frontend{
+				EntryPoints:    []string{"http", "https"},
+				Backend:        "foo.com/bar",
+				PassHostHeader: true,
+				PassTLSCert:    true,
+				Routes: map[string]types.Route{
+					"/bar":    {Rule: "PathPrefix:/bar"},
+					"foo.com": {Rule: "Host:foo.com"},
+				},
+				Redirect: &types.Redirect{EntryPoint: "https"},
+			},
+		},
+		{
+			desc: "regex redirect",
+			annotations: map[string]string{
+				annotationKubernetesRedirectRegex:       `^http://foo\.com/(.*)`,
+				annotationKubernetesRedirectReplacement: "http://bar.com/$1",
+			},
+			expected: &types.Frontend{
+				Backend:        "foo.com/bar",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"/bar":    {Rule: "PathPrefix:/bar"},
+					"foo.com": {Rule: "Host:foo.com"},
+				},
+				Redirect: &types.Redirect{Regex: `^http://foo\.com/(.*)`, Replacement: "http://bar.com/$1"},
+			},
+		},
+		{
+			desc: "invalid redirects are ignored",
+			annotations: map[string]string{
+				annotationKubernetesRedirectEntryPoint: "https",
+				annotationKubernetesRedirectRegex:      `^http://foo\.com/(.*)`,
+			},
+			expected: &types.Frontend{
+				Backend:        "foo.com/bar",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"/bar":    {Rule: "PathPrefix:/bar"},
+					"foo.com": {Rule: "Host:foo.com"},
+				},
+			},
+		},
+		{
+			desc: "rate limit and error pages",
+			annotations: map[string]string{
+				annotationKubernetesRateLimit: `
+extractorFunc: client.ip
+rateset:
+  bursty:
+    period: 10s
+    average: 100
+    burst: 200
+`,
+				annotationKubernetesErrorPages: `
+foo:
+  status:
+  - "500-599"
+  backend: errors
+  query: /{status}.html
+`,
+			},
+			expected: &types.Frontend{
+				Backend:        "foo.com/bar",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"/bar":    {Rule: "PathPrefix:/bar"},
+					"foo.com": {Rule: "Host:foo.com"},
+				},
+				RateLimit: &types.RateLimit{
+					ExtractorFunc: "client.ip",
+					RateSet: map[string]*types.Rate{
+						"bursty": {Period: flaeg.Duration(10 * time.Second), Average: 100, Burst: 200},
+					},
+				},
+				Errors: map[string]types.ErrorPage{
+					"foo": {Status: []string{"500-599"}, Backend: "errors", Query: "/{status}.html"},
+				},
+			},
+		},
+		{
+			desc: "invalid rate limit and error pages are ignored",
+			annotations: map[string]string{
+				annotationKubernetesRateLimit: `
+extractorFunc: foo
+rateset:
+  bursty:
+    period: 10s
+    average: 100
+`,
+				annotationKubernetesErrorPages: `
+foo:
+  query: /{status}.html
+`,
+			},
+			expected: &types.Frontend{
+				Backend:        "foo.com/bar",
+				PassHostHeader: true,
+				Routes: map[string]types.Route{
+					"/bar":    {Rule: "PathPrefix:/bar"},
+					"foo.com": {Rule: "Host:foo.com"},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := clientMock{
+				ingresses: []*v1beta1.Ingress{
+					{
+						ObjectMeta: v1.ObjectMeta{
+							Namespace:   "testing",
+							Annotations: test.annotations,
+						},
+						Spec: v1beta1.IngressSpec{
+							Rules: []v1beta1.IngressRule{
+								{
+									Host: "foo.com",
+									IngressRuleValue: v1beta1.IngressRuleValue{
+										HTTP: &v1beta1.HTTPIngressRuleValue{
+											Paths: []v1beta1.HTTPIngressPath{
+												{
+													Path: "/bar",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "service1",
+														ServicePort: intstr.FromInt(80),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				services: []*v1.Service{
+					{
+						ObjectMeta: v1.ObjectMeta{
+							Name:      "service1",
+							UID:       "1",
+							Namespace: "testing",
+						},
+						Spec: v1.ServiceSpec{
+							ClusterIP: "10.0.0.1",
+							Ports:     []v1.ServicePort{{Port: 80}},
+						},
+					},
+				},
+				watchChan: make(chan interface{}),
+			}
+			provider := Provider{}
+
+			templateObjects, err := provider.loadIngresses(client)
+			require.NoError(t, err)
+
+			// The frontends go through the template, so the options must survive the rendering.
+			actual := provider.loadConfig(*templateObjects)
+			require.NotNil(t, actual)
+			require.Contains(t, actual.Frontends, "foo.com/bar")
+
+			frontend := actual.Frontends["foo.com/bar"]
+			// The template renders the lists, which decode as empty.
+			if len(frontend.EntryPoints) == 0 {
+				frontend.EntryPoints = nil
+			}
+			if len(frontend.WhitelistSourceRange) == 0 {
+				frontend.WhitelistSourceRange = nil
+			}
+			if len(frontend.BasicAuth) == 0 {
+				frontend.BasicAuth = nil
+			}
+			assert.Equal(t, test.expected, frontend)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"reflect"
 	"sync"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"k8s.io/client-go/pkg/fields"
 	"k8s.io/client-go/pkg/labels"
 	"k8s.io/client-go/pkg/runtime"
+	"k8s.io/client-go/pkg/runtime/serializer"
 	"k8s.io/client-go/pkg/watch"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
@@ -61,29 +63,38 @@ func (im *informerManager) extend(informer cache.SharedInformer, withSyncFunc bo
 // WatchAll starts the watch of the Provider resources and updates the stores.
 // The stores can then be accessed via the Get* functions.
 type Client interface {
-	WatchAll(namespaces Namespaces, labelSelector string, stopCh <-chan struct{}) (<-chan interface{}, error)
+	WatchAll(namespaces Namespaces, labelSelector string, withCustomResources bool, stopCh <-chan struct{}) (<-chan interface{}, error)
 	GetIngresses() []*v1beta1.Ingress
+	GetIngressRoutes() []*IngressRoute
+	GetMiddleware(namespace, name string) (*Middleware, bool, error)
 	GetService(namespace, name string) (*v1.Service, bool, error)
 	GetSecret(namespace, name string) (*v1.Secret, bool, error)
 	GetEndpoints(namespace, name string) (*v1.Endpoints, bool, error)
+	UpdateIngressStatus(namespace, name string, addresses []v1.LoadBalancerIngress) error
 }
 
 type clientImpl struct {
 	clientset      *kubernetes.Clientset
+	crdClient      *rest.RESTClient
 	ingStores      []cache.Store
+	ingRouteStores []cache.Store
 	svcStores      map[string]cache.Store
 	epStores       map[string]cache.Store
 	secStores      map[string]cache.Store
+	mwStores       map[string]cache.Store
 	isNamespaceAll bool
 }
 
-func newClientImpl(clientset *kubernetes.Clientset) Client {
+func newClientImpl(clientset *kubernetes.Clientset, crdClient *rest.RESTClient) Client {
 	return &clientImpl{
-		clientset: clientset,
-		ingStores: []cache.Store{},
-		svcStores: map[string]cache.Store{},
-		epStores:  map[string]cache.Store{},
-		secStores: map[string]cache.Store{},
+		clientset:      clientset,
+		crdClient:      crdClient,
+		ingStores:      []cache.Store{},
+		ingRouteStores: []cache.Store{},
+		svcStores:      map[string]cache.Store{},
+		epStores:       map[string]cache.Store{},
+		secStores:      map[string]cache.Store{},
+		mwStores:       map[string]cache.Store{},
 	}
 }
 
@@ -133,11 +144,23 @@ func createClientFromConfig(c *rest.Config) (Client, error) {
 		return nil, err
 	}
 
-	return newClientImpl(clientset), nil
+	crdConfig := *c
+	crdConfig.GroupVersion = &SchemeGroupVersion
+	crdConfig.APIPath = "/apis"
+	crdConfig.ContentType = runtime.ContentTypeJSON
+	crdConfig.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: api.Codecs}
+	crdClient, err := rest.RESTClientFor(&crdConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClientImpl(clientset, crdClient), nil
 }
 
 // WatchAll starts namespace-specific controllers for all relevant kinds.
-func (c *clientImpl) WatchAll(namespaces Namespaces, labelSelector string, stopCh <-chan struct{}) (<-chan interface{}, error) {
+// The IngressRoute and Middleware custom resources are only watched when withCustomResources is set,
+// since their definitions must be installed in the cluster.
+func (c *clientImpl) WatchAll(namespaces Namespaces, labelSelector string, withCustomResources bool, stopCh <-chan struct{}) (<-chan interface{}, error) {
 	eventCh := make(chan interface{}, 1)
 
 	kubeLabelSelector, err := labels.Parse(labelSelector)
@@ -161,6 +184,10 @@ func (c *clientImpl) WatchAll(namespaces Namespaces, labelSelector string, stopC
 		// https://github.com/containous/traefik/issues/1784 should improve the
 		// situation here in the future.
 		informManager.extend(c.WatchObjects(ns, kindSecrets, &v1.Secret{}, c.secStores, eventCh), false)
+		if withCustomResources {
+			informManager.extend(c.WatchIngressRoutes(ns, kubeLabelSelector, eventCh), true)
+			informManager.extend(c.WatchMiddlewares(ns, eventCh), true)
+		}
 	}
 
 	var wg sync.WaitGroup
@@ -200,6 +227,33 @@ func (c *clientImpl) WatchIngresses(namespace string, labelSelector labels.Selec
 	return informer
 }
 
+// WatchIngressRoutes sets up a watch on IngressRoute objects and returns a corresponding shared informer.
+func (c *clientImpl) WatchIngressRoutes(namespace string, labelSelector labels.Selector, watchCh chan<- interface{}) cache.SharedInformer {
+	listWatch := newListWatchFromClientWithLabelSelector(
+		c.crdClient,
+		kindIngressRoutes,
+		namespace,
+		fields.Everything(),
+		labelSelector)
+
+	informer := loadInformer(listWatch, &IngressRoute{}, watchCh)
+	c.ingRouteStores = append(c.ingRouteStores, informer.GetStore())
+	return informer
+}
+
+// WatchMiddlewares sets up a watch on Middleware objects and returns a corresponding shared informer.
+func (c *clientImpl) WatchMiddlewares(namespace string, watchCh chan<- interface{}) cache.SharedInformer {
+	listWatch := cache.NewListWatchFromClient(
+		c.crdClient,
+		kindMiddlewares,
+		namespace,
+		fields.Everything())
+
+	informer := loadInformer(listWatch, &Middleware{}, watchCh)
+	c.mwStores[namespace] = informer.GetStore()
+	return informer
+}
+
 // WatchObjects sets up a watch on objects and returns a corresponding shared informer.
 func (c *clientImpl) WatchObjects(namespace, kind string, object runtime.Object, storeMap map[string]cache.Store, watchCh chan<- interface{}) cache.SharedInformer {
 	listWatch := cache.NewListWatchFromClient(
@@ -243,10 +297,29 @@ func (c *clientImpl) GetIngresses() []*v1beta1.Ingress {
 	return result
 }
 
+// GetIngressRoutes returns all IngressRoutes for observed namespaces in the cluster.
+func (c *clientImpl) GetIngressRoutes() []*IngressRoute {
+	var result []*IngressRoute
+
+	for _, store := range c.ingRouteStores {
+		for _, obj := range store.List() {
+			ingressRoute := obj.(*IngressRoute)
+			result = append(result, ingressRoute)
+		}
+	}
+
+	return result
+}
+
 // GetService returns the named service from the given namespace.
 func (c *clientImpl) GetService(namespace, name string) (*v1.Service, bool, error) {
+	store, ok := c.svcStores[c.lookupNamespace(namespace)]
+	if !ok {
+		return nil, false, fmt.Errorf("namespace %q is not watched", namespace)
+	}
+
 	var service *v1.Service
-	item, exists, err := c.svcStores[c.lookupNamespace(namespace)].GetByKey(namespace + "/" + name)
+	item, exists, err := store.GetByKey(namespace + "/" + name)
 	if item != nil {
 		service = item.(*v1.Service)
 	}
@@ -254,6 +327,25 @@ func (c *clientImpl) GetService(namespace, name string) (*v1.Service, bool, erro
 	return service, exists, err
 }
 
+// UpdateIngressStatus sets the load balancer addresses in the status of the named ingress.
+// The ingress is read from the API, so a status update is not based on a stale object.
+func (c *clientImpl) UpdateIngressStatus(namespace, name string, addresses []v1.LoadBalancerIngress) error {
+	ingress, err := c.clientset.ExtensionsV1beta1().Ingresses(namespace).Get(name)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(ingress.Status.LoadBalancer.Ingress, addresses) {
+		return nil
+	}
+
+	ingress.Status = v1beta1.IngressStatus{
+		LoadBalancer: v1.LoadBalancerStatus{Ingress: addresses},
+	}
+	_, err = c.clientset.ExtensionsV1beta1().Ingresses(namespace).UpdateStatus(ingress)
+	return err
+}
+
 // GetEndpoints returns the named endpoints from the given namespace.
 func (c *clientImpl) GetEndpoints(namespace, name string) (*v1.Endpoints, bool, error) {
 	var endpoint *v1.Endpoints
@@ -277,6 +369,22 @@ func (c *clientImpl) GetSecret(namespace, name string) (*v1.Secret, bool, error)
 	return secret, exists, err
 }
 
+// GetMiddleware returns the named middleware from the given namespace.
+func (c *clientImpl) GetMiddleware(namespace, name string) (*Middleware, bool, error) {
+	store, ok := c.mwStores[c.lookupNamespace(namespace)]
+	if !ok {
+		return nil, false, fmt.Errorf("namespace %q is not watched", namespace)
+	}
+
+	var middleware *Middleware
+	item, exists, err := store.GetByKey(namespace + "/" + name)
+	if err == nil && item != nil {
+		middleware = item.(*Middleware)
+	}
+
+	return middleware, exists, err
+}
+
 // lookupNamespace returns the lookup namespace key for the given namespace.
 // When listening on all namespaces, it returns the client-go identifier ("")
 // for all-namespaces. Otherwise, it returns the given namespace.
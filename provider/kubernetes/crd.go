@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"encoding/json"
+
+	"github.com/containous/traefik/types"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/runtime"
+	"k8s.io/client-go/pkg/util/intstr"
+)
+
+const (
+	crdGroupName = "traefik.containo.us"
+	crdVersion   = "v1alpha1"
+
+	kindIngressRoutes = "ingressroutes"
+	kindMiddlewares   = "middlewares"
+)
+
+// SchemeGroupVersion is the group version of the Traefik custom resources.
+var SchemeGroupVersion = unversioned.GroupVersion{Group: crdGroupName, Version: crdVersion}
+
+func init() {
+	schemeBuilder := runtime.NewSchemeBuilder(func(scheme *runtime.Scheme) error {
+		scheme.AddKnownTypes(SchemeGroupVersion,
+			&IngressRoute{},
+			&IngressRouteList{},
+			&Middleware{},
+			&MiddlewareList{},
+			&api.ListOptions{},
+			&api.DeleteOptions{},
+		)
+		return nil
+	})
+	if err := schemeBuilder.AddToScheme(api.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+// IngressRoute is a custom resource describing the routes of a set of services.
+type IngressRoute struct {
+	unversioned.TypeMeta `json:",inline"`
+	v1.ObjectMeta        `json:"metadata,omitempty"`
+
+	Spec IngressRouteSpec `json:"spec"`
+}
+
+// IngressRouteSpec holds the entry points and the routes of an IngressRoute.
+type IngressRouteSpec struct {
+	EntryPoints []string `json:"entryPoints,omitempty"`
+	Routes      []Route  `json:"routes"`
+}
+
+// Route is a route of an IngressRoute, mapped onto a frontend and its backend.
+type Route struct {
+	Match          string          `json:"match"`
+	Priority       int             `json:"priority,omitempty"`
+	PassHostHeader *bool           `json:"passHostHeader,omitempty"`
+	Services       []RouteService  `json:"services"`
+	Middlewares    []MiddlewareRef `json:"middlewares,omitempty"`
+}
+
+// RouteService is a Kubernetes service receiving the requests of a route.
+type RouteService struct {
+	Name   string             `json:"name"`
+	Port   intstr.IntOrString `json:"port"`
+	Weight int                `json:"weight,omitempty"`
+}
+
+// MiddlewareRef references a Middleware, by default in the namespace of the IngressRoute.
+type MiddlewareRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// UnmarshalJSON decodes the IngressRoute with encoding/json.
+// The runtime JSON serializer does not handle the embedded metadata of custom types.
+func (ir *IngressRoute) UnmarshalJSON(data []byte) error {
+	type ingressRoute IngressRoute
+	return json.Unmarshal(data, (*ingressRoute)(ir))
+}
+
+// IngressRouteList is a list of IngressRoutes.
+type IngressRouteList struct {
+	unversioned.TypeMeta `json:",inline"`
+	unversioned.ListMeta `json:"metadata,omitempty"`
+
+	Items []IngressRoute `json:"items"`
+}
+
+// UnmarshalJSON decodes the IngressRouteList with encoding/json.
+func (l *IngressRouteList) UnmarshalJSON(data []byte) error {
+	type ingressRouteList IngressRouteList
+	return json.Unmarshal(data, (*ingressRouteList)(l))
+}
+
+// Middleware is a custom resource holding frontend options shared by several routes.
+type Middleware struct {
+	unversioned.TypeMeta `json:",inline"`
+	v1.ObjectMeta        `json:"metadata,omitempty"`
+
+	Spec MiddlewareSpec `json:"spec"`
+}
+
+// MiddlewareSpec holds the frontend options applied by a Middleware.
+type MiddlewareSpec struct {
+	Auth                 *types.Auth                `json:"auth,omitempty"`
+	BasicAuth            []string                   `json:"basicAuth,omitempty"`
+	WhitelistSourceRange []string                   `json:"whitelistSourceRange,omitempty"`
+	Headers              *types.Headers             `json:"headers,omitempty"`
+	Errors               map[string]types.ErrorPage `json:"errors,omitempty"`
+	RateLimit            *types.RateLimit           `json:"rateLimit,omitempty"`
+	PassTLSCert          bool                       `json:"passTLSCert,omitempty"`
+}
+
+// UnmarshalJSON decodes the Middleware with encoding/json.
+func (m *Middleware) UnmarshalJSON(data []byte) error {
+	type middleware Middleware
+	return json.Unmarshal(data, (*middleware)(m))
+}
+
+// MiddlewareList is a list of Middlewares.
+type MiddlewareList struct {
+	unversioned.TypeMeta `json:",inline"`
+	unversioned.ListMeta `json:"metadata,omitempty"`
+
+	Items []Middleware `json:"items"`
+}
+
+// UnmarshalJSON decodes the MiddlewareList with encoding/json.
+func (l *MiddlewareList) UnmarshalJSON(data []byte) error {
+	type middlewareList MiddlewareList
+	return json.Unmarshal(data, (*middlewareList)(l))
+}
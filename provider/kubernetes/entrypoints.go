@@ -0,0 +1,32 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntryPoints holds the entry points the Ingress TLS certificates are attached to.
+type EntryPoints []string
+
+// Set adds strings elem into the parser.
+// It splits str on , and ;
+func (ep *EntryPoints) Set(str string) error {
+	fargs := func(c rune) bool {
+		return c == ',' || c == ';'
+	}
+	// get function
+	slice := strings.FieldsFunc(str, fargs)
+	*ep = append(*ep, slice...)
+	return nil
+}
+
+// Get returns the entry points as []string.
+func (ep *EntryPoints) Get() interface{} { return EntryPoints(*ep) }
+
+// String returns the slice as a string.
+func (ep *EntryPoints) String() string { return fmt.Sprintf("%v", *ep) }
+
+// SetValue sets the entry points with val.
+func (ep *EntryPoints) SetValue(val interface{}) {
+	*ep = EntryPoints(val.(EntryPoints))
+}
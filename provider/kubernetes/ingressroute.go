@@ -0,0 +1,149 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const ingressRouteRuleName = "match"
+
+// loadIngressRoutes maps the IngressRoute custom resources onto frontends and backends.
+// Each route of an IngressRoute gets its own frontend and backend.
+func (p *Provider) loadIngressRoutes(k8sClient Client) (*types.Configuration, error) {
+	configuration := &types.Configuration{
+		Backends:  map[string]*types.Backend{},
+		Frontends: map[string]*types.Frontend{},
+	}
+
+	for _, ingressRoute := range k8sClient.GetIngressRoutes() {
+		for idx, route := range ingressRoute.Spec.Routes {
+			name := ingressRoute.Namespace + "/" + ingressRoute.Name + "/" + strconv.Itoa(idx)
+
+			if len(route.Match) == 0 {
+				log.Errorf("Skipping route %s: match must be set", name)
+				continue
+			}
+
+			backend, err := p.loadRouteBackend(k8sClient, ingressRoute.Namespace, route)
+			if err != nil {
+				return nil, err
+			}
+			if backend == nil {
+				continue
+			}
+
+			frontend := &types.Frontend{
+				EntryPoints:    ingressRoute.Spec.EntryPoints,
+				Backend:        name,
+				PassHostHeader: p.getPassHostHeader(),
+				Priority:       route.Priority,
+				Routes: map[string]types.Route{
+					ingressRouteRuleName: {Rule: route.Match},
+				},
+			}
+			if route.PassHostHeader != nil {
+				frontend.PassHostHeader = *route.PassHostHeader
+			}
+
+			if err := applyMiddlewares(k8sClient, ingressRoute.Namespace, route.Middlewares, frontend); err != nil {
+				log.Errorf("Skipping route %s: %s", name, err)
+				continue
+			}
+
+			configuration.Backends[name] = backend
+			configuration.Frontends[name] = frontend
+		}
+	}
+
+	return configuration, nil
+}
+
+// loadRouteBackend returns the backend holding the servers of all the services of the route,
+// or nil when a service does not exist.
+func (p *Provider) loadRouteBackend(k8sClient Client, namespace string, route Route) (*types.Backend, error) {
+	backend := &types.Backend{
+		Servers: make(map[string]types.Server),
+		LoadBalancer: &types.LoadBalancer{
+			Method: "wrr",
+		},
+	}
+
+	for _, routeService := range route.Services {
+		service, exists, err := k8sClient.GetService(namespace, routeService.Name)
+		if err != nil {
+			log.Errorf("Error while retrieving service information from k8s API %s/%s: %v", namespace, routeService.Name, err)
+			return nil, err
+		}
+
+		if !exists {
+			log.Errorf("Service not found for %s/%s", namespace, routeService.Name)
+			return nil, nil
+		}
+
+		weight := routeService.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+
+		servers, err := p.getServers(k8sClient, service, routeService.Port, weight, false)
+		if err != nil {
+			return nil, err
+		}
+		for name, server := range servers {
+			// Several services may share endpoints, so the server names are prefixed by the service name.
+			backend.Servers[routeService.Name+"-"+name] = server
+		}
+	}
+
+	return backend, nil
+}
+
+// applyMiddlewares applies the referenced middlewares in order, a middleware overriding the options set by the previous ones.
+func applyMiddlewares(k8sClient Client, namespace string, refs []MiddlewareRef, frontend *types.Frontend) error {
+	for _, ref := range refs {
+		middlewareNamespace := ref.Namespace
+		if len(middlewareNamespace) == 0 {
+			middlewareNamespace = namespace
+		}
+
+		middleware, exists, err := k8sClient.GetMiddleware(middlewareNamespace, ref.Name)
+		switch {
+		case err != nil:
+			return fmt.Errorf("failed to fetch middleware %q/%q: %s", middlewareNamespace, ref.Name, err)
+		case !exists || middleware == nil:
+			return fmt.Errorf("middleware %q/%q not found", middlewareNamespace, ref.Name)
+		}
+
+		spec := middleware.Spec
+		if spec.Auth != nil {
+			frontend.Auth = spec.Auth
+		}
+		if len(spec.BasicAuth) > 0 {
+			frontend.BasicAuth = spec.BasicAuth
+		}
+		if len(spec.WhitelistSourceRange) > 0 {
+			frontend.WhitelistSourceRange = spec.WhitelistSourceRange
+		}
+		if spec.Headers != nil {
+			frontend.Headers = *spec.Headers
+		}
+		if len(spec.Errors) > 0 {
+			if frontend.Errors == nil {
+				frontend.Errors = make(map[string]types.ErrorPage)
+			}
+			for name, errorPage := range spec.Errors {
+				frontend.Errors[name] = errorPage
+			}
+		}
+		if spec.RateLimit != nil {
+			frontend.RateLimit = spec.RateLimit
+		}
+		if spec.PassTLSCert {
+			frontend.PassTLSCert = true
+		}
+	}
+	return nil
+}
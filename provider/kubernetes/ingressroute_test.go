@@ -0,0 +1,248 @@
+package kubernetes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/util/intstr"
+	"k8s.io/client-go/rest"
+)
+
+func TestLoadIngressRoutes(t *testing.T) {
+	passHostHeader := false
+	ingressRoutes := []*IngressRoute{
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "testing",
+			},
+			Spec: IngressRouteSpec{
+				EntryPoints: []string{"https"},
+				Routes: []Route{
+					{
+						Match:    "Host:foo.com;PathPrefix:/api",
+						Priority: 10,
+						Services: []RouteService{
+							{Name: "service1", Port: intstr.FromInt(80), Weight: 3},
+							{Name: "service2", Port: intstr.FromString("http")},
+						},
+						Middlewares: []MiddlewareRef{
+							{Name: "auth"},
+							{Name: "headers", Namespace: "shared"},
+						},
+					},
+					{
+						Match:          "Host:foo.com",
+						PassHostHeader: &passHostHeader,
+						Services: []RouteService{
+							{Name: "service1", Port: intstr.FromInt(80)},
+						},
+					},
+					{
+						Match: "Host:missing.com",
+						Services: []RouteService{
+							{Name: "missing", Port: intstr.FromInt(80)},
+						},
+					},
+					{
+						Match: "Host:unknown-middleware.com",
+						Services: []RouteService{
+							{Name: "service1", Port: intstr.FromInt(80)},
+						},
+						Middlewares: []MiddlewareRef{
+							{Name: "unknown"},
+						},
+					},
+				},
+			},
+		},
+	}
+	middlewares := []*Middleware{
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "auth",
+				Namespace: "testing",
+			},
+			Spec: MiddlewareSpec{
+				BasicAuth:            []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+				WhitelistSourceRange: []string{"10.0.0.0/8"},
+				Headers: &types.Headers{
+					CustomRequestHeaders: map[string]string{"X-Auth": "true"},
+				},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "headers",
+				Namespace: "shared",
+			},
+			Spec: MiddlewareSpec{
+				Headers: &types.Headers{
+					CustomResponseHeaders: map[string]string{"X-Shared": "true"},
+				},
+			},
+		},
+	}
+	services := []*v1.Service{
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "service1",
+				UID:       "1",
+				Namespace: "testing",
+			},
+			Spec: v1.ServiceSpec{
+				ClusterIP: "10.0.0.1",
+				Ports:     []v1.ServicePort{{Port: 80}},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "service2",
+				UID:       "2",
+				Namespace: "testing",
+			},
+			Spec: v1.ServiceSpec{
+				ClusterIP: "10.0.0.2",
+				Ports:     []v1.ServicePort{{Name: "http", Port: 8080}},
+			},
+		},
+	}
+	endpoints := []*v1.Endpoints{
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "service1",
+				UID:       "1",
+				Namespace: "testing",
+			},
+			Subsets: []v1.EndpointSubset{
+				{
+					Addresses: []v1.EndpointAddress{{IP: "10.10.0.1"}},
+					Ports:     []v1.EndpointPort{{Port: 8080}},
+				},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "service2",
+				UID:       "2",
+				Namespace: "testing",
+			},
+			Subsets: []v1.EndpointSubset{
+				{
+					Addresses: []v1.EndpointAddress{{IP: "10.20.0.1"}},
+					Ports:     []v1.EndpointPort{{Name: "http", Port: 8081}},
+				},
+			},
+		},
+	}
+	client := clientMock{
+		ingressRoutes: ingressRoutes,
+		middlewares:   middlewares,
+		services:      services,
+		endpoints:     endpoints,
+		watchChan:     make(chan interface{}),
+	}
+	provider := Provider{}
+
+	actual, err := provider.loadIngressRoutes(client)
+	require.NoError(t, err)
+
+	expected := &types.Configuration{
+		Backends: map[string]*types.Backend{
+			"testing/foo/0": {
+				Servers: map[string]types.Server{
+					"service1-http://10.10.0.1:8080": {URL: "http://10.10.0.1:8080", Weight: 3},
+					"service2-http://10.20.0.1:8081": {URL: "http://10.20.0.1:8081", Weight: 1},
+				},
+				LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+			},
+			"testing/foo/1": {
+				Servers: map[string]types.Server{
+					"service1-http://10.10.0.1:8080": {URL: "http://10.10.0.1:8080", Weight: 1},
+				},
+				LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+			},
+		},
+		Frontends: map[string]*types.Frontend{
+			"testing/foo/0": {
+				EntryPoints:    []string{"https"},
+				Backend:        "testing/foo/0",
+				PassHostHeader: true,
+				Priority:       10,
+				Routes: map[string]types.Route{
+					"match": {Rule: "Host:foo.com;PathPrefix:/api"},
+				},
+				BasicAuth:            []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+				WhitelistSourceRange: []string{"10.0.0.0/8"},
+				Headers: types.Headers{
+					CustomResponseHeaders: map[string]string{"X-Shared": "true"},
+				},
+			},
+			"testing/foo/1": {
+				EntryPoints:    []string{"https"},
+				Backend:        "testing/foo/1",
+				PassHostHeader: false,
+				Routes: map[string]types.Route{
+					"match": {Rule: "Host:foo.com"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, actual)
+
+	// The IngressRoutes configuration is added to the Ingresses one.
+	objects, err := (&Provider{CustomResources: true}).loadObjects(client)
+	require.NoError(t, err)
+	configuration := provider.buildConfiguration(objects)
+	require.NotNil(t, configuration)
+	assert.Equal(t, expected.Frontends, configuration.Frontends)
+	assert.Equal(t, expected.Backends, configuration.Backends)
+}
+
+func TestCustomResourcesClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apis/traefik.containo.us/v1alpha1/namespaces/testing/ingressroutes", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+  "apiVersion": "traefik.containo.us/v1alpha1",
+  "kind": "IngressRouteList",
+  "metadata": {"resourceVersion": "42"},
+  "items": [{
+    "apiVersion": "traefik.containo.us/v1alpha1",
+    "kind": "IngressRoute",
+    "metadata": {"name": "foo", "namespace": "testing"},
+    "spec": {
+      "entryPoints": ["http"],
+      "routes": [{"match": "Host:foo.com", "services": [{"name": "service1", "port": 80}]}]
+    }
+  }]
+}`))
+	}))
+	defer ts.Close()
+
+	client, err := createClientFromConfig(&rest.Config{Host: ts.URL})
+	require.NoError(t, err)
+
+	list := &IngressRouteList{}
+	err = client.(*clientImpl).crdClient.Get().Namespace("testing").Resource(kindIngressRoutes).Do().Into(list)
+	require.NoError(t, err)
+
+	assert.Equal(t, "42", list.ResourceVersion)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "testing", list.Items[0].Namespace)
+	assert.Equal(t, "foo", list.Items[0].Name)
+	assert.Equal(t, IngressRouteSpec{
+		EntryPoints: []string{"http"},
+		Routes: []Route{
+			{
+				Match:    "Host:foo.com",
+				Services: []RouteService{{Name: "service1", Port: intstr.FromInt(80)}},
+			},
+		},
+	}, list.Items[0].Spec)
+}
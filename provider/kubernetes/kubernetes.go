@@ -8,16 +8,19 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
 	"time"
 
 	"github.com/cenk/backoff"
+	"github.com/containous/flaeg"
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
 	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 	"k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
@@ -32,24 +35,64 @@ const (
 
 	annotationKubernetesIngressClass         = "kubernetes.io/ingress.class"
 	annotationKubernetesAuthRealm            = "ingress.kubernetes.io/auth-realm"
+	annotationKubernetesAuthRemoveHeader     = "ingress.kubernetes.io/auth-remove-header"
 	annotationKubernetesAuthType             = "ingress.kubernetes.io/auth-type"
 	annotationKubernetesAuthSecret           = "ingress.kubernetes.io/auth-secret"
 	annotationKubernetesRewriteTarget        = "ingress.kubernetes.io/rewrite-target"
 	annotationKubernetesWhitelistSourceRange = "ingress.kubernetes.io/whitelist-source-range"
-)
 
-const traefikDefaultRealm = "traefik"
+	annotationKubernetesCustomRequestHeaders    = "ingress.kubernetes.io/custom-request-headers"
+	annotationKubernetesCustomResponseHeaders   = "ingress.kubernetes.io/custom-response-headers"
+	annotationKubernetesAllowedHosts            = "ingress.kubernetes.io/allowed-hosts"
+	annotationKubernetesProxyHeaders            = "ingress.kubernetes.io/proxy-headers"
+	annotationKubernetesSSLRedirect             = "ingress.kubernetes.io/ssl-redirect"
+	annotationKubernetesSSLTemporaryRedirect    = "ingress.kubernetes.io/ssl-temporary-redirect"
+	annotationKubernetesSSLHost                 = "ingress.kubernetes.io/ssl-host"
+	annotationKubernetesSSLProxyHeaders         = "ingress.kubernetes.io/ssl-proxy-headers"
+	annotationKubernetesHSTSMaxAge              = "ingress.kubernetes.io/hsts-max-age"
+	annotationKubernetesHSTSIncludeSubdomains   = "ingress.kubernetes.io/hsts-include-subdomains"
+	annotationKubernetesHSTSPreload             = "ingress.kubernetes.io/hsts-preload"
+	annotationKubernetesForceHSTSHeader         = "ingress.kubernetes.io/force-hsts"
+	annotationKubernetesFrameDeny               = "ingress.kubernetes.io/frame-deny"
+	annotationKubernetesCustomFrameOptionsValue = "ingress.kubernetes.io/custom-frame-options-value"
+	annotationKubernetesContentTypeNosniff      = "ingress.kubernetes.io/content-type-nosniff"
+	annotationKubernetesBrowserXSSFilter        = "ingress.kubernetes.io/browser-xss-filter"
+	annotationKubernetesContentSecurityPolicy   = "ingress.kubernetes.io/content-security-policy"
+	annotationKubernetesPublicKey               = "ingress.kubernetes.io/public-key"
+	annotationKubernetesReferrerPolicy          = "ingress.kubernetes.io/referrer-policy"
+	annotationKubernetesIsDevelopment           = "ingress.kubernetes.io/is-development"
+	annotationKubernetesRedirectEntryPoint      = "ingress.kubernetes.io/redirect-entry-point"
+	annotationKubernetesRedirectRegex           = "ingress.kubernetes.io/redirect-regex"
+	annotationKubernetesRedirectReplacement     = "ingress.kubernetes.io/redirect-replacement"
+	annotationKubernetesRateLimit               = "ingress.kubernetes.io/rate-limit"
+	annotationKubernetesErrorPages              = "ingress.kubernetes.io/error-pages"
+	annotationKubernetesPassTLSCert             = "ingress.kubernetes.io/pass-tls-cert"
+	annotationKubernetesServiceUpstream         = "ingress.kubernetes.io/service-upstream"
+
+	defaultTLSEntryPoint = "https"
+
+	defaultBackendName  = "global-default-backend"
+	defaultFrontendName = "global-default-frontend"
+	defaultFrontendRule = "PathPrefix:/"
+)
 
 // Provider holds configurations of the provider.
 type Provider struct {
 	provider.BaseProvider  `mapstructure:",squash" export:"true"`
-	Endpoint               string     `description:"Kubernetes server endpoint (required for external cluster client)"`
-	Token                  string     `description:"Kubernetes bearer token (not needed for in-cluster client)"`
-	CertAuthFilePath       string     `description:"Kubernetes certificate authority file path (not needed for in-cluster client)"`
-	DisablePassHostHeaders bool       `description:"Kubernetes disable PassHost Headers" export:"true"`
-	Namespaces             Namespaces `description:"Kubernetes namespaces" export:"true"`
-	LabelSelector          string     `description:"Kubernetes api label selector to use" export:"true"`
+	Endpoint               string           `description:"Kubernetes server endpoint (required for external cluster client)"`
+	Token                  string           `description:"Kubernetes bearer token (not needed for in-cluster client)"`
+	CertAuthFilePath       string           `description:"Kubernetes certificate authority file path (not needed for in-cluster client)"`
+	DisablePassHostHeaders bool             `description:"Kubernetes disable PassHost Headers" export:"true"`
+	Namespaces             Namespaces       `description:"Kubernetes namespaces" export:"true"`
+	LabelSelector          string           `description:"Kubernetes api label selector to use" export:"true"`
+	TLSEntryPoints         EntryPoints      `description:"Kubernetes entry points for the Ingress TLS certificates (default: https)" export:"true"`
+	CustomResources        bool             `description:"Kubernetes enable the IngressRoute and Middleware custom resources" export:"true"`
+	IngressEndpoint        *IngressEndpoint `description:"Kubernetes Ingress Endpoint" export:"true"`
+	NotReadyEndpoints      string           `description:"Kubernetes handling of the not ready endpoints: drop, keep or drain (default: drop)" export:"true"`
+	DrainTimeout           flaeg.Duration   `description:"Kubernetes duration a not ready endpoint stays in rotation when draining (default: 30s)" export:"true"`
 	lastConfiguration      safe.Safe
+	isLeader               func() bool
+	notReady               *notReadyTracker
 }
 
 func (p *Provider) newK8sClient() (Client, error) {
@@ -76,19 +119,45 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 	// hard with an exit code > 0.
 	flag.Set("logtostderr", "true")
 
+	if p.IngressEndpoint != nil {
+		if err := p.IngressEndpoint.validate(); err != nil {
+			return fmt.Errorf("invalid Kubernetes ingress endpoint: %s", err)
+		}
+	}
+	if err := checkNotReadyEndpoints(p.NotReadyEndpoints); err != nil {
+		return err
+	}
+	if p.NotReadyEndpoints == notReadyEndpointsDrain {
+		p.notReady = newNotReadyTracker()
+	}
+
 	k8sClient, err := p.newK8sClient()
 	if err != nil {
 		return err
 	}
 	p.Constraints = append(p.Constraints, constraints...)
 
+	var statuses *statusPublisher
+	if p.IngressEndpoint != nil {
+		statuses = newStatusPublisher(p, k8sClient)
+		pool.Go(statuses.run)
+	}
+
 	pool.Go(func(stop chan bool) {
 		operation := func() error {
+			// The drained endpoints leave the rotation once their timeout has elapsed, even without event.
+			var drainTick <-chan time.Time
+			if p.notReady != nil {
+				ticker := time.NewTicker(drainCheckInterval)
+				defer ticker.Stop()
+				drainTick = ticker.C
+			}
+
 			for {
 				stopWatch := make(chan struct{}, 1)
 				defer close(stopWatch)
 				log.Debugf("Using label selector: '%s'", p.LabelSelector)
-				eventsChan, err := k8sClient.WatchAll(p.Namespaces, p.LabelSelector, stopWatch)
+				eventsChan, err := k8sClient.WatchAll(p.Namespaces, p.LabelSelector, p.CustomResources, stopWatch)
 				if err != nil {
 					log.Errorf("Error watching kubernetes events: %v", err)
 					timer := time.NewTimer(1 * time.Second)
@@ -105,18 +174,15 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 						return nil
 					case event := <-eventsChan:
 						log.Debugf("Received Kubernetes event kind %T", event)
-						templateObjects, err := p.loadIngresses(k8sClient)
-						if err != nil {
+						if statuses != nil {
+							statuses.request()
+						}
+						if err := p.sendConfiguration(k8sClient, event, configurationChan); err != nil {
 							return err
 						}
-						if reflect.DeepEqual(p.lastConfiguration.Get(), templateObjects) {
-							log.Debugf("Skipping Kubernetes event kind %T", event)
-						} else {
-							p.lastConfiguration.Set(templateObjects)
-							configurationChan <- types.ConfigMessage{
-								ProviderName:  "kubernetes",
-								Configuration: p.loadConfig(*templateObjects),
-							}
+					case <-drainTick:
+						if err := p.sendConfiguration(k8sClient, nil, configurationChan); err != nil {
+							return err
 						}
 					}
 				}
@@ -135,6 +201,77 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 	return nil
 }
 
+// sendConfiguration sends the configuration built from the Kubernetes objects, unless it is unchanged.
+func (p *Provider) sendConfiguration(k8sClient Client, event interface{}, configurationChan chan<- types.ConfigMessage) error {
+	objects, err := p.loadObjects(k8sClient)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(p.lastConfiguration.Get(), objects) {
+		if event != nil {
+			log.Debugf("Skipping Kubernetes event kind %T", event)
+		}
+		return nil
+	}
+
+	p.lastConfiguration.Set(objects)
+	configurationChan <- types.ConfigMessage{
+		ProviderName:  p.GetProviderName("kubernetes"),
+		Configuration: p.buildConfiguration(objects),
+	}
+	return nil
+}
+
+// kubernetesObjects holds the configurations built from the Ingresses and from the IngressRoutes.
+type kubernetesObjects struct {
+	ingresses     *types.Configuration
+	ingressRoutes *types.Configuration
+}
+
+func (p *Provider) loadObjects(k8sClient Client) (*kubernetesObjects, error) {
+	if p.notReady != nil {
+		p.notReady.sweep()
+	}
+
+	ingresses, err := p.loadIngresses(k8sClient)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := &kubernetesObjects{ingresses: ingresses}
+	if p.CustomResources {
+		objects.ingressRoutes, err = p.loadIngressRoutes(k8sClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+// buildConfiguration renders the Ingresses configuration through the template,
+// and adds the IngressRoutes configuration which maps directly onto frontends and backends.
+func (p *Provider) buildConfiguration(objects *kubernetesObjects) *types.Configuration {
+	configuration := p.loadConfig(*objects.ingresses)
+	if configuration == nil || objects.ingressRoutes == nil {
+		return configuration
+	}
+
+	if configuration.Backends == nil {
+		configuration.Backends = map[string]*types.Backend{}
+	}
+	for name, backend := range objects.ingressRoutes.Backends {
+		configuration.Backends[name] = backend
+	}
+	if configuration.Frontends == nil {
+		configuration.Frontends = map[string]*types.Frontend{}
+	}
+	for name, frontend := range objects.ingressRoutes.Frontends {
+		configuration.Frontends[name] = frontend
+	}
+	return configuration
+}
+
 func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error) {
 	ingresses := k8sClient.GetIngresses()
 
@@ -142,6 +279,7 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 		Backends:  map[string]*types.Backend{},
 		Frontends: map[string]*types.Frontend{},
 	}
+	tlsConfigs := map[string]*tls.Configuration{}
 	for _, i := range ingresses {
 		ingressClass := i.Annotations[annotationKubernetesIngressClass]
 
@@ -149,6 +287,24 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 			continue
 		}
 
+		if err := p.addTLSConfigurations(i, k8sClient, tlsConfigs); err != nil {
+			log.Errorf("Error configuring TLS for ingress %s/%s: %s", i.Namespace, i.Name, err)
+		}
+
+		entryPoints := getEntryPoints(i)
+		headers := getHeaders(i)
+		errorPages := getErrorPages(i)
+		rateLimit := getRateLimit(i)
+		redirect := getRedirect(i)
+		passTLSCert := getBoolAnnotation(i, annotationKubernetesPassTLSCert)
+		serviceUpstream := getBoolAnnotation(i, annotationKubernetesServiceUpstream)
+
+		if i.Spec.Backend != nil {
+			if err := p.addGlobalBackend(k8sClient, i, entryPoints, serviceUpstream, &templateObjects); err != nil {
+				log.Errorf("Error creating global backend for ingress %s/%s: %s", i.Namespace, i.Name, err)
+			}
+		}
+
 		for _, r := range i.Spec.Rules {
 			if r.HTTP == nil {
 				log.Warn("Error in ingress: HTTP is nil")
@@ -178,12 +334,6 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 				default:
 					log.Warnf("Unknown value '%s' for %s, falling back to %s", passHostHeaderAnnotation, types.LabelFrontendPassHostHeader, PassHostHeader)
 				}
-				if realm := i.Annotations[annotationKubernetesAuthRealm]; realm != "" && realm != traefikDefaultRealm {
-					log.Errorf("Value for annotation %q on ingress %s/%s invalid: no realm customization supported", annotationKubernetesAuthRealm, i.ObjectMeta.Namespace, i.ObjectMeta.Name)
-					delete(templateObjects.Backends, r.Host+pa.Path)
-					continue
-				}
-
 				whitelistSourceRangeAnnotation := i.Annotations[annotationKubernetesWhitelistSourceRange]
 				whitelistSourceRange := provider.SplitAndTrimString(whitelistSourceRangeAnnotation)
 
@@ -196,13 +346,32 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 
 					priority := p.getPriority(pa, i)
 
+					var auth *types.Auth
+					realm := i.Annotations[annotationKubernetesAuthRealm]
+					removeHeader := i.Annotations[annotationKubernetesAuthRemoveHeader] == "true"
+					if len(basicAuthCreds) > 0 && (realm != "" || removeHeader) {
+						auth = &types.Auth{
+							Basic: &types.Basic{
+								Realm:        realm,
+								RemoveHeader: removeHeader,
+							},
+						}
+					}
+
 					templateObjects.Frontends[r.Host+pa.Path] = &types.Frontend{
+						EntryPoints:          entryPoints,
 						Backend:              r.Host + pa.Path,
 						PassHostHeader:       PassHostHeader,
+						PassTLSCert:          passTLSCert,
 						Routes:               make(map[string]types.Route),
 						Priority:             priority,
 						BasicAuth:            basicAuthCreds,
+						Auth:                 auth,
 						WhitelistSourceRange: whitelistSourceRange,
+						Headers:              headers,
+						Errors:               errorPages,
+						RateLimit:            rateLimit,
+						Redirect:             redirect,
 					}
 				}
 				if len(r.Host) > 0 {
@@ -238,81 +407,267 @@ func (p *Provider) loadIngresses(k8sClient Client) (*types.Configuration, error)
 					continue
 				}
 
-				if expression := service.Annotations[types.LabelTraefikBackendCircuitbreaker]; expression != "" {
-					templateObjects.Backends[r.Host+pa.Path].CircuitBreaker = &types.CircuitBreaker{
-						Expression: expression,
-					}
-				}
+				configureBackend(templateObjects.Backends[r.Host+pa.Path], service)
 
-				if service.Annotations[types.LabelBackendLoadbalancerMethod] == "drr" {
-					templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Method = "drr"
+				servers, err := p.getServers(k8sClient, service, pa.Backend.ServicePort, 1, serviceUpstream)
+				if err != nil {
+					return nil, err
 				}
-
-				if sticky := service.Annotations[types.LabelBackendLoadbalancerSticky]; len(sticky) > 0 {
-					log.Warnf("Deprecated configuration found: %s. Please use %s.", types.LabelBackendLoadbalancerSticky, types.LabelBackendLoadbalancerStickiness)
-					templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Sticky = strings.EqualFold(strings.TrimSpace(sticky), "true")
+				for name, server := range servers {
+					templateObjects.Backends[r.Host+pa.Path].Servers[name] = server
 				}
+			}
+		}
+	}
+	templateObjects.TLSConfiguration = getTLSConfigurations(tlsConfigs)
+	return &templateObjects, nil
+}
 
-				if service.Annotations[types.LabelBackendLoadbalancerStickiness] == "true" {
-					templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Stickiness = &types.Stickiness{}
-					if cookieName := service.Annotations[types.LabelBackendLoadbalancerStickinessCookieName]; len(cookieName) > 0 {
-						templateObjects.Backends[r.Host+pa.Path].LoadBalancer.Stickiness.CookieName = cookieName
-					}
+// configureBackend applies the options of the service annotations to the backend.
+func configureBackend(backend *types.Backend, service *v1.Service) {
+	if expression := service.Annotations[types.LabelTraefikBackendCircuitbreaker]; expression != "" {
+		backend.CircuitBreaker = &types.CircuitBreaker{
+			Expression: expression,
+		}
+	}
+
+	if method := service.Annotations[types.LabelBackendLoadbalancerMethod]; len(method) > 0 {
+		if _, err := types.NewLoadBalancerMethod(&types.LoadBalancer{Method: method}); err != nil {
+			log.Errorf("Invalid %s annotation on service %s/%s: %v", types.LabelBackendLoadbalancerMethod, service.Namespace, service.Name, err)
+		} else {
+			backend.LoadBalancer.Method = method
+		}
+	}
+	backend.LoadBalancer.HashOn = service.Annotations[types.LabelBackendLoadbalancerHashOn]
+
+	if sticky := service.Annotations[types.LabelBackendLoadbalancerSticky]; len(sticky) > 0 {
+		log.Warnf("Deprecated configuration found: %s. Please use %s.", types.LabelBackendLoadbalancerSticky, types.LabelBackendLoadbalancerStickiness)
+		backend.LoadBalancer.Sticky = strings.EqualFold(strings.TrimSpace(sticky), "true")
+	}
+
+	if service.Annotations[types.LabelBackendLoadbalancerStickiness] == "true" {
+		backend.LoadBalancer.Stickiness = &types.Stickiness{}
+		if cookieName := service.Annotations[types.LabelBackendLoadbalancerStickinessCookieName]; len(cookieName) > 0 {
+			backend.LoadBalancer.Stickiness.CookieName = cookieName
+		}
+	}
+
+	backend.ServersTransport = getServersTransport(service)
+}
+
+// addGlobalBackend adds the default backend of the ingress as a catch-all frontend, matched after all the others.
+// Only one ingress may define the default backend.
+func (p *Provider) addGlobalBackend(k8sClient Client, i *v1beta1.Ingress, entryPoints []string, serviceUpstream bool, templateObjects *types.Configuration) error {
+	if _, exists := templateObjects.Frontends[defaultFrontendName]; exists {
+		return errors.New("duplicate frontend: " + defaultFrontendName)
+	}
+	if _, exists := templateObjects.Backends[defaultBackendName]; exists {
+		return errors.New("duplicate backend: " + defaultBackendName)
+	}
+
+	service, exists, err := k8sClient.GetService(i.Namespace, i.Spec.Backend.ServiceName)
+	if err != nil {
+		return fmt.Errorf("error while retrieving service information from k8s API %s/%s: %v", i.Namespace, i.Spec.Backend.ServiceName, err)
+	}
+	if !exists {
+		return fmt.Errorf("service not found for %s/%s", i.Namespace, i.Spec.Backend.ServiceName)
+	}
+
+	backend := &types.Backend{
+		Servers: make(map[string]types.Server),
+		LoadBalancer: &types.LoadBalancer{
+			Method: "wrr",
+		},
+	}
+	configureBackend(backend, service)
+
+	backend.Servers, err = p.getServers(k8sClient, service, i.Spec.Backend.ServicePort, 1, serviceUpstream)
+	if err != nil {
+		return err
+	}
+
+	templateObjects.Backends[defaultBackendName] = backend
+	templateObjects.Frontends[defaultFrontendName] = &types.Frontend{
+		EntryPoints:    entryPoints,
+		Backend:        defaultBackendName,
+		PassHostHeader: p.getPassHostHeader(),
+		Priority:       types.FrontendPriorityLowest,
+		Routes: map[string]types.Route{
+			"/": {Rule: defaultFrontendRule},
+		},
+	}
+	return nil
+}
+
+// addTLSConfigurations loads the certificates of the Secrets referenced in the TLS section of the ingress.
+// The configurations are indexed by Secret and entry points, so a Secret shared by several ingresses is loaded once.
+func (p *Provider) addTLSConfigurations(i *v1beta1.Ingress, k8sClient Client, tlsConfigs map[string]*tls.Configuration) error {
+	if len(i.Spec.TLS) == 0 {
+		return nil
+	}
+
+	entryPoints := p.getTLSEntryPoints(i)
+	for _, t := range i.Spec.TLS {
+		if t.SecretName == "" {
+			// SNI routing without a certificate is not supported.
+			log.Debugf("Skipping TLS section without secret in ingress %s/%s", i.Namespace, i.Name)
+			continue
+		}
+
+		configKey := i.Namespace + "/" + t.SecretName + "@" + strings.Join(entryPoints, ",")
+		if _, exists := tlsConfigs[configKey]; exists {
+			continue
+		}
+
+		cert, key, err := loadTLSSecret(i.Namespace, t.SecretName, k8sClient)
+		if err != nil {
+			return err
+		}
+
+		tlsConfigs[configKey] = &tls.Configuration{
+			EntryPoints: entryPoints,
+			Certificate: &tls.Certificate{
+				CertFile: tls.FileOrContent(cert),
+				KeyFile:  tls.FileOrContent(key),
+			},
+		}
+	}
+	return nil
+}
+
+// getTLSEntryPoints returns the entry points of the ingress frontend annotation,
+// or the TLS entry points configured on the provider.
+func (p *Provider) getTLSEntryPoints(i *v1beta1.Ingress) []string {
+	if entryPoints := getEntryPoints(i); len(entryPoints) > 0 {
+		return entryPoints
+	}
+	if len(p.TLSEntryPoints) > 0 {
+		return p.TLSEntryPoints
+	}
+	return []string{defaultTLSEntryPoint}
+}
+
+func loadTLSSecret(namespace, secretName string, k8sClient Client) (string, string, error) {
+	secret, exists, err := k8sClient.GetSecret(namespace, secretName)
+	switch {
+	case err != nil:
+		return "", "", fmt.Errorf("failed to fetch secret %q/%q: %s", namespace, secretName, err)
+	case !exists:
+		return "", "", fmt.Errorf("secret %q/%q not found", namespace, secretName)
+	case secret == nil:
+		return "", "", fmt.Errorf("data for secret %q/%q must not be nil", namespace, secretName)
+	}
+
+	cert, certExists := secret.Data[v1.TLSCertKey]
+	key, keyExists := secret.Data[v1.TLSPrivateKeyKey]
+	var missingEntries []string
+	if !certExists || len(cert) == 0 {
+		missingEntries = append(missingEntries, v1.TLSCertKey)
+	}
+	if !keyExists || len(key) == 0 {
+		missingEntries = append(missingEntries, v1.TLSPrivateKeyKey)
+	}
+	if len(missingEntries) > 0 {
+		return "", "", fmt.Errorf("secret %q/%q is missing the following TLS data entries: %s", namespace, secretName, strings.Join(missingEntries, ", "))
+	}
+
+	return string(cert), string(key), nil
+}
+
+// getTLSConfigurations returns the TLS configurations sorted by key,
+// so that unchanged Secrets produce the same configuration on every event.
+func getTLSConfigurations(tlsConfigs map[string]*tls.Configuration) []*tls.Configuration {
+	if len(tlsConfigs) == 0 {
+		return nil
+	}
+
+	var keys []string
+	for key := range tlsConfigs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var configs []*tls.Configuration
+	for _, key := range keys {
+		configs = append(configs, tlsConfigs[key])
+	}
+	return configs
+}
+
+// getServers returns the servers of the service port: the pod endpoints,
+// or the cluster IP of the service when serviceUpstream is set.
+func (p *Provider) getServers(k8sClient Client, service *v1.Service, servicePort intstr.IntOrString, weight int, serviceUpstream bool) (map[string]types.Server, error) {
+	servers := make(map[string]types.Server)
+
+	protocol := "http"
+	for _, port := range service.Spec.Ports {
+		if !equalPorts(port, servicePort) {
+			continue
+		}
+
+		if port.Port == 443 {
+			protocol = "https"
+		}
+
+		if service.Spec.Type == "ExternalName" {
+			url := protocol + "://" + service.Spec.ExternalName
+			servers[url] = types.Server{
+				URL:    url,
+				Weight: weight,
+			}
+			return servers, nil
+		}
+
+		if serviceUpstream {
+			if len(service.Spec.ClusterIP) > 0 && service.Spec.ClusterIP != v1.ClusterIPNone {
+				url := protocol + "://" + service.Spec.ClusterIP + ":" + strconv.Itoa(int(port.Port))
+				servers[url] = types.Server{
+					URL:    url,
+					Weight: weight,
 				}
+				return servers, nil
+			}
+			log.Warnf("Service %s/%s has no cluster IP, using its endpoints", service.Namespace, service.Name)
+		}
 
-				protocol := "http"
-				for _, port := range service.Spec.Ports {
-					if equalPorts(port, pa.Backend.ServicePort) {
-						if port.Port == 443 {
-							protocol = "https"
-						}
+		endpoints, exists, err := k8sClient.GetEndpoints(service.ObjectMeta.Namespace, service.ObjectMeta.Name)
+		if err != nil {
+			log.Errorf("Error retrieving endpoints %s/%s: %v", service.ObjectMeta.Namespace, service.ObjectMeta.Name, err)
+			return nil, err
+		}
 
-						if service.Spec.Type == "ExternalName" {
-							url := protocol + "://" + service.Spec.ExternalName
-							name := url
-
-							templateObjects.Backends[r.Host+pa.Path].Servers[name] = types.Server{
-								URL:    url,
-								Weight: 1,
-							}
-						} else {
-							endpoints, exists, err := k8sClient.GetEndpoints(service.ObjectMeta.Namespace, service.ObjectMeta.Name)
-							if err != nil {
-								log.Errorf("Error retrieving endpoints %s/%s: %v", service.ObjectMeta.Namespace, service.ObjectMeta.Name, err)
-								return nil, err
-							}
-
-							if !exists {
-								log.Warnf("Endpoints not found for %s/%s", service.ObjectMeta.Namespace, service.ObjectMeta.Name)
-								break
-							}
-
-							if len(endpoints.Subsets) == 0 {
-								log.Warnf("Endpoints not available for %s/%s", service.ObjectMeta.Namespace, service.ObjectMeta.Name)
-								break
-							}
-
-							for _, subset := range endpoints.Subsets {
-								for _, address := range subset.Addresses {
-									url := protocol + "://" + address.IP + ":" + strconv.Itoa(endpointPortNumber(port, subset.Ports))
-									name := url
-									if address.TargetRef != nil && address.TargetRef.Name != "" {
-										name = address.TargetRef.Name
-									}
-									templateObjects.Backends[r.Host+pa.Path].Servers[name] = types.Server{
-										URL:    url,
-										Weight: 1,
-									}
-								}
-							}
-						}
-						break
-					}
+		if !exists {
+			log.Warnf("Endpoints not found for %s/%s", service.ObjectMeta.Namespace, service.ObjectMeta.Name)
+			return servers, nil
+		}
+
+		if len(endpoints.Subsets) == 0 {
+			log.Warnf("Endpoints not available for %s/%s", service.ObjectMeta.Namespace, service.ObjectMeta.Name)
+			return servers, nil
+		}
+
+		for _, subset := range endpoints.Subsets {
+			addresses := subset.Addresses
+			if notReadyAddresses := p.getNotReadyAddresses(endpoints, subset); len(notReadyAddresses) > 0 {
+				addresses = append(append([]v1.EndpointAddress{}, addresses...), notReadyAddresses...)
+			}
+
+			for _, address := range addresses {
+				url := protocol + "://" + address.IP + ":" + strconv.Itoa(endpointPortNumber(port, subset.Ports))
+				name := url
+				if address.TargetRef != nil && address.TargetRef.Name != "" {
+					name = address.TargetRef.Name
+				}
+				servers[name] = types.Server{
+					URL:    url,
+					Weight: weight,
 				}
 			}
 		}
+		return servers, nil
 	}
-	return &templateObjects, nil
+
+	return servers, nil
 }
 
 func getRuleForPath(pa v1beta1.HTTPIngressPath, i *v1beta1.Ingress) string {
@@ -402,6 +757,46 @@ func loadAuthCredentials(namespace, secretName string, k8sClient Client) ([]stri
 	return creds, nil
 }
 
+func getServersTransport(service *v1.Service) *types.ServersTransport {
+	serversTransportAnnotations := []string{
+		types.LabelBackendServersTransportServerName,
+		types.LabelBackendServersTransportInsecure,
+		types.LabelBackendServersTransportRootCAs,
+		types.LabelBackendServersTransportCert,
+		types.LabelBackendServersTransportKey,
+		types.LabelBackendServersTransportMaxIdleConns,
+	}
+
+	found := false
+	for _, annotation := range serversTransportAnnotations {
+		if _, ok := service.Annotations[annotation]; ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	serversTransport := &types.ServersTransport{
+		ServerName:         service.Annotations[types.LabelBackendServersTransportServerName],
+		InsecureSkipVerify: strings.EqualFold(strings.TrimSpace(service.Annotations[types.LabelBackendServersTransportInsecure]), "true"),
+		RootCAs:            provider.SplitAndTrimString(service.Annotations[types.LabelBackendServersTransportRootCAs]),
+		Cert:               service.Annotations[types.LabelBackendServersTransportCert],
+		Key:                service.Annotations[types.LabelBackendServersTransportKey],
+	}
+
+	if maxIdleConnsRaw, ok := service.Annotations[types.LabelBackendServersTransportMaxIdleConns]; ok {
+		maxIdleConns, err := strconv.Atoi(maxIdleConnsRaw)
+		if err != nil {
+			log.Errorf("Error in service %s/%s: failed to parse %q value %q.", service.Namespace, service.Name, types.LabelBackendServersTransportMaxIdleConns, maxIdleConnsRaw)
+		}
+		serversTransport.MaxIdleConnsPerHost = maxIdleConns
+	}
+
+	return serversTransport
+}
+
 func endpointPortNumber(servicePort v1.ServicePort, endpointPorts []v1.EndpointPort) int {
 	if len(endpointPorts) > 0 {
 		//name is optional if there is only one port
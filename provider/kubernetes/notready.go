@@ -0,0 +1,98 @@
+package kubernetes
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Values of the notReadyEndpoints option.
+const (
+	notReadyEndpointsDrop  = "drop"
+	notReadyEndpointsKeep  = "keep"
+	notReadyEndpointsDrain = "drain"
+
+	defaultDrainTimeout = 30 * time.Second
+	drainCheckInterval  = time.Second
+)
+
+func checkNotReadyEndpoints(value string) error {
+	switch value {
+	case "", notReadyEndpointsDrop, notReadyEndpointsKeep, notReadyEndpointsDrain:
+		return nil
+	default:
+		return fmt.Errorf("invalid notReadyEndpoints %q, the value must be %s, %s or %s", value, notReadyEndpointsDrop, notReadyEndpointsKeep, notReadyEndpointsDrain)
+	}
+}
+
+// notReadyTracker remembers since when the endpoint addresses are not ready.
+// An address that is not seen during a load is ready again or gone, and is forgotten.
+type notReadyTracker struct {
+	lock     sync.Mutex
+	current  map[string]time.Time
+	previous map[string]time.Time
+}
+
+func newNotReadyTracker() *notReadyTracker {
+	return &notReadyTracker{
+		current:  make(map[string]time.Time),
+		previous: make(map[string]time.Time),
+	}
+}
+
+// sweep starts a new load of the endpoints.
+func (t *notReadyTracker) sweep() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.previous = t.current
+	t.current = make(map[string]time.Time)
+}
+
+// since returns the time the address was first seen not ready.
+func (t *notReadyTracker) since(key string, now time.Time) time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if since, ok := t.current[key]; ok {
+		return since
+	}
+	since, ok := t.previous[key]
+	if !ok {
+		since = now
+	}
+	t.current[key] = since
+	return since
+}
+
+// getNotReadyAddresses returns the not ready addresses of the subset that stay in rotation.
+func (p *Provider) getNotReadyAddresses(endpoints *v1.Endpoints, subset v1.EndpointSubset) []v1.EndpointAddress {
+	switch p.NotReadyEndpoints {
+	case notReadyEndpointsKeep:
+		return subset.NotReadyAddresses
+	case notReadyEndpointsDrain:
+		// Without tracker, the addresses have just been seen not ready.
+		if p.notReady == nil {
+			return subset.NotReadyAddresses
+		}
+
+		drainTimeout := time.Duration(p.DrainTimeout)
+		if drainTimeout <= 0 {
+			drainTimeout = defaultDrainTimeout
+		}
+
+		now := time.Now()
+		var addresses []v1.EndpointAddress
+		for _, address := range subset.NotReadyAddresses {
+			since := p.notReady.since(endpoints.Namespace+"/"+endpoints.Name+"/"+address.IP, now)
+			if now.Sub(since) < drainTimeout {
+				addresses = append(addresses, address)
+			}
+		}
+		return addresses
+	default:
+		return nil
+	}
+}
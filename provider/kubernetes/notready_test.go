@@ -0,0 +1,152 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/util/intstr"
+)
+
+func TestNotReadyEndpoints(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "service1",
+			UID:       "1",
+			Namespace: "testing",
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP: "10.0.0.1",
+			Ports:     []v1.ServicePort{{Port: 80}},
+		},
+	}
+	client := clientMock{
+		services: []*v1.Service{service},
+		endpoints: []*v1.Endpoints{
+			{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "service1",
+					UID:       "1",
+					Namespace: "testing",
+				},
+				Subsets: []v1.EndpointSubset{
+					{
+						Addresses: []v1.EndpointAddress{{IP: "10.10.0.1"}},
+						NotReadyAddresses: []v1.EndpointAddress{
+							{IP: "10.10.0.2"},
+							{IP: "10.10.0.3"},
+						},
+						Ports: []v1.EndpointPort{{Port: 8080}},
+					},
+				},
+			},
+		},
+		watchChan: make(chan interface{}),
+	}
+
+	testCases := []struct {
+		desc              string
+		notReadyEndpoints string
+		drainTimeout      flaeg.Duration
+		notReadySince     map[string]time.Time
+		expected          map[string]types.Server
+	}{
+		{
+			desc: "dropped by default",
+			expected: map[string]types.Server{
+				"http://10.10.0.1:8080": {URL: "http://10.10.0.1:8080", Weight: 1},
+			},
+		},
+		{
+			desc:              "drop",
+			notReadyEndpoints: notReadyEndpointsDrop,
+			expected: map[string]types.Server{
+				"http://10.10.0.1:8080": {URL: "http://10.10.0.1:8080", Weight: 1},
+			},
+		},
+		{
+			desc:              "keep",
+			notReadyEndpoints: notReadyEndpointsKeep,
+			expected: map[string]types.Server{
+				"http://10.10.0.1:8080": {URL: "http://10.10.0.1:8080", Weight: 1},
+				"http://10.10.0.2:8080": {URL: "http://10.10.0.2:8080", Weight: 1},
+				"http://10.10.0.3:8080": {URL: "http://10.10.0.3:8080", Weight: 1},
+			},
+		},
+		{
+			desc:              "drain with the default timeout",
+			notReadyEndpoints: notReadyEndpointsDrain,
+			notReadySince: map[string]time.Time{
+				"testing/service1/10.10.0.2": time.Now().Add(-time.Minute),
+			},
+			expected: map[string]types.Server{
+				"http://10.10.0.1:8080": {URL: "http://10.10.0.1:8080", Weight: 1},
+				"http://10.10.0.3:8080": {URL: "http://10.10.0.3:8080", Weight: 1},
+			},
+		},
+		{
+			desc:              "drain with a timeout",
+			notReadyEndpoints: notReadyEndpointsDrain,
+			drainTimeout:      flaeg.Duration(2 * time.Minute),
+			notReadySince: map[string]time.Time{
+				"testing/service1/10.10.0.2": time.Now().Add(-time.Minute),
+				"testing/service1/10.10.0.3": time.Now().Add(-time.Hour),
+			},
+			expected: map[string]types.Server{
+				"http://10.10.0.1:8080": {URL: "http://10.10.0.1:8080", Weight: 1},
+				"http://10.10.0.2:8080": {URL: "http://10.10.0.2:8080", Weight: 1},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			provider := Provider{
+				NotReadyEndpoints: test.notReadyEndpoints,
+				DrainTimeout:      test.drainTimeout,
+			}
+			if test.notReadyEndpoints == notReadyEndpointsDrain {
+				provider.notReady = newNotReadyTracker()
+				provider.notReady.current = test.notReadySince
+				// The addresses are remembered across the loads.
+				provider.notReady.sweep()
+			}
+
+			actual, err := provider.getServers(client, service, intstr.FromInt(80), 1, false)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestNotReadyTracker(t *testing.T) {
+	tracker := newNotReadyTracker()
+	start := time.Now()
+
+	assert.Equal(t, start, tracker.since("foo", start))
+	assert.Equal(t, start, tracker.since("bar", start))
+
+	// An address still not ready keeps its first time.
+	tracker.sweep()
+	assert.Equal(t, start, tracker.since("foo", start.Add(time.Second)))
+
+	// An address which was not seen during the previous load is forgotten.
+	tracker.sweep()
+	assert.Equal(t, start.Add(2*time.Second), tracker.since("bar", start.Add(2*time.Second)))
+	assert.Equal(t, start, tracker.since("foo", start.Add(2*time.Second)))
+}
+
+func TestCheckNotReadyEndpoints(t *testing.T) {
+	for _, value := range []string{"", notReadyEndpointsDrop, notReadyEndpointsKeep, notReadyEndpointsDrain} {
+		assert.NoError(t, checkNotReadyEndpoints(value))
+	}
+	assert.Error(t, checkNotReadyEndpoints("ignore"))
+}
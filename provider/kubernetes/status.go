@@ -0,0 +1,180 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/containous/traefik/log"
+	kubeerror "k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const (
+	statusUpdateMaxElapsedTime = 10 * time.Second
+	// leaderCheckInterval is the interval between two checks of the cluster leadership,
+	// so that a newly elected leader publishes the status without waiting for an event.
+	leaderCheckInterval = 10 * time.Second
+)
+
+// IngressEndpoint holds the address published in the status of the Ingresses.
+type IngressEndpoint struct {
+	IP               string `description:"IP used for Kubernetes Ingress endpoints" export:"true"`
+	Hostname         string `description:"Hostname used for Kubernetes Ingress endpoints" export:"true"`
+	PublishedService string `description:"Published Kubernetes Service to copy status from (namespace/name)" export:"true"`
+}
+
+func (e *IngressEndpoint) validate() error {
+	if len(e.PublishedService) > 0 {
+		if len(e.IP) > 0 || len(e.Hostname) > 0 {
+			return errors.New("publishedService can't be used with ip or hostname")
+		}
+		if parts := strings.Split(e.PublishedService, "/"); len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return fmt.Errorf("invalid publishedService %q, the format is namespace/name", e.PublishedService)
+		}
+		return nil
+	}
+
+	if len(e.IP) == 0 && len(e.Hostname) == 0 {
+		return errors.New("ip, hostname or publishedService must be set")
+	}
+	return nil
+}
+
+// SetLeaderCheck sets the function telling whether this instance is the cluster leader.
+// Only the leader publishes the Ingresses status.
+func (p *Provider) SetLeaderCheck(isLeader func() bool) {
+	p.isLeader = isLeader
+}
+
+// statusPublisher publishes the Ingresses status in its own goroutine,
+// so that a slow or failing API server doesn't delay the configuration updates.
+// The updates requested while one is running are coalesced into a single one.
+type statusPublisher struct {
+	provider            *Provider
+	client              Client
+	requests            chan struct{}
+	leaderCheckInterval time.Duration
+}
+
+func newStatusPublisher(p *Provider, k8sClient Client) *statusPublisher {
+	return &statusPublisher{
+		provider:            p,
+		client:              k8sClient,
+		requests:            make(chan struct{}, 1),
+		leaderCheckInterval: leaderCheckInterval,
+	}
+}
+
+// request asks for a status update, without blocking.
+func (s *statusPublisher) request() {
+	select {
+	case s.requests <- struct{}{}:
+	default:
+		// an update is already pending
+	}
+}
+
+// run publishes the status when requested, and when this instance becomes the cluster leader.
+func (s *statusPublisher) run(stop chan bool) {
+	ticker := time.NewTicker(s.leaderCheckInterval)
+	defer ticker.Stop()
+
+	wasLeader := false
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.requests:
+			s.provider.updateIngressStatuses(s.client)
+		case <-ticker.C:
+			isLeader := s.provider.isLeader == nil || s.provider.isLeader()
+			if isLeader && !wasLeader {
+				s.provider.updateIngressStatuses(s.client)
+			}
+			wasLeader = isLeader
+		}
+	}
+}
+
+// updateIngressStatuses publishes the ingress endpoint in the status of the Ingresses handled by Traefik.
+func (p *Provider) updateIngressStatuses(k8sClient Client) {
+	if p.IngressEndpoint == nil {
+		return
+	}
+	if p.isLeader != nil && !p.isLeader() {
+		log.Debug("Skipping Kubernetes Ingress status update: not the cluster leader")
+		return
+	}
+
+	addresses, err := p.getIngressAddresses(k8sClient)
+	if err != nil {
+		log.Errorf("Error publishing the Kubernetes Ingress status: %s", err)
+		return
+	}
+
+	for _, i := range k8sClient.GetIngresses() {
+		if !shouldProcessIngress(i.Annotations[annotationKubernetesIngressClass]) {
+			continue
+		}
+		if reflect.DeepEqual(i.Status.LoadBalancer.Ingress, addresses) {
+			continue
+		}
+
+		if err := updateIngressStatus(k8sClient, i.Namespace, i.Name, addresses); err != nil {
+			log.Errorf("Error updating the status of ingress %s/%s: %s", i.Namespace, i.Name, err)
+		}
+	}
+}
+
+// getIngressAddresses returns the configured IP and hostname,
+// or the load balancer addresses of the published service.
+func (p *Provider) getIngressAddresses(k8sClient Client) ([]v1.LoadBalancerIngress, error) {
+	if len(p.IngressEndpoint.PublishedService) == 0 {
+		return []v1.LoadBalancerIngress{{IP: p.IngressEndpoint.IP, Hostname: p.IngressEndpoint.Hostname}}, nil
+	}
+
+	parts := strings.SplitN(p.IngressEndpoint.PublishedService, "/", 2)
+	service, exists, err := k8sClient.GetService(parts[0], parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch published service %s: %s", p.IngressEndpoint.PublishedService, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("published service %s not found", p.IngressEndpoint.PublishedService)
+	}
+
+	if len(service.Status.LoadBalancer.Ingress) > 0 {
+		return service.Status.LoadBalancer.Ingress, nil
+	}
+
+	var addresses []v1.LoadBalancerIngress
+	for _, ip := range service.Spec.ExternalIPs {
+		addresses = append(addresses, v1.LoadBalancerIngress{IP: ip})
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("published service %s has no load balancer ingress nor external IP", p.IngressEndpoint.PublishedService)
+	}
+	return addresses, nil
+}
+
+// updateIngressStatus retries the update of the status, unless the ingress is gone or the update is forbidden.
+func updateIngressStatus(k8sClient Client, namespace, name string, addresses []v1.LoadBalancerIngress) error {
+	operation := func() error {
+		err := k8sClient.UpdateIngressStatus(namespace, name, addresses)
+		if kubeerror.IsNotFound(err) || kubeerror.IsForbidden(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+
+	notify := func(err error, time time.Duration) {
+		log.Debugf("Failed to update the status of ingress %s/%s: %s; retrying in %s", namespace, name, err, time)
+	}
+
+	ebo := backoff.NewExponentialBackOff()
+	ebo.MaxElapsedTime = statusUpdateMaxElapsedTime
+	return backoff.RetryNotify(operation, ebo, notify)
+}
@@ -0,0 +1,356 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubeerror "k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/rest"
+)
+
+func TestUpdateIngressStatuses(t *testing.T) {
+	ingresses := []*v1beta1.Ingress{
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "testing"},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        "other",
+				Namespace:   "testing",
+				Annotations: map[string]string{annotationKubernetesIngressClass: "nginx"},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "uptodate", Namespace: "testing"},
+			Status: v1beta1.IngressStatus{
+				LoadBalancer: v1.LoadBalancerStatus{
+					Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+				},
+			},
+		},
+	}
+	services := []*v1.Service{
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "traefik", Namespace: "kube-system"},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{
+					Ingress: []v1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+				},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "traefik-external", Namespace: "kube-system"},
+			Spec: v1.ServiceSpec{
+				ExternalIPs: []string{"5.6.7.8"},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "traefik-internal", Namespace: "kube-system"},
+		},
+	}
+
+	testCases := []struct {
+		desc            string
+		ingressEndpoint *IngressEndpoint
+		isLeader        func() bool
+		expected        map[string][]v1.LoadBalancerIngress
+	}{
+		{
+			desc:     "no ingress endpoint",
+			expected: map[string][]v1.LoadBalancerIngress{},
+		},
+		{
+			desc:            "IP",
+			ingressEndpoint: &IngressEndpoint{IP: "1.2.3.4"},
+			expected: map[string][]v1.LoadBalancerIngress{
+				"testing/foo": {{IP: "1.2.3.4"}},
+			},
+		},
+		{
+			desc:            "IP and hostname",
+			ingressEndpoint: &IngressEndpoint{IP: "1.2.3.4", Hostname: "traefik.example.com"},
+			expected: map[string][]v1.LoadBalancerIngress{
+				"testing/foo":      {{IP: "1.2.3.4", Hostname: "traefik.example.com"}},
+				"testing/uptodate": {{IP: "1.2.3.4", Hostname: "traefik.example.com"}},
+			},
+		},
+		{
+			desc:            "published service with a load balancer",
+			ingressEndpoint: &IngressEndpoint{PublishedService: "kube-system/traefik"},
+			expected: map[string][]v1.LoadBalancerIngress{
+				"testing/foo":      {{Hostname: "lb.example.com"}},
+				"testing/uptodate": {{Hostname: "lb.example.com"}},
+			},
+		},
+		{
+			desc:            "published service with external IPs",
+			ingressEndpoint: &IngressEndpoint{PublishedService: "kube-system/traefik-external"},
+			expected: map[string][]v1.LoadBalancerIngress{
+				"testing/foo":      {{IP: "5.6.7.8"}},
+				"testing/uptodate": {{IP: "5.6.7.8"}},
+			},
+		},
+		{
+			desc:            "published service without address",
+			ingressEndpoint: &IngressEndpoint{PublishedService: "kube-system/traefik-internal"},
+			expected:        map[string][]v1.LoadBalancerIngress{},
+		},
+		{
+			desc:            "missing published service",
+			ingressEndpoint: &IngressEndpoint{PublishedService: "kube-system/missing"},
+			expected:        map[string][]v1.LoadBalancerIngress{},
+		},
+		{
+			desc:            "cluster leader",
+			ingressEndpoint: &IngressEndpoint{IP: "1.2.3.4"},
+			isLeader:        func() bool { return true },
+			expected: map[string][]v1.LoadBalancerIngress{
+				"testing/foo": {{IP: "1.2.3.4"}},
+			},
+		},
+		{
+			desc:            "not the cluster leader",
+			ingressEndpoint: &IngressEndpoint{IP: "1.2.3.4"},
+			isLeader:        func() bool { return false },
+			expected:        map[string][]v1.LoadBalancerIngress{},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			actual := make(map[string][]v1.LoadBalancerIngress)
+			client := clientMock{
+				ingresses: ingresses,
+				services:  services,
+				updateIngressStatus: func(namespace, name string, addresses []v1.LoadBalancerIngress) error {
+					actual[namespace+"/"+name] = addresses
+					return nil
+				},
+			}
+			provider := Provider{IngressEndpoint: test.ingressEndpoint}
+			provider.SetLeaderCheck(test.isLeader)
+
+			provider.updateIngressStatuses(client)
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestUpdateIngressStatusRetry(t *testing.T) {
+	groupResource := unversioned.GroupResource{Group: "extensions", Resource: "ingresses"}
+
+	testCases := []struct {
+		desc          string
+		errors        []error
+		expectedCalls int
+		expectedError bool
+	}{
+		{
+			desc:          "success",
+			expectedCalls: 1,
+		},
+		{
+			desc:          "conflict is retried",
+			errors:        []error{kubeerror.NewConflict(groupResource, "foo", errors.New("modified"))},
+			expectedCalls: 2,
+		},
+		{
+			desc:          "not found is not retried",
+			errors:        []error{kubeerror.NewNotFound(groupResource, "foo")},
+			expectedCalls: 1,
+			expectedError: true,
+		},
+		{
+			desc:          "forbidden is not retried",
+			errors:        []error{kubeerror.NewForbidden(groupResource, "foo", errors.New("denied"))},
+			expectedCalls: 1,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+			client := clientMock{
+				updateIngressStatus: func(namespace, name string, addresses []v1.LoadBalancerIngress) error {
+					calls++
+					if calls <= len(test.errors) {
+						return test.errors[calls-1]
+					}
+					return nil
+				},
+			}
+
+			err := updateIngressStatus(client, "testing", "foo", []v1.LoadBalancerIngress{{IP: "1.2.3.4"}})
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedCalls, calls)
+		})
+	}
+}
+
+func TestStatusPublisher(t *testing.T) {
+	release := make(chan struct{})
+	updates := make(chan string, 10)
+	client := clientMock{
+		ingresses: []*v1beta1.Ingress{{ObjectMeta: v1.ObjectMeta{Name: "foo", Namespace: "testing"}}},
+		updateIngressStatus: func(namespace, name string, addresses []v1.LoadBalancerIngress) error {
+			// a slow API server
+			<-release
+			updates <- namespace + "/" + name
+			return nil
+		},
+	}
+
+	var leader int32
+	provider := &Provider{IngressEndpoint: &IngressEndpoint{IP: "1.2.3.4"}}
+	provider.SetLeaderCheck(func() bool { return atomic.LoadInt32(&leader) == 1 })
+
+	publisher := newStatusPublisher(provider, client)
+	publisher.leaderCheckInterval = 10 * time.Millisecond
+	stop := make(chan bool)
+	done := make(chan struct{})
+	go func() {
+		publisher.run(stop)
+		close(done)
+	}()
+
+	// the requests don't wait for the updates
+	for i := 0; i < 10; i++ {
+		publisher.request()
+	}
+
+	// not the leader yet
+	close(release)
+	select {
+	case update := <-updates:
+		t.Fatalf("unexpected status update %s", update)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// a newly elected leader publishes the status without any request
+	atomic.StoreInt32(&leader, 1)
+	select {
+	case update := <-updates:
+		assert.Equal(t, "testing/foo", update)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no status update after the leader election")
+	}
+
+	stop <- true
+	<-done
+}
+
+func TestIngressEndpointValidate(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		ingressEndpoint IngressEndpoint
+		expectedError   bool
+	}{
+		{
+			desc:            "IP",
+			ingressEndpoint: IngressEndpoint{IP: "1.2.3.4"},
+		},
+		{
+			desc:            "hostname",
+			ingressEndpoint: IngressEndpoint{Hostname: "traefik.example.com"},
+		},
+		{
+			desc:            "published service",
+			ingressEndpoint: IngressEndpoint{PublishedService: "kube-system/traefik"},
+		},
+		{
+			desc:          "empty",
+			expectedError: true,
+		},
+		{
+			desc:            "published service and IP",
+			ingressEndpoint: IngressEndpoint{IP: "1.2.3.4", PublishedService: "kube-system/traefik"},
+			expectedError:   true,
+		},
+		{
+			desc:            "published service without namespace",
+			ingressEndpoint: IngressEndpoint{PublishedService: "traefik"},
+			expectedError:   true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.ingressEndpoint.validate()
+			if test.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpdateIngressStatusClient(t *testing.T) {
+	var mu sync.Mutex
+	var updated *v1beta1.Ingress
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/extensions/v1beta1/namespaces/testing/ingresses/foo":
+			w.Write([]byte(`{"apiVersion": "extensions/v1beta1", "kind": "Ingress", "metadata": {"name": "foo", "namespace": "testing", "resourceVersion": "42"}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/apis/extensions/v1beta1/namespaces/testing/ingresses/foo/status":
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+
+			mu.Lock()
+			updated = &v1beta1.Ingress{}
+			err = json.Unmarshal(body, updated)
+			mu.Unlock()
+			require.NoError(t, err)
+
+			w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"apiVersion": "v1", "kind": "Status", "status": "Failure", "reason": "NotFound", "code": 404}`))
+		}
+	}))
+	defer ts.Close()
+
+	client, err := createClientFromConfig(&rest.Config{Host: ts.URL})
+	require.NoError(t, err)
+
+	err = client.UpdateIngressStatus("testing", "foo", []v1.LoadBalancerIngress{{IP: "1.2.3.4"}})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotNil(t, updated)
+	assert.Equal(t, "42", updated.ResourceVersion)
+	assert.Equal(t, []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}, updated.Status.LoadBalancer.Ingress)
+
+	err = client.UpdateIngressStatus("testing", "missing", []v1.LoadBalancerIngress{{IP: "1.2.3.4"}})
+	assert.True(t, kubeerror.IsNotFound(err))
+}
@@ -80,7 +80,7 @@ func (p *Provider) watchKv(configurationChan chan<- types.ConfigMessage, prefix
 				configuration := p.loadConfig()
 				if configuration != nil {
 					configurationChan <- types.ConfigMessage{
-						ProviderName:  string(p.storeType),
+						ProviderName:  p.GetProviderName(string(p.storeType)),
 						Configuration: configuration,
 					}
 				}
@@ -115,7 +115,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 		}
 		configuration := p.loadConfig()
 		configurationChan <- types.ConfigMessage{
-			ProviderName:  string(p.storeType),
+			ProviderName:  p.GetProviderName(string(p.storeType)),
 			Configuration: configuration,
 		}
 		return nil
@@ -0,0 +1,181 @@
+package label
+
+import (
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// DefaultLoadBalancerMethod is the load balancer method used when only the stickiness is set.
+const (
+	DefaultLoadBalancerMethod = "wrr"
+
+	serversTransportPrefix       = types.LabelPrefix + "backend.serverstransport."
+	retryPrefix                  = types.LabelPrefix + "backend.retry."
+	circuitBreakerFallbackPrefix = types.LabelPrefix + "backend.circuitbreaker.fallback."
+)
+
+// GetBackend builds the backend options from the traefik.backend.* labels.
+// The servers are left to the provider.
+func GetBackend(labels map[string]string) *types.Backend {
+	return &types.Backend{
+		CircuitBreaker:   GetCircuitBreaker(labels),
+		LoadBalancer:     GetLoadBalancer(labels),
+		MaxConn:          GetMaxConn(labels),
+		HealthCheck:      GetHealthCheck(labels),
+		ServersTransport: GetServersTransport(labels),
+		Retry:            GetRetry(labels),
+	}
+}
+
+// GetCircuitBreaker builds the circuit breaker from the traefik.backend.circuitbreaker.expression label,
+// or from the legacy traefik.backend.circuitbreaker label, with its durations and its fallback.
+func GetCircuitBreaker(labels map[string]string) *types.CircuitBreaker {
+	expression := GetStringValue(labels, types.LabelBackendCircuitbreakerExpression, "")
+	if len(expression) == 0 {
+		expression = GetStringValue(labels, types.LabelTraefikBackendCircuitbreaker, "")
+	}
+	if len(expression) == 0 {
+		return nil
+	}
+
+	circuitBreaker := &types.CircuitBreaker{Expression: expression}
+
+	durations := map[string]*flaeg.Duration{
+		types.LabelBackendCircuitbreakerFallbackDuration: &circuitBreaker.FallbackDuration,
+		types.LabelBackendCircuitbreakerRecoveryDuration: &circuitBreaker.RecoveryDuration,
+		types.LabelBackendCircuitbreakerCheckPeriod:      &circuitBreaker.CheckPeriod,
+	}
+	for labelName, duration := range durations {
+		if err := duration.Set(GetStringValue(labels, labelName, "0")); err != nil {
+			log.Errorf("Invalid %s: %v", labelName, err)
+			return nil
+		}
+	}
+
+	if HasPrefix(labels, circuitBreakerFallbackPrefix) {
+		circuitBreaker.Fallback = &types.CircuitBreakerFallback{
+			StatusCode:  GetIntValue(labels, types.LabelBackendCircuitbreakerFallbackStatusCode, 0),
+			ContentType: GetStringValue(labels, types.LabelBackendCircuitbreakerFallbackContentType, ""),
+			Body:        GetStringValue(labels, types.LabelBackendCircuitbreakerFallbackBody, ""),
+			Backend:     GetStringValue(labels, types.LabelBackendCircuitbreakerFallbackBackend, ""),
+		}
+	}
+
+	return circuitBreaker
+}
+
+// GetLoadBalancer builds the load balancer from the traefik.backend.loadbalancer.* labels.
+// It returns nil if none of these labels is set.
+func GetLoadBalancer(labels map[string]string) *types.LoadBalancer {
+	if !Has(labels, types.LabelBackendLoadbalancerMethod) &&
+		!Has(labels, types.LabelBackendLoadbalancerSticky) &&
+		!Has(labels, types.LabelBackendLoadbalancerStickiness) &&
+		!Has(labels, types.LabelBackendLoadbalancerStickinessCookieName) {
+		return nil
+	}
+
+	loadBalancer := &types.LoadBalancer{
+		Method: GetStringValue(labels, types.LabelBackendLoadbalancerMethod, DefaultLoadBalancerMethod),
+		Sticky: GetBoolValue(labels, types.LabelBackendLoadbalancerSticky, false),
+		HashOn: GetStringValue(labels, types.LabelBackendLoadbalancerHashOn, ""),
+	}
+	if loadBalancer.Sticky {
+		log.Warnf("Deprecated configuration found: %s. Please use %s.", types.LabelBackendLoadbalancerSticky, types.LabelBackendLoadbalancerStickiness)
+	}
+
+	if GetBoolValue(labels, types.LabelBackendLoadbalancerStickiness, false) {
+		loadBalancer.Stickiness = &types.Stickiness{
+			CookieName:  GetStringValue(labels, types.LabelBackendLoadbalancerStickinessCookieName, ""),
+			Secure:      GetBoolValue(labels, types.LabelBackendLoadbalancerStickinessSecure, false),
+			HTTPOnly:    GetBoolValue(labels, types.LabelBackendLoadbalancerStickinessHTTPOnly, false),
+			SameSite:    GetStringValue(labels, types.LabelBackendLoadbalancerStickinessSameSite, ""),
+			MaxAge:      GetIntValue(labels, types.LabelBackendLoadbalancerStickinessMaxAge, 0),
+			Domain:      GetStringValue(labels, types.LabelBackendLoadbalancerStickinessDomain, ""),
+			Obfuscation: GetStringValue(labels, types.LabelBackendLoadbalancerStickinessObfuscation, ""),
+			Secret:      GetStringValue(labels, types.LabelBackendLoadbalancerStickinessSecret, ""),
+			Mode:        GetStringValue(labels, types.LabelBackendLoadbalancerStickinessMode, ""),
+			HashOn:      GetStringValue(labels, types.LabelBackendLoadbalancerStickinessHashOn, ""),
+		}
+	}
+	return loadBalancer
+}
+
+// GetMaxConn builds the maximum connections limit from the traefik.backend.maxconn.* labels.
+// Both the amount and the extractor function are required.
+func GetMaxConn(labels map[string]string) *types.MaxConn {
+	if !Has(labels, types.LabelBackendMaxconnAmount) || !Has(labels, types.LabelBackendMaxconnExtractorfunc) {
+		return nil
+	}
+
+	amount := GetInt64Value(labels, types.LabelBackendMaxconnAmount, 0)
+	if amount <= 0 {
+		log.Errorf("Invalid %s: %q", types.LabelBackendMaxconnAmount, labels[types.LabelBackendMaxconnAmount])
+		return nil
+	}
+
+	return &types.MaxConn{
+		Amount:        amount,
+		ExtractorFunc: GetStringValue(labels, types.LabelBackendMaxconnExtractorfunc, ""),
+	}
+}
+
+// GetHealthCheck builds the health check from the traefik.backend.healthcheck.* labels.
+// The path is required.
+func GetHealthCheck(labels map[string]string) *types.HealthCheck {
+	path := GetStringValue(labels, types.LabelBackendHealthcheckPath, "")
+	if len(path) == 0 {
+		return nil
+	}
+
+	return &types.HealthCheck{
+		Path:     path,
+		Port:     GetIntValue(labels, types.LabelBackendHealthcheckPort, 0),
+		Interval: GetStringValue(labels, types.LabelBackendHealthcheckInterval, ""),
+	}
+}
+
+// GetServersTransport builds the transport used to reach the servers from the traefik.backend.serverstransport.* labels.
+func GetServersTransport(labels map[string]string) *types.ServersTransport {
+	if !HasPrefix(labels, serversTransportPrefix) {
+		return nil
+	}
+
+	return &types.ServersTransport{
+		ServerName:          GetStringValue(labels, types.LabelBackendServersTransportServerName, ""),
+		InsecureSkipVerify:  GetBoolValue(labels, types.LabelBackendServersTransportInsecure, false),
+		RootCAs:             GetSliceStringValue(labels, types.LabelBackendServersTransportRootCAs),
+		Cert:                GetStringValue(labels, types.LabelBackendServersTransportCert, ""),
+		Key:                 GetStringValue(labels, types.LabelBackendServersTransportKey, ""),
+		MaxIdleConnsPerHost: GetIntValue(labels, types.LabelBackendServersTransportMaxIdleConns, 0),
+	}
+}
+
+// GetRetry builds the retry policy from the traefik.backend.retry.* labels.
+// It returns nil if one of the durations is invalid.
+func GetRetry(labels map[string]string) *types.Retry {
+	if !HasPrefix(labels, retryPrefix) {
+		return nil
+	}
+
+	retry := &types.Retry{
+		Attempts:    GetIntValue(labels, types.LabelBackendRetryAttempts, 0),
+		StatusCodes: GetSliceStringValue(labels, types.LabelBackendRetryStatusCodes),
+		Methods:     GetSliceStringValue(labels, types.LabelBackendRetryMethods),
+		MaxBodySize: GetInt64Value(labels, types.LabelBackendRetryMaxBodySize, 0),
+	}
+
+	durations := map[string]*flaeg.Duration{
+		types.LabelBackendRetryInitialInterval: &retry.InitialInterval,
+		types.LabelBackendRetryMaxInterval:     &retry.MaxInterval,
+		types.LabelBackendRetryMaxDuration:     &retry.MaxDuration,
+	}
+	for labelName, duration := range durations {
+		if err := duration.Set(GetStringValue(labels, labelName, "0")); err != nil {
+			log.Errorf("Invalid %s: %v", labelName, err)
+			return nil
+		}
+	}
+
+	return retry
+}
@@ -0,0 +1,309 @@
+package label
+
+import (
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBackend(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.Backend
+	}{
+		{
+			desc:     "no labels",
+			labels:   map[string]string{},
+			expected: &types.Backend{},
+		},
+		{
+			desc: "all labels",
+			labels: map[string]string{
+				types.LabelBackendCircuitbreakerExpression:         "NetworkErrorRatio() > 0.5",
+				types.LabelBackendLoadbalancerMethod:               "drr",
+				types.LabelBackendLoadbalancerStickiness:           "true",
+				types.LabelBackendLoadbalancerStickinessCookieName: "chocolate",
+				types.LabelBackendMaxconnAmount:                    "10",
+				types.LabelBackendMaxconnExtractorfunc:             "client.ip",
+				types.LabelBackendHealthcheckPath:                  "/health",
+				types.LabelBackendHealthcheckPort:                  "8080",
+				types.LabelBackendHealthcheckInterval:              "5s",
+				types.LabelBackendServersTransportServerName:       "foo.example.com",
+				types.LabelBackendServersTransportInsecure:         "true",
+				types.LabelBackendServersTransportRootCAs:          "ca1.pem, ca2.pem",
+				types.LabelBackendServersTransportMaxIdleConns:     "42",
+			},
+			expected: &types.Backend{
+				CircuitBreaker: &types.CircuitBreaker{Expression: "NetworkErrorRatio() > 0.5"},
+				LoadBalancer: &types.LoadBalancer{
+					Method:     "drr",
+					Stickiness: &types.Stickiness{CookieName: "chocolate"},
+				},
+				MaxConn:     &types.MaxConn{Amount: 10, ExtractorFunc: "client.ip"},
+				HealthCheck: &types.HealthCheck{Path: "/health", Port: 8080, Interval: "5s"},
+				ServersTransport: &types.ServersTransport{
+					ServerName:          "foo.example.com",
+					InsecureSkipVerify:  true,
+					RootCAs:             []string{"ca1.pem", "ca2.pem"},
+					MaxIdleConnsPerHost: 42,
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetBackend(test.labels))
+		})
+	}
+}
+
+func TestGetCircuitBreaker(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.CircuitBreaker
+	}{
+		{
+			desc: "no labels",
+		},
+		{
+			desc:     "legacy expression",
+			labels:   map[string]string{types.LabelTraefikBackendCircuitbreaker: "NetworkErrorRatio() > 0.5"},
+			expected: &types.CircuitBreaker{Expression: "NetworkErrorRatio() > 0.5"},
+		},
+		{
+			desc: "fallback without expression",
+			labels: map[string]string{
+				types.LabelBackendCircuitbreakerFallbackStatusCode: "503",
+			},
+		},
+		{
+			desc: "durations and fallback",
+			labels: map[string]string{
+				types.LabelBackendCircuitbreakerExpression:          "NetworkErrorRatio() > 0.5",
+				types.LabelBackendCircuitbreakerFallbackDuration:    "30s",
+				types.LabelBackendCircuitbreakerRecoveryDuration:    "1m",
+				types.LabelBackendCircuitbreakerCheckPeriod:         "200ms",
+				types.LabelBackendCircuitbreakerFallbackStatusCode:  "503",
+				types.LabelBackendCircuitbreakerFallbackContentType: "application/json",
+				types.LabelBackendCircuitbreakerFallbackBody:        `{"error":"unavailable"}`,
+				types.LabelBackendCircuitbreakerFallbackBackend:     "backend-fallback",
+			},
+			expected: &types.CircuitBreaker{
+				Expression:       "NetworkErrorRatio() > 0.5",
+				FallbackDuration: flaeg.Duration(30 * time.Second),
+				RecoveryDuration: flaeg.Duration(time.Minute),
+				CheckPeriod:      flaeg.Duration(200 * time.Millisecond),
+				Fallback: &types.CircuitBreakerFallback{
+					StatusCode:  503,
+					ContentType: "application/json",
+					Body:        `{"error":"unavailable"}`,
+					Backend:     "backend-fallback",
+				},
+			},
+		},
+		{
+			desc: "invalid duration",
+			labels: map[string]string{
+				types.LabelBackendCircuitbreakerExpression:       "NetworkErrorRatio() > 0.5",
+				types.LabelBackendCircuitbreakerFallbackDuration: "soon",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetCircuitBreaker(test.labels))
+		})
+	}
+}
+
+func TestGetLoadBalancer(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.LoadBalancer
+	}{
+		{
+			desc: "no labels",
+		},
+		{
+			desc:     "method",
+			labels:   map[string]string{types.LabelBackendLoadbalancerMethod: "drr"},
+			expected: &types.LoadBalancer{Method: "drr"},
+		},
+		{
+			desc: "ring hash",
+			labels: map[string]string{
+				types.LabelBackendLoadbalancerMethod: "ringhash",
+				types.LabelBackendLoadbalancerHashOn: "request.cookie.session",
+			},
+			expected: &types.LoadBalancer{Method: "ringhash", HashOn: "request.cookie.session"},
+		},
+		{
+			desc:     "deprecated sticky",
+			labels:   map[string]string{types.LabelBackendLoadbalancerSticky: "true"},
+			expected: &types.LoadBalancer{Method: "wrr", Sticky: true},
+		},
+		{
+			desc:     "stickiness disabled",
+			labels:   map[string]string{types.LabelBackendLoadbalancerStickiness: "false"},
+			expected: &types.LoadBalancer{Method: "wrr"},
+		},
+		{
+			desc:     "stickiness",
+			labels:   map[string]string{types.LabelBackendLoadbalancerStickiness: "true"},
+			expected: &types.LoadBalancer{Method: "wrr", Stickiness: &types.Stickiness{}},
+		},
+		{
+			desc: "stickiness options",
+			labels: map[string]string{
+				types.LabelBackendLoadbalancerStickiness:            "true",
+				types.LabelBackendLoadbalancerStickinessSecure:      "true",
+				types.LabelBackendLoadbalancerStickinessHTTPOnly:    "true",
+				types.LabelBackendLoadbalancerStickinessSameSite:    "strict",
+				types.LabelBackendLoadbalancerStickinessMaxAge:      "3600",
+				types.LabelBackendLoadbalancerStickinessDomain:      "example.com",
+				types.LabelBackendLoadbalancerStickinessObfuscation: "hmac",
+				types.LabelBackendLoadbalancerStickinessSecret:      "secret",
+			},
+			expected: &types.LoadBalancer{
+				Method: "wrr",
+				Stickiness: &types.Stickiness{
+					Secure:      true,
+					HTTPOnly:    true,
+					SameSite:    "strict",
+					MaxAge:      3600,
+					Domain:      "example.com",
+					Obfuscation: "hmac",
+					Secret:      "secret",
+				},
+			},
+		},
+		{
+			desc: "hash stickiness",
+			labels: map[string]string{
+				types.LabelBackendLoadbalancerStickiness:       "true",
+				types.LabelBackendLoadbalancerStickinessMode:   "hash",
+				types.LabelBackendLoadbalancerStickinessHashOn: "request.header.X-User",
+			},
+			expected: &types.LoadBalancer{
+				Method:     "wrr",
+				Stickiness: &types.Stickiness{Mode: "hash", HashOn: "request.header.X-User"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetLoadBalancer(test.labels))
+		})
+	}
+}
+
+func TestGetMaxConn(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.MaxConn
+	}{
+		{
+			desc:   "amount only",
+			labels: map[string]string{types.LabelBackendMaxconnAmount: "10"},
+		},
+		{
+			desc:   "extractor function only",
+			labels: map[string]string{types.LabelBackendMaxconnExtractorfunc: "client.ip"},
+		},
+		{
+			desc: "invalid amount",
+			labels: map[string]string{
+				types.LabelBackendMaxconnAmount:        "foo",
+				types.LabelBackendMaxconnExtractorfunc: "client.ip",
+			},
+		},
+		{
+			desc: "amount and extractor function",
+			labels: map[string]string{
+				types.LabelBackendMaxconnAmount:        "10",
+				types.LabelBackendMaxconnExtractorfunc: "client.ip",
+			},
+			expected: &types.MaxConn{Amount: 10, ExtractorFunc: "client.ip"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetMaxConn(test.labels))
+		})
+	}
+}
+
+func TestGetRetry(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.Retry
+	}{
+		{
+			desc:   "no labels",
+			labels: map[string]string{},
+		},
+		{
+			desc:     "attempts only",
+			labels:   map[string]string{types.LabelBackendRetryAttempts: "3"},
+			expected: &types.Retry{Attempts: 3},
+		},
+		{
+			desc: "all labels",
+			labels: map[string]string{
+				types.LabelBackendRetryAttempts:        "3",
+				types.LabelBackendRetryStatusCodes:     "502-504, 429",
+				types.LabelBackendRetryMethods:         "GET, POST",
+				types.LabelBackendRetryInitialInterval: "100ms",
+				types.LabelBackendRetryMaxInterval:     "1s",
+				types.LabelBackendRetryMaxDuration:     "5s",
+				types.LabelBackendRetryMaxBodySize:     "4096",
+			},
+			expected: &types.Retry{
+				Attempts:        3,
+				StatusCodes:     []string{"502-504", "429"},
+				Methods:         []string{"GET", "POST"},
+				InitialInterval: flaeg.Duration(100 * time.Millisecond),
+				MaxInterval:     flaeg.Duration(time.Second),
+				MaxDuration:     flaeg.Duration(5 * time.Second),
+				MaxBodySize:     4096,
+			},
+		},
+		{
+			desc:   "invalid duration",
+			labels: map[string]string{types.LabelBackendRetryMaxDuration: "forever"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetRetry(test.labels))
+		})
+	}
+}
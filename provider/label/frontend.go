@@ -0,0 +1,230 @@
+package label
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+// GetFrontend builds the frontend options from the traefik.frontend.* labels.
+// The backend and the routes are left to the provider.
+func GetFrontend(labels map[string]string) *types.Frontend {
+	return &types.Frontend{
+		EntryPoints:          GetSliceStringValue(labels, types.LabelFrontendEntryPoints),
+		PassHostHeader:       GetBoolValue(labels, types.LabelFrontendPassHostHeader, true),
+		PassTLSCert:          GetBoolValue(labels, types.LabelFrontendPassTLSCert, false),
+		Priority:             GetIntValue(labels, types.LabelFrontendPriority, 0),
+		BasicAuth:            GetSliceStringValue(labels, types.LabelFrontendAuthBasic),
+		WhitelistSourceRange: GetSliceStringValue(labels, types.LabelTraefikFrontendWhitelistSourceRange),
+		WhiteList:            GetWhiteList(labels),
+		Headers:              GetHeaders(labels),
+		Errors:               GetErrorPages(labels),
+		RateLimit:            GetRateLimit(labels),
+		Redirect:             GetRedirect(labels),
+		Compress:             GetCompress(labels),
+		Cache:                GetCache(labels),
+		Auth: types.GetAuthFromLabels(func(labelName string) (string, bool) {
+			value, ok := labels[labelName]
+			return value, ok
+		}),
+	}
+}
+
+// GetHeaders builds the headers options from the traefik.frontend.headers.* labels.
+func GetHeaders(labels map[string]string) types.Headers {
+	return types.Headers{
+		CustomRequestHeaders:    GetMapValue(labels, types.LabelFrontendRequestHeader),
+		CustomResponseHeaders:   GetMapValue(labels, types.LabelFrontendResponseHeader),
+		AllowedHosts:            GetSliceStringValue(labels, types.LabelFrontendAllowedHosts),
+		HostsProxyHeaders:       GetSliceStringValue(labels, types.LabelFrontendHostsProxyHeaders),
+		SSLRedirect:             GetBoolValue(labels, types.LabelFrontendSSLRedirect, false),
+		SSLTemporaryRedirect:    GetBoolValue(labels, types.LabelFrontendSSLTemporaryRedirect, false),
+		SSLHost:                 GetStringValue(labels, types.LabelFrontendSSLHost, ""),
+		SSLProxyHeaders:         GetMapValue(labels, types.LabelFrontendSSLProxyHeaders),
+		STSSeconds:              GetInt64Value(labels, types.LabelFrontendSTSSeconds, 0),
+		STSIncludeSubdomains:    GetBoolValue(labels, types.LabelFrontendSTSIncludeSubdomains, false),
+		STSPreload:              GetBoolValue(labels, types.LabelFrontendSTSPreload, false),
+		ForceSTSHeader:          GetBoolValue(labels, types.LabelFrontendForceSTSHeader, false),
+		FrameDeny:               GetBoolValue(labels, types.LabelFrontendFrameDeny, false),
+		CustomFrameOptionsValue: GetStringValue(labels, types.LabelFrontendCustomFrameOptionsValue, ""),
+		ContentTypeNosniff:      GetBoolValue(labels, types.LabelFrontendContentTypeNosniff, false),
+		BrowserXSSFilter:        GetBoolValue(labels, types.LabelFrontendBrowserXSSFilter, false),
+		ContentSecurityPolicy:   GetStringValue(labels, types.LabelFrontendContentSecurityPolicy, ""),
+		PublicKey:               GetStringValue(labels, types.LabelFrontendPublicKey, ""),
+		ReferrerPolicy:          GetStringValue(labels, types.LabelFrontendReferrerPolicy, ""),
+		IsDevelopment:           GetBoolValue(labels, types.LabelFrontendIsDevelopment, false),
+	}
+}
+
+// GetErrorPages builds the error pages from the traefik.frontend.errors.<name>.status|backend|query|content|contentType labels.
+// The error pages can't be served from local files, which the owners of the labels could read otherwise.
+func GetErrorPages(labels map[string]string) map[string]types.ErrorPage {
+	var errorPages map[string]types.ErrorPage
+	for _, name := range getSubKeys(labels, types.LabelFrontendErrorPages) {
+		prefix := types.LabelFrontendErrorPages + name + "."
+		errorPage := types.ErrorPage{
+			Status:      GetSliceStringValue(labels, prefix+"status"),
+			Backend:     GetStringValue(labels, prefix+"backend", ""),
+			Query:       GetStringValue(labels, prefix+"query", ""),
+			Content:     GetStringValue(labels, prefix+"content", ""),
+			ContentType: GetStringValue(labels, prefix+"contentType", ""),
+		}
+		if len(errorPage.Status) == 0 || len(errorPage.Backend)+len(errorPage.Content) == 0 {
+			log.Errorf("Invalid error page %q: the status and the backend or the content are required", name)
+			continue
+		}
+
+		if errorPages == nil {
+			errorPages = make(map[string]types.ErrorPage)
+		}
+		errorPages[name] = errorPage
+	}
+	return errorPages
+}
+
+// GetRateLimit builds the rate limit from the traefik.frontend.rateLimit.* labels.
+// The rates are set by the traefik.frontend.rateLimit.rateSet.<name>.period|average|burst labels,
+// and the algorithm by the traefik.frontend.rateLimit.algorithm label.
+func GetRateLimit(labels map[string]string) *types.RateLimit {
+	rateSet := make(map[string]*types.Rate)
+	for _, name := range getSubKeys(labels, types.LabelFrontendRateLimitRateSet) {
+		prefix := types.LabelFrontendRateLimitRateSet + name + "."
+
+		var period flaeg.Duration
+		if err := period.Set(GetStringValue(labels, prefix+"period", "0")); err != nil {
+			log.Errorf("Invalid rate %q period: %v", name, err)
+			continue
+		}
+
+		rateSet[name] = &types.Rate{
+			Period:  period,
+			Average: GetInt64Value(labels, prefix+"average", 0),
+			Burst:   GetInt64Value(labels, prefix+"burst", 0),
+		}
+	}
+
+	if len(rateSet) == 0 {
+		return nil
+	}
+
+	extractorFunc := GetStringValue(labels, types.LabelFrontendRateLimitExtractorFunc, "")
+	if len(extractorFunc) == 0 {
+		log.Errorf("The label %s is required with rate limits", types.LabelFrontendRateLimitExtractorFunc)
+		return nil
+	}
+
+	return &types.RateLimit{
+		ExtractorFunc: extractorFunc,
+		RateSet:       rateSet,
+		Algorithm:     GetStringValue(labels, types.LabelFrontendRateLimitAlgorithm, ""),
+	}
+}
+
+// GetRedirect builds the redirect from the traefik.frontend.redirect.* labels.
+func GetRedirect(labels map[string]string) *types.Redirect {
+	redirect := &types.Redirect{
+		EntryPoint:  GetStringValue(labels, types.LabelFrontendRedirectEntryPoint, ""),
+		Regex:       GetStringValue(labels, types.LabelFrontendRedirectRegex, ""),
+		Replacement: GetStringValue(labels, types.LabelFrontendRedirectReplacement, ""),
+	}
+
+	if len(redirect.EntryPoint) > 0 {
+		return &types.Redirect{EntryPoint: redirect.EntryPoint}
+	}
+	if len(redirect.Regex) > 0 && len(redirect.Replacement) > 0 {
+		return redirect
+	}
+	return nil
+}
+
+// GetCompress builds the compression options from the traefik.frontend.compress* labels.
+// The compression is enabled by traefik.frontend.compress=true, or by any compression option.
+func GetCompress(labels map[string]string) *types.Compress {
+	if !GetBoolValue(labels, types.LabelFrontendCompress, false) && !HasPrefix(labels, types.LabelFrontendCompress+".") {
+		return nil
+	}
+
+	return &types.Compress{
+		Encodings:            GetSliceStringValue(labels, types.LabelFrontendCompressEncodings),
+		Level:                GetIntValue(labels, types.LabelFrontendCompressLevel, 0),
+		BrotliLevel:          GetIntValue(labels, types.LabelFrontendCompressBrotliLevel, 0),
+		MinSize:              GetIntValue(labels, types.LabelFrontendCompressMinSize, 0),
+		ContentTypes:         GetSliceStringValue(labels, types.LabelFrontendCompressContentTypes),
+		ExcludedContentTypes: GetSliceStringValue(labels, types.LabelFrontendCompressExcludedContentTypes),
+	}
+}
+
+// GetCache builds the response cache options from the traefik.frontend.cache* labels, nil when a duration is invalid.
+// The cache is enabled by traefik.frontend.cache=true, or by any cache option.
+func GetCache(labels map[string]string) *types.Cache {
+	if !GetBoolValue(labels, types.LabelFrontendCache, false) && !HasPrefix(labels, types.LabelFrontendCache+".") {
+		return nil
+	}
+
+	cache := &types.Cache{
+		Store:        GetStringValue(labels, types.LabelFrontendCacheStore, ""),
+		Directory:    GetStringValue(labels, types.LabelFrontendCacheDirectory, ""),
+		MaxSize:      GetInt64Value(labels, types.LabelFrontendCacheMaxSize, 0),
+		MaxEntrySize: GetInt64Value(labels, types.LabelFrontendCacheMaxEntrySize, 0),
+	}
+
+	durations := map[string]*flaeg.Duration{
+		types.LabelFrontendCacheDefaultTTL:           &cache.DefaultTTL,
+		types.LabelFrontendCacheStaleWhileRevalidate: &cache.StaleWhileRevalidate,
+		types.LabelFrontendCacheStaleIfError:         &cache.StaleIfError,
+	}
+	for labelName, duration := range durations {
+		if err := duration.Set(GetStringValue(labels, labelName, "0")); err != nil {
+			log.Errorf("Invalid %s: %v", labelName, err)
+			return nil
+		}
+	}
+
+	return cache
+}
+
+// GetWhiteList builds the white list options from the traefik.frontend.whiteList.* labels, nil when none is set.
+// The traefik.frontend.whiteList.ipStrategy=true label alone uses the forwarded headers trusted IPs of the entry point.
+func GetWhiteList(labels map[string]string) *types.WhiteList {
+	if !HasPrefix(labels, types.LabelPrefix+"frontend.whiteList.") {
+		return nil
+	}
+
+	whiteList := &types.WhiteList{
+		SourceRange:      GetSliceStringValue(labels, types.LabelFrontendWhiteListSourceRange),
+		Deny:             GetBoolValue(labels, types.LabelFrontendWhiteListDeny, false),
+		RejectStatusCode: GetIntValue(labels, types.LabelFrontendWhiteListRejectStatusCode, 0),
+		RejectBody:       GetStringValue(labels, types.LabelFrontendWhiteListRejectBody, ""),
+	}
+
+	if GetBoolValue(labels, types.LabelFrontendWhiteListIPStrategy, false) || HasPrefix(labels, types.LabelFrontendWhiteListIPStrategy+".") {
+		whiteList.IPStrategy = &types.IPStrategy{
+			Depth:          GetIntValue(labels, types.LabelFrontendWhiteListIPStrategyDepth, 0),
+			TrustedProxies: GetSliceStringValue(labels, types.LabelFrontendWhiteListIPStrategyProxies),
+		}
+	}
+
+	return whiteList
+}
+
+// getSubKeys returns the sorted distinct names found after the prefix in the <prefix><name>.<property> labels.
+func getSubKeys(labels map[string]string, prefix string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for labelName := range labels {
+		if !strings.HasPrefix(labelName, prefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(labelName, prefix), ".", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || seen[parts[0]] {
+			continue
+		}
+		seen[parts[0]] = true
+		names = append(names, parts[0])
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,393 @@
+package label
+
+import (
+	"testing"
+	"time"
+
+	"github.com/containous/flaeg"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFrontend(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.Frontend
+	}{
+		{
+			desc:   "no labels",
+			labels: map[string]string{},
+			expected: &types.Frontend{
+				PassHostHeader: true,
+			},
+		},
+		{
+			desc: "all labels",
+			labels: map[string]string{
+				types.LabelFrontendEntryPoints:                    "http, https",
+				types.LabelFrontendPassHostHeader:                 "false",
+				types.LabelFrontendPassTLSCert:                    "true",
+				types.LabelFrontendPriority:                       "10",
+				types.LabelFrontendAuthBasic:                      "test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/",
+				types.LabelFrontendAuthHeaderField:                "X-WebAuth-User",
+				types.LabelTraefikFrontendWhitelistSourceRange:    "10.10.10.10, 10.20.0.0/16",
+				types.LabelFrontendRequestHeader:                  "X-Foo:bar||X-Bar:foo",
+				types.LabelFrontendResponseHeader:                 "X-Powered-By:traefik",
+				types.LabelFrontendAllowedHosts:                   "foo.example.com,bar.example.com",
+				types.LabelFrontendHostsProxyHeaders:              "X-Forwarded-Host",
+				types.LabelFrontendSSLRedirect:                    "true",
+				types.LabelFrontendSSLTemporaryRedirect:           "true",
+				types.LabelFrontendSSLHost:                        "foo.example.com",
+				types.LabelFrontendSSLProxyHeaders:                "X-Forwarded-Proto:https",
+				types.LabelFrontendSTSSeconds:                     "666",
+				types.LabelFrontendSTSIncludeSubdomains:           "true",
+				types.LabelFrontendSTSPreload:                     "true",
+				types.LabelFrontendForceSTSHeader:                 "true",
+				types.LabelFrontendFrameDeny:                      "true",
+				types.LabelFrontendCustomFrameOptionsValue:        "SAMEORIGIN",
+				types.LabelFrontendContentTypeNosniff:             "true",
+				types.LabelFrontendBrowserXSSFilter:               "true",
+				types.LabelFrontendContentSecurityPolicy:          "default-src 'self'",
+				types.LabelFrontendPublicKey:                      "pin-sha256",
+				types.LabelFrontendReferrerPolicy:                 "same-origin",
+				types.LabelFrontendIsDevelopment:                  "true",
+				types.LabelFrontendErrorPages + "foo.status":      "404,500-599",
+				types.LabelFrontendErrorPages + "foo.backend":     "error",
+				types.LabelFrontendErrorPages + "foo.query":       "/{status}.html",
+				types.LabelFrontendRateLimitExtractorFunc:         "client.ip",
+				types.LabelFrontendRateLimitRateSet + "a.period":  "6",
+				types.LabelFrontendRateLimitRateSet + "a.average": "12",
+				types.LabelFrontendRateLimitRateSet + "a.burst":   "18",
+				types.LabelFrontendRateLimitRateSet + "b.period":  "1m",
+				types.LabelFrontendRateLimitRateSet + "b.average": "100",
+				types.LabelFrontendRedirectEntryPoint:             "https",
+			},
+			expected: &types.Frontend{
+				EntryPoints:          []string{"http", "https"},
+				PassHostHeader:       false,
+				PassTLSCert:          true,
+				Priority:             10,
+				BasicAuth:            []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+				WhitelistSourceRange: []string{"10.10.10.10", "10.20.0.0/16"},
+				Auth:                 &types.Auth{HeaderField: "X-WebAuth-User"},
+				Headers: types.Headers{
+					CustomRequestHeaders:    map[string]string{"X-Foo": "bar", "X-Bar": "foo"},
+					CustomResponseHeaders:   map[string]string{"X-Powered-By": "traefik"},
+					AllowedHosts:            []string{"foo.example.com", "bar.example.com"},
+					HostsProxyHeaders:       []string{"X-Forwarded-Host"},
+					SSLRedirect:             true,
+					SSLTemporaryRedirect:    true,
+					SSLHost:                 "foo.example.com",
+					SSLProxyHeaders:         map[string]string{"X-Forwarded-Proto": "https"},
+					STSSeconds:              666,
+					STSIncludeSubdomains:    true,
+					STSPreload:              true,
+					ForceSTSHeader:          true,
+					FrameDeny:               true,
+					CustomFrameOptionsValue: "SAMEORIGIN",
+					ContentTypeNosniff:      true,
+					BrowserXSSFilter:        true,
+					ContentSecurityPolicy:   "default-src 'self'",
+					PublicKey:               "pin-sha256",
+					ReferrerPolicy:          "same-origin",
+					IsDevelopment:           true,
+				},
+				Errors: map[string]types.ErrorPage{
+					"foo": {Status: []string{"404", "500-599"}, Backend: "error", Query: "/{status}.html"},
+				},
+				RateLimit: &types.RateLimit{
+					ExtractorFunc: "client.ip",
+					RateSet: map[string]*types.Rate{
+						"a": {Period: flaeg.Duration(6 * time.Second), Average: 12, Burst: 18},
+						"b": {Period: flaeg.Duration(time.Minute), Average: 100},
+					},
+				},
+				Redirect: &types.Redirect{EntryPoint: "https"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetFrontend(test.labels))
+		})
+	}
+}
+
+func TestGetErrorPages(t *testing.T) {
+	labels := map[string]string{
+		types.LabelFrontendErrorPages + "foo.status":      "404",
+		types.LabelFrontendErrorPages + "foo.backend":     "error",
+		types.LabelFrontendErrorPages + "bar.status":      "500",
+		types.LabelFrontendErrorPages + "baz.status":      "502-504",
+		types.LabelFrontendErrorPages + "baz.file":        "/etc/traefik/errors",
+		types.LabelFrontendErrorPages + "qux.status":      "503",
+		types.LabelFrontendErrorPages + "qux.content":     `{"error": "{statusText}"}`,
+		types.LabelFrontendErrorPages + "qux.contentType": "application/json",
+	}
+
+	// the files are ignored
+	expected := map[string]types.ErrorPage{
+		"foo": {Status: []string{"404"}, Backend: "error"},
+		"qux": {Status: []string{"503"}, Content: `{"error": "{statusText}"}`, ContentType: "application/json"},
+	}
+	assert.Equal(t, expected, GetErrorPages(labels))
+}
+
+func TestGetRateLimit(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.RateLimit
+	}{
+		{
+			desc:   "no rate",
+			labels: map[string]string{types.LabelFrontendRateLimitExtractorFunc: "client.ip"},
+		},
+		{
+			desc:   "missing extractor function",
+			labels: map[string]string{types.LabelFrontendRateLimitRateSet + "a.average": "12"},
+		},
+		{
+			desc: "invalid period",
+			labels: map[string]string{
+				types.LabelFrontendRateLimitExtractorFunc:        "client.ip",
+				types.LabelFrontendRateLimitRateSet + "a.period": "foo",
+			},
+		},
+		{
+			desc: "sliding window",
+			labels: map[string]string{
+				types.LabelFrontendRateLimitExtractorFunc:         "request.cookie.session",
+				types.LabelFrontendRateLimitAlgorithm:             "sliding-window",
+				types.LabelFrontendRateLimitRateSet + "a.period":  "10s",
+				types.LabelFrontendRateLimitRateSet + "a.average": "100",
+			},
+			expected: &types.RateLimit{
+				ExtractorFunc: "request.cookie.session",
+				Algorithm:     "sliding-window",
+				RateSet: map[string]*types.Rate{
+					"a": {Period: flaeg.Duration(10 * time.Second), Average: 100},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetRateLimit(test.labels))
+		})
+	}
+}
+
+func TestGetRedirect(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.Redirect
+	}{
+		{
+			desc: "no redirect",
+		},
+		{
+			desc: "entry point takes precedence",
+			labels: map[string]string{
+				types.LabelFrontendRedirectEntryPoint:  "https",
+				types.LabelFrontendRedirectRegex:       "^http://foo(.*)",
+				types.LabelFrontendRedirectReplacement: "https://bar$1",
+			},
+			expected: &types.Redirect{EntryPoint: "https"},
+		},
+		{
+			desc: "regex",
+			labels: map[string]string{
+				types.LabelFrontendRedirectRegex:       "^http://foo(.*)",
+				types.LabelFrontendRedirectReplacement: "https://bar$1",
+			},
+			expected: &types.Redirect{Regex: "^http://foo(.*)", Replacement: "https://bar$1"},
+		},
+		{
+			desc:   "regex without replacement",
+			labels: map[string]string{types.LabelFrontendRedirectRegex: "^http://foo(.*)"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetRedirect(test.labels))
+		})
+	}
+}
+
+func TestGetCompress(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.Compress
+	}{
+		{
+			desc: "no compression",
+		},
+		{
+			desc:   "disabled",
+			labels: map[string]string{types.LabelFrontendCompress: "false"},
+		},
+		{
+			desc:     "enabled with the defaults",
+			labels:   map[string]string{types.LabelFrontendCompress: "true"},
+			expected: &types.Compress{},
+		},
+		{
+			desc: "options",
+			labels: map[string]string{
+				types.LabelFrontendCompressEncodings:            "gzip",
+				types.LabelFrontendCompressLevel:                "9",
+				types.LabelFrontendCompressBrotliLevel:          "4",
+				types.LabelFrontendCompressMinSize:              "1024",
+				types.LabelFrontendCompressContentTypes:         "text/html, application/json",
+				types.LabelFrontendCompressExcludedContentTypes: "text/event-stream",
+			},
+			expected: &types.Compress{
+				Encodings:            []string{"gzip"},
+				Level:                9,
+				BrotliLevel:          4,
+				MinSize:              1024,
+				ContentTypes:         []string{"text/html", "application/json"},
+				ExcludedContentTypes: []string{"text/event-stream"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetCompress(test.labels))
+		})
+	}
+}
+
+func TestGetCache(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.Cache
+	}{
+		{
+			desc: "no cache",
+		},
+		{
+			desc:   "disabled",
+			labels: map[string]string{types.LabelFrontendCache: "false"},
+		},
+		{
+			desc:     "enabled with the defaults",
+			labels:   map[string]string{types.LabelFrontendCache: "true"},
+			expected: &types.Cache{},
+		},
+		{
+			desc: "options",
+			labels: map[string]string{
+				types.LabelFrontendCacheStore:                "disk",
+				types.LabelFrontendCacheDirectory:            "/var/cache/traefik",
+				types.LabelFrontendCacheMaxSize:              "1073741824",
+				types.LabelFrontendCacheMaxEntrySize:         "10485760",
+				types.LabelFrontendCacheDefaultTTL:           "5m",
+				types.LabelFrontendCacheStaleWhileRevalidate: "30s",
+				types.LabelFrontendCacheStaleIfError:         "1h",
+			},
+			expected: &types.Cache{
+				Store:                "disk",
+				Directory:            "/var/cache/traefik",
+				MaxSize:              1073741824,
+				MaxEntrySize:         10485760,
+				DefaultTTL:           flaeg.Duration(5 * time.Minute),
+				StaleWhileRevalidate: flaeg.Duration(30 * time.Second),
+				StaleIfError:         flaeg.Duration(time.Hour),
+			},
+		},
+		{
+			desc: "invalid duration",
+			labels: map[string]string{
+				types.LabelFrontendCacheDefaultTTL: "forever",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetCache(test.labels))
+		})
+	}
+}
+
+func TestGetWhiteList(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *types.WhiteList
+	}{
+		{
+			desc: "no white list",
+		},
+		{
+			desc:   "deprecated source range only",
+			labels: map[string]string{types.LabelTraefikFrontendWhitelistSourceRange: "10.0.0.0/8"},
+		},
+		{
+			desc: "source range with the entry point trusted IPs",
+			labels: map[string]string{
+				types.LabelFrontendWhiteListSourceRange: "10.0.0.0/8, 192.168.1.7",
+				types.LabelFrontendWhiteListIPStrategy:  "true",
+			},
+			expected: &types.WhiteList{
+				SourceRange: []string{"10.0.0.0/8", "192.168.1.7"},
+				IPStrategy:  &types.IPStrategy{},
+			},
+		},
+		{
+			desc: "all options",
+			labels: map[string]string{
+				types.LabelFrontendWhiteListSourceRange:       "10.0.0.0/8",
+				types.LabelFrontendWhiteListIPStrategyDepth:   "2",
+				types.LabelFrontendWhiteListIPStrategyProxies: "172.16.0.0/12",
+				types.LabelFrontendWhiteListDeny:              "true",
+				types.LabelFrontendWhiteListRejectStatusCode:  "404",
+				types.LabelFrontendWhiteListRejectBody:        "Not Found",
+			},
+			expected: &types.WhiteList{
+				SourceRange: []string{"10.0.0.0/8"},
+				IPStrategy: &types.IPStrategy{
+					Depth:          2,
+					TrustedProxies: []string{"172.16.0.0/12"},
+				},
+				Deny:             true,
+				RejectStatusCode: 404,
+				RejectBody:       "Not Found",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, GetWhiteList(test.labels))
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package label
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/containous/traefik/log"
+	"github.com/containous/traefik/types"
+)
+
+const mapEntrySeparator = "||"
+
+// Has returns true if the label is set.
+func Has(labels map[string]string, labelName string) bool {
+	_, ok := labels[labelName]
+	return ok
+}
+
+// HasPrefix returns true if a label starting with the prefix is set.
+func HasPrefix(labels map[string]string, prefix string) bool {
+	for name := range labels {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStringValue returns the value of the label, or the default value if the label is not set.
+func GetStringValue(labels map[string]string, labelName string, defaultValue string) string {
+	if value, ok := labels[labelName]; ok && len(value) > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// GetBoolValue returns the value of the label parsed as a boolean, or the default value if the label is not set or invalid.
+func GetBoolValue(labels map[string]string, labelName string, defaultValue bool) bool {
+	rawValue, ok := labels[labelName]
+	if !ok || len(strings.TrimSpace(rawValue)) == 0 {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(strings.TrimSpace(rawValue))
+	if err != nil {
+		log.Errorf("Unable to parse %q: %q, falling back to %v: %v", labelName, rawValue, defaultValue, err)
+		return defaultValue
+	}
+	return value
+}
+
+// GetIntValue returns the value of the label parsed as an int, or the default value if the label is not set or invalid.
+func GetIntValue(labels map[string]string, labelName string, defaultValue int) int {
+	rawValue, ok := labels[labelName]
+	if !ok || len(strings.TrimSpace(rawValue)) == 0 {
+		return defaultValue
+	}
+
+	value, err := strconv.Atoi(strings.TrimSpace(rawValue))
+	if err != nil {
+		log.Errorf("Unable to parse %q: %q, falling back to %v: %v", labelName, rawValue, defaultValue, err)
+		return defaultValue
+	}
+	return value
+}
+
+// GetInt64Value returns the value of the label parsed as an int64, or the default value if the label is not set or invalid.
+func GetInt64Value(labels map[string]string, labelName string, defaultValue int64) int64 {
+	rawValue, ok := labels[labelName]
+	if !ok || len(strings.TrimSpace(rawValue)) == 0 {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseInt(strings.TrimSpace(rawValue), 10, 64)
+	if err != nil {
+		log.Errorf("Unable to parse %q: %q, falling back to %v: %v", labelName, rawValue, defaultValue, err)
+		return defaultValue
+	}
+	return value
+}
+
+// GetSliceStringValue returns the comma separated values of the label, trimmed and without the empty ones.
+func GetSliceStringValue(labels map[string]string, labelName string) []string {
+	var values []string
+	for _, value := range strings.Split(labels[labelName], ",") {
+		if value = strings.TrimSpace(value); len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// GetMapValue parses a label with the format "Name1:Value1||Name2:Value2".
+// The legacy format "Name1:Value1,Name2:Value2" is used when the label holds no "||".
+func GetMapValue(labels map[string]string, labelName string) map[string]string {
+	rawValue, ok := labels[labelName]
+	if !ok || len(strings.TrimSpace(rawValue)) == 0 {
+		return nil
+	}
+
+	separator := mapEntrySeparator
+	if !strings.Contains(rawValue, mapEntrySeparator) {
+		separator = ","
+	}
+
+	values := make(map[string]string)
+	for _, part := range strings.Split(rawValue, separator) {
+		pair := strings.SplitN(part, ":", 2)
+		if len(pair) != 2 || len(strings.TrimSpace(pair[0])) == 0 {
+			log.Warnf("Invalid %q entry %q, the format is Name:Value, skipping...", labelName, part)
+			continue
+		}
+		values[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+	}
+
+	if len(values) == 0 {
+		log.Errorf("Could not load any value of %q", labelName)
+		return nil
+	}
+	return values
+}
+
+// GetServiceLabels returns the labels of the named service: the traefik.<serviceName>.<property> labels
+// override the traefik.<property> labels.
+func GetServiceLabels(labels map[string]string, serviceName string) map[string]string {
+	if len(serviceName) == 0 {
+		return labels
+	}
+
+	servicePrefix := types.LabelPrefix + serviceName + "."
+	serviceLabels := make(map[string]string, len(labels))
+	for name, value := range labels {
+		if !strings.HasPrefix(name, servicePrefix) {
+			if _, ok := serviceLabels[name]; !ok {
+				serviceLabels[name] = value
+			}
+			continue
+		}
+		serviceLabels[types.LabelPrefix+strings.TrimPrefix(name, servicePrefix)] = value
+	}
+	return serviceLabels
+}
@@ -0,0 +1,111 @@
+package label
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetValues(t *testing.T) {
+	labels := map[string]string{
+		"traefik.string":  "foo",
+		"traefik.empty":   "",
+		"traefik.bool":    "true",
+		"traefik.int":     "42",
+		"traefik.invalid": "foo",
+		"traefik.slice":   " foo, ,bar ",
+	}
+
+	assert.True(t, Has(labels, "traefik.empty"))
+	assert.False(t, Has(labels, "traefik.missing"))
+	assert.True(t, HasPrefix(labels, "traefik.str"))
+	assert.False(t, HasPrefix(labels, "traefik.missing"))
+
+	assert.Equal(t, "foo", GetStringValue(labels, "traefik.string", "bar"))
+	assert.Equal(t, "bar", GetStringValue(labels, "traefik.empty", "bar"))
+	assert.Equal(t, "bar", GetStringValue(labels, "traefik.missing", "bar"))
+
+	assert.True(t, GetBoolValue(labels, "traefik.bool", false))
+	assert.True(t, GetBoolValue(labels, "traefik.invalid", true))
+	assert.False(t, GetBoolValue(labels, "traefik.missing", false))
+
+	assert.Equal(t, 42, GetIntValue(labels, "traefik.int", 0))
+	assert.Equal(t, 1, GetIntValue(labels, "traefik.invalid", 1))
+	assert.Equal(t, int64(42), GetInt64Value(labels, "traefik.int", 0))
+	assert.Equal(t, int64(1), GetInt64Value(labels, "traefik.missing", 1))
+
+	assert.Equal(t, []string{"foo", "bar"}, GetSliceStringValue(labels, "traefik.slice"))
+	assert.Nil(t, GetSliceStringValue(labels, "traefik.missing"))
+}
+
+func TestGetMapValue(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    string
+		expected map[string]string
+	}{
+		{
+			desc:     "empty",
+			expected: nil,
+		},
+		{
+			desc:     "one entry",
+			value:    "Access-Control-Allow-Methods:POST",
+			expected: map[string]string{"Access-Control-Allow-Methods": "POST"},
+		},
+		{
+			desc:  "pipes separator",
+			value: "Access-Control-Allow-Methods: POST,GET,OPTIONS || Content-type:application/json; charset=utf-8",
+			expected: map[string]string{
+				"Access-Control-Allow-Methods": "POST,GET,OPTIONS",
+				"Content-type":                 "application/json; charset=utf-8",
+			},
+		},
+		{
+			desc:  "legacy comma separator",
+			value: "X-Foo:bar,X-Bar:foo",
+			expected: map[string]string{
+				"X-Foo": "bar",
+				"X-Bar": "foo",
+			},
+		},
+		{
+			desc:     "invalid entries are skipped",
+			value:    "X-Foo||X-Bar:foo",
+			expected: map[string]string{"X-Bar": "foo"},
+		},
+		{
+			desc:     "no valid entry",
+			value:    "X-Foo",
+			expected: nil,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			labels := map[string]string{"traefik.headers": test.value}
+			assert.Equal(t, test.expected, GetMapValue(labels, "traefik.headers"))
+		})
+	}
+}
+
+func TestGetServiceLabels(t *testing.T) {
+	labels := map[string]string{
+		"traefik.frontend.rule":                    "Host:foo.example.com",
+		"traefik.frontend.priority":                "10",
+		"traefik.web.frontend.rule":                "Host:web.example.com",
+		"traefik.web.frontend.redirect.entryPoint": "https",
+	}
+
+	assert.Equal(t, labels, GetServiceLabels(labels, ""))
+
+	expected := map[string]string{
+		"traefik.frontend.rule":                "Host:web.example.com",
+		"traefik.frontend.priority":            "10",
+		"traefik.frontend.redirect.entryPoint": "https",
+	}
+	assert.Equal(t, expected, GetServiceLabels(labels, "web"))
+}
@@ -38,7 +38,7 @@ func appPorts(ports ...int) func(*marathon.Application) {
 	}
 }
 
-func label(key, value string) func(*marathon.Application) {
+func withLabel(key, value string) func(*marathon.Application) {
 	return func(app *marathon.Application) {
 		app.AddLabel(key, value)
 	}
@@ -3,7 +3,6 @@ package marathon
 import (
 	"errors"
 	"fmt"
-	"math"
 	"net"
 	"net/http"
 	"net/url"
@@ -20,6 +19,7 @@ import (
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/gambol99/go-marathon"
@@ -137,7 +137,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 						configuration := p.loadMarathonConfig()
 						if configuration != nil {
 							configurationChan <- types.ConfigMessage{
-								ProviderName:  "marathon",
+								ProviderName:  p.GetProviderName("marathon"),
 								Configuration: configuration,
 							}
 						}
@@ -147,7 +147,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 		}
 		configuration := p.loadMarathonConfig()
 		configurationChan <- types.ConfigMessage{
-			ProviderName:  "marathon",
+			ProviderName:  p.GetProviderName("marathon"),
 			Configuration: configuration,
 		}
 		return nil
@@ -165,35 +165,20 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 
 func (p *Provider) loadMarathonConfig() *types.Configuration {
 	var MarathonFuncMap = template.FuncMap{
-		"getBackend":                  p.getBackend,
-		"getBackendServer":            p.getBackendServer,
-		"getPort":                     p.getPort,
-		"getWeight":                   p.getWeight,
-		"getDomain":                   p.getDomain,
-		"getSubDomain":                p.getSubDomain,
-		"getProtocol":                 p.getProtocol,
-		"getPassHostHeader":           p.getPassHostHeader,
-		"getPriority":                 p.getPriority,
-		"getEntryPoints":              p.getEntryPoints,
-		"getFrontendRule":             p.getFrontendRule,
-		"getFrontendName":             p.getFrontendName,
-		"hasCircuitBreakerLabels":     p.hasCircuitBreakerLabels,
-		"hasLoadBalancerLabels":       p.hasLoadBalancerLabels,
-		"hasMaxConnLabels":            p.hasMaxConnLabels,
-		"getMaxConnExtractorFunc":     p.getMaxConnExtractorFunc,
-		"getMaxConnAmount":            p.getMaxConnAmount,
-		"getLoadBalancerMethod":       p.getLoadBalancerMethod,
-		"getCircuitBreakerExpression": p.getCircuitBreakerExpression,
-		"getSticky":                   p.getSticky,
-		"hasStickinessLabel":          p.hasStickinessLabel,
-		"getStickinessCookieName":     p.getStickinessCookieName,
-		"hasHealthCheckLabels":        p.hasHealthCheckLabels,
-		"getHealthCheckPath":          p.getHealthCheckPath,
-		"getHealthCheckInterval":      p.getHealthCheckInterval,
-		"hasServices":                 p.hasServices,
-		"getServiceNames":             p.getServiceNames,
-		"getServiceNameSuffix":        p.getServiceNameSuffix,
-		"getBasicAuth":                p.getBasicAuth,
+		"getBackend":           p.getBackend,
+		"getBackendServer":     p.getBackendServer,
+		"getPort":              p.getPort,
+		"getWeight":            p.getWeight,
+		"getDomain":            p.getDomain,
+		"getSubDomain":         p.getSubDomain,
+		"getProtocol":          p.getProtocol,
+		"getFrontendRule":      p.getFrontendRule,
+		"getFrontendName":      p.getFrontendName,
+		"hasServices":          p.hasServices,
+		"getServiceNames":      p.getServiceNames,
+		"getServiceNameSuffix": p.getServiceNameSuffix,
+		"getFrontendOptions":   p.getFrontendOptions,
+		"getBackendOptions":    p.getBackendOptions,
 	}
 
 	v := url.Values{}
@@ -426,47 +411,6 @@ func (p *Provider) getProtocol(application marathon.Application, serviceName str
 	return "http"
 }
 
-func (p *Provider) getSticky(application marathon.Application) string {
-	if sticky, ok := p.getAppLabel(application, types.LabelBackendLoadbalancerSticky); ok {
-		log.Warnf("Deprecated configuration found: %s. Please use %s.", types.LabelBackendLoadbalancerSticky, types.LabelBackendLoadbalancerStickiness)
-		return sticky
-	}
-	return "false"
-}
-
-func (p *Provider) hasStickinessLabel(application marathon.Application) bool {
-	labelStickiness, okStickiness := p.getAppLabel(application, types.LabelBackendLoadbalancerStickiness)
-	return okStickiness && len(labelStickiness) > 0 && strings.EqualFold(strings.TrimSpace(labelStickiness), "true")
-}
-
-func (p *Provider) getStickinessCookieName(application marathon.Application) string {
-	if label, ok := p.getAppLabel(application, types.LabelBackendLoadbalancerStickinessCookieName); ok {
-		return label
-	}
-	return ""
-}
-
-func (p *Provider) getPassHostHeader(application marathon.Application, serviceName string) string {
-	if passHostHeader, ok := p.getLabel(application, types.LabelFrontendPassHostHeader, serviceName); ok {
-		return passHostHeader
-	}
-	return "true"
-}
-
-func (p *Provider) getPriority(application marathon.Application, serviceName string) string {
-	if priority, ok := p.getLabel(application, types.LabelFrontendPriority, serviceName); ok {
-		return priority
-	}
-	return "0"
-}
-
-func (p *Provider) getEntryPoints(application marathon.Application, serviceName string) []string {
-	if entryPoints, ok := p.getLabel(application, types.LabelFrontendEntryPoints, serviceName); ok {
-		return strings.Split(entryPoints, ",")
-	}
-	return []string{}
-}
-
 // getFrontendRule returns the frontend rule for the specified application, using
 // its label. If service is provided, it will look for serviceName label before generic one.
 // It returns a default one (Host) if the label is not present.
@@ -507,82 +451,21 @@ func (p *Provider) getSubDomain(name string) string {
 	return strings.Replace(strings.TrimPrefix(name, "/"), "/", "-", -1)
 }
 
-func (p *Provider) hasCircuitBreakerLabels(application marathon.Application) bool {
-	_, ok := p.getAppLabel(application, types.LabelBackendCircuitbreakerExpression)
-	return ok
-}
-
-func (p *Provider) hasLoadBalancerLabels(application marathon.Application) bool {
-	_, errMethod := p.getAppLabel(application, types.LabelBackendLoadbalancerMethod)
-	_, errSticky := p.getAppLabel(application, types.LabelBackendLoadbalancerSticky)
-	return errMethod || errSticky
-}
-
-func (p *Provider) hasMaxConnLabels(application marathon.Application) bool {
-	if _, ok := p.getAppLabel(application, types.LabelBackendMaxconnAmount); !ok {
-		return false
-	}
-	_, ok := p.getAppLabel(application, types.LabelBackendMaxconnExtractorfunc)
-	return ok
-}
-
-func (p *Provider) getMaxConnAmount(application marathon.Application) int64 {
-	if label, ok := p.getAppLabel(application, types.LabelBackendMaxconnAmount); ok {
-		i, errConv := strconv.ParseInt(label, 10, 64)
-		if errConv != nil {
-			log.Errorf("Unable to parse traefik.backend.maxconn.amount %s", label)
-			return math.MaxInt64
-		}
-		return i
-	}
-	return math.MaxInt64
-}
-
-func (p *Provider) getMaxConnExtractorFunc(application marathon.Application) string {
-	if label, ok := p.getAppLabel(application, types.LabelBackendMaxconnExtractorfunc); ok {
-		return label
-	}
-	return "request.host"
-}
-
-func (p *Provider) getLoadBalancerMethod(application marathon.Application) string {
-	if label, ok := p.getAppLabel(application, types.LabelBackendLoadbalancerMethod); ok {
-		return label
-	}
-	return "wrr"
+// getFrontendOptions returns the frontend options of a given service, the service labels overriding the application ones.
+func (p *Provider) getFrontendOptions(application marathon.Application, serviceName string) *types.Frontend {
+	return label.GetFrontend(label.GetServiceLabels(getLabels(application), serviceName))
 }
 
-func (p *Provider) getCircuitBreakerExpression(application marathon.Application) string {
-	if label, ok := p.getAppLabel(application, types.LabelBackendCircuitbreakerExpression); ok {
-		return label
-	}
-	return "NetworkErrorRatio() > 1"
+// getBackendOptions returns the backend options set by the application labels.
+func (p *Provider) getBackendOptions(application marathon.Application) *types.Backend {
+	return label.GetBackend(getLabels(application))
 }
 
-func (p *Provider) hasHealthCheckLabels(application marathon.Application) bool {
-	return p.getHealthCheckPath(application) != ""
-}
-
-func (p *Provider) getHealthCheckPath(application marathon.Application) string {
-	if label, ok := p.getAppLabel(application, types.LabelBackendHealthcheckPath); ok {
-		return label
-	}
-	return ""
-}
-
-func (p *Provider) getHealthCheckInterval(application marathon.Application) string {
-	if label, ok := p.getAppLabel(application, types.LabelBackendHealthcheckInterval); ok {
-		return label
-	}
-	return ""
-}
-
-func (p *Provider) getBasicAuth(application marathon.Application, serviceName string) []string {
-	if basicAuth, ok := p.getLabel(application, types.LabelFrontendAuthBasic, serviceName); ok {
-		return strings.Split(basicAuth, ",")
+func getLabels(application marathon.Application) map[string]string {
+	if application.Labels == nil {
+		return nil
 	}
-
-	return []string{}
+	return *application.Labels
 }
 
 // processPorts returns the configured port.
@@ -3,12 +3,9 @@ package marathon
 import (
 	"errors"
 	"fmt"
-	"math"
-	"reflect"
 	"testing"
 
 	"github.com/containous/traefik/provider/marathon/mocks"
-	"github.com/containous/traefik/testhelpers"
 	"github.com/containous/traefik/types"
 	"github.com/gambol99/go-marathon"
 	"github.com/stretchr/testify/assert"
@@ -101,8 +98,8 @@ func TestMarathonLoadConfigNonAPIErrors(t *testing.T) {
 			desc: "load balancer / circuit breaker labels",
 			application: application(
 				appPorts(80),
-				label(types.LabelBackendLoadbalancerMethod, "drr"),
-				label(types.LabelBackendCircuitbreakerExpression, "NetworkErrorRatio() > 0.5"),
+				withLabel(types.LabelBackendLoadbalancerMethod, "drr"),
+				withLabel(types.LabelBackendCircuitbreakerExpression, "NetworkErrorRatio() > 0.5"),
 			),
 			task: localhostTask(taskPorts(80)),
 			expectedFrontends: map[string]*types.Frontend{
@@ -136,8 +133,8 @@ func TestMarathonLoadConfigNonAPIErrors(t *testing.T) {
 			desc: "general max connection labels",
 			application: application(
 				appPorts(80),
-				label(types.LabelBackendMaxconnAmount, "1000"),
-				label(types.LabelBackendMaxconnExtractorfunc, "client.ip"),
+				withLabel(types.LabelBackendMaxconnAmount, "1000"),
+				withLabel(types.LabelBackendMaxconnExtractorfunc, "client.ip"),
 			),
 			task: localhostTask(taskPorts(80)),
 			expectedFrontends: map[string]*types.Frontend{
@@ -169,7 +166,7 @@ func TestMarathonLoadConfigNonAPIErrors(t *testing.T) {
 			desc: "max connection amount label only",
 			application: application(
 				appPorts(80),
-				label(types.LabelBackendMaxconnAmount, "1000"),
+				withLabel(types.LabelBackendMaxconnAmount, "1000"),
 			),
 			task: localhostTask(taskPorts(80)),
 			expectedFrontends: map[string]*types.Frontend{
@@ -198,7 +195,7 @@ func TestMarathonLoadConfigNonAPIErrors(t *testing.T) {
 			desc: "max connection extractor function label only",
 			application: application(
 				appPorts(80),
-				label(types.LabelBackendMaxconnExtractorfunc, "client.ip"),
+				withLabel(types.LabelBackendMaxconnExtractorfunc, "client.ip"),
 			),
 			task: localhostTask(taskPorts(80)),
 			expectedFrontends: map[string]*types.Frontend{
@@ -227,8 +224,8 @@ func TestMarathonLoadConfigNonAPIErrors(t *testing.T) {
 			desc: "health check labels",
 			application: application(
 				appPorts(80),
-				label(types.LabelBackendHealthcheckPath, "/path"),
-				label(types.LabelBackendHealthcheckInterval, "5m"),
+				withLabel(types.LabelBackendHealthcheckPath, "/path"),
+				withLabel(types.LabelBackendHealthcheckInterval, "5m"),
 			),
 			task: task(
 				host("127.0.0.1"),
@@ -292,13 +289,13 @@ func TestMarathonLoadConfigNonAPIErrors(t *testing.T) {
 			desc: "multiple ports with services",
 			application: application(
 				appPorts(80, 81),
-				label(types.LabelBackendMaxconnAmount, "1000"),
-				label(types.LabelBackendMaxconnExtractorfunc, "client.ip"),
-				label("traefik.web.port", "80"),
-				label("traefik.admin.port", "81"),
-				label("traefik..port", "82"), // This should be ignored, as it fails to match the servicesPropertiesRegexp regex.
-				label("traefik.web.frontend.rule", "Host:web.app.docker.localhost"),
-				label("traefik.admin.frontend.rule", "Host:admin.app.docker.localhost"),
+				withLabel(types.LabelBackendMaxconnAmount, "1000"),
+				withLabel(types.LabelBackendMaxconnExtractorfunc, "client.ip"),
+				withLabel("traefik.web.port", "80"),
+				withLabel("traefik.admin.port", "81"),
+				withLabel("traefik..port", "82"), // This should be ignored, as it fails to match the servicesPropertiesRegexp regex.
+				withLabel("traefik.web.frontend.rule", "Host:web.app.docker.localhost"),
+				withLabel("traefik.admin.frontend.rule", "Host:admin.app.docker.localhost"),
 			),
 			task: localhostTask(
 				taskPorts(80, 81),
@@ -421,8 +418,8 @@ func TestMarathonTaskFilter(t *testing.T) {
 			task: task(taskPorts(80, 443)),
 			application: application(
 				appPorts(80, 443),
-				label(types.LabelPort, "443"),
-				label(types.LabelPortIndex, "1"),
+				withLabel(types.LabelPort, "443"),
+				withLabel(types.LabelPortIndex, "1"),
 			),
 			expected: true,
 		},
@@ -532,15 +529,15 @@ func TestMarathonApplicationFilterConstraints(t *testing.T) {
 		},
 		{
 			desc:                    "tag matching",
-			application:             application(label(types.LabelTags, "valid")),
+			application:             application(withLabel(types.LabelTags, "valid")),
 			marathonLBCompatibility: false,
 			expected:                true,
 		},
 		{
 			desc: "LB compatibility tag matching",
 			application: application(
-				label("HAPROXY_GROUP", "valid"),
-				label(types.LabelTags, "notvalid"),
+				withLabel("HAPROXY_GROUP", "valid"),
+				withLabel(types.LabelTags, "notvalid"),
 			),
 			marathonLBCompatibility: true,
 			expected:                true,
@@ -618,7 +615,7 @@ func TestMarathonApplicationFilterEnabled(t *testing.T) {
 		t.Run(c.desc, func(t *testing.T) {
 			t.Parallel()
 			provider := &Provider{ExposedByDefault: c.exposedByDefault}
-			app := application(label(types.LabelEnable, c.enabledLabel))
+			app := application(withLabel(types.LabelEnable, c.enabledLabel))
 			if provider.applicationFilter(app) != c.expected {
 				t.Errorf("got unexpected filtering = %t", !c.expected)
 			}
@@ -644,19 +641,19 @@ func TestMarathonGetPort(t *testing.T) {
 		},
 		{
 			desc:        "numeric port",
-			application: application(label(types.LabelPort, "80")),
+			application: application(withLabel(types.LabelPort, "80")),
 			task:        task(),
 			expected:    "80",
 		},
 		{
 			desc:        "string port",
-			application: application(label(types.LabelPort, "foobar")),
+			application: application(withLabel(types.LabelPort, "foobar")),
 			task:        task(taskPorts(80)),
 			expected:    "",
 		},
 		{
 			desc:        "negative port",
-			application: application(label(types.LabelPort, "-1")),
+			application: application(withLabel(types.LabelPort, "-1")),
 			task:        task(taskPorts(80)),
 			expected:    "",
 		},
@@ -688,21 +685,21 @@ func TestMarathonGetPort(t *testing.T) {
 		},
 		{
 			desc:        "numeric port index specified",
-			application: application(label(types.LabelPortIndex, "1")),
+			application: application(withLabel(types.LabelPortIndex, "1")),
 			task:        task(taskPorts(80, 443)),
 			expected:    "443",
 		},
 		{
 			desc:        "string port index specified",
-			application: application(label(types.LabelPortIndex, "foobar")),
+			application: application(withLabel(types.LabelPortIndex, "foobar")),
 			task:        task(taskPorts(80)),
 			expected:    "",
 		},
 		{
 			desc: "port and port index specified",
 			application: application(
-				label(types.LabelPort, "80"),
-				label(types.LabelPortIndex, "1"),
+				withLabel(types.LabelPort, "80"),
+				withLabel(types.LabelPortIndex, "1"),
 			),
 			task:     task(taskPorts(80, 443)),
 			expected: "80",
@@ -715,21 +712,21 @@ func TestMarathonGetPort(t *testing.T) {
 		},
 		{
 			desc:        "multiple task ports with service index available",
-			application: application(label(types.LabelPrefix+"http.portIndex", "0")),
+			application: application(withLabel(types.LabelPrefix+"http.portIndex", "0")),
 			task:        task(taskPorts(80, 443)),
 			serviceName: "http",
 			expected:    "80",
 		},
 		{
 			desc:        "multiple task ports with service port available",
-			application: application(label(types.LabelPrefix+"https.port", "443")),
+			application: application(withLabel(types.LabelPrefix+"https.port", "443")),
 			task:        task(taskPorts(80, 443)),
 			serviceName: "https",
 			expected:    "443",
 		},
 		{
 			desc:        "multiple task ports with services but default port available",
-			application: application(label(types.LabelPrefix+"http.weight", "100")),
+			application: application(withLabel(types.LabelPrefix+"http.weight", "100")),
 			task:        task(taskPorts(80, 443)),
 			serviceName: "http",
 			expected:    "80",
@@ -762,7 +759,7 @@ func TestMarathonGetWeight(t *testing.T) {
 		},
 		{
 			desc:        "label existing",
-			application: application(label(types.LabelWeight, "10")),
+			application: application(withLabel(types.LabelWeight, "10")),
 			expected:    "10",
 		},
 		{
@@ -799,7 +796,7 @@ func TestMarathonGetDomain(t *testing.T) {
 		},
 		{
 			desc:        "label existing",
-			application: application(label(types.LabelDomain, "foo.bar")),
+			application: application(withLabel(types.LabelDomain, "foo.bar")),
 			expected:    "foo.bar",
 		},
 	}
@@ -833,7 +830,7 @@ func TestMarathonGetProtocol(t *testing.T) {
 		},
 		{
 			desc:        "label existing",
-			application: application(label(types.LabelProtocol, "https")),
+			application: application(withLabel(types.LabelProtocol, "https")),
 			expected:    "https",
 		},
 		{
@@ -856,271 +853,6 @@ func TestMarathonGetProtocol(t *testing.T) {
 		})
 	}
 }
-func TestMarathonGetSticky(t *testing.T) {
-	testCases := []struct {
-		desc        string
-		application marathon.Application
-		expected    string
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    "false",
-		},
-		{
-			desc:        "label existing",
-			application: application(label(types.LabelBackendLoadbalancerSticky, "true")),
-			expected:    "true",
-		},
-	}
-
-	for _, test := range testCases {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getSticky(test.application)
-			if actual != test.expected {
-				t.Errorf("actual %q, expected %q", actual, test.expected)
-			}
-		})
-	}
-}
-
-func TestMarathonHasStickinessLabel(t *testing.T) {
-	testCases := []struct {
-		desc        string
-		application marathon.Application
-		expected    bool
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    false,
-		},
-		{
-			desc:        "stickiness=true",
-			application: application(label(types.LabelBackendLoadbalancerStickiness, "true")),
-			expected:    true,
-		},
-		{
-			desc:        "stickiness=false ",
-			application: application(label(types.LabelBackendLoadbalancerStickiness, "true")),
-			expected:    true,
-		},
-	}
-
-	for _, test := range testCases {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
-
-			provider := &Provider{}
-			actual := provider.hasStickinessLabel(test.application)
-			if actual != test.expected {
-				t.Errorf("actual %q, expected %q", actual, test.expected)
-			}
-		})
-	}
-}
-
-func TestMarathonGetPassHostHeader(t *testing.T) {
-	cases := []struct {
-		desc        string
-		application marathon.Application
-		serviceName string
-		expected    string
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    "true",
-		},
-		{
-			desc:        "label existing",
-			application: application(label(types.LabelFrontendPassHostHeader, "false")),
-			expected:    "false",
-		},
-		{
-			desc:        "label existing",
-			application: application(labelWithService(types.LabelFrontendPassHostHeader, "false", "app")),
-			serviceName: "app",
-			expected:    "false",
-		},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getPassHostHeader(c.application, c.serviceName)
-			if actual != c.expected {
-				t.Errorf("actual %q, expected %q", actual, c.expected)
-			}
-		})
-	}
-}
-
-func TestMarathonMaxConnAmount(t *testing.T) {
-	cases := []struct {
-		desc        string
-		application marathon.Application
-		expected    int64
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    math.MaxInt64,
-		},
-		{
-			desc:        "non-integer value",
-			application: application(label(types.LabelBackendMaxconnAmount, "foobar")),
-			expected:    math.MaxInt64,
-		},
-		{
-			desc:        "label existing",
-			application: application(label(types.LabelBackendMaxconnAmount, "32")),
-			expected:    32,
-		},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getMaxConnAmount(c.application)
-			if actual != c.expected {
-				t.Errorf("actual %d, expected %d", actual, c.expected)
-			}
-		})
-	}
-}
-
-func TestMarathonGetMaxConnExtractorFunc(t *testing.T) {
-	cases := []struct {
-		desc        string
-		application marathon.Application
-		expected    string
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    "request.host",
-		},
-		{
-			desc:        "label existing",
-			application: application(label(types.LabelBackendMaxconnExtractorfunc, "client.ip")),
-			expected:    "client.ip",
-		},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getMaxConnExtractorFunc(c.application)
-			if actual != c.expected {
-				t.Errorf("actual %q, expected %q", actual, c.expected)
-			}
-		})
-	}
-}
-
-func TestMarathonGetLoadBalancerMethod(t *testing.T) {
-	cases := []struct {
-		desc        string
-		application marathon.Application
-		expected    string
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    "wrr",
-		},
-		{
-			desc:        "label existing",
-			application: application(label(types.LabelBackendLoadbalancerMethod, "drr")),
-			expected:    "drr",
-		},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getLoadBalancerMethod(c.application)
-			if actual != c.expected {
-				t.Errorf("actual %q, expected %q", actual, c.expected)
-			}
-		})
-	}
-}
-
-func TestMarathonGetCircuitBreakerExpression(t *testing.T) {
-	cases := []struct {
-		desc        string
-		application marathon.Application
-		expected    string
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    "NetworkErrorRatio() > 1",
-		},
-		{
-			desc:        "label existing",
-			application: application(label(types.LabelBackendCircuitbreakerExpression, "NetworkErrorRatio() > 0.5")),
-			expected:    "NetworkErrorRatio() > 0.5",
-		},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getCircuitBreakerExpression(c.application)
-			if actual != c.expected {
-				t.Errorf("actual %q, expected %q", actual, c.expected)
-			}
-		})
-	}
-}
-
-func TestMarathonGetEntryPoints(t *testing.T) {
-	cases := []struct {
-		desc        string
-		application marathon.Application
-		expected    []string
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    []string{},
-		},
-		{
-			desc:        "label existing",
-			application: application(label(types.LabelFrontendEntryPoints, "http,https")),
-			expected:    []string{"http", "https"},
-		},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getEntryPoints(c.application, "")
-			if !reflect.DeepEqual(actual, c.expected) {
-				t.Errorf("actual %#v, expected %#v", actual, c.expected)
-			}
-		})
-	}
-}
 
 func TestMarathonGetFrontendRule(t *testing.T) {
 	cases := []struct {
@@ -1140,14 +872,14 @@ func TestMarathonGetFrontendRule(t *testing.T) {
 			desc: "HAProxy vhost available and LB compat disabled",
 			application: application(
 				appID("test"),
-				label("HAPROXY_0_VHOST", "foo.bar"),
+				withLabel("HAPROXY_0_VHOST", "foo.bar"),
 			),
 			marathonLBCompatibility: false,
 			expected:                "Host:test.docker.localhost",
 		},
 		{
 			desc:                    "HAProxy vhost available and LB compat enabled",
-			application:             application(label("HAPROXY_0_VHOST", "foo.bar")),
+			application:             application(withLabel("HAPROXY_0_VHOST", "foo.bar")),
 			marathonLBCompatibility: true,
 			expected:                "Host:foo.bar",
 		},
@@ -1155,8 +887,8 @@ func TestMarathonGetFrontendRule(t *testing.T) {
 			desc: "frontend rule available",
 
 			application: application(
-				label(types.LabelFrontendRule, "Host:foo.bar"),
-				label("HAPROXY_0_VHOST", "unused"),
+				withLabel(types.LabelFrontendRule, "Host:foo.bar"),
+				withLabel("HAPROXY_0_VHOST", "unused"),
 			),
 			marathonLBCompatibility: true,
 			expected:                "Host:foo.bar",
@@ -1200,7 +932,7 @@ func TestMarathonGetBackend(t *testing.T) {
 		},
 		{
 			desc:        "label existing",
-			application: application(label(types.LabelBackend, "bar")),
+			application: application(withLabel(types.LabelBackend, "bar")),
 			expected:    "bar",
 		},
 		{
@@ -1224,57 +956,41 @@ func TestMarathonGetBackend(t *testing.T) {
 	}
 }
 
-func TestMarathonGetSubDomain(t *testing.T) {
+func TestMarathonGetFrontendOptions(t *testing.T) {
 	cases := []struct {
-		path             string
-		expected         string
-		groupAsSubDomain bool
-	}{
-		{"/test", "test", false},
-		{"/test", "test", true},
-		{"/a/b/c/d", "d.c.b.a", true},
-		{"/b/a/d/c", "c.d.a.b", true},
-		{"/d/c/b/a", "a.b.c.d", true},
-		{"/c/d/a/b", "b.a.d.c", true},
-		{"/a/b/c/d", "a-b-c-d", false},
-		{"/b/a/d/c", "b-a-d-c", false},
-		{"/d/c/b/a", "d-c-b-a", false},
-		{"/c/d/a/b", "c-d-a-b", false},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(fmt.Sprintf("path=%s,group=%t", c.path, c.groupAsSubDomain), func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{GroupsAsSubDomains: c.groupAsSubDomain}
-			actual := provider.getSubDomain(c.path)
-			if actual != c.expected {
-				t.Errorf("actual %q, expected %q", actual, c.expected)
-			}
-		})
-	}
-}
-
-func TestMarathonHasHealthCheckLabels(t *testing.T) {
-	cases := []struct {
-		desc     string
-		value    *string
-		expected bool
+		desc        string
+		application marathon.Application
+		serviceName string
+		expected    *types.Frontend
 	}{
 		{
-			desc:     "label missing",
-			value:    nil,
-			expected: false,
+			desc:        "label missing",
+			application: application(),
+			expected:    &types.Frontend{PassHostHeader: true},
 		},
 		{
-			desc:     "empty path",
-			value:    testhelpers.Stringp(""),
-			expected: false,
+			desc: "label existing",
+			application: application(
+				withLabel(types.LabelFrontendEntryPoints, "http,https"),
+				withLabel(types.LabelFrontendSSLRedirect, "true"),
+			),
+			expected: &types.Frontend{
+				PassHostHeader: true,
+				EntryPoints:    []string{"http", "https"},
+				Headers:        types.Headers{SSLRedirect: true},
+			},
 		},
 		{
-			desc:     "non-empty path",
-			value:    testhelpers.Stringp("/path"),
-			expected: true,
+			desc: "service label existing",
+			application: application(
+				withLabel(types.LabelFrontendEntryPoints, "http,https"),
+				labelWithService(types.LabelFrontendEntryPoints, "https", "app"),
+				labelWithService(types.LabelFrontendPassHostHeader, "false", "app"),
+			),
+			serviceName: "app",
+			expected: &types.Frontend{
+				EntryPoints: []string{"https"},
+			},
 		},
 	}
 
@@ -1282,80 +998,52 @@ func TestMarathonHasHealthCheckLabels(t *testing.T) {
 		c := c
 		t.Run(c.desc, func(t *testing.T) {
 			t.Parallel()
-			app := application()
-			if c.value != nil {
-				app.AddLabel(types.LabelBackendHealthcheckPath, *c.value)
-			}
 			provider := &Provider{}
-			actual := provider.hasHealthCheckLabels(app)
-			if actual != c.expected {
-				t.Errorf("actual %t, expected %t", actual, c.expected)
-			}
+			actual := provider.getFrontendOptions(c.application, c.serviceName)
+			assert.Equal(t, c.expected, actual)
 		})
 	}
 }
 
-func TestMarathonGetHealthCheckPath(t *testing.T) {
-	cases := []struct {
-		desc     string
-		value    string
-		expected string
-	}{
-		{
-			desc:     "label missing",
-			expected: "",
-		},
-		{
-			desc:     "path existing",
-			value:    "/path",
-			expected: "/path",
-		},
-	}
+func TestMarathonGetBackendOptions(t *testing.T) {
+	provider := &Provider{}
+	application := application(
+		withLabel(types.LabelBackendHealthcheckPath, "/health"),
+		withLabel(types.LabelBackendHealthcheckInterval, "5m"),
+		withLabel(types.LabelBackendCircuitbreakerExpression, "NetworkErrorRatio() > 0.5"),
+	)
 
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			app := application()
-			if c.value != "" {
-				app.AddLabel(types.LabelBackendHealthcheckPath, c.value)
-			}
-			provider := &Provider{}
-			actual := provider.getHealthCheckPath(app)
-			if actual != c.expected {
-				t.Errorf("actual %q, expected %q", actual, c.expected)
-			}
-		})
+	expected := &types.Backend{
+		CircuitBreaker: &types.CircuitBreaker{Expression: "NetworkErrorRatio() > 0.5"},
+		HealthCheck:    &types.HealthCheck{Path: "/health", Interval: "5m"},
 	}
+	assert.Equal(t, expected, provider.getBackendOptions(application))
 }
 
-func TestMarathonGetHealthCheckInterval(t *testing.T) {
+func TestMarathonGetSubDomain(t *testing.T) {
 	cases := []struct {
-		desc     string
-		value    string
-		expected string
+		path             string
+		expected         string
+		groupAsSubDomain bool
 	}{
-		{
-			desc:     "label missing",
-			expected: "",
-		},
-		{
-			desc:     "interval existing",
-			value:    "5m",
-			expected: "5m",
-		},
+		{"/test", "test", false},
+		{"/test", "test", true},
+		{"/a/b/c/d", "d.c.b.a", true},
+		{"/b/a/d/c", "c.d.a.b", true},
+		{"/d/c/b/a", "a.b.c.d", true},
+		{"/c/d/a/b", "b.a.d.c", true},
+		{"/a/b/c/d", "a-b-c-d", false},
+		{"/b/a/d/c", "b-a-d-c", false},
+		{"/d/c/b/a", "d-c-b-a", false},
+		{"/c/d/a/b", "c-d-a-b", false},
 	}
 
 	for _, c := range cases {
 		c := c
-		t.Run(c.desc, func(t *testing.T) {
+		t.Run(fmt.Sprintf("path=%s,group=%t", c.path, c.groupAsSubDomain), func(t *testing.T) {
 			t.Parallel()
-			app := application()
-			if c.value != "" {
-				app.AddLabel(types.LabelBackendHealthcheckInterval, c.value)
-			}
-			provider := &Provider{}
-			actual := provider.getHealthCheckInterval(app)
+			provider := &Provider{GroupsAsSubDomains: c.groupAsSubDomain}
+			actual := provider.getSubDomain(c.path)
 			if actual != c.expected {
 				t.Errorf("actual %q, expected %q", actual, c.expected)
 			}
@@ -1404,7 +1092,7 @@ func TestGetBackendServer(t *testing.T) {
 		{
 			desc: "multiple task IP addresses with invalid index label",
 			application: application(
-				label("traefik.ipAddressIdx", "invalid"),
+				withLabel("traefik.ipAddressIdx", "invalid"),
 				ipAddrPerTask(8000),
 			),
 			task:           task(ipAddresses("1.1.1.1", "2.2.2.2")),
@@ -1413,7 +1101,7 @@ func TestGetBackendServer(t *testing.T) {
 		{
 			desc: "multiple task IP addresses with valid index label",
 			application: application(
-				label("traefik.ipAddressIdx", "1"),
+				withLabel("traefik.ipAddressIdx", "1"),
 				ipAddrPerTask(8000),
 			),
 			task:           task(ipAddresses("1.1.1.1", "2.2.2.2")),
@@ -1492,34 +1180,3 @@ func TestParseIndex(t *testing.T) {
 		})
 	}
 }
-
-func TestMarathonGetBasicAuth(t *testing.T) {
-	cases := []struct {
-		desc        string
-		application marathon.Application
-		expected    []string
-	}{
-		{
-			desc:        "label missing",
-			application: application(),
-			expected:    []string{},
-		},
-		{
-			desc:        "label existing",
-			application: application(label(types.LabelFrontendAuthBasic, "user:password")),
-			expected:    []string{"user:password"},
-		},
-	}
-
-	for _, c := range cases {
-		c := c
-		t.Run(c.desc, func(t *testing.T) {
-			t.Parallel()
-			provider := &Provider{}
-			actual := provider.getBasicAuth(c.application, "")
-			if !reflect.DeepEqual(actual, c.expected) {
-				t.Errorf("actual %q, expected %q", actual, c.expected)
-			}
-		})
-	}
-}
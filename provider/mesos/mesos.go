@@ -13,6 +13,7 @@ import (
 	"github.com/containous/traefik/job"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 	"github.com/mesos/mesos-go/detector"
@@ -85,7 +86,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 				configuration := p.loadMesosConfig()
 				if configuration != nil {
 					configurationChan <- types.ConfigMessage{
-						ProviderName:  "mesos",
+						ProviderName:  p.GetProviderName("mesos"),
 						Configuration: configuration,
 					}
 				}
@@ -101,7 +102,7 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 				configuration := p.loadMesosConfig()
 				if configuration != nil {
 					configurationChan <- types.ConfigMessage{
-						ProviderName:  "mesos",
+						ProviderName:  p.GetProviderName("mesos"),
 						Configuration: configuration,
 					}
 				}
@@ -129,10 +130,9 @@ func (p *Provider) loadMesosConfig() *types.Configuration {
 		"getWeight":          p.getWeight,
 		"getDomain":          p.getDomain,
 		"getProtocol":        p.getProtocol,
-		"getPassHostHeader":  p.getPassHostHeader,
-		"getPriority":        p.getPriority,
-		"getEntryPoints":     p.getEntryPoints,
 		"getFrontendRule":    p.getFrontendRule,
+		"getFrontendOptions": p.getFrontendOptions,
+		"getBackendOptions":  p.getBackendOptions,
 		"getFrontendBackend": p.getFrontendBackend,
 		"getID":              p.getID,
 		"getFrontEndName":    p.getFrontEndName,
@@ -325,25 +325,25 @@ func (p *Provider) getProtocol(task state.Task, applications []state.Task) strin
 	return "http"
 }
 
-func (p *Provider) getPassHostHeader(task state.Task) string {
-	if passHostHeader, err := p.getLabel(task, types.LabelFrontendPassHostHeader); err == nil {
-		return passHostHeader
+func (p *Provider) getLabels(task state.Task) map[string]string {
+	labels := make(map[string]string, len(task.Labels))
+	for _, l := range task.Labels {
+		labels[l.Key] = l.Value
 	}
-	return "false"
+	return labels
 }
 
-func (p *Provider) getPriority(task state.Task) string {
-	if priority, err := p.getLabel(task, types.LabelFrontendPriority); err == nil {
-		return priority
-	}
-	return "0"
+// getFrontendOptions returns the frontend options set by the task labels.
+// Unlike the other providers, the host header is not passed by default.
+func (p *Provider) getFrontendOptions(task state.Task) *types.Frontend {
+	labels := p.getLabels(task)
+	frontend := label.GetFrontend(labels)
+	frontend.PassHostHeader = label.GetBoolValue(labels, types.LabelFrontendPassHostHeader, false)
+	return frontend
 }
 
-func (p *Provider) getEntryPoints(task state.Task) []string {
-	if entryPoints, err := p.getLabel(task, types.LabelFrontendEntryPoints); err == nil {
-		return strings.Split(entryPoints, ",")
-	}
-	return []string{}
+func (p *Provider) getBackendOptions(task state.Task) *types.Backend {
+	return label.GetBackend(p.getLabels(task))
 }
 
 // getFrontendRule returns the frontend rule for the specified application, using
@@ -275,6 +275,57 @@ func TestMesosGetSubDomain(t *testing.T) {
 	}
 }
 
+func TestMesosGetFrontendOptions(t *testing.T) {
+	provider := &Provider{}
+
+	cases := []struct {
+		desc     string
+		task     state.Task
+		expected *types.Frontend
+	}{
+		{
+			desc:     "without labels",
+			task:     task(),
+			expected: &types.Frontend{},
+		},
+		{
+			desc: "with labels",
+			task: task(setLabels(
+				types.LabelFrontendPassHostHeader, "true",
+				types.LabelFrontendEntryPoints, "http,https",
+				types.LabelFrontendRedirectEntryPoint, "https",
+			)),
+			expected: &types.Frontend{
+				PassHostHeader: true,
+				EntryPoints:    []string{"http", "https"},
+				Redirect:       &types.Redirect{EntryPoint: "https"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		actual := provider.getFrontendOptions(c.task)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %+v, got %+v", c.desc, c.expected, actual)
+		}
+	}
+}
+
+func TestMesosGetBackendOptions(t *testing.T) {
+	provider := &Provider{}
+	task := task(setLabels(
+		types.LabelBackendMaxconnAmount, "10",
+		types.LabelBackendMaxconnExtractorfunc, "client.ip",
+	))
+
+	expected := &types.Backend{
+		MaxConn: &types.MaxConn{Amount: 10, ExtractorFunc: "client.ip"},
+	}
+	if actual := provider.getBackendOptions(task); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
 // test helpers
 
 type (
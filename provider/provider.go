@@ -15,6 +15,9 @@ import (
 	"github.com/containous/traefik/types"
 )
 
+// commonTemplateFile holds the frontendOptions and backendOptions templates shared by the providers.
+const commonTemplateFile = "templates/common.tmpl"
+
 // Provider defines methods of a provider.
 type Provider interface {
 	// Provide allows the provider to provide configurations to traefik
@@ -29,6 +32,21 @@ type BaseProvider struct {
 	Constraints               types.Constraints `description:"Filter services by constraint, matching with Traefik tags." export:"true"`
 	Trace                     bool              `description:"Display additional provider logs (if available)." export:"true"`
 	DebugLogGeneratedTemplate bool              `description:"Enable debug logging of generated configuration template." export:"true"`
+	instanceName              string
+}
+
+// SetInstanceName names this instance of the provider, when several instances of the same provider type are configured.
+func (p *BaseProvider) SetInstanceName(name string) {
+	p.instanceName = name
+}
+
+// GetProviderName returns the name identifying the configurations sent by this provider:
+// the provider type, followed by the instance name if any (e.g. docker.east).
+func (p *BaseProvider) GetProviderName(providerType string) string {
+	if len(p.instanceName) == 0 {
+		return providerType
+	}
+	return providerType + "." + p.instanceName
 }
 
 // MatchConstraints must match with EVERY single contraint
@@ -58,16 +76,22 @@ func (p *BaseProvider) GetConfiguration(defaultTemplateFile string, funcMap temp
 	)
 	configuration := new(types.Configuration)
 
-	var defaultFuncMap = sprig.TxtFuncMap()
-	// tolower is deprecated in favor of sprig's lower function
-	defaultFuncMap["tolower"] = strings.ToLower
-	defaultFuncMap["normalize"] = Normalize
-	defaultFuncMap["split"] = split
+	var defaultFuncMap = GetDefaultFuncMap()
 	for funcID, funcElement := range funcMap {
 		defaultFuncMap[funcID] = funcElement
 	}
 
 	tmpl := template.New(p.Filename).Funcs(defaultFuncMap)
+
+	// The shared frontend and backend options templates can be used by every template, the custom ones included.
+	buf, err = autogen.Asset(commonTemplateFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tmpl.New(commonTemplateFile).Parse(string(buf)); err != nil {
+		return nil, err
+	}
+
 	if len(p.Filename) > 0 {
 		buf, err = ioutil.ReadFile(p.Filename)
 		if err != nil {
@@ -100,6 +124,16 @@ func (p *BaseProvider) GetConfiguration(defaultTemplateFile string, funcMap temp
 	return configuration, nil
 }
 
+// GetDefaultFuncMap returns the functions available in every template: the sprig functions and the Traefik helpers.
+func GetDefaultFuncMap() template.FuncMap {
+	funcMap := sprig.TxtFuncMap()
+	// tolower is deprecated in favor of sprig's lower function
+	funcMap["tolower"] = strings.ToLower
+	funcMap["normalize"] = Normalize
+	funcMap["split"] = split
+	return funcMap
+}
+
 func split(sep, s string) []string {
 	return strings.Split(s, sep)
 }
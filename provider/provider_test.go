@@ -6,8 +6,12 @@ import (
 	"strings"
 	"testing"
 	"text/template"
+	"time"
 
+	"github.com/containous/flaeg"
 	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type myProvider struct {
@@ -431,3 +435,92 @@ func TestSprigFunctions(t *testing.T) {
 		t.Fatal("Frontend frontend-1 should exists, but it not")
 	}
 }
+
+func TestCommonTemplates(t *testing.T) {
+	templateFile, err := ioutil.TempFile("", "provider-configuration")
+	require.NoError(t, err)
+	defer os.RemoveAll(templateFile.Name())
+
+	data := []byte(`
+[backends]
+  [backends.backend1]
+  {{template "backendOptions" (dict "Path" "backends.backend1" "Backend" .Backend)}}
+    [backends.backend1.servers.server1]
+    url = "http://172.17.0.2:80"
+    weight = 1
+
+[frontends]
+  [frontends."frontend-1"]
+  backend = "backend1"
+  {{template "frontendOptions" (dict "Path" (printf "frontends.%q" "frontend-1") "Frontend" .Frontend)}}
+    [frontends."frontend-1".routes.route1]
+    rule = "Path:/test"`)
+	err = ioutil.WriteFile(templateFile.Name(), data, 0700)
+	require.NoError(t, err)
+
+	frontend := &types.Frontend{
+		EntryPoints:          []string{"http", "https"},
+		PassHostHeader:       true,
+		PassTLSCert:          true,
+		Priority:             10,
+		BasicAuth:            []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+		WhitelistSourceRange: []string{"10.10.10.10"},
+		Auth: &types.Auth{
+			HeaderField: "X-WebAuth-User",
+			Basic: &types.Basic{
+				Users: []string{"test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/"},
+				Realm: "traefik",
+			},
+		},
+		Redirect: &types.Redirect{EntryPoint: "https"},
+		RateLimit: &types.RateLimit{
+			ExtractorFunc: "client.ip",
+			RateSet: map[string]*types.Rate{
+				"foo": {Period: flaeg.Duration(6 * time.Second), Average: 12, Burst: 18},
+			},
+		},
+		Errors: map[string]types.ErrorPage{
+			"foo": {Status: []string{"404", "500-599"}, Backend: "error", Query: "/{status}.html"},
+			"bar": {Status: []string{"503"}, Content: "<h1>\"{statusText}\"</h1>\n", ContentType: "text/html"},
+		},
+		Headers: types.Headers{
+			CustomRequestHeaders: map[string]string{"X-Foo": "bar"},
+			SSLRedirect:          true,
+			STSSeconds:           666,
+			SSLProxyHeaders:      map[string]string{"X-Forwarded-Proto": "https"},
+		},
+	}
+	backend := &types.Backend{
+		CircuitBreaker: &types.CircuitBreaker{Expression: "NetworkErrorRatio() > 0.5"},
+		LoadBalancer: &types.LoadBalancer{
+			Method:     "drr",
+			Stickiness: &types.Stickiness{CookieName: "chocolate"},
+		},
+		MaxConn:     &types.MaxConn{Amount: 10, ExtractorFunc: "client.ip"},
+		HealthCheck: &types.HealthCheck{Path: "/health", Port: 8080, Interval: "10s"},
+		ServersTransport: &types.ServersTransport{
+			ServerName:          "foo.example.com",
+			RootCAs:             []string{"ca.pem"},
+			MaxIdleConnsPerHost: 42,
+		},
+	}
+
+	provider := &myProvider{
+		BaseProvider{
+			Filename: templateFile.Name(),
+		},
+		nil,
+	}
+	configuration, err := provider.GetConfiguration(templateFile.Name(), nil, map[string]interface{}{
+		"Frontend": frontend,
+		"Backend":  backend,
+	})
+	require.NoError(t, err)
+
+	frontend.Backend = "backend1"
+	frontend.Routes = map[string]types.Route{"route1": {Rule: "Path:/test"}}
+	assert.Equal(t, frontend, configuration.Frontends["frontend-1"])
+
+	backend.Servers = map[string]types.Server{"server1": {URL: "http://172.17.0.2:80", Weight: 1}}
+	assert.Equal(t, backend, configuration.Backends["backend1"])
+}
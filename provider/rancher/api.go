@@ -80,7 +80,7 @@ func (p *Provider) apiProvide(configurationChan chan<- types.ConfigMessage, pool
 
 			configuration := p.loadRancherConfig(rancherData)
 			configurationChan <- types.ConfigMessage{
-				ProviderName:  "rancher",
+				ProviderName:  p.GetProviderName("rancher"),
 				Configuration: configuration,
 			}
 
@@ -102,7 +102,7 @@ func (p *Provider) apiProvide(configurationChan chan<- types.ConfigMessage, pool
 							configuration := p.loadRancherConfig(rancherData)
 							if configuration != nil {
 								configurationChan <- types.ConfigMessage{
-									ProviderName:  "rancher",
+									ProviderName:  p.GetProviderName("rancher"),
 									Configuration: configuration,
 								}
 							}
@@ -47,7 +47,7 @@ func (p *Provider) metadataProvide(configurationChan chan<- types.ConfigMessage,
 				rancherData := parseMetadataSourcedRancherData(stacks)
 				configuration := p.loadRancherConfig(rancherData)
 				configurationChan <- types.ConfigMessage{
-					ProviderName:  "rancher",
+					ProviderName:  p.GetProviderName("rancher"),
 					Configuration: configuration,
 				}
 			}
@@ -2,14 +2,13 @@ package rancher
 
 import (
 	"fmt"
-	"math"
-	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/BurntSushi/ty/fun"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/label"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/types"
 )
@@ -41,25 +40,8 @@ func (r rancherData) String() string {
 }
 
 // Frontend Labels
-func (p *Provider) getPassHostHeader(service rancherData) string {
-	if passHostHeader, err := getServiceLabel(service, types.LabelFrontendPassHostHeader); err == nil {
-		return passHostHeader
-	}
-	return "true"
-}
-
-func (p *Provider) getPriority(service rancherData) string {
-	if priority, err := getServiceLabel(service, types.LabelFrontendPriority); err == nil {
-		return priority
-	}
-	return "0"
-}
-
-func (p *Provider) getEntryPoints(service rancherData) []string {
-	if entryPoints, err := getServiceLabel(service, types.LabelFrontendEntryPoints); err == nil {
-		return strings.Split(entryPoints, ",")
-	}
-	return []string{}
+func (p *Provider) getFrontendOptions(service rancherData) *types.Frontend {
+	return label.GetFrontend(service.Labels)
 }
 
 func (p *Provider) getFrontendRule(service rancherData) string {
@@ -69,68 +51,14 @@ func (p *Provider) getFrontendRule(service rancherData) string {
 	return "Host:" + strings.ToLower(strings.Replace(service.Name, "/", ".", -1)) + "." + p.Domain
 }
 
-func (p *Provider) getBasicAuth(service rancherData) []string {
-	if basicAuth, err := getServiceLabel(service, types.LabelFrontendAuthBasic); err == nil {
-		return strings.Split(basicAuth, ",")
-	}
-	return []string{}
-}
-
 func (p *Provider) getFrontendName(service rancherData) string {
 	// Replace '.' with '-' in quoted keys because of this issue https://github.com/BurntSushi/toml/issues/78
 	return provider.Normalize(p.getFrontendRule(service))
 }
 
 // Backend Labels
-func (p *Provider) getLoadBalancerMethod(service rancherData) string {
-	if label, err := getServiceLabel(service, types.LabelBackendLoadbalancerMethod); err == nil {
-		return label
-	}
-	return "wrr"
-}
-
-func (p *Provider) hasLoadBalancerLabel(service rancherData) bool {
-	_, errMethod := getServiceLabel(service, types.LabelBackendLoadbalancerMethod)
-	_, errSticky := getServiceLabel(service, types.LabelBackendLoadbalancerSticky)
-	_, errStickiness := getServiceLabel(service, types.LabelBackendLoadbalancerStickiness)
-	_, errCookieName := getServiceLabel(service, types.LabelBackendLoadbalancerStickinessCookieName)
-
-	return errMethod == nil || errSticky == nil || errStickiness == nil || errCookieName == nil
-}
-
-func (p *Provider) hasCircuitBreakerLabel(service rancherData) bool {
-	if _, err := getServiceLabel(service, types.LabelBackendCircuitbreakerExpression); err != nil {
-		return false
-	}
-	return true
-}
-
-func (p *Provider) getCircuitBreakerExpression(service rancherData) string {
-	if label, err := getServiceLabel(service, types.LabelBackendCircuitbreakerExpression); err == nil {
-		return label
-	}
-	return "NetworkErrorRatio() > 1"
-}
-
-func (p *Provider) getSticky(service rancherData) string {
-	if _, err := getServiceLabel(service, types.LabelBackendLoadbalancerSticky); err == nil {
-		log.Warnf("Deprecated configuration found: %s. Please use %s.", types.LabelBackendLoadbalancerSticky, types.LabelBackendLoadbalancerStickiness)
-		return "true"
-	}
-	return "false"
-}
-
-func (p *Provider) hasStickinessLabel(service rancherData) bool {
-	labelStickiness, errStickiness := getServiceLabel(service, types.LabelBackendLoadbalancerStickiness)
-
-	return errStickiness == nil && len(labelStickiness) > 0 && strings.EqualFold(strings.TrimSpace(labelStickiness), "true")
-}
-
-func (p *Provider) getStickinessCookieName(service rancherData, backendName string) string {
-	if label, err := getServiceLabel(service, types.LabelBackendLoadbalancerStickinessCookieName); err == nil {
-		return label
-	}
-	return ""
+func (p *Provider) getBackendOptions(service rancherData) *types.Backend {
+	return label.GetBackend(service.Labels)
 }
 
 func (p *Provider) getBackend(service rancherData) string {
@@ -169,35 +97,6 @@ func (p *Provider) getDomain(service rancherData) string {
 	return p.Domain
 }
 
-func (p *Provider) hasMaxConnLabels(service rancherData) bool {
-	if _, err := getServiceLabel(service, types.LabelBackendMaxconnAmount); err != nil {
-		return false
-	}
-	if _, err := getServiceLabel(service, types.LabelBackendMaxconnExtractorfunc); err != nil {
-		return false
-	}
-	return true
-}
-
-func (p *Provider) getMaxConnAmount(service rancherData) int64 {
-	if label, err := getServiceLabel(service, types.LabelBackendMaxconnAmount); err == nil {
-		i, errConv := strconv.ParseInt(label, 10, 64)
-		if errConv != nil {
-			log.Errorf("Unable to parse %s %s", types.LabelBackendMaxconnAmount, label)
-			return math.MaxInt64
-		}
-		return i
-	}
-	return math.MaxInt64
-}
-
-func (p *Provider) getMaxConnExtractorFunc(service rancherData) string {
-	if label, err := getServiceLabel(service, types.LabelBackendMaxconnExtractorfunc); err == nil {
-		return label
-	}
-	return "request.host"
-}
-
 func getServiceLabel(service rancherData, label string) (string, error) {
 	for key, value := range service.Labels {
 		if key == label {
@@ -219,26 +118,14 @@ func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *s
 func (p *Provider) loadRancherConfig(services []rancherData) *types.Configuration {
 
 	var RancherFuncMap = template.FuncMap{
-		"getPort":                     p.getPort,
-		"getBackend":                  p.getBackend,
-		"getWeight":                   p.getWeight,
-		"getDomain":                   p.getDomain,
-		"getProtocol":                 p.getProtocol,
-		"getPassHostHeader":           p.getPassHostHeader,
-		"getPriority":                 p.getPriority,
-		"getEntryPoints":              p.getEntryPoints,
-		"getBasicAuth":                p.getBasicAuth,
-		"getFrontendRule":             p.getFrontendRule,
-		"hasCircuitBreakerLabel":      p.hasCircuitBreakerLabel,
-		"getCircuitBreakerExpression": p.getCircuitBreakerExpression,
-		"hasLoadBalancerLabel":        p.hasLoadBalancerLabel,
-		"getLoadBalancerMethod":       p.getLoadBalancerMethod,
-		"hasMaxConnLabels":            p.hasMaxConnLabels,
-		"getMaxConnAmount":            p.getMaxConnAmount,
-		"getMaxConnExtractorFunc":     p.getMaxConnExtractorFunc,
-		"getSticky":                   p.getSticky,
-		"hasStickinessLabel":          p.hasStickinessLabel,
-		"getStickinessCookieName":     p.getStickinessCookieName,
+		"getPort":            p.getPort,
+		"getBackend":         p.getBackend,
+		"getWeight":          p.getWeight,
+		"getDomain":          p.getDomain,
+		"getProtocol":        p.getProtocol,
+		"getFrontendRule":    p.getFrontendRule,
+		"getFrontendOptions": p.getFrontendOptions,
+		"getBackendOptions":  p.getBackendOptions,
 	}
 
 	// filter services
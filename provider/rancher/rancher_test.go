@@ -454,41 +454,6 @@ func TestRancherGetProtocol(t *testing.T) {
 	}
 }
 
-func TestRancherGetPassHostHeader(t *testing.T) {
-	provider := &Provider{
-		Domain: "rancher.localhost",
-	}
-
-	services := []struct {
-		service  rancherData
-		expected string
-	}{
-		{
-			service: rancherData{
-				Name: "test-service",
-			},
-			expected: "true",
-		},
-		{
-			service: rancherData{
-				Name: "test-service",
-				Labels: map[string]string{
-					types.LabelFrontendPassHostHeader: "false",
-				},
-			},
-
-			expected: "false",
-		},
-	}
-
-	for _, e := range services {
-		actual := provider.getPassHostHeader(e.service)
-		if actual != e.expected {
-			t.Fatalf("expected %q, got %q", e.expected, actual)
-		}
-	}
-}
-
 func TestRancherGetLabel(t *testing.T) {
 	services := []struct {
 		service  rancherData
@@ -599,52 +564,30 @@ func TestRancherLoadRancherConfig(t *testing.T) {
 	}
 }
 
-func TestRancherHasStickinessLabel(t *testing.T) {
-	provider := &Provider{
-		Domain: "rancher.localhost",
-	}
-
-	testCases := []struct {
-		desc     string
-		service  rancherData
-		expected bool
-	}{
-		{
-			desc: "no labels",
-			service: rancherData{
-				Name: "test-service",
-			},
-			expected: false,
-		},
-		{
-			desc: "stickiness=true",
-			service: rancherData{
-				Name: "test-service",
-				Labels: map[string]string{
-					types.LabelBackendLoadbalancerStickiness: "true",
-				},
-			},
-			expected: true,
-		},
-		{
-			desc: "stickiness=true",
-			service: rancherData{
-				Name: "test-service",
-				Labels: map[string]string{
-					types.LabelBackendLoadbalancerStickiness: "false",
-				},
-			},
-			expected: false,
+func TestRancherGetOptions(t *testing.T) {
+	provider := &Provider{}
+	service := rancherData{
+		Name: "test-service",
+		Labels: map[string]string{
+			types.LabelFrontendPassHostHeader:                  "false",
+			types.LabelFrontendRequestHeader:                   "X-Foo:bar",
+			types.LabelBackendLoadbalancerStickiness:           "true",
+			types.LabelBackendLoadbalancerStickinessCookieName: "chocolate",
+			types.LabelBackendHealthcheckPath:                  "/health",
 		},
 	}
 
-	for _, test := range testCases {
-		test := test
-		t.Run(test.desc, func(t *testing.T) {
-			t.Parallel()
+	expectedFrontend := &types.Frontend{
+		Headers: types.Headers{CustomRequestHeaders: map[string]string{"X-Foo": "bar"}},
+	}
+	assert.Equal(t, expectedFrontend, provider.getFrontendOptions(service))
 
-			actual := provider.hasStickinessLabel(test.service)
-			assert.Equal(t, actual, test.expected)
-		})
+	expectedBackend := &types.Backend{
+		LoadBalancer: &types.LoadBalancer{
+			Method:     "wrr",
+			Stickiness: &types.Stickiness{CookieName: "chocolate"},
+		},
+		HealthCheck: &types.HealthCheck{Path: "/health"},
 	}
+	assert.Equal(t, expectedBackend, provider.getBackendOptions(service))
 }
@@ -1,14 +1,21 @@
 package rest
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"github.com/containous/mux"
+	"github.com/containous/staert"
+	"github.com/containous/traefik/cluster"
 	"github.com/containous/traefik/log"
 	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/tls"
 	"github.com/containous/traefik/types"
 	"github.com/unrolled/render"
 )
@@ -17,13 +24,48 @@ import (
 type Provider struct {
 	configurationChan     chan<- types.ConfigMessage
 	EntryPoint            string `description:"EntryPoint" export:"true"`
+	Storage               string `description:"File or KV key (in cluster mode) used to persist the configuration managed by the REST API" export:"true"`
 	CurrentConfigurations *safe.Safe
+
+	lock          sync.Mutex
+	configuration *types.Configuration
+	etag          string
+	store         store
+	leadership    *cluster.Leadership
+	validator     func(*types.Configuration) error
+}
+
+// statusError is an error returned to the API client with its HTTP status code.
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func newStatusError(status int, format string, args ...interface{}) error {
+	return &statusError{status: status, message: fmt.Sprintf(format, args...)}
 }
 
 var templatesRenderer = render.New(render.Options{Directory: "nowhere"})
 
+// SetValidator sets the function checking the configurations submitted through the REST API.
+// An invalid configuration is rejected, instead of having its invalid frontends skipped by the server.
+func (p *Provider) SetValidator(validator func(*types.Configuration) error) {
+	p.validator = validator
+}
+
+// SetLeadership sets the cluster leadership, whose KV store persists the configuration.
+func (p *Provider) SetLeadership(leadership *cluster.Leadership) {
+	p.leadership = leadership
+}
+
 // AddRoutes add rest provider routes on a router
 func (p *Provider) AddRoutes(systemRouter *mux.Router) {
+	systemRouter.Methods("GET").Path("/api/providers/rest").HandlerFunc(p.getRestConfigHandler)
+
 	systemRouter.Methods("PUT").Path("/api/providers/{provider}").HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
 		vars := mux.Vars(request)
 		// TODO: Deprecated configuration - Need to be removed in the future
@@ -36,30 +78,399 @@ func (p *Provider) AddRoutes(systemRouter *mux.Router) {
 		}
 
 		configuration := new(types.Configuration)
-		body, _ := ioutil.ReadAll(request.Body)
-		err := json.Unmarshal(body, configuration)
-		if err == nil {
-			// TODO: Deprecated configuration - Change to `rest` in the future
-			p.configurationChan <- types.ConfigMessage{ProviderName: "web", Configuration: configuration}
-			p.getConfigHandler(response, request)
-		} else {
-			log.Errorf("Error parsing configuration %+v", err)
-			http.Error(response, fmt.Sprintf("%+v", err), http.StatusBadRequest)
+		if err := decodeBody(request, configuration); err != nil {
+			writeError(response, err)
+			return
+		}
+
+		_, etag, err := p.update(request, func(current *types.Configuration) (int, error) {
+			*current = *configuration
+			return http.StatusOK, nil
+		})
+		if err != nil {
+			writeError(response, err)
+			return
 		}
+
+		response.Header().Set("ETag", etag)
+		p.getConfigHandler(response, request)
 	})
+
+	systemRouter.Methods("POST", "PUT").Path("/api/providers/rest/frontends/{frontend}").HandlerFunc(p.putFrontendHandler)
+	systemRouter.Methods("DELETE").Path("/api/providers/rest/frontends/{frontend}").HandlerFunc(p.deleteFrontendHandler)
+	systemRouter.Methods("POST", "PUT").Path("/api/providers/rest/backends/{backend}").HandlerFunc(p.putBackendHandler)
+	systemRouter.Methods("DELETE").Path("/api/providers/rest/backends/{backend}").HandlerFunc(p.deleteBackendHandler)
+	systemRouter.Methods("POST").Path("/api/providers/rest/certificates").HandlerFunc(p.postCertificateHandler)
+	systemRouter.Methods("PUT").Path("/api/providers/rest/certificates/{certificate}").HandlerFunc(p.putCertificateHandler)
+	systemRouter.Methods("DELETE").Path("/api/providers/rest/certificates/{certificate}").HandlerFunc(p.deleteCertificateHandler)
 }
 
 // Provide allows the provider to provide configurations to traefik
 // using the given configuration channel.
 func (p *Provider) Provide(configurationChan chan<- types.ConfigMessage, pool *safe.Pool, _ types.Constraints) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.Storage) > 0 {
+		if err := p.createStore(configurationChan); err != nil {
+			return err
+		}
+
+		configuration, err := p.store.load()
+		if err != nil {
+			return fmt.Errorf("unable to load the REST configuration from %s: %v", p.Storage, err)
+		}
+		if configuration != nil {
+			p.setConfiguration(configurationChan, configuration)
+		}
+	}
+
+	if p.configuration == nil {
+		p.configuration = &types.Configuration{}
+		p.etag = computeETag(p.configuration)
+	}
 	p.configurationChan = configurationChan
 	return nil
 }
 
+func (p *Provider) createStore(configurationChan chan<- types.ConfigMessage) error {
+	if p.leadership == nil {
+		p.store = &fileStore{filename: p.Storage}
+		return nil
+	}
+
+	// the configuration changed through the other nodes of the cluster
+	listener := func(cluster.Object) error {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		return p.reload(configurationChan)
+	}
+
+	datastore, err := cluster.NewDataStore(
+		p.leadership.Pool.Ctx(),
+		staert.KvSource{
+			Store:  p.leadership.Store,
+			Prefix: p.Storage,
+		},
+		&storedConfiguration{},
+		listener)
+	if err != nil {
+		return err
+	}
+
+	p.store = &kvStore{datastore: datastore}
+	return nil
+}
+
+// reload provides the configuration of the store when it differs from the current one.
+// The store is read again, instead of using the object notified by the datastore, which may predate
+// a commit of this node: the commits are made under the lock, so the current configuration is their result,
+// and is not provided twice.
+func (p *Provider) reload(configurationChan chan<- types.ConfigMessage) error {
+	configuration, err := p.store.load()
+	if err != nil {
+		return err
+	}
+	if configuration == nil || computeETag(configuration) == p.etag {
+		return nil
+	}
+
+	p.setConfiguration(configurationChan, configuration)
+	return nil
+}
+
+// update applies a change to a copy of the REST configuration, then validates, persists and provides the result.
+// The change returns the HTTP status of the response, which is returned with the ETag of the new configuration.
+func (p *Provider) update(request *http.Request, change func(*types.Configuration) (int, error)) (int, string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.configurationChan == nil {
+		return 0, "", newStatusError(http.StatusServiceUnavailable, "the REST provider is not started")
+	}
+
+	if match := request.Header.Get("If-Match"); len(match) > 0 && match != "*" && match != p.etag {
+		return 0, "", newStatusError(http.StatusPreconditionFailed, "the REST configuration has been modified, its current ETag is %s", p.etag)
+	}
+
+	configuration, err := copyConfiguration(p.configuration)
+	if err != nil {
+		return 0, "", err
+	}
+
+	status, err := change(configuration)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if p.validator != nil {
+		if err := p.validator(configuration); err != nil {
+			return 0, "", newStatusError(http.StatusBadRequest, "invalid configuration: %v", err)
+		}
+	}
+
+	if p.store != nil {
+		if err := p.store.save(configuration); err != nil {
+			return 0, "", fmt.Errorf("unable to persist the REST configuration: %v", err)
+		}
+	}
+
+	p.setConfiguration(p.configurationChan, configuration)
+
+	return status, p.etag, nil
+}
+
+// setConfiguration sets the REST configuration and provides it, p.lock must be held.
+func (p *Provider) setConfiguration(configurationChan chan<- types.ConfigMessage, configuration *types.Configuration) {
+	p.configuration = configuration
+	p.etag = computeETag(configuration)
+
+	// the server completes the configurations it receives, which must not change the REST one
+	configurationCopy, err := copyConfiguration(configuration)
+	if err != nil {
+		log.Errorf("Error copying the REST configuration: %v", err)
+		return
+	}
+
+	// TODO: Deprecated configuration - Change to `rest` in the future
+	configurationChan <- types.ConfigMessage{ProviderName: "web", Configuration: configurationCopy}
+}
+
+func (p *Provider) putFrontendHandler(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["frontend"]
+
+	frontend := new(types.Frontend)
+	if err := decodeBody(request, frontend); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	status, etag, err := p.update(request, func(configuration *types.Configuration) (int, error) {
+		_, exists := configuration.Frontends[name]
+		if exists && request.Method == http.MethodPost {
+			return 0, newStatusError(http.StatusConflict, "frontend %s already exists", name)
+		}
+
+		if configuration.Frontends == nil {
+			configuration.Frontends = make(map[string]*types.Frontend)
+		}
+		configuration.Frontends[name] = frontend
+
+		if exists {
+			return http.StatusOK, nil
+		}
+		return http.StatusCreated, nil
+	})
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+	writeResponse(response, status, etag, frontend.WithoutSecrets())
+}
+
+func (p *Provider) deleteFrontendHandler(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["frontend"]
+
+	status, etag, err := p.update(request, func(configuration *types.Configuration) (int, error) {
+		if _, exists := configuration.Frontends[name]; !exists {
+			return 0, newStatusError(http.StatusNotFound, "frontend %s not found", name)
+		}
+		delete(configuration.Frontends, name)
+		return http.StatusNoContent, nil
+	})
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+	writeResponse(response, status, etag, nil)
+}
+
+func (p *Provider) putBackendHandler(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["backend"]
+
+	backend := new(types.Backend)
+	if err := decodeBody(request, backend); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	status, etag, err := p.update(request, func(configuration *types.Configuration) (int, error) {
+		_, exists := configuration.Backends[name]
+		if exists && request.Method == http.MethodPost {
+			return 0, newStatusError(http.StatusConflict, "backend %s already exists", name)
+		}
+
+		if configuration.Backends == nil {
+			configuration.Backends = make(map[string]*types.Backend)
+		}
+		configuration.Backends[name] = backend
+
+		if exists {
+			return http.StatusOK, nil
+		}
+		return http.StatusCreated, nil
+	})
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+	writeResponse(response, status, etag, backend.WithoutSecrets())
+}
+
+func (p *Provider) deleteBackendHandler(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["backend"]
+
+	status, etag, err := p.update(request, func(configuration *types.Configuration) (int, error) {
+		if _, exists := configuration.Backends[name]; !exists {
+			return 0, newStatusError(http.StatusNotFound, "backend %s not found", name)
+		}
+		delete(configuration.Backends, name)
+		return http.StatusNoContent, nil
+	})
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+	writeResponse(response, status, etag, nil)
+}
+
+// The TLS configurations have no name, they are identified by their index.
+// The ETags prevent a client from using an index shifted by a concurrent change.
+
+func (p *Provider) postCertificateHandler(response http.ResponseWriter, request *http.Request) {
+	tlsConfiguration := new(tls.Configuration)
+	if err := decodeBody(request, tlsConfiguration); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	var index int
+	status, etag, err := p.update(request, func(configuration *types.Configuration) (int, error) {
+		index = len(configuration.TLSConfiguration)
+		configuration.TLSConfiguration = append(configuration.TLSConfiguration, tlsConfiguration)
+		return http.StatusCreated, nil
+	})
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+	response.Header().Set("Location", request.URL.Path+"/"+strconv.Itoa(index))
+	writeResponse(response, status, etag, tlsConfiguration.WithoutSecrets())
+}
+
+func (p *Provider) putCertificateHandler(response http.ResponseWriter, request *http.Request) {
+	tlsConfiguration := new(tls.Configuration)
+	if err := decodeBody(request, tlsConfiguration); err != nil {
+		writeError(response, err)
+		return
+	}
+
+	status, etag, err := p.update(request, func(configuration *types.Configuration) (int, error) {
+		index, err := getCertificateIndex(request, configuration)
+		if err != nil {
+			return 0, err
+		}
+		configuration.TLSConfiguration[index] = tlsConfiguration
+		return http.StatusOK, nil
+	})
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+	writeResponse(response, status, etag, tlsConfiguration.WithoutSecrets())
+}
+
+func (p *Provider) deleteCertificateHandler(response http.ResponseWriter, request *http.Request) {
+	status, etag, err := p.update(request, func(configuration *types.Configuration) (int, error) {
+		index, err := getCertificateIndex(request, configuration)
+		if err != nil {
+			return 0, err
+		}
+		configuration.TLSConfiguration = append(configuration.TLSConfiguration[:index], configuration.TLSConfiguration[index+1:]...)
+		return http.StatusNoContent, nil
+	})
+	if err != nil {
+		writeError(response, err)
+		return
+	}
+	writeResponse(response, status, etag, nil)
+}
+
+func getCertificateIndex(request *http.Request, configuration *types.Configuration) (int, error) {
+	certificate := mux.Vars(request)["certificate"]
+	index, err := strconv.Atoi(certificate)
+	if err != nil || index < 0 || index >= len(configuration.TLSConfiguration) {
+		return 0, newStatusError(http.StatusNotFound, "certificate %s not found", certificate)
+	}
+	return index, nil
+}
+
+func (p *Provider) getRestConfigHandler(response http.ResponseWriter, request *http.Request) {
+	p.lock.Lock()
+	configuration, etag := p.configuration, p.etag
+	p.lock.Unlock()
+
+	if configuration == nil {
+		configuration = &types.Configuration{}
+		etag = computeETag(configuration)
+	}
+
+	response.Header().Set("ETag", etag)
+	err := templatesRenderer.JSON(response, http.StatusOK, configuration.WithoutSecrets())
+	if err != nil {
+		log.Error(err)
+	}
+}
+
 func (p *Provider) getConfigHandler(response http.ResponseWriter, request *http.Request) {
 	currentConfigurations := p.CurrentConfigurations.Get().(types.Configurations)
-	err := templatesRenderer.JSON(response, http.StatusOK, currentConfigurations)
+	err := templatesRenderer.JSON(response, http.StatusOK, currentConfigurations.WithoutSecrets())
 	if err != nil {
 		log.Error(err)
 	}
 }
+
+func decodeBody(request *http.Request, object interface{}) error {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return newStatusError(http.StatusBadRequest, "%+v", err)
+	}
+	if err := json.Unmarshal(body, object); err != nil {
+		log.Errorf("Error parsing configuration %+v", err)
+		return newStatusError(http.StatusBadRequest, "%+v", err)
+	}
+	return nil
+}
+
+// writeResponse writes the object changed by a request.
+func writeResponse(response http.ResponseWriter, status int, etag string, object interface{}) {
+	response.Header().Set("ETag", etag)
+	if object == nil {
+		response.WriteHeader(status)
+		return
+	}
+
+	if err := templatesRenderer.JSON(response, status, object); err != nil {
+		log.Error(err)
+	}
+}
+
+func writeError(response http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if statusErr, ok := err.(*statusError); ok {
+		status = statusErr.status
+	}
+	if status == http.StatusInternalServerError {
+		log.Errorf("Error updating the REST configuration: %v", err)
+	}
+	http.Error(response, err.Error(), status)
+}
+
+func computeETag(configuration *types.Configuration) string {
+	content, err := json.Marshal(configuration)
+	if err != nil {
+		log.Errorf("Error computing the REST configuration ETag: %v", err)
+	}
+	hash := sha1.Sum(content)
+	return `"` + hex.EncodeToString(hash[:]) + `"`
+}
@@ -0,0 +1,222 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/containous/mux"
+	"github.com/containous/traefik/safe"
+	"github.com/containous/traefik/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startProvider(t *testing.T, p *Provider) (*mux.Router, chan types.ConfigMessage) {
+	configurationChan := make(chan types.ConfigMessage, 10)
+	err := p.Provide(configurationChan, safe.NewPool(context.Background()), nil)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	p.AddRoutes(router)
+	return router, configurationChan
+}
+
+func serve(router *mux.Router, method, path, etag, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if len(etag) > 0 {
+		request.Header.Set("If-Match", etag)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestProviderObjectsCRUD(t *testing.T) {
+	p := &Provider{}
+	router, configurationChan := startProvider(t, p)
+
+	resp := serve(router, http.MethodPost, "/api/providers/rest/backends/backend1", "", `{"servers": {"server1": {"url": "http://127.0.0.1:8080"}}}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	require.Len(t, configurationChan, 1)
+	configMsg := <-configurationChan
+	assert.Equal(t, "web", configMsg.ProviderName)
+	assert.Contains(t, configMsg.Configuration.Backends, "backend1")
+
+	resp = serve(router, http.MethodPost, "/api/providers/rest/backends/backend1", "", `{}`)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	resp = serve(router, http.MethodPut, "/api/providers/rest/frontends/frontend1", "", `{"backend": "backend1"}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+
+	resp = serve(router, http.MethodPut, "/api/providers/rest/frontends/frontend1", "", `{"backend": "backend1", "priority": 10}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 10, p.configuration.Frontends["frontend1"].Priority)
+
+	resp = serve(router, http.MethodPost, "/api/providers/rest/certificates", "", `{"entryPoints": ["https"], "certificate": {"certFile": "foo.crt", "keyFile": "foo.key"}}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, "/api/providers/rest/certificates/0", resp.Header().Get("Location"))
+
+	resp = serve(router, http.MethodDelete, "/api/providers/rest/certificates/1", "", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = serve(router, http.MethodDelete, "/api/providers/rest/certificates/0", "", "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Empty(t, p.configuration.TLSConfiguration)
+
+	resp = serve(router, http.MethodDelete, "/api/providers/rest/frontends/frontend1", "", "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+
+	resp = serve(router, http.MethodDelete, "/api/providers/rest/frontends/frontend1", "", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = serve(router, http.MethodGet, "/api/providers/rest", "", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, p.etag, resp.Header().Get("ETag"))
+	assert.Contains(t, resp.Body.String(), "backend1")
+	assert.NotContains(t, resp.Body.String(), "frontend1")
+}
+
+func TestProviderHidesSecrets(t *testing.T) {
+	p := &Provider{}
+	router, _ := startProvider(t, p)
+
+	resp := serve(router, http.MethodPut, "/api/providers/rest/backends/backend1", "", `{"loadBalancer": {"method": "wrr", "stickiness": {"secret": "stickiness-secret"}}}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "stickiness-secret")
+
+	resp = serve(router, http.MethodGet, "/api/providers/rest", "", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "backend1")
+	assert.NotContains(t, resp.Body.String(), "stickiness-secret")
+
+	assert.Equal(t, "stickiness-secret", p.configuration.Backends["backend1"].LoadBalancer.Stickiness.Secret)
+}
+
+func TestProviderValidation(t *testing.T) {
+	p := &Provider{}
+	p.SetValidator(func(configuration *types.Configuration) error {
+		for name, frontend := range configuration.Frontends {
+			if _, ok := configuration.Backends[frontend.Backend]; !ok {
+				return errors.New("undefined backend for frontend " + name)
+			}
+		}
+		return nil
+	})
+	router, configurationChan := startProvider(t, p)
+
+	resp := serve(router, http.MethodPut, "/api/providers/rest/frontends/frontend1", "", `{"backend": "backend1"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "undefined backend for frontend frontend1")
+
+	resp = serve(router, http.MethodPut, "/api/providers/rest/frontends/frontend1", "", `{"backend": `)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	assert.Len(t, configurationChan, 0)
+	assert.Empty(t, p.configuration.Frontends)
+}
+
+func TestProviderConcurrency(t *testing.T) {
+	p := &Provider{}
+	router, _ := startProvider(t, p)
+
+	etag := serve(router, http.MethodGet, "/api/providers/rest", "", "").Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	resp := serve(router, http.MethodPut, "/api/providers/rest/backends/backend1", etag, `{}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	newETag := resp.Header().Get("ETag")
+	assert.NotEqual(t, etag, newETag)
+
+	// the configuration changed since the first ETag
+	resp = serve(router, http.MethodPut, "/api/providers/rest/backends/backend2", etag, `{}`)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.Code)
+
+	resp = serve(router, http.MethodPut, "/api/providers/rest/backends/backend2", newETag, `{}`)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+}
+
+func TestProviderFileStorage(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "rest")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	storage := filepath.Join(tempDir, "rest.json")
+
+	p := &Provider{Storage: storage}
+	router, _ := startProvider(t, p)
+
+	resp := serve(router, http.MethodPut, "/api/providers/rest/backends/backend1", "", `{"servers": {"server1": {"url": "http://127.0.0.1:8080"}}}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	// a restarted provider provides the persisted configuration
+	restarted := &Provider{Storage: storage}
+	_, configurationChan := startProvider(t, restarted)
+
+	require.Len(t, configurationChan, 1)
+	configMsg := <-configurationChan
+	require.Contains(t, configMsg.Configuration.Backends, "backend1")
+	assert.Equal(t, "http://127.0.0.1:8080", configMsg.Configuration.Backends["backend1"].Servers["server1"].URL)
+	assert.Equal(t, p.etag, restarted.etag)
+}
+
+type memoryStore struct {
+	configuration *types.Configuration
+}
+
+func (s *memoryStore) load() (*types.Configuration, error) {
+	return copyConfiguration(s.configuration)
+}
+
+func (s *memoryStore) save(configuration *types.Configuration) error {
+	var err error
+	s.configuration, err = copyConfiguration(configuration)
+	return err
+}
+
+func TestProviderReload(t *testing.T) {
+	p := &Provider{}
+	router, configurationChan := startProvider(t, p)
+	store := &memoryStore{}
+	p.store = store
+
+	resp := serve(router, http.MethodPut, "/api/providers/rest/backends/backend1", "", `{}`)
+	require.Equal(t, http.StatusCreated, resp.Code)
+	require.Len(t, configurationChan, 1)
+	<-configurationChan
+
+	// the commits of this node are not provided again
+	require.NoError(t, p.reload(configurationChan))
+	assert.Len(t, configurationChan, 0)
+
+	// another node deleted the backend
+	store.configuration = &types.Configuration{}
+	require.NoError(t, p.reload(configurationChan))
+	require.Len(t, configurationChan, 1)
+	configMsg := <-configurationChan
+	assert.Empty(t, configMsg.Configuration.Backends)
+	assert.Empty(t, p.configuration.Backends)
+}
+
+func TestStoredConfigurationDecoding(t *testing.T) {
+	stored := &storedConfiguration{}
+	require.NoError(t, json.Unmarshal([]byte(`{"backends": {"backend1": {}, "backend2": {}}}`), stored))
+	assert.Len(t, stored.Backends, 2)
+
+	// the backends deleted since the previous decoding are not kept
+	require.NoError(t, json.Unmarshal([]byte(`{"backends": {"backend2": {}}}`), stored))
+	assert.Len(t, stored.Backends, 1)
+	assert.Contains(t, stored.Backends, "backend2")
+
+	content, err := json.Marshal(stored)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"backends": {"backend2": {}}}`, string(content))
+}
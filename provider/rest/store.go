@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/containous/traefik/cluster"
+	"github.com/containous/traefik/types"
+)
+
+// store persists the configuration managed by the REST API.
+type store interface {
+	// load returns the persisted configuration, or nil when there is none.
+	load() (*types.Configuration, error)
+	save(configuration *types.Configuration) error
+}
+
+var _ store = (*fileStore)(nil)
+
+// fileStore persists the configuration in a local JSON file.
+type fileStore struct {
+	filename string
+}
+
+func (s *fileStore) load() (*types.Configuration, error) {
+	content, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	configuration := new(types.Configuration)
+	if err := json.Unmarshal(content, configuration); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", s.filename, err)
+	}
+	return configuration, nil
+}
+
+func (s *fileStore) save(configuration *types.Configuration) error {
+	content, err := json.MarshalIndent(configuration, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that a failure never leaves a truncated configuration
+	tmpFilename := s.filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFilename, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilename, s.filename)
+}
+
+var _ store = (*kvStore)(nil)
+
+// kvStore persists the configuration in the KV store of the cluster.
+type kvStore struct {
+	datastore cluster.Store
+}
+
+func (s *kvStore) load() (*types.Configuration, error) {
+	object, err := s.datastore.Load()
+	if err != nil {
+		return nil, err
+	}
+	return copyConfiguration(&object.(*storedConfiguration).Configuration)
+}
+
+func (s *kvStore) save(configuration *types.Configuration) error {
+	transaction, _, err := s.datastore.Begin()
+	if err != nil {
+		return err
+	}
+
+	// the datastore keeps the committed object, which must not be shared with the provider
+	object, err := copyConfiguration(configuration)
+	if err != nil {
+		return err
+	}
+	return transaction.Commit(&storedConfiguration{Configuration: *object})
+}
+
+// storedConfiguration is the configuration kept by the datastore, which decodes each reload into the same object.
+// It is reset before being decoded, so that the objects deleted by the other nodes are not kept.
+type storedConfiguration struct {
+	types.Configuration
+}
+
+// UnmarshalJSON decodes the configuration into a new one.
+func (c *storedConfiguration) UnmarshalJSON(data []byte) error {
+	configuration := types.Configuration{}
+	if err := json.Unmarshal(data, &configuration); err != nil {
+		return err
+	}
+	c.Configuration = configuration
+	return nil
+}
+
+// copyConfiguration returns a deep copy of the configuration.
+func copyConfiguration(configuration *types.Configuration) (*types.Configuration, error) {
+	content, err := json.Marshal(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	configurationCopy := new(types.Configuration)
+	if err := json.Unmarshal(content, configurationCopy); err != nil {
+		return nil, err
+	}
+	return configurationCopy, nil
+}
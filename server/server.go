@@ -30,7 +30,12 @@ import (
 	"github.com/containous/traefik/middlewares"
 	"github.com/containous/traefik/middlewares/accesslog"
 	mauth "github.com/containous/traefik/middlewares/auth"
+	"github.com/containous/traefik/middlewares/balancer"
+	"github.com/containous/traefik/middlewares/cache"
+	mratelimit "github.com/containous/traefik/middlewares/ratelimit"
+	"github.com/containous/traefik/middlewares/sticky"
 	"github.com/containous/traefik/provider"
+	"github.com/containous/traefik/provider/kubernetes"
 	"github.com/containous/traefik/safe"
 	"github.com/containous/traefik/server/cookie"
 	traefikTls "github.com/containous/traefik/tls"
@@ -69,6 +74,11 @@ type Server struct {
 	metricsRegistry               metrics.Registry
 	lastReceivedConfiguration     *safe.Safe
 	lastConfigs                   cmap.ConcurrentMap
+	rateLimitStore                mratelimit.Store
+	circuitBreakers               middlewares.CircuitBreakers
+	caches                        cache.Caches
+	serversTransportsLock         sync.Mutex
+	serversTransports             map[string]*http.Transport
 }
 
 type serverEntryPoints map[string]*serverEntryPoint
@@ -105,6 +115,8 @@ func NewServer(globalConfiguration configuration.GlobalConfiguration) *Server {
 	server.globalConfiguration = globalConfiguration
 	if server.globalConfiguration.API != nil {
 		server.globalConfiguration.API.CurrentConfigurations = &server.currentConfigurations
+		server.globalConfiguration.API.CircuitBreakers = &server.circuitBreakers
+		server.globalConfiguration.API.Caches = &server.caches
 	}
 
 	server.routinesPool = safe.NewPool(context.Background())
@@ -122,6 +134,15 @@ func NewServer(globalConfiguration configuration.GlobalConfiguration) *Server {
 		server.leadership = cluster.NewLeadership(server.routinesPool.Ctx(), globalConfiguration.Cluster)
 	}
 
+	if globalConfiguration.RateLimitStore != nil {
+		rateLimitStore, err := createRateLimitStore(globalConfiguration)
+		if err != nil {
+			log.Errorf("Unable to create the rate limit store, the rate limits are local to this instance: %v", err)
+		} else {
+			server.rateLimitStore = rateLimitStore
+		}
+	}
+
 	if globalConfiguration.AccessLogsFile != "" {
 		globalConfiguration.AccessLog = &types.AccessLog{FilePath: globalConfiguration.AccessLogsFile, Format: accesslog.CommonFormat}
 	}
@@ -300,8 +321,24 @@ func (server *Server) setupServerEntryPoint(newServerEntryPointName string, newS
 		}
 
 	}
+	if server.globalConfiguration.EntryPoints[newServerEntryPointName].RequestID != nil {
+		var requestIDListener middlewares.RequestIDListener
+		if server.accessLoggerMiddleware != nil {
+			requestIDListener = &accesslog.SaveRequestID{}
+		}
+		requestIDMiddleware, err := middlewares.NewRequestID(server.globalConfiguration.EntryPoints[newServerEntryPointName].RequestID, requestIDListener)
+		if err != nil {
+			log.Fatal("Error starting server: ", err)
+		}
+		serverMiddlewares = append(serverMiddlewares, requestIDMiddleware)
+	}
+	errorPagesMiddlewares, err := buildEntryPointErrorPages(server.globalConfiguration.EntryPoints[newServerEntryPointName].Errors)
+	if err != nil {
+		log.Fatal("Error starting server: ", err)
+	}
+	serverMiddlewares = append(serverMiddlewares, errorPagesMiddlewares...)
 	if server.globalConfiguration.EntryPoints[newServerEntryPointName].Auth != nil {
-		authMiddleware, err := mauth.NewAuthenticator(server.globalConfiguration.EntryPoints[newServerEntryPointName].Auth)
+		authMiddleware, err := mauth.NewAuthenticatorWithTrustedIPs(server.globalConfiguration.EntryPoints[newServerEntryPointName].Auth, server.globalConfiguration.EntryPoints[newServerEntryPointName].ForwardedHeaders.GetTrustedIPs())
 		if err != nil {
 			log.Fatal("Error starting server: ", err)
 		}
@@ -309,13 +346,17 @@ func (server *Server) setupServerEntryPoint(newServerEntryPointName string, newS
 		serverInternalMiddlewares = append(serverInternalMiddlewares, authMiddleware)
 	}
 	if server.globalConfiguration.EntryPoints[newServerEntryPointName].Compress {
-		serverMiddlewares = append(serverMiddlewares, &middlewares.Compress{})
-	}
-	if len(server.globalConfiguration.EntryPoints[newServerEntryPointName].WhitelistSourceRange) > 0 {
-		ipWhitelistMiddleware, err := middlewares.NewIPWhitelister(server.globalConfiguration.EntryPoints[newServerEntryPointName].WhitelistSourceRange)
+		compressMiddleware, err := middlewares.NewCompress(server.globalConfiguration.EntryPoints[newServerEntryPointName].Compression)
 		if err != nil {
 			log.Fatal("Error starting server: ", err)
 		}
+		serverMiddlewares = append(serverMiddlewares, compressMiddleware)
+	}
+	ipWhitelistMiddleware, err := configureIPWhitelistMiddleware(server.globalConfiguration.EntryPoints[newServerEntryPointName].GetWhiteList(), server.globalConfiguration.EntryPoints[newServerEntryPointName].ForwardedHeaders)
+	if err != nil {
+		log.Fatal("Error starting server: ", err)
+	}
+	if ipWhitelistMiddleware != nil {
 		serverMiddlewares = append(serverMiddlewares, ipWhitelistMiddleware)
 		serverInternalMiddlewares = append(serverInternalMiddlewares, ipWhitelistMiddleware)
 	}
@@ -330,6 +371,28 @@ func (server *Server) setupServerEntryPoint(newServerEntryPointName string, newS
 	return serverEntryPoint
 }
 
+// buildEntryPointErrorPages creates the error pages of an entry point, which are served from files or inline contents.
+// They also apply to the responses generated by Traefik, e.g. when no frontend matches the request.
+func buildEntryPointErrorPages(errorPages map[string]types.ErrorPage) ([]negroni.Handler, error) {
+	var handlers []negroni.Handler
+	for _, name := range sortedErrorPageNames(errorPages) {
+		errorPage := errorPages[name]
+		if err := middlewares.ValidateErrorPage(errorPage); err != nil {
+			return nil, fmt.Errorf("invalid error page %s: %v", name, err)
+		}
+		if len(errorPage.Backend) > 0 {
+			return nil, fmt.Errorf("invalid error page %s: the backends are not supported on the entry points", name)
+		}
+
+		handler, err := middlewares.NewErrorPagesHandler(errorPage, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid error page %s: %v", name, err)
+		}
+		handlers = append(handlers, handler)
+	}
+	return handlers, nil
+}
+
 func (server *Server) listenProviders(stop chan bool) {
 	for {
 		select {
@@ -511,6 +574,10 @@ func (server *Server) configureProviders() {
 	if server.globalConfiguration.Rest != nil {
 		server.providers = append(server.providers, server.globalConfiguration.Rest)
 		server.globalConfiguration.Rest.CurrentConfigurations = &server.currentConfigurations
+		server.globalConfiguration.Rest.SetValidator(server.validateConfiguration)
+		if server.leadership != nil {
+			server.globalConfiguration.Rest.SetLeadership(server.leadership)
+		}
 	}
 	if server.globalConfiguration.Consul != nil {
 		server.providers = append(server.providers, server.globalConfiguration.Consul)
@@ -528,6 +595,9 @@ func (server *Server) configureProviders() {
 		server.providers = append(server.providers, server.globalConfiguration.Boltdb)
 	}
 	if server.globalConfiguration.Kubernetes != nil {
+		if server.leadership != nil {
+			server.globalConfiguration.Kubernetes.SetLeaderCheck(server.leadership.IsLeader)
+		}
 		server.providers = append(server.providers, server.globalConfiguration.Kubernetes)
 	}
 	if server.globalConfiguration.Mesos != nil {
@@ -545,6 +615,17 @@ func (server *Server) configureProviders() {
 	if server.globalConfiguration.DynamoDB != nil {
 		server.providers = append(server.providers, server.globalConfiguration.DynamoDB)
 	}
+	if server.globalConfiguration.HTTP != nil {
+		server.providers = append(server.providers, server.globalConfiguration.HTTP)
+	}
+	if server.globalConfiguration.Providers != nil {
+		for _, p := range server.globalConfiguration.Providers.GetInstances() {
+			if k8s, ok := p.(*kubernetes.Provider); ok && server.leadership != nil {
+				k8s.SetLeaderCheck(server.leadership.IsLeader)
+			}
+			server.providers = append(server.providers, p)
+		}
+	}
 }
 
 func (server *Server) startProviders() {
@@ -849,9 +930,18 @@ func (server *Server) buildEntryPoints(globalConfiguration configuration.GlobalC
 }
 
 // getRoundTripper will either use server.defaultForwardingRoundTripper or create a new one
-// given a custom TLS configuration is passed and the passTLSCert option is set to true.
-func (server *Server) getRoundTripper(entryPointName string, globalConfiguration configuration.GlobalConfiguration, passTLSCert bool, tls *traefikTls.TLS) (http.RoundTripper, error) {
+// given a custom TLS configuration is passed and the passTLSCert option is set to true,
+// or the backend defines its own servers transport.
+// The transports created by the previous configuration are reused when their settings are the same,
+// the transports of the configuration being loaded are collected in transports.
+func (server *Server) getRoundTripper(entryPointName string, globalConfiguration configuration.GlobalConfiguration, passTLSCert bool, tls *traefikTls.TLS,
+	serversTransport *types.ServersTransport, transports map[string]*http.Transport) (http.RoundTripper, error) {
 	if passTLSCert {
+		key := "passTLSCert:" + entryPointName
+		if transport := server.getServersTransport(key, transports); transport != nil {
+			return transport, nil
+		}
+
 		tlsConfig, err := createClientTLSConfig(entryPointName, tls)
 		if err != nil {
 			log.Errorf("Failed to create TLSClientConfig: %s", err)
@@ -860,12 +950,107 @@ func (server *Server) getRoundTripper(entryPointName string, globalConfiguration
 
 		transport := createHTTPTransport(globalConfiguration)
 		transport.TLSClientConfig = tlsConfig
+		transports[key] = transport
+		return transport, nil
+	}
+
+	if serversTransport != nil {
+		key := fmt.Sprintf("serversTransport:%#v", *serversTransport)
+		if transport := server.getServersTransport(key, transports); transport != nil {
+			return transport, nil
+		}
+
+		transport, err := createServersTransport(globalConfiguration, serversTransport)
+		if err != nil {
+			return nil, err
+		}
+		transports[key] = transport
 		return transport, nil
 	}
 
 	return server.defaultForwardingRoundTripper, nil
 }
 
+// getServersTransport returns the transport of the key, from the configuration being loaded or else from the current one.
+func (server *Server) getServersTransport(key string, transports map[string]*http.Transport) *http.Transport {
+	if transport, ok := transports[key]; ok {
+		return transport
+	}
+
+	server.serversTransportsLock.Lock()
+	defer server.serversTransportsLock.Unlock()
+
+	if transport, ok := server.serversTransports[key]; ok {
+		transports[key] = transport
+		return transport
+	}
+	return nil
+}
+
+// setServersTransports replaces the transports of the current configuration,
+// closing the idle connections of the transports which are not used anymore.
+func (server *Server) setServersTransports(transports map[string]*http.Transport) {
+	server.serversTransportsLock.Lock()
+	defer server.serversTransportsLock.Unlock()
+
+	for key, transport := range server.serversTransports {
+		if _, ok := transports[key]; !ok {
+			transport.CloseIdleConnections()
+		}
+	}
+	server.serversTransports = transports
+}
+
+// createServersTransport creates an http.Transport dedicated to the servers of a backend.
+// The backend TLS settings replace the global RootCAs and InsecureSkipVerify settings.
+func createServersTransport(globalConfiguration configuration.GlobalConfiguration, serversTransport *types.ServersTransport) (*http.Transport, error) {
+	tlsConfig, err := serversTransport.CreateTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(serversTransport.RootCAs) == 0 && len(globalConfiguration.RootCAs) > 0 {
+		tlsConfig.RootCAs = createRootCACertPool(globalConfiguration.RootCAs)
+	}
+
+	// HTTP/2 is already registered on the transport, it only needs to be offered during the handshake.
+	tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+
+	transport := createHTTPTransport(globalConfiguration)
+	transport.TLSClientConfig = tlsConfig
+	if serversTransport.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = serversTransport.MaxIdleConnsPerHost
+	}
+
+	return transport, nil
+}
+
+// buildFrontendAuth merges the basic auth users of a frontend into its authentication configuration.
+// They can't be merged into another kind of authentication, which would be replaced by the basic one.
+func buildFrontendAuth(frontend *types.Frontend) (*types.Auth, error) {
+	if len(frontend.BasicAuth) == 0 {
+		return frontend.Auth, nil
+	}
+
+	auth := &types.Auth{}
+	if frontend.Auth != nil {
+		*auth = *frontend.Auth
+	}
+	if auth.Digest != nil || auth.Forward != nil || auth.JWT != nil || auth.OIDC != nil {
+		return nil, errors.New("the basic auth users can't be combined with a digest, forward, JWT or OIDC authentication")
+	}
+
+	basic := &types.Basic{}
+	if auth.Basic != nil {
+		*basic = *auth.Basic
+	}
+	users := append(types.Users{}, frontend.BasicAuth...)
+	basic.Users = append(users, basic.Users...)
+	auth.Basic = basic
+
+	return auth, nil
+}
+
 // LoadConfig returns a new gorilla.mux Route from the specified global configuration and the dynamic
 // provider configurations.
 func (server *Server) loadConfig(configurations types.Configurations, globalConfiguration configuration.GlobalConfiguration) (map[string]*serverEntryPoint, error) {
@@ -873,7 +1058,9 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 	redirectHandlers := make(map[string]negroni.Handler)
 	backends := map[string]http.Handler{}
 	backendsHealthCheck := map[string]*healthcheck.BackendHealthCheck{}
-	errorHandler := NewRecordingErrorHandler(middlewares.DefaultNetErrorRecorder{})
+	circuitBreakers := map[string]map[string]*middlewares.CircuitBreaker{}
+	caches := map[string]*cache.Cache{}
+	transports := map[string]*http.Transport{}
 
 	for _, config := range configurations {
 		frontendNames := sortedFrontendNamesForConfig(config)
@@ -927,48 +1114,30 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 							redirectHandlers[entryPointName] = handler
 						}
 					}
-				}
-				if backends[entryPointName+frontend.Backend] == nil {
-					log.Debugf("Creating backend %s", frontend.Backend)
-
-					roundTripper, err := server.getRoundTripper(entryPointName, globalConfiguration, frontend.PassTLSCert, entryPoint.TLS)
+				} else if frontend.Redirect != nil && frontend.Redirect.EntryPoint != entryPointName {
+					// A frontend also wired to the redirection entry point must not be redirected there.
+					handler, err := server.buildRedirectHandler(entryPointName, frontend.Redirect.EntryPoint, frontend.Redirect.Regex, frontend.Redirect.Replacement)
 					if err != nil {
-						log.Errorf("Failed to create RoundTripper for frontend %s: %v", frontendName, err)
+						log.Errorf("Error creating redirect for frontend %s: %v", frontendName, err)
 						log.Errorf("Skipping frontend %s...", frontendName)
 						continue frontend
 					}
-
-					rewriter, err := NewHeaderRewriter(entryPoint.ForwardedHeaders.TrustedIPs, entryPoint.ForwardedHeaders.Insecure)
-					if err != nil {
-						log.Errorf("Error creating rewriter for frontend %s: %v", frontendName, err)
-						log.Errorf("Skipping frontend %s...", frontendName)
-						continue frontend
+					if server.accessLoggerMiddleware != nil {
+						n.Use(accesslog.NewSaveNegroniFrontend(handler, frontendName))
+					} else {
+						n.Use(handler)
 					}
+				}
+				if backends[entryPointName+frontend.Backend] == nil {
+					log.Debugf("Creating backend %s", frontend.Backend)
 
-					fwd, err := forward.New(
-						forward.Logger(oxyLogger),
-						forward.PassHostHeader(frontend.PassHostHeader),
-						forward.RoundTripper(roundTripper),
-						forward.ErrorHandler(errorHandler),
-						forward.Rewriter(rewriter),
-					)
-
+					forwarder, err := server.buildForwarder(entryPointName, entryPoint, globalConfiguration, frontendName, frontend, frontend.Backend, config.Backends[frontend.Backend], transports)
 					if err != nil {
 						log.Errorf("Error creating forwarder for frontend %s: %v", frontendName, err)
 						log.Errorf("Skipping frontend %s...", frontendName)
 						continue frontend
 					}
 
-					var rr *roundrobin.RoundRobin
-					var saveFrontend http.Handler
-					if server.accessLoggerMiddleware != nil {
-						saveBackend := accesslog.NewSaveBackend(fwd, frontend.Backend)
-						saveFrontend = accesslog.NewSaveFrontend(saveBackend, frontendName)
-						rr, _ = roundrobin.New(saveFrontend)
-					} else {
-						rr, _ = roundrobin.New(fwd)
-					}
-
 					if config.Backends[frontend.Backend] == nil {
 						log.Errorf("Undefined backend '%s' for frontend %s", frontend.Backend, frontendName)
 						log.Errorf("Skipping frontend %s...", frontendName)
@@ -982,23 +1151,29 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 						continue frontend
 					}
 
-					var sticky *roundrobin.StickySession
-					var cookieName string
+					var stickySession *sticky.Sticky
 					if stickiness := config.Backends[frontend.Backend].LoadBalancer.Stickiness; stickiness != nil {
-						cookieName = cookie.GetName(stickiness.CookieName, frontend.Backend)
-						sticky = roundrobin.NewStickySession(cookieName)
+						stickySession, err = buildStickiness(forwarder, frontend.Backend, stickiness)
+						if err != nil {
+							log.Errorf("Error creating stickiness for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						log.Debugf("Sticky session with %s affinity", stickiness.Mode)
+						forwarder = stickySession.Forwarder()
 					}
 
+					rr, _ := roundrobin.New(forwarder)
+
 					var lb http.Handler
 					switch lbMethod {
 					case types.Drr:
 						log.Debugf("Creating load-balancer drr")
 						rebalancer, _ := roundrobin.NewRebalancer(rr, roundrobin.RebalancerLogger(oxyLogger))
-						if sticky != nil {
-							log.Debugf("Sticky session with cookie %v", cookieName)
-							rebalancer, _ = roundrobin.NewRebalancer(rr, roundrobin.RebalancerLogger(oxyLogger), roundrobin.RebalancerStickySession(sticky))
-						}
 						lb = rebalancer
+						if stickySession != nil {
+							lb = stickySession.Handler(rebalancer)
+						}
 						if err := configureLBServers(rebalancer, config, frontend); err != nil {
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
@@ -1011,15 +1186,10 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 						lb = middlewares.NewEmptyBackendHandler(rebalancer, lb)
 					case types.Wrr:
 						log.Debugf("Creating load-balancer wrr")
-						if sticky != nil {
-							log.Debugf("Sticky session with cookie %v", cookieName)
-							if server.accessLoggerMiddleware != nil {
-								rr, _ = roundrobin.New(saveFrontend, roundrobin.EnableStickySession(sticky))
-							} else {
-								rr, _ = roundrobin.New(fwd, roundrobin.EnableStickySession(sticky))
-							}
-						}
 						lb = rr
+						if stickySession != nil {
+							lb = stickySession.Handler(rr)
+						}
 						if err := configureLBServers(rr, config, frontend); err != nil {
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
@@ -1030,25 +1200,54 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 							backendsHealthCheck[entryPointName+frontend.Backend] = healthcheck.NewBackendHealthCheck(*hcOpts)
 						}
 						lb = middlewares.NewEmptyBackendHandler(rr, lb)
+					default:
+						log.Debugf("Creating load-balancer %s", config.Backends[frontend.Backend].LoadBalancer.Method)
+						loadBalancer, err := buildBalancer(lbMethod, forwarder, config.Backends[frontend.Backend].LoadBalancer)
+						if err != nil {
+							log.Errorf("Error creating load-balancer for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						lb = loadBalancer
+						if stickySession != nil {
+							lb = stickySession.Handler(loadBalancer)
+						}
+						if err := configureLBServers(loadBalancer, config, frontend); err != nil {
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						hcOpts := parseHealthCheckOptions(loadBalancer, frontend.Backend, config.Backends[frontend.Backend].HealthCheck, globalConfiguration.HealthCheck)
+						if hcOpts != nil {
+							log.Debugf("Setting up backend health check %s", *hcOpts)
+							backendsHealthCheck[entryPointName+frontend.Backend] = healthcheck.NewBackendHealthCheck(*hcOpts)
+						}
+						lb = middlewares.NewEmptyBackendHandler(loadBalancer, lb)
 					}
 
-					if len(frontend.Errors) > 0 {
-						for _, errorPage := range frontend.Errors {
-							if config.Backends[errorPage.Backend] != nil && config.Backends[errorPage.Backend].Servers["error"].URL != "" {
-								errorPageHandler, err := middlewares.NewErrorPagesHandler(errorPage, config.Backends[errorPage.Backend].Servers["error"].URL)
-								if err != nil {
-									log.Errorf("Error creating custom error page middleware, %v", err)
-								} else {
-									n.Use(errorPageHandler)
-								}
-							} else {
+					for _, errorPageName := range sortedErrorPageNames(frontend.Errors) {
+						errorPage := frontend.Errors[errorPageName]
+						if len(errorPage.File) > 0 {
+							log.Errorf("Error page %s of frontend %s: the files are only served by the entry point error pages", errorPageName, frontendName)
+							continue
+						}
+						var backendURL string
+						if len(errorPage.Backend) > 0 {
+							if config.Backends[errorPage.Backend] == nil || config.Backends[errorPage.Backend].Servers["error"].URL == "" {
 								log.Errorf("Error Page is configured for Frontend %s, but either Backend %s is not set or Backend URL is missing", frontendName, errorPage.Backend)
+								continue
 							}
+							backendURL = config.Backends[errorPage.Backend].Servers["error"].URL
+						}
+						errorPageHandler, err := middlewares.NewErrorPagesHandler(errorPage, backendURL)
+						if err != nil {
+							log.Errorf("Error creating custom error page middleware, %v", err)
+							continue
 						}
+						n.Use(errorPageHandler)
 					}
 
 					if frontend.RateLimit != nil && len(frontend.RateLimit.RateSet) > 0 {
-						lb, err = server.buildRateLimiter(lb, frontend.RateLimit)
+						lb, err = server.buildRateLimiter(lb, frontendName, frontend.RateLimit)
 						if err != nil {
 							log.Errorf("Error creating rate limiter: %v", err)
 							log.Errorf("Skipping frontend %s...", frontendName)
@@ -1073,39 +1272,54 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 						}
 					}
 
-					if globalConfiguration.Retry != nil {
-						countServers := len(config.Backends[frontend.Backend].Servers)
-						lb = server.buildRetryMiddleware(lb, globalConfiguration, countServers, frontend.Backend)
+					if globalConfiguration.Retry != nil || config.Backends[frontend.Backend].Retry != nil {
+						lb, err = server.buildRetryMiddleware(lb, globalConfiguration, config.Backends[frontend.Backend], frontend.Backend)
+						if err != nil {
+							log.Errorf("Error creating retries: %v", err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
 					}
 
 					if server.metricsRegistry.IsEnabled() {
 						n.Use(middlewares.NewMetricsWrapper(server.metricsRegistry, frontend.Backend))
 					}
 
-					ipWhitelistMiddleware, err := configureIPWhitelistMiddleware(frontend.WhitelistSourceRange)
+					whiteList := frontend.GetWhiteList()
+					ipWhitelistMiddleware, err := configureIPWhitelistMiddleware(whiteList, entryPoint.ForwardedHeaders)
 					if err != nil {
-						log.Fatalf("Error creating IP Whitelister: %s", err)
+						log.Errorf("Error creating IP Whitelister for frontend %s: %s", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
 					} else if ipWhitelistMiddleware != nil {
 						n.Use(ipWhitelistMiddleware)
-						log.Infof("Configured IP Whitelists: %s", frontend.WhitelistSourceRange)
+						log.Infof("Configured IP Whitelists: %s", whiteList.SourceRange)
 					}
 
-					if len(frontend.BasicAuth) > 0 {
-						users := types.Users{}
-						for _, user := range frontend.BasicAuth {
-							users = append(users, user)
+					auth, err := buildFrontendAuth(frontend)
+					if err != nil {
+						log.Errorf("Invalid auth for frontend %s: %s", frontendName, err)
+						log.Errorf("Skipping frontend %s...", frontendName)
+						continue frontend
+					}
+					if auth != nil {
+						authMiddleware, err := mauth.NewAuthenticatorWithTrustedIPs(auth, entryPoint.ForwardedHeaders.GetTrustedIPs())
+						if err != nil {
+							log.Errorf("Error creating Auth for frontend %s: %s", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
 						}
+						n.Use(authMiddleware)
+					}
 
-						auth := &types.Auth{}
-						auth.Basic = &types.Basic{
-							Users: users,
-						}
-						authMiddleware, err := mauth.NewAuthenticator(auth)
+					if frontend.Compress != nil {
+						compressMiddleware, err := middlewares.NewCompress(frontend.Compress)
 						if err != nil {
-							log.Errorf("Error creating Auth: %s", err)
-						} else {
-							n.Use(authMiddleware)
+							log.Errorf("Error creating compression for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
 						}
+						n.Use(compressMiddleware)
 					}
 
 					if frontend.Headers.HasCustomHeadersDefined() {
@@ -1119,14 +1333,41 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 						n.UseFunc(secureMiddleware.HandlerFuncWithNext)
 					}
 
-					if config.Backends[frontend.Backend].CircuitBreaker != nil {
-						log.Debugf("Creating circuit breaker %s", config.Backends[frontend.Backend].CircuitBreaker.Expression)
-						circuitBreaker, err := middlewares.NewCircuitBreaker(lb, config.Backends[frontend.Backend].CircuitBreaker.Expression, cbreaker.Logger(oxyLogger))
+					if frontend.Cache != nil {
+						cacheMiddleware, err := server.buildCache(frontendName, frontend.Cache, caches)
+						if err != nil {
+							log.Errorf("Error creating cache for frontend %s: %v", frontendName, err)
+							log.Errorf("Skipping frontend %s...", frontendName)
+							continue frontend
+						}
+						n.Use(cacheMiddleware)
+					}
+
+					if cbConfig := config.Backends[frontend.Backend].CircuitBreaker; cbConfig != nil {
+						log.Debugf("Creating circuit breaker %s", cbConfig.Expression)
+						var fallbackForwarder http.Handler
+						if cbConfig.Fallback != nil && len(cbConfig.Fallback.Backend) > 0 {
+							fallbackName := cbConfig.Fallback.Backend
+							fallbackForwarder, err = server.buildForwarder(entryPointName, entryPoint, globalConfiguration, frontendName, frontend, fallbackName, config.Backends[fallbackName], transports)
+							if err != nil {
+								log.Errorf("Error creating fallback forwarder for frontend %s: %v", frontendName, err)
+								log.Errorf("Skipping frontend %s...", frontendName)
+								continue frontend
+							}
+						}
+						circuitBreaker, fallbackHealthCheck, err := server.buildCircuitBreaker(lb, fallbackForwarder, config, frontend.Backend, cbConfig, globalConfiguration.HealthCheck)
 						if err != nil {
 							log.Errorf("Error creating circuit breaker: %v", err)
 							log.Errorf("Skipping frontend %s...", frontendName)
 							continue frontend
 						}
+						if fallbackHealthCheck != nil {
+							backendsHealthCheck[entryPointName+frontend.Backend+"/fallback"] = fallbackHealthCheck
+						}
+						if circuitBreakers[frontend.Backend] == nil {
+							circuitBreakers[frontend.Backend] = make(map[string]*middlewares.CircuitBreaker)
+						}
+						circuitBreakers[frontend.Backend][entryPointName] = circuitBreaker
 						n.Use(circuitBreaker)
 					} else {
 						n.UseHandler(lb)
@@ -1135,7 +1376,7 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 				} else {
 					log.Debugf("Reusing backend %s", frontend.Backend)
 				}
-				if frontend.Priority > 0 {
+				if frontend.Priority > 0 || frontend.Priority == types.FrontendPriorityLowest {
 					newServerRoute.route.Priority(frontend.Priority)
 				}
 				server.wireFrontendBackend(newServerRoute, backends[entryPointName+frontend.Backend])
@@ -1148,6 +1389,9 @@ func (server *Server) loadConfig(configurations types.Configurations, globalConf
 		}
 	}
 	healthcheck.GetHealthCheck().SetBackendsConfiguration(server.routinesPool.Ctx(), backendsHealthCheck)
+	server.circuitBreakers.Set(circuitBreakers)
+	server.caches.Set(caches)
+	server.setServersTransports(transports)
 	// Get new certificates list sorted per entrypoints
 	// Update certificates
 	entryPointsCertificates, err := server.loadHTTPSConfiguration(configurations)
@@ -1179,11 +1423,14 @@ func configureLBServers(lb healthcheck.LoadBalancer, config *types.Configuration
 	return nil
 }
 
-func configureIPWhitelistMiddleware(whitelistSourceRanges []string) (negroni.Handler, error) {
-	if len(whitelistSourceRanges) > 0 {
-		ipSourceRanges := whitelistSourceRanges
-		ipWhitelistMiddleware, err := middlewares.NewIPWhitelister(ipSourceRanges)
+func configureIPWhitelistMiddleware(whiteList *types.WhiteList, forwardedHeaders *configuration.ForwardedHeaders) (negroni.Handler, error) {
+	if whiteList != nil && len(whiteList.SourceRange) > 0 {
+		strategy, err := buildIPStrategy(whiteList.IPStrategy, forwardedHeaders)
+		if err != nil {
+			return nil, err
+		}
 
+		ipWhitelistMiddleware, err := middlewares.NewIPWhitelister(whiteList, strategy)
 		if err != nil {
 			return nil, err
 		}
@@ -1194,6 +1441,38 @@ func configureIPWhitelistMiddleware(whitelistSourceRanges []string) (negroni.Han
 	return nil, nil
 }
 
+// buildIPStrategy builds the strategy extracting the client IP of the requests.
+// Without depth nor trusted proxies, the forwarded headers trusted IPs of the entry point are the trusted proxies.
+// The insecure forwarded headers of the entry point, which trust every address, never make the proxies trusted:
+// the client could then choose its IP with the X-Forwarded-For header.
+func buildIPStrategy(ipStrategy *types.IPStrategy, forwardedHeaders *configuration.ForwardedHeaders) (whitelist.Strategy, error) {
+	if ipStrategy == nil {
+		return &whitelist.RemoteAddrStrategy{}, nil
+	}
+
+	if ipStrategy.Depth < 0 {
+		return nil, fmt.Errorf("invalid IP strategy depth %d", ipStrategy.Depth)
+	}
+	if ipStrategy.Depth > 0 {
+		return &whitelist.DepthStrategy{Depth: ipStrategy.Depth}, nil
+	}
+
+	trustedProxies := ipStrategy.TrustedProxies
+	if len(trustedProxies) == 0 {
+		trustedProxies = forwardedHeaders.GetTrustedIPs()
+	}
+
+	if len(trustedProxies) == 0 {
+		return &whitelist.RemoteAddrStrategy{}, nil
+	}
+
+	proxies, err := whitelist.NewIP(trustedProxies, false)
+	if err != nil {
+		return nil, fmt.Errorf("parsing trusted proxies %s: %v", trustedProxies, err)
+	}
+	return &whitelist.TrustedProxiesStrategy{Proxies: proxies}, nil
+}
+
 func (server *Server) wireFrontendBackend(serverRoute *serverRoute, handler http.Handler) {
 	// path replace - This needs to always be the very last on the handler chain (first in the order in this function)
 	// -- Replacing Path should happen at the very end of the Modifier chain, after all the Matcher+Modifiers ran
@@ -1239,21 +1518,24 @@ func (server *Server) wireFrontendBackend(serverRoute *serverRoute, handler http
 }
 
 func (server *Server) loadEntryPointConfig(entryPointName string, entryPoint *configuration.EntryPoint) (negroni.Handler, error) {
-	regex := entryPoint.Redirect.Regex
-	replacement := entryPoint.Redirect.Replacement
-	if len(entryPoint.Redirect.EntryPoint) > 0 {
+	return server.buildRedirectHandler(entryPointName, entryPoint.Redirect.EntryPoint, entryPoint.Redirect.Regex, entryPoint.Redirect.Replacement)
+}
+
+// buildRedirectHandler creates a redirection to the given entry point, or with the given regex and replacement.
+func (server *Server) buildRedirectHandler(entryPointName, redirectEntryPoint, regex, replacement string) (negroni.Handler, error) {
+	if len(redirectEntryPoint) > 0 {
 		regex = `^(?:https?:\/\/)?([\w\._-]+)(?::\d+)?(.*)$`
-		if server.globalConfiguration.EntryPoints[entryPoint.Redirect.EntryPoint] == nil {
-			return nil, errors.New("Unknown entrypoint " + entryPoint.Redirect.EntryPoint)
+		if server.globalConfiguration.EntryPoints[redirectEntryPoint] == nil {
+			return nil, errors.New("Unknown entrypoint " + redirectEntryPoint)
 		}
 		protocol := "http"
-		if server.globalConfiguration.EntryPoints[entryPoint.Redirect.EntryPoint].TLS != nil {
+		if server.globalConfiguration.EntryPoints[redirectEntryPoint].TLS != nil {
 			protocol = "https"
 		}
 		r, _ := regexp.Compile(`(:\d+)`)
-		match := r.FindStringSubmatch(server.globalConfiguration.EntryPoints[entryPoint.Redirect.EntryPoint].Address)
+		match := r.FindStringSubmatch(server.globalConfiguration.EntryPoints[redirectEntryPoint].Address)
 		if len(match) == 0 {
-			return nil, errors.New("Bad Address format: " + server.globalConfiguration.EntryPoints[entryPoint.Redirect.EntryPoint].Address)
+			return nil, errors.New("Bad Address format: " + server.globalConfiguration.EntryPoints[redirectEntryPoint].Address)
 		}
 		replacement = protocol + "://$1" + match[0] + "$2"
 	}
@@ -1261,7 +1543,7 @@ func (server *Server) loadEntryPointConfig(entryPointName string, entryPoint *co
 	if err != nil {
 		return nil, err
 	}
-	log.Debugf("Creating entryPoint redirect %s -> %s : %s -> %s", entryPointName, entryPoint.Redirect.EntryPoint, regex, replacement)
+	log.Debugf("Creating entryPoint redirect %s -> %s : %s -> %s", entryPointName, redirectEntryPoint, regex, replacement)
 
 	return rewrite, nil
 }
@@ -1311,6 +1593,152 @@ func getRoute(serverRoute *serverRoute, route *types.Route) error {
 	return nil
 }
 
+// validateConfiguration checks a provider configuration like the server does when loading it,
+// but returns the first error instead of skipping the invalid frontends.
+func (server *Server) validateConfiguration(config *types.Configuration) error {
+	for _, frontendName := range sortedFrontendNamesForConfig(config) {
+		frontend := config.Frontends[frontendName]
+		if frontend == nil {
+			return fmt.Errorf("empty frontend %s", frontendName)
+		}
+
+		entryPoints := frontend.EntryPoints
+		if len(entryPoints) == 0 {
+			entryPoints = server.globalConfiguration.DefaultEntryPoints
+		}
+		if len(entryPoints) == 0 {
+			return fmt.Errorf("no entrypoint defined for frontend %s", frontendName)
+		}
+		for _, entryPointName := range entryPoints {
+			if _, ok := server.globalConfiguration.EntryPoints[entryPointName]; !ok {
+				return fmt.Errorf("undefined entrypoint '%s' for frontend %s", entryPointName, frontendName)
+			}
+		}
+
+		if _, err := buildFrontendAuth(frontend); err != nil {
+			return fmt.Errorf("invalid auth for frontend %s: %v", frontendName, err)
+		}
+
+		for routeName, route := range frontend.Routes {
+			rules := Rules{route: &serverRoute{route: mux.NewRouter().NewRoute()}}
+			if _, err := rules.Parse(route.Rule); err != nil {
+				return fmt.Errorf("invalid route %s for frontend %s: %v", routeName, frontendName, err)
+			}
+		}
+
+		if whiteList := frontend.GetWhiteList(); whiteList != nil {
+			if _, err := configureIPWhitelistMiddleware(whiteList, nil); err != nil {
+				return fmt.Errorf("invalid whitelist for frontend %s: %v", frontendName, err)
+			}
+		}
+
+		if frontend.RateLimit != nil {
+			if err := mratelimit.ValidateAlgorithm(frontend.RateLimit.Algorithm); err != nil {
+				return fmt.Errorf("invalid rate limit for frontend %s: %v", frontendName, err)
+			}
+			if _, err := mratelimit.NewExtractor(frontend.RateLimit.ExtractorFunc); err != nil {
+				return fmt.Errorf("invalid rate limit for frontend %s: %v", frontendName, err)
+			}
+		}
+
+		if frontend.Compress != nil {
+			if _, err := middlewares.NewCompress(frontend.Compress); err != nil {
+				return fmt.Errorf("invalid compression for frontend %s: %v", frontendName, err)
+			}
+		}
+
+		for errorPageName, errorPage := range frontend.Errors {
+			if err := middlewares.ValidateErrorPage(errorPage); err != nil {
+				return fmt.Errorf("invalid error page %s for frontend %s: %v", errorPageName, frontendName, err)
+			}
+			if len(errorPage.File) > 0 {
+				return fmt.Errorf("invalid error page %s for frontend %s: the files are only served by the entry point error pages", errorPageName, frontendName)
+			}
+		}
+
+		if frontend.Cache != nil {
+			if err := cache.ValidateConfig(frontend.Cache); err != nil {
+				return fmt.Errorf("invalid cache for frontend %s: %v", frontendName, err)
+			}
+		}
+
+		if config.Backends[frontend.Backend] == nil {
+			return fmt.Errorf("undefined backend '%s' for frontend %s", frontend.Backend, frontendName)
+		}
+	}
+
+	for backendName, backend := range config.Backends {
+		if backend == nil {
+			return fmt.Errorf("empty backend %s", backendName)
+		}
+
+		if backend.LoadBalancer != nil && len(backend.LoadBalancer.Method) > 0 {
+			method, err := types.NewLoadBalancerMethod(backend.LoadBalancer)
+			if err != nil {
+				return fmt.Errorf("backend %s: %v", backendName, err)
+			}
+			if method != types.Wrr && method != types.Drr {
+				if _, err := buildBalancer(method, nil, backend.LoadBalancer); err != nil {
+					return fmt.Errorf("invalid load-balancer for backend %s: %v", backendName, err)
+				}
+			}
+		}
+
+		if backend.LoadBalancer != nil && backend.LoadBalancer.Stickiness != nil {
+			if _, err := buildStickiness(nil, backendName, backend.LoadBalancer.Stickiness); err != nil {
+				return fmt.Errorf("invalid stickiness for backend %s: %v", backendName, err)
+			}
+		}
+
+		for serverName, srv := range backend.Servers {
+			u, err := url.Parse(srv.URL)
+			if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+				return fmt.Errorf("invalid URL '%s' for server %s of backend %s", srv.URL, serverName, backendName)
+			}
+		}
+
+		if backend.CircuitBreaker != nil {
+			if _, err := middlewares.NewCircuitBreaker(nil, backend.CircuitBreaker, nil, nil); err != nil {
+				return fmt.Errorf("invalid circuit breaker for backend %s: %v", backendName, err)
+			}
+			if fallback := backend.CircuitBreaker.Fallback; fallback != nil && len(fallback.Backend) > 0 && config.Backends[fallback.Backend] == nil {
+				return fmt.Errorf("undefined circuit breaker fallback backend '%s' for backend %s", fallback.Backend, backendName)
+			}
+		}
+
+		if backend.Retry != nil {
+			if _, err := middlewares.NewRetryPolicy(len(backend.Servers), backend.Retry); err != nil {
+				return fmt.Errorf("invalid retry for backend %s: %v", backendName, err)
+			}
+		}
+
+		if backend.HealthCheck != nil && len(backend.HealthCheck.Interval) > 0 {
+			if _, err := time.ParseDuration(backend.HealthCheck.Interval); err != nil {
+				return fmt.Errorf("illegal healthcheck interval for backend %s: %v", backendName, err)
+			}
+		}
+	}
+
+	for i, tlsConfiguration := range config.TLSConfiguration {
+		if tlsConfiguration == nil || tlsConfiguration.Certificate == nil {
+			return fmt.Errorf("no certificate defined in TLS configuration %d", i)
+		}
+	}
+	if err := traefikTls.SortTLSConfigurationPerEntryPoints(config.TLSConfiguration, nil); err != nil {
+		return fmt.Errorf("invalid TLS configuration: %v", err)
+	}
+	return nil
+}
+
+func sortedErrorPageNames(errorPages map[string]types.ErrorPage) []string {
+	var names []string
+	for name := range errorPages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func sortedFrontendNamesForConfig(configuration *types.Configuration) []string {
 	keys := []string{}
 	for key := range configuration.Frontends {
@@ -1397,11 +1825,29 @@ func stopMetricsClients() {
 	metrics.StopInfluxDB()
 }
 
-func (server *Server) buildRateLimiter(handler http.Handler, rlConfig *types.RateLimit) (http.Handler, error) {
-	extractFunc, err := utils.NewExtractor(rlConfig.ExtractorFunc)
+func (server *Server) buildRateLimiter(handler http.Handler, frontendName string, rlConfig *types.RateLimit) (http.Handler, error) {
+	extractFunc, err := mratelimit.NewExtractor(rlConfig.ExtractorFunc)
 	if err != nil {
 		return nil, err
 	}
+
+	algorithm := rlConfig.Algorithm
+	if len(algorithm) == 0 && server.rateLimitStore != nil {
+		algorithm = mratelimit.SlidingWindow
+	}
+
+	if len(algorithm) > 0 && algorithm != mratelimit.TokenBucket {
+		log.Debugf("Creating %s rate limiter", algorithm)
+		config := *rlConfig
+		config.Algorithm = algorithm
+		failClosed := server.rateLimitStore != nil && server.globalConfiguration.RateLimitStore.FailClosed
+		return mratelimit.New(handler, frontendName, &config, extractFunc, server.rateLimitStore, failClosed)
+	}
+
+	if server.rateLimitStore != nil {
+		log.Warnf("The token bucket rate limiter of frontend %s is local to this instance", frontendName)
+	}
+
 	log.Debugf("Creating load-balancer rate limiter")
 	rateSet := ratelimit.NewRateSet()
 	for _, rate := range rlConfig.RateSet {
@@ -1412,7 +1858,179 @@ func (server *Server) buildRateLimiter(handler http.Handler, rlConfig *types.Rat
 	return ratelimit.New(handler, extractFunc, rateSet, ratelimit.Logger(oxyLogger))
 }
 
-func (server *Server) buildRetryMiddleware(handler http.Handler, globalConfig configuration.GlobalConfiguration, countServers int, backendName string) http.Handler {
+// createRateLimitStore creates the store of the rate limiters counters, shared between the Traefik instances.
+func createRateLimitStore(globalConfiguration configuration.GlobalConfiguration) (mratelimit.Store, error) {
+	config := globalConfiguration.RateLimitStore
+
+	switch strings.ToLower(config.Backend) {
+	case "kv":
+		if globalConfiguration.Cluster == nil || globalConfiguration.Cluster.Store == nil {
+			return nil, errors.New("the kv rate limit store requires a KV store")
+		}
+		prefix := config.Prefix
+		if len(prefix) == 0 {
+			prefix = globalConfiguration.Cluster.Store.Prefix + "/ratelimit"
+		}
+		return mratelimit.NewKVStore(globalConfiguration.Cluster.Store.Store, prefix), nil
+	case "redis", "":
+		prefix := config.Prefix
+		if len(prefix) == 0 {
+			prefix = "traefik:ratelimit"
+		}
+		return mratelimit.NewRedisStore(mratelimit.RedisOptions{
+			Address:  config.Address,
+			Password: config.Password,
+			Database: config.Database,
+			Prefix:   prefix,
+		})
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", config.Backend)
+	}
+}
+
+// buildBalancer creates the load balancer of the method, other than the oxy round robins.
+func buildBalancer(method types.LoadBalancerMethod, next http.Handler, loadBalancer *types.LoadBalancer) (*balancer.Balancer, error) {
+	switch method {
+	case types.LeastConn:
+		return balancer.NewLeastConn(next), nil
+	case types.LeastLatency:
+		return balancer.NewLeastLatency(next), nil
+	case types.P2c:
+		return balancer.NewP2C(next), nil
+	case types.RingHash:
+		hashOn := loadBalancer.HashOn
+		if len(hashOn) == 0 {
+			hashOn = "client.ip"
+		}
+		extractor, err := mratelimit.NewExtractor(hashOn)
+		if err != nil {
+			return nil, err
+		}
+		return balancer.NewRingHash(next, extractor), nil
+	default:
+		return nil, fmt.Errorf("unsupported load-balancing method %d", method)
+	}
+}
+
+// buildStickiness creates the stickiness of the backend, forwarding the sticky requests to the next handler.
+func buildStickiness(next http.Handler, backendName string, stickiness *types.Stickiness) (*sticky.Sticky, error) {
+	var extractor utils.SourceExtractor
+	if strings.EqualFold(stickiness.Mode, sticky.ModeHash) {
+		hashOn := stickiness.HashOn
+		if len(hashOn) == 0 {
+			hashOn = "client.ip"
+		}
+
+		var err error
+		extractor, err = mratelimit.NewExtractor(hashOn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sticky.New(next, cookie.GetName(stickiness.CookieName, backendName), stickiness, extractor)
+}
+
+// buildCache returns the response cache of a frontend.
+// The cache of the current configuration is kept, with its responses, as long as its configuration doesn't change.
+func (server *Server) buildCache(frontendName string, config *types.Cache, caches map[string]*cache.Cache) (*cache.Cache, error) {
+	if c, ok := caches[frontendName]; ok {
+		return c, nil
+	}
+
+	if c, ok := server.caches.Get(frontendName); ok && c.SameConfig(config) {
+		caches[frontendName] = c
+		return c, nil
+	}
+
+	c, err := cache.New(frontendName, config)
+	if err != nil {
+		return nil, err
+	}
+	caches[frontendName] = c
+	return c, nil
+}
+
+// buildForwarder creates the forwarder of the requests of a frontend to the servers of a backend,
+// through the servers transport of the backend.
+func (server *Server) buildForwarder(entryPointName string, entryPoint *configuration.EntryPoint, globalConfiguration configuration.GlobalConfiguration,
+	frontendName string, frontend *types.Frontend, backendName string, backend *types.Backend, transports map[string]*http.Transport) (http.Handler, error) {
+	var serversTransport *types.ServersTransport
+	if backend != nil {
+		serversTransport = backend.ServersTransport
+	}
+
+	roundTripper, err := server.getRoundTripper(entryPointName, globalConfiguration, frontend.PassTLSCert, entryPoint.TLS, serversTransport, transports)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RoundTripper: %v", err)
+	}
+
+	rewriter, err := NewHeaderRewriter(entryPoint.ForwardedHeaders.TrustedIPs, entryPoint.ForwardedHeaders.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("error creating rewriter: %v", err)
+	}
+
+	fwd, err := forward.New(
+		forward.Logger(oxyLogger),
+		forward.PassHostHeader(frontend.PassHostHeader),
+		forward.RoundTripper(roundTripper),
+		forward.ErrorHandler(NewRecordingErrorHandler(middlewares.DefaultNetErrorRecorder{})),
+		forward.Rewriter(rewriter),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if server.accessLoggerMiddleware != nil {
+		saveBackend := accesslog.NewSaveBackend(fwd, backendName)
+		return accesslog.NewSaveFrontend(saveBackend, frontendName), nil
+	}
+	return fwd, nil
+}
+
+// buildCircuitBreaker creates the circuit breaker of a backend.
+// Its fallback backend, if any, is load balanced with a round robin through the fallback forwarder,
+// and health checked like the other backends.
+func (server *Server) buildCircuitBreaker(handler http.Handler, fallbackForwarder http.Handler, config *types.Configuration, backendName string,
+	cbConfig *types.CircuitBreaker, hcConfig *configuration.HealthCheckConfig) (*middlewares.CircuitBreaker, *healthcheck.BackendHealthCheck, error) {
+	var fallback http.Handler
+	var fallbackHealthCheck *healthcheck.BackendHealthCheck
+	if cbConfig.Fallback != nil && len(cbConfig.Fallback.Backend) > 0 {
+		fallbackName := cbConfig.Fallback.Backend
+		fallbackBackend := config.Backends[fallbackName]
+		if fallbackBackend == nil {
+			return nil, nil, fmt.Errorf("undefined fallback backend '%s'", fallbackName)
+		}
+
+		rr, err := roundrobin.New(fallbackForwarder)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := configureLBServers(rr, config, &types.Frontend{Backend: fallbackName}); err != nil {
+			return nil, nil, err
+		}
+		if hcOpts := parseHealthCheckOptions(rr, fallbackName, fallbackBackend.HealthCheck, hcConfig); hcOpts != nil {
+			log.Debugf("Setting up fallback backend health check %s", *hcOpts)
+			fallbackHealthCheck = healthcheck.NewBackendHealthCheck(*hcOpts)
+		}
+		fallback = middlewares.NewEmptyBackendHandler(rr, rr)
+	}
+
+	var listener middlewares.CircuitBreakerListener
+	if server.metricsRegistry.IsEnabled() {
+		listener = middlewares.NewMetricsCircuitBreakerListener(server.metricsRegistry, backendName)
+	}
+
+	circuitBreaker, err := middlewares.NewCircuitBreaker(handler, cbConfig, fallback, listener, cbreaker.Logger(oxyLogger))
+	if err != nil {
+		return nil, nil, err
+	}
+	return circuitBreaker, fallbackHealthCheck, nil
+}
+
+// buildRetryMiddleware retries the requests according to the retry policy of the backend,
+// which defaults to the global retry configuration.
+func (server *Server) buildRetryMiddleware(handler http.Handler, globalConfig configuration.GlobalConfiguration, backend *types.Backend, backendName string) (http.Handler, error) {
 	retryListeners := middlewares.RetryListeners{}
 	if server.metricsRegistry.IsEnabled() {
 		retryListeners = append(retryListeners, middlewares.NewMetricsRetryListener(server.metricsRegistry, backendName))
@@ -1421,12 +2039,17 @@ func (server *Server) buildRetryMiddleware(handler http.Handler, globalConfig co
 		retryListeners = append(retryListeners, &accesslog.SaveRetries{})
 	}
 
-	retryAttempts := countServers
-	if globalConfig.Retry.Attempts > 0 {
+	retryAttempts := len(backend.Servers)
+	if globalConfig.Retry != nil && globalConfig.Retry.Attempts > 0 {
 		retryAttempts = globalConfig.Retry.Attempts
 	}
 
-	log.Debugf("Creating retries max attempts %d", retryAttempts)
+	policy, err := middlewares.NewRetryPolicy(retryAttempts, backend.Retry)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("Creating retries max attempts %d", policy.Attempts)
 
-	return middlewares.NewRetry(retryAttempts, handler, retryListeners)
+	return middlewares.NewRetryWithPolicy(policy, handler, retryListeners), nil
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	cryptotls "crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -77,6 +78,98 @@ func (lb *testLoadBalancer) Servers() []*url.URL {
 	return []*url.URL{}
 }
 
+func TestCreateServersTransport(t *testing.T) {
+	cert, err := localhostCert.Read()
+	require.NoError(t, err)
+	key, err := localhostKey.Read()
+	require.NoError(t, err)
+	keyPair, err := cryptotls.X509KeyPair(cert, key)
+	require.NoError(t, err)
+
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	backend.TLS = &cryptotls.Config{
+		Certificates: []cryptotls.Certificate{keyPair},
+		ClientAuth:   cryptotls.RequireAnyClientCert,
+	}
+	backend.StartTLS()
+	defer backend.Close()
+
+	testCases := []struct {
+		desc             string
+		serversTransport *types.ServersTransport
+		expectedErr      bool
+	}{
+		{
+			desc: "client certificate and pinned CA",
+			serversTransport: &types.ServersTransport{
+				ServerName: "example.com",
+				RootCAs:    []string{localhostCert.String()},
+				Cert:       localhostCert.String(),
+				Key:        localhostKey.String(),
+			},
+		},
+		{
+			desc: "without client certificate",
+			serversTransport: &types.ServersTransport{
+				ServerName: "example.com",
+				RootCAs:    []string{localhostCert.String()},
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "wrong server name",
+			serversTransport: &types.ServersTransport{
+				ServerName: "wrong.example.com",
+				RootCAs:    []string{localhostCert.String()},
+				Cert:       localhostCert.String(),
+				Key:        localhostKey.String(),
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			transport, err := createServersTransport(configuration.GlobalConfiguration{}, test.serversTransport)
+			require.NoError(t, err)
+
+			req := testhelpers.MustNewRequest(http.MethodGet, backend.URL, nil)
+			resp, err := transport.RoundTrip(req)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+}
+
+func TestGetRoundTripperReusesServersTransports(t *testing.T) {
+	srv := &Server{}
+	serversTransport := &types.ServersTransport{ServerName: "example.com", InsecureSkipVerify: true}
+
+	transports := map[string]*http.Transport{}
+	first, err := srv.getRoundTripper("http", configuration.GlobalConfiguration{}, false, nil, serversTransport, transports)
+	require.NoError(t, err)
+	srv.setServersTransports(transports)
+
+	// the same settings after a reload
+	transports = map[string]*http.Transport{}
+	second, err := srv.getRoundTripper("http", configuration.GlobalConfiguration{}, false, nil, &types.ServersTransport{ServerName: "example.com", InsecureSkipVerify: true}, transports)
+	require.NoError(t, err)
+	assert.True(t, first == second)
+
+	other, err := srv.getRoundTripper("http", configuration.GlobalConfiguration{}, false, nil, &types.ServersTransport{ServerName: "other.example.com"}, transports)
+	require.NoError(t, err)
+	assert.False(t, first == other)
+
+	srv.setServersTransports(transports)
+	assert.Len(t, srv.serversTransports, 2)
+}
+
 func TestPrepareServerTimeouts(t *testing.T) {
 	tests := []struct {
 		desc             string
@@ -310,6 +403,51 @@ func TestServerLoadConfigHealthCheckOptions(t *testing.T) {
 	}
 }
 
+func TestServerLoadConfigCircuitBreakerFallbackHealthCheck(t *testing.T) {
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{
+				ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true},
+			},
+		},
+		HealthCheck: &configuration.HealthCheckConfig{Interval: flaeg.Duration(5 * time.Second)},
+	}
+
+	dynamicConfigs := types.Configurations{
+		"config": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"frontend": {
+					EntryPoints: []string{"http"},
+					Backend:     "backend",
+				},
+			},
+			Backends: map[string]*types.Backend{
+				"backend": {
+					Servers:      map[string]types.Server{"server": {URL: "http://localhost"}},
+					LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+					CircuitBreaker: &types.CircuitBreaker{
+						Expression: "NetworkErrorRatio() > 0.5",
+						Fallback:   &types.CircuitBreakerFallback{Backend: "fallback"},
+					},
+				},
+				"fallback": {
+					Servers:          map[string]types.Server{"server": {URL: "https://localhost"}},
+					HealthCheck:      &types.HealthCheck{Path: "/health"},
+					ServersTransport: &types.ServersTransport{InsecureSkipVerify: true},
+				},
+			},
+		},
+	}
+
+	srv := NewServer(globalConfig)
+	_, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	backends := healthcheck.GetHealthCheck().Backends
+	require.Len(t, backends, 1)
+	assert.Contains(t, backends, "httpbackend/fallback")
+}
+
 func TestServerParseHealthCheckOptions(t *testing.T) {
 	lb := &testLoadBalancer{}
 	globalInterval := 15 * time.Second
@@ -416,7 +554,7 @@ func TestNewServerWithWhitelistSourceRange(t *testing.T) {
 		tc := tc
 		t.Run(tc.desc, func(t *testing.T) {
 			t.Parallel()
-			middleware, err := configureIPWhitelistMiddleware(tc.whitelistStrings)
+			middleware, err := configureIPWhitelistMiddleware(&types.WhiteList{SourceRange: tc.whitelistStrings}, nil)
 
 			if tc.errMessage != "" {
 				require.EqualError(t, err, tc.errMessage)
@@ -433,6 +571,156 @@ func TestNewServerWithWhitelistSourceRange(t *testing.T) {
 	}
 }
 
+func TestIPWhitelistDefaultEntryPoint(t *testing.T) {
+	entryPoints := configuration.EntryPoints{}
+	require.NoError(t, entryPoints.Set("Name:http Address::80"))
+	gc := &configuration.GlobalConfiguration{EntryPoints: entryPoints}
+	gc.SetEffectiveConfiguration("")
+	require.True(t, gc.EntryPoints["http"].ForwardedHeaders.Insecure)
+
+	// the traefik.frontend.whiteList.ipStrategy=true label
+	whiteList := &types.WhiteList{SourceRange: []string{"1.1.1.1"}, IPStrategy: &types.IPStrategy{}}
+	middleware, err := configureIPWhitelistMiddleware(whiteList, gc.EntryPoints["http"].ForwardedHeaders)
+	require.NoError(t, err)
+
+	n := negroni.New(middleware)
+	n.UseHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// the client can't choose its IP with the X-Forwarded-For header
+	req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+	recorder := httptest.NewRecorder()
+	n.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestServerLoadConfigInvalidWhiteList(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	globalConfig := configuration.GlobalConfiguration{
+		EntryPoints: configuration.EntryPoints{
+			"http": &configuration.EntryPoint{ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+		},
+	}
+
+	dynamicConfigs := types.Configurations{
+		"config": &types.Configuration{
+			Frontends: map[string]*types.Frontend{
+				"invalid": {
+					EntryPoints: []string{"http"},
+					Backend:     "backend",
+					Routes:      map[string]types.Route{"route": {Rule: "Host:invalid.localhost"}},
+					WhiteList:   &types.WhiteList{SourceRange: []string{"foo"}},
+				},
+				"valid": {
+					EntryPoints: []string{"http"},
+					Backend:     "backend",
+					Routes:      map[string]types.Route{"route": {Rule: "Host:valid.localhost"}},
+				},
+			},
+			Backends: map[string]*types.Backend{
+				"backend": {
+					Servers:      map[string]types.Server{"server": {URL: backend.URL}},
+					LoadBalancer: &types.LoadBalancer{Method: "wrr"},
+				},
+			},
+		},
+	}
+
+	srv := NewServer(globalConfig)
+	entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+	require.NoError(t, err)
+
+	// the frontend with the invalid whitelist is skipped
+	recorder := httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://invalid.localhost", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	entryPoints["http"].httpRouter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://valid.localhost", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestBuildIPStrategy(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		ipStrategy       *types.IPStrategy
+		forwardedHeaders *configuration.ForwardedHeaders
+		expectedIP       string
+		expectedError    bool
+	}{
+		{
+			desc:       "remote address",
+			expectedIP: "10.0.0.1",
+		},
+		{
+			desc:       "depth",
+			ipStrategy: &types.IPStrategy{Depth: 2},
+			expectedIP: "2.2.2.2",
+		},
+		{
+			desc:          "invalid depth",
+			ipStrategy:    &types.IPStrategy{Depth: -1},
+			expectedError: true,
+		},
+		{
+			desc:       "trusted proxies",
+			ipStrategy: &types.IPStrategy{TrustedProxies: []string{"10.0.0.0/8", "3.3.3.3"}},
+			expectedIP: "2.2.2.2",
+		},
+		{
+			desc:          "invalid trusted proxies",
+			ipStrategy:    &types.IPStrategy{TrustedProxies: []string{"foo"}},
+			expectedError: true,
+		},
+		{
+			desc:             "entry point trusted IPs",
+			ipStrategy:       &types.IPStrategy{},
+			forwardedHeaders: &configuration.ForwardedHeaders{TrustedIPs: []string{"10.0.0.0/8"}},
+			expectedIP:       "3.3.3.3",
+		},
+		{
+			desc:             "insecure entry point",
+			ipStrategy:       &types.IPStrategy{},
+			forwardedHeaders: &configuration.ForwardedHeaders{Insecure: true},
+			expectedIP:       "10.0.0.1",
+		},
+		{
+			desc:             "insecure entry point with trusted IPs",
+			ipStrategy:       &types.IPStrategy{},
+			forwardedHeaders: &configuration.ForwardedHeaders{Insecure: true, TrustedIPs: []string{"10.0.0.0/8"}},
+			expectedIP:       "3.3.3.3",
+		},
+		{
+			desc:             "entry point without trusted IPs",
+			ipStrategy:       &types.IPStrategy{},
+			forwardedHeaders: &configuration.ForwardedHeaders{},
+			expectedIP:       "10.0.0.1",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			strategy, err := buildIPStrategy(test.ipStrategy, test.forwardedHeaders)
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2, 3.3.3.3")
+			assert.Equal(t, test.expectedIP, strategy.GetIP(req))
+		})
+	}
+}
+
 func TestServerLoadConfigEmptyBasicAuth(t *testing.T) {
 	globalConfig := configuration.GlobalConfiguration{
 		EntryPoints: configuration.EntryPoints{
@@ -479,6 +767,87 @@ func TestServerLoadConfigEmptyBasicAuth(t *testing.T) {
 	}
 }
 
+func TestBuildFrontendAuth(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		frontend      *types.Frontend
+		expected      *types.Auth
+		expectedError bool
+	}{
+		{
+			desc:     "no auth",
+			frontend: &types.Frontend{},
+			expected: nil,
+		},
+		{
+			desc:     "basic auth users only",
+			frontend: &types.Frontend{BasicAuth: []string{"test:hash"}},
+			expected: &types.Auth{Basic: &types.Basic{Users: types.Users{"test:hash"}}},
+		},
+		{
+			desc: "basic auth users merged with the auth options",
+			frontend: &types.Frontend{
+				BasicAuth: []string{"test:hash"},
+				Auth: &types.Auth{
+					HeaderField: "X-WebAuth-User",
+					Basic:       &types.Basic{Users: types.Users{"test2:hash2"}, Realm: "myrealm"},
+				},
+			},
+			expected: &types.Auth{
+				HeaderField: "X-WebAuth-User",
+				Basic:       &types.Basic{Users: types.Users{"test:hash", "test2:hash2"}, Realm: "myrealm"},
+			},
+		},
+		{
+			desc: "auth options only",
+			frontend: &types.Frontend{
+				Auth: &types.Auth{Forward: &types.Forward{Address: "http://auth.server"}},
+			},
+			expected: &types.Auth{Forward: &types.Forward{Address: "http://auth.server"}},
+		},
+		{
+			desc: "basic auth users with a forward auth",
+			frontend: &types.Frontend{
+				BasicAuth: []string{"test:hash"},
+				Auth:      &types.Auth{Forward: &types.Forward{Address: "http://auth.server"}},
+			},
+			expectedError: true,
+		},
+		{
+			desc: "basic auth users with a JWT auth",
+			frontend: &types.Frontend{
+				BasicAuth: []string{"test:hash"},
+				Auth:      &types.Auth{JWT: &types.JWT{JWKSURL: "http://auth.server/jwks"}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var original *types.Auth
+			if test.frontend.Auth != nil && test.frontend.Auth.Basic != nil {
+				original = &types.Auth{Basic: &types.Basic{Users: test.frontend.Auth.Basic.Users}}
+			}
+
+			auth, err := buildFrontendAuth(test.frontend)
+			if test.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, auth)
+			if original != nil {
+				assert.Equal(t, original.Basic.Users, test.frontend.Auth.Basic.Users, "the frontend auth must not be modified")
+			}
+		})
+	}
+}
+
 func TestConfigureBackends(t *testing.T) {
 	validMethod := "Drr"
 	defaultMethod := "wrr"
@@ -554,6 +923,231 @@ func TestConfigureBackends(t *testing.T) {
 	}
 }
 
+func TestServerValidateConfiguration(t *testing.T) {
+	validBackends := map[string]*types.Backend{
+		"backend": {
+			Servers: map[string]types.Server{"server": {URL: "http://127.0.0.1:8080"}},
+		},
+	}
+
+	testCases := []struct {
+		desc        string
+		config      *types.Configuration
+		expectedErr string
+	}{
+		{
+			desc: "valid configuration",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {
+						Backend: "backend",
+						Routes:  map[string]types.Route{"route": {Rule: "Host:foo.bar"}},
+					},
+				},
+				Backends: validBackends,
+			},
+		},
+		{
+			desc: "undefined entrypoint",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {Backend: "backend", EntryPoints: []string{"https"}},
+				},
+				Backends: validBackends,
+			},
+			expectedErr: "undefined entrypoint 'https' for frontend frontend",
+		},
+		{
+			desc: "invalid rule",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {
+						Backend: "backend",
+						Routes:  map[string]types.Route{"route": {Rule: "Foo:bar"}},
+					},
+				},
+				Backends: validBackends,
+			},
+			expectedErr: "invalid route route for frontend frontend",
+		},
+		{
+			desc: "undefined backend",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {Backend: "foo"},
+				},
+				Backends: validBackends,
+			},
+			expectedErr: "undefined backend 'foo' for frontend frontend",
+		},
+		{
+			desc: "invalid whitelist",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {
+						Backend:   "backend",
+						WhiteList: &types.WhiteList{SourceRange: []string{"10.0.0.0/8"}, IPStrategy: &types.IPStrategy{Depth: -1}},
+					},
+				},
+				Backends: validBackends,
+			},
+			expectedErr: "invalid whitelist for frontend frontend",
+		},
+		{
+			desc: "basic auth users with an OIDC auth",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {
+						Backend:   "backend",
+						BasicAuth: []string{"test:hash"},
+						Auth:      &types.Auth{OIDC: &types.OIDC{Issuer: "https://idp.example.com"}},
+					},
+				},
+				Backends: validBackends,
+			},
+			expectedErr: "invalid auth for frontend frontend",
+		},
+		{
+			desc: "invalid error page",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {
+						Backend: "backend",
+						Errors:  map[string]types.ErrorPage{"foo": {Status: []string{"5xx"}, Content: "error"}},
+					},
+				},
+				Backends: validBackends,
+			},
+			expectedErr: "invalid error page foo for frontend frontend",
+		},
+		{
+			desc: "error page file",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {
+						Backend: "backend",
+						Errors:  map[string]types.ErrorPage{"foo": {Status: []string{"500"}, File: "/etc/traefik/acme.json"}},
+					},
+				},
+				Backends: validBackends,
+			},
+			expectedErr: "invalid error page foo for frontend frontend: the files are only served by the entry point error pages",
+		},
+		{
+			desc: "invalid cache",
+			config: &types.Configuration{
+				Frontends: map[string]*types.Frontend{
+					"frontend": {
+						Backend: "backend",
+						Cache:   &types.Cache{Store: "disk"},
+					},
+				},
+				Backends: validBackends,
+			},
+			expectedErr: "invalid cache for frontend frontend",
+		},
+		{
+			desc: "invalid load balancer method",
+			config: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend": {LoadBalancer: &types.LoadBalancer{Method: "foo"}},
+				},
+			},
+			expectedErr: "invalid load-balancing method 'foo'",
+		},
+		{
+			desc: "invalid ring hash value",
+			config: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend": {LoadBalancer: &types.LoadBalancer{Method: "ringhash", HashOn: "foo"}},
+				},
+			},
+			expectedErr: "invalid load-balancer for backend backend",
+		},
+		{
+			desc: "invalid stickiness",
+			config: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend": {LoadBalancer: &types.LoadBalancer{Method: "wrr", Stickiness: &types.Stickiness{Mode: "hash", HashOn: "foo"}}},
+				},
+			},
+			expectedErr: "invalid stickiness for backend backend",
+		},
+		{
+			desc: "invalid circuit breaker expression",
+			config: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend": {CircuitBreaker: &types.CircuitBreaker{Expression: "NetworkErrorRatio() >"}},
+				},
+			},
+			expectedErr: "invalid circuit breaker for backend backend",
+		},
+		{
+			desc: "undefined circuit breaker fallback backend",
+			config: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend": {
+						CircuitBreaker: &types.CircuitBreaker{
+							Expression: "NetworkErrorRatio() > 0.5",
+							Fallback:   &types.CircuitBreakerFallback{Backend: "fallback"},
+						},
+					},
+				},
+			},
+			expectedErr: "undefined circuit breaker fallback backend 'fallback' for backend backend",
+		},
+		{
+			desc: "invalid retry status codes",
+			config: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend": {Retry: &types.Retry{StatusCodes: []string{"5xx"}}},
+				},
+			},
+			expectedErr: "invalid retry for backend backend",
+		},
+		{
+			desc: "invalid server URL",
+			config: &types.Configuration{
+				Backends: map[string]*types.Backend{
+					"backend": {
+						Servers: map[string]types.Server{"server": {URL: "127.0.0.1:8080"}},
+					},
+				},
+			},
+			expectedErr: "invalid URL '127.0.0.1:8080' for server server of backend backend",
+		},
+		{
+			desc: "missing certificate",
+			config: &types.Configuration{
+				TLSConfiguration: []*tls.Configuration{{EntryPoints: []string{"http"}}},
+			},
+			expectedErr: "no certificate defined in TLS configuration 0",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			srv := Server{
+				globalConfiguration: configuration.GlobalConfiguration{
+					EntryPoints:        configuration.EntryPoints{"http": &configuration.EntryPoint{}},
+					DefaultEntryPoints: []string{"http"},
+				},
+			}
+
+			err := srv.validateConfiguration(test.config)
+			if len(test.expectedErr) == 0 {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+			}
+		})
+	}
+}
+
 func TestServerEntryPointWhitelistConfig(t *testing.T) {
 	tests := []struct {
 		desc           string
@@ -616,6 +1210,54 @@ func TestServerEntryPointWhitelistConfig(t *testing.T) {
 	}
 }
 
+func TestServerEntryPointErrorPages(t *testing.T) {
+	srv := Server{
+		globalConfiguration: configuration.GlobalConfiguration{
+			EntryPoints: map[string]*configuration.EntryPoint{
+				"test": {
+					Address:          ":0",
+					ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true},
+					Errors: map[string]types.ErrorPage{
+						"notfound": {Status: []string{"404"}, Content: "<h1>{status} {statusText}</h1>"},
+					},
+				},
+			},
+		},
+		metricsRegistry: metrics.NewVoidRegistry(),
+	}
+
+	srv.serverEntryPoints = srv.buildEntryPoints(srv.globalConfiguration)
+	srvEntryPoint := srv.setupServerEntryPoint("test", srv.serverEntryPoints["test"])
+	defer srvEntryPoint.listener.Close()
+
+	recorder := httptest.NewRecorder()
+	srvEntryPoint.httpServer.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/unknown", nil))
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "<h1>404 Not Found</h1>", recorder.Body.String())
+}
+
+func TestBuildEntryPointErrorPages(t *testing.T) {
+	handlers, err := buildEntryPointErrorPages(map[string]types.ErrorPage{
+		"b": {Status: []string{"500-599"}, Content: "error"},
+		"a": {Status: []string{"404"}, Content: "not found"},
+	})
+	require.NoError(t, err)
+	require.Len(t, handlers, 2)
+	assert.Equal(t, [][2]int{{404, 404}}, handlers[0].(*middlewares.ErrorPagesHandler).HTTPCodeRanges)
+
+	_, err = buildEntryPointErrorPages(map[string]types.ErrorPage{
+		"a": {Status: []string{"404"}, Backend: "error"},
+	})
+	assert.Error(t, err)
+
+	_, err = buildEntryPointErrorPages(map[string]types.ErrorPage{
+		"a": {Status: []string{"404"}, File: "/nonexistent/404.html"},
+	})
+	assert.Error(t, err)
+}
+
 func TestServerResponseEmptyBackend(t *testing.T) {
 	const requestPath = "/path"
 	const routeRule = "Path:" + requestPath
@@ -682,6 +1324,46 @@ func TestServerResponseEmptyBackend(t *testing.T) {
 			},
 			wantStatusCode: http.StatusServiceUnavailable,
 		},
+		{
+			desc: "Ok LB-LeastConn",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withServer("testServer", testServerURL), withLoadBalancer("LeastConn", false))),
+				)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			desc: "Ok LB-RingHash Sticky",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withServer("testServer", testServerURL), withLoadBalancer("RingHash", true))),
+				)
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			desc: "Empty Backend LB-LeastLatency",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withLoadBalancer("LeastLatency", false))),
+				)
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			desc: "Empty Backend LB-P2c Sticky",
+			dynamicConfig: func(testServerURL string) *types.Configuration {
+				return buildDynamicConfig(
+					withFrontend("frontend", buildFrontend(withRoute(requestPath, routeRule))),
+					withBackend("backend", buildBackend(withLoadBalancer("P2c", true))),
+				)
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
 	}
 
 	for _, test := range testCases {
@@ -720,6 +1402,82 @@ func TestServerResponseEmptyBackend(t *testing.T) {
 	}
 }
 
+func TestServerFrontendRedirect(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		redirect         *types.Redirect
+		entryPoint       string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			desc:             "redirect to entry point",
+			redirect:         &types.Redirect{EntryPoint: "https"},
+			entryPoint:       "http",
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://foo.bar:443/path",
+		},
+		{
+			desc:           "no redirect on the redirection entry point",
+			redirect:       &types.Redirect{EntryPoint: "https"},
+			entryPoint:     "https",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:             "redirect with regex",
+			redirect:         &types.Redirect{Regex: `^http://foo\.bar/(.*)`, Replacement: "http://bar.foo/$1"},
+			entryPoint:       "http",
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "http://bar.foo/path",
+		},
+		{
+			desc:           "unknown entry point",
+			redirect:       &types.Redirect{EntryPoint: "foo"},
+			entryPoint:     "http",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			testServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			}))
+			defer testServer.Close()
+
+			globalConfig := configuration.GlobalConfiguration{
+				EntryPoints: configuration.EntryPoints{
+					"http":  &configuration.EntryPoint{Address: ":80", ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+					"https": &configuration.EntryPoint{Address: ":443", TLS: &tls.TLS{}, ForwardedHeaders: &configuration.ForwardedHeaders{Insecure: true}},
+				},
+			}
+			frontend := buildFrontend(withRoute("/path", "Path:/path"))
+			frontend.EntryPoints = []string{"http", "https"}
+			frontend.Redirect = test.redirect
+			dynamicConfigs := types.Configurations{"config": buildDynamicConfig(
+				withFrontend("frontend", frontend),
+				withBackend("backend", buildBackend(withServer("testServer", testServer.URL))),
+			)}
+
+			srv := NewServer(globalConfig)
+			entryPoints, err := srv.loadConfig(dynamicConfigs, globalConfig)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/path", nil)
+			request.Host = "foo.bar"
+			entryPoints[test.entryPoint].httpRouter.ServeHTTP(recorder, request)
+
+			assert.Equal(t, test.expectedStatus, recorder.Code)
+			assert.Equal(t, test.expectedLocation, recorder.Header().Get("Location"))
+		})
+	}
+}
+
 func buildDynamicConfig(dynamicConfigBuilders ...func(*types.Configuration)) *types.Configuration {
 	config := &types.Configuration{
 		Frontends: make(map[string]*types.Frontend),
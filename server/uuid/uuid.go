@@ -12,3 +12,8 @@ func init() {
 func Get() string {
 	return uuid
 }
+
+// New returns a new random (version 4) UUID
+func New() string {
+	return guuid.NewV4().String()
+}
@@ -34,6 +34,23 @@ type Configuration struct {
 	Certificate *Certificate
 }
 
+// WithoutSecrets returns a copy of the configuration without the private key of its certificate,
+// to be exposed through the API.
+func (c *Configuration) WithoutSecrets() *Configuration {
+	if c == nil {
+		return nil
+	}
+	withoutSecrets := *c
+
+	if c.Certificate != nil && len(c.Certificate.KeyFile) > 0 {
+		certificate := *c.Certificate
+		certificate.KeyFile = ""
+		withoutSecrets.Certificate = &certificate
+	}
+
+	return &withoutSecrets
+}
+
 // Set is the method to set the flag value, part of the flag.Value interface.
 // Set's argument is a string to be parsed to set the flag.
 // It's a comma-separated list, so we split it.
@@ -4,36 +4,127 @@ import "strings"
 
 // Traefik labels
 const (
-	LabelPrefix                                  = "traefik."
-	LabelDomain                                  = LabelPrefix + "domain"
-	LabelEnable                                  = LabelPrefix + "enable"
-	LabelPort                                    = LabelPrefix + "port"
-	LabelPortIndex                               = LabelPrefix + "portIndex"
-	LabelProtocol                                = LabelPrefix + "protocol"
-	LabelTags                                    = LabelPrefix + "tags"
-	LabelWeight                                  = LabelPrefix + "weight"
-	LabelFrontendAuthBasic                       = LabelPrefix + "frontend.auth.basic"
-	LabelFrontendEntryPoints                     = LabelPrefix + "frontend.entryPoints"
-	LabelFrontendRequestHeader                   = LabelPrefix + "frontend.headers.customrequestheaders"
-	LabelFrontendResponseHeader                  = LabelPrefix + "frontend.headers.customresponseheaders"
-	LabelFrontendPassHostHeader                  = LabelPrefix + "frontend.passHostHeader"
-	LabelFrontendPriority                        = LabelPrefix + "frontend.priority"
-	LabelFrontendRule                            = LabelPrefix + "frontend.rule"
-	LabelFrontendRuleType                        = LabelPrefix + "frontend.rule.type"
-	LabelTraefikFrontendValue                    = LabelPrefix + "frontend.value"
-	LabelTraefikFrontendWhitelistSourceRange     = LabelPrefix + "frontend.whitelistSourceRange"
-	LabelBackend                                 = LabelPrefix + "backend"
-	LabelBackendID                               = LabelPrefix + "backend.id"
-	LabelTraefikBackendCircuitbreaker            = LabelPrefix + "backend.circuitbreaker"
-	LabelBackendCircuitbreakerExpression         = LabelPrefix + "backend.circuitbreaker.expression"
-	LabelBackendHealthcheckPath                  = LabelPrefix + "backend.healthcheck.path"
-	LabelBackendHealthcheckInterval              = LabelPrefix + "backend.healthcheck.interval"
-	LabelBackendLoadbalancerMethod               = LabelPrefix + "backend.loadbalancer.method"
-	LabelBackendLoadbalancerSticky               = LabelPrefix + "backend.loadbalancer.sticky"
-	LabelBackendLoadbalancerStickiness           = LabelPrefix + "backend.loadbalancer.stickiness"
-	LabelBackendLoadbalancerStickinessCookieName = LabelPrefix + "backend.loadbalancer.stickiness.cookieName"
-	LabelBackendMaxconnAmount                    = LabelPrefix + "backend.maxconn.amount"
-	LabelBackendMaxconnExtractorfunc             = LabelPrefix + "backend.maxconn.extractorfunc"
+	LabelPrefix                                   = "traefik."
+	LabelDomain                                   = LabelPrefix + "domain"
+	LabelEnable                                   = LabelPrefix + "enable"
+	LabelPort                                     = LabelPrefix + "port"
+	LabelPortIndex                                = LabelPrefix + "portIndex"
+	LabelProtocol                                 = LabelPrefix + "protocol"
+	LabelTags                                     = LabelPrefix + "tags"
+	LabelWeight                                   = LabelPrefix + "weight"
+	LabelFrontendAuthBasic                        = LabelPrefix + "frontend.auth.basic"
+	LabelFrontendAuthBasicUsers                   = LabelPrefix + "frontend.auth.basic.users"
+	LabelFrontendAuthBasicUsersFile               = LabelPrefix + "frontend.auth.basic.usersFile"
+	LabelFrontendAuthBasicRealm                   = LabelPrefix + "frontend.auth.basic.realm"
+	LabelFrontendAuthBasicRemoveHeader            = LabelPrefix + "frontend.auth.basic.removeHeader"
+	LabelFrontendAuthDigestUsers                  = LabelPrefix + "frontend.auth.digest.users"
+	LabelFrontendAuthDigestUsersFile              = LabelPrefix + "frontend.auth.digest.usersFile"
+	LabelFrontendAuthDigestRealm                  = LabelPrefix + "frontend.auth.digest.realm"
+	LabelFrontendAuthDigestRemoveHeader           = LabelPrefix + "frontend.auth.digest.removeHeader"
+	LabelFrontendAuthForwardAddress               = LabelPrefix + "frontend.auth.forward.address"
+	LabelFrontendAuthForwardTrustForwardHeader    = LabelPrefix + "frontend.auth.forward.trustForwardHeader"
+	LabelFrontendAuthForwardAuthResponseHeaders   = LabelPrefix + "frontend.auth.forward.authResponseHeaders"
+	LabelFrontendAuthHeaderField                  = LabelPrefix + "frontend.auth.headerField"
+	LabelFrontendCompress                         = LabelPrefix + "frontend.compress"
+	LabelFrontendCompressEncodings                = LabelPrefix + "frontend.compress.encodings"
+	LabelFrontendCompressLevel                    = LabelPrefix + "frontend.compress.level"
+	LabelFrontendCompressBrotliLevel              = LabelPrefix + "frontend.compress.brotliLevel"
+	LabelFrontendCompressMinSize                  = LabelPrefix + "frontend.compress.minSize"
+	LabelFrontendCompressContentTypes             = LabelPrefix + "frontend.compress.contentTypes"
+	LabelFrontendCompressExcludedContentTypes     = LabelPrefix + "frontend.compress.excludedContentTypes"
+	LabelFrontendCache                            = LabelPrefix + "frontend.cache"
+	LabelFrontendCacheStore                       = LabelPrefix + "frontend.cache.store"
+	LabelFrontendCacheDirectory                   = LabelPrefix + "frontend.cache.directory"
+	LabelFrontendCacheMaxSize                     = LabelPrefix + "frontend.cache.maxSize"
+	LabelFrontendCacheMaxEntrySize                = LabelPrefix + "frontend.cache.maxEntrySize"
+	LabelFrontendCacheDefaultTTL                  = LabelPrefix + "frontend.cache.defaultTTL"
+	LabelFrontendCacheStaleWhileRevalidate        = LabelPrefix + "frontend.cache.staleWhileRevalidate"
+	LabelFrontendCacheStaleIfError                = LabelPrefix + "frontend.cache.staleIfError"
+	LabelFrontendEntryPoints                      = LabelPrefix + "frontend.entryPoints"
+	LabelFrontendErrorPages                       = LabelPrefix + "frontend.errors."
+	LabelFrontendRequestHeader                    = LabelPrefix + "frontend.headers.customrequestheaders"
+	LabelFrontendResponseHeader                   = LabelPrefix + "frontend.headers.customresponseheaders"
+	LabelFrontendAllowedHosts                     = LabelPrefix + "frontend.headers.allowedHosts"
+	LabelFrontendHostsProxyHeaders                = LabelPrefix + "frontend.headers.hostsProxyHeaders"
+	LabelFrontendSSLRedirect                      = LabelPrefix + "frontend.headers.SSLRedirect"
+	LabelFrontendSSLTemporaryRedirect             = LabelPrefix + "frontend.headers.SSLTemporaryRedirect"
+	LabelFrontendSSLHost                          = LabelPrefix + "frontend.headers.SSLHost"
+	LabelFrontendSSLProxyHeaders                  = LabelPrefix + "frontend.headers.SSLProxyHeaders"
+	LabelFrontendSTSSeconds                       = LabelPrefix + "frontend.headers.STSSeconds"
+	LabelFrontendSTSIncludeSubdomains             = LabelPrefix + "frontend.headers.STSIncludeSubdomains"
+	LabelFrontendSTSPreload                       = LabelPrefix + "frontend.headers.STSPreload"
+	LabelFrontendForceSTSHeader                   = LabelPrefix + "frontend.headers.forceSTSHeader"
+	LabelFrontendFrameDeny                        = LabelPrefix + "frontend.headers.frameDeny"
+	LabelFrontendCustomFrameOptionsValue          = LabelPrefix + "frontend.headers.customFrameOptionsValue"
+	LabelFrontendContentTypeNosniff               = LabelPrefix + "frontend.headers.contentTypeNosniff"
+	LabelFrontendBrowserXSSFilter                 = LabelPrefix + "frontend.headers.browserXSSFilter"
+	LabelFrontendContentSecurityPolicy            = LabelPrefix + "frontend.headers.contentSecurityPolicy"
+	LabelFrontendPublicKey                        = LabelPrefix + "frontend.headers.publicKey"
+	LabelFrontendReferrerPolicy                   = LabelPrefix + "frontend.headers.referrerPolicy"
+	LabelFrontendIsDevelopment                    = LabelPrefix + "frontend.headers.isDevelopment"
+	LabelFrontendPassHostHeader                   = LabelPrefix + "frontend.passHostHeader"
+	LabelFrontendPassTLSCert                      = LabelPrefix + "frontend.passTLSCert"
+	LabelFrontendPriority                         = LabelPrefix + "frontend.priority"
+	LabelFrontendRule                             = LabelPrefix + "frontend.rule"
+	LabelFrontendRuleType                         = LabelPrefix + "frontend.rule.type"
+	LabelFrontendRateLimitExtractorFunc           = LabelPrefix + "frontend.rateLimit.extractorFunc"
+	LabelFrontendRateLimitAlgorithm               = LabelPrefix + "frontend.rateLimit.algorithm"
+	LabelFrontendRateLimitRateSet                 = LabelPrefix + "frontend.rateLimit.rateSet."
+	LabelFrontendRedirectEntryPoint               = LabelPrefix + "frontend.redirect.entryPoint"
+	LabelFrontendRedirectRegex                    = LabelPrefix + "frontend.redirect.regex"
+	LabelFrontendRedirectReplacement              = LabelPrefix + "frontend.redirect.replacement"
+	LabelTraefikFrontendValue                     = LabelPrefix + "frontend.value"
+	LabelTraefikFrontendWhitelistSourceRange      = LabelPrefix + "frontend.whitelistSourceRange"
+	LabelFrontendWhiteListSourceRange             = LabelPrefix + "frontend.whiteList.sourceRange"
+	LabelFrontendWhiteListIPStrategy              = LabelPrefix + "frontend.whiteList.ipStrategy"
+	LabelFrontendWhiteListIPStrategyDepth         = LabelPrefix + "frontend.whiteList.ipStrategy.depth"
+	LabelFrontendWhiteListIPStrategyProxies       = LabelPrefix + "frontend.whiteList.ipStrategy.trustedProxies"
+	LabelFrontendWhiteListDeny                    = LabelPrefix + "frontend.whiteList.deny"
+	LabelFrontendWhiteListRejectStatusCode        = LabelPrefix + "frontend.whiteList.rejectStatusCode"
+	LabelFrontendWhiteListRejectBody              = LabelPrefix + "frontend.whiteList.rejectBody"
+	LabelBackend                                  = LabelPrefix + "backend"
+	LabelBackendID                                = LabelPrefix + "backend.id"
+	LabelTraefikBackendCircuitbreaker             = LabelPrefix + "backend.circuitbreaker"
+	LabelBackendCircuitbreakerExpression          = LabelPrefix + "backend.circuitbreaker.expression"
+	LabelBackendCircuitbreakerFallbackDuration    = LabelPrefix + "backend.circuitbreaker.fallbackDuration"
+	LabelBackendCircuitbreakerRecoveryDuration    = LabelPrefix + "backend.circuitbreaker.recoveryDuration"
+	LabelBackendCircuitbreakerCheckPeriod         = LabelPrefix + "backend.circuitbreaker.checkPeriod"
+	LabelBackendCircuitbreakerFallbackStatusCode  = LabelPrefix + "backend.circuitbreaker.fallback.statusCode"
+	LabelBackendCircuitbreakerFallbackContentType = LabelPrefix + "backend.circuitbreaker.fallback.contentType"
+	LabelBackendCircuitbreakerFallbackBody        = LabelPrefix + "backend.circuitbreaker.fallback.body"
+	LabelBackendCircuitbreakerFallbackBackend     = LabelPrefix + "backend.circuitbreaker.fallback.backend"
+	LabelBackendHealthcheckPath                   = LabelPrefix + "backend.healthcheck.path"
+	LabelBackendHealthcheckPort                   = LabelPrefix + "backend.healthcheck.port"
+	LabelBackendHealthcheckInterval               = LabelPrefix + "backend.healthcheck.interval"
+	LabelBackendLoadbalancerMethod                = LabelPrefix + "backend.loadbalancer.method"
+	LabelBackendLoadbalancerHashOn                = LabelPrefix + "backend.loadbalancer.hashOn"
+	LabelBackendLoadbalancerSticky                = LabelPrefix + "backend.loadbalancer.sticky"
+	LabelBackendLoadbalancerStickiness            = LabelPrefix + "backend.loadbalancer.stickiness"
+	LabelBackendLoadbalancerStickinessCookieName  = LabelPrefix + "backend.loadbalancer.stickiness.cookieName"
+	LabelBackendLoadbalancerStickinessSecure      = LabelPrefix + "backend.loadbalancer.stickiness.secure"
+	LabelBackendLoadbalancerStickinessHTTPOnly    = LabelPrefix + "backend.loadbalancer.stickiness.httpOnly"
+	LabelBackendLoadbalancerStickinessSameSite    = LabelPrefix + "backend.loadbalancer.stickiness.sameSite"
+	LabelBackendLoadbalancerStickinessMaxAge      = LabelPrefix + "backend.loadbalancer.stickiness.maxAge"
+	LabelBackendLoadbalancerStickinessDomain      = LabelPrefix + "backend.loadbalancer.stickiness.domain"
+	LabelBackendLoadbalancerStickinessObfuscation = LabelPrefix + "backend.loadbalancer.stickiness.obfuscation"
+	LabelBackendLoadbalancerStickinessSecret      = LabelPrefix + "backend.loadbalancer.stickiness.secret"
+	LabelBackendLoadbalancerStickinessMode        = LabelPrefix + "backend.loadbalancer.stickiness.mode"
+	LabelBackendLoadbalancerStickinessHashOn      = LabelPrefix + "backend.loadbalancer.stickiness.hashOn"
+	LabelBackendMaxconnAmount                     = LabelPrefix + "backend.maxconn.amount"
+	LabelBackendMaxconnExtractorfunc              = LabelPrefix + "backend.maxconn.extractorfunc"
+	LabelBackendRetryAttempts                     = LabelPrefix + "backend.retry.attempts"
+	LabelBackendRetryStatusCodes                  = LabelPrefix + "backend.retry.statusCodes"
+	LabelBackendRetryMethods                      = LabelPrefix + "backend.retry.methods"
+	LabelBackendRetryInitialInterval              = LabelPrefix + "backend.retry.initialInterval"
+	LabelBackendRetryMaxInterval                  = LabelPrefix + "backend.retry.maxInterval"
+	LabelBackendRetryMaxDuration                  = LabelPrefix + "backend.retry.maxDuration"
+	LabelBackendRetryMaxBodySize                  = LabelPrefix + "backend.retry.maxBodySize"
+	LabelBackendServersTransportServerName        = LabelPrefix + "backend.serverstransport.servername"
+	LabelBackendServersTransportInsecure          = LabelPrefix + "backend.serverstransport.insecureskipverify"
+	LabelBackendServersTransportRootCAs           = LabelPrefix + "backend.serverstransport.rootcas"
+	LabelBackendServersTransportCert              = LabelPrefix + "backend.serverstransport.cert"
+	LabelBackendServersTransportKey               = LabelPrefix + "backend.serverstransport.key"
+	LabelBackendServersTransportMaxIdleConns      = LabelPrefix + "backend.serverstransport.maxidleconnsperhost"
 )
 
 //ServiceLabel converts a key value of Label*, given a serviceName, into a pattern <LabelPrefix>.<serviceName>.<property>
@@ -45,3 +136,76 @@ func ServiceLabel(key, serviceName string) string {
 	}
 	return key
 }
+
+// GetAuthFromLabels builds the frontend authentication from the frontend.auth.* labels.
+// getLabel returns the value of the given label, and whether it is set.
+// It returns nil if no authentication method is configured by these labels,
+// the options alone, e.g. the header field, don't enable the authentication.
+func GetAuthFromLabels(getLabel func(label string) (string, bool)) *Auth {
+	auth := &Auth{}
+	configured := false
+
+	getList := func(label string) []string {
+		var values []string
+		if value, ok := getLabel(label); ok {
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); len(v) > 0 {
+					values = append(values, v)
+				}
+			}
+		}
+		return values
+	}
+	getString := func(label string) string {
+		value, ok := getLabel(label)
+		if ok {
+			configured = true
+		}
+		return value
+	}
+	getBool := func(label string) bool {
+		value := getString(label)
+		return value == "true"
+	}
+
+	basic := &Basic{
+		Users:        getList(LabelFrontendAuthBasicUsers),
+		UsersFile:    getString(LabelFrontendAuthBasicUsersFile),
+		Realm:        getString(LabelFrontendAuthBasicRealm),
+		RemoveHeader: getBool(LabelFrontendAuthBasicRemoveHeader),
+	}
+	if configured || len(basic.Users) > 0 {
+		auth.Basic = basic
+	}
+
+	configured = false
+	digest := &Digest{
+		Users:        getList(LabelFrontendAuthDigestUsers),
+		UsersFile:    getString(LabelFrontendAuthDigestUsersFile),
+		Realm:        getString(LabelFrontendAuthDigestRealm),
+		RemoveHeader: getBool(LabelFrontendAuthDigestRemoveHeader),
+	}
+	if configured || len(digest.Users) > 0 {
+		auth.Digest = digest
+	}
+
+	if address, ok := getLabel(LabelFrontendAuthForwardAddress); ok {
+		auth.Forward = &Forward{
+			Address:             address,
+			AuthResponseHeaders: getList(LabelFrontendAuthForwardAuthResponseHeaders),
+		}
+		if value, ok := getLabel(LabelFrontendAuthForwardTrustForwardHeader); ok {
+			auth.Forward.TrustForwardHeader = value == "true"
+		}
+	}
+
+	auth.HeaderField, _ = getLabel(LabelFrontendAuthHeaderField)
+
+	if auth.Basic == nil && auth.Digest == nil && auth.Forward == nil {
+		// the header field is kept for the users of the frontend.auth.basic label, merged by the server
+		if _, legacyBasic := getLabel(LabelFrontendAuthBasic); !legacyBasic || len(auth.HeaderField) == 0 {
+			return nil
+		}
+	}
+	return auth
+}
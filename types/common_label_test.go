@@ -0,0 +1,94 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuthFromLabels(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		labels   map[string]string
+		expected *Auth
+	}{
+		{
+			desc:     "no auth labels",
+			labels:   map[string]string{LabelFrontendAuthBasic: "test:hash"},
+			expected: nil,
+		},
+		{
+			desc: "basic auth",
+			labels: map[string]string{
+				LabelFrontendAuthBasicUsers:        "test:hash, test2:hash2",
+				LabelFrontendAuthBasicRealm:        "myrealm",
+				LabelFrontendAuthBasicRemoveHeader: "true",
+				LabelFrontendAuthHeaderField:       "X-WebAuth-User",
+			},
+			expected: &Auth{
+				HeaderField: "X-WebAuth-User",
+				Basic: &Basic{
+					Users:        Users{"test:hash", "test2:hash2"},
+					Realm:        "myrealm",
+					RemoveHeader: true,
+				},
+			},
+		},
+		{
+			desc: "digest auth with users file",
+			labels: map[string]string{
+				LabelFrontendAuthDigestUsersFile: "/etc/traefik/users",
+			},
+			expected: &Auth{
+				Digest: &Digest{
+					UsersFile: "/etc/traefik/users",
+				},
+			},
+		},
+		{
+			desc: "forward auth",
+			labels: map[string]string{
+				LabelFrontendAuthForwardAddress:             "http://auth.server",
+				LabelFrontendAuthForwardTrustForwardHeader:  "true",
+				LabelFrontendAuthForwardAuthResponseHeaders: "X-Auth-User,X-Auth-Roles",
+			},
+			expected: &Auth{
+				Forward: &Forward{
+					Address:             "http://auth.server",
+					TrustForwardHeader:  true,
+					AuthResponseHeaders: []string{"X-Auth-User", "X-Auth-Roles"},
+				},
+			},
+		},
+		{
+			desc: "header field only",
+			labels: map[string]string{
+				LabelFrontendAuthHeaderField: "X-WebAuth-User",
+			},
+			expected: nil,
+		},
+		{
+			desc: "header field with basic users label",
+			labels: map[string]string{
+				LabelFrontendAuthBasic:       "test:$apr1$H6uskkkW$IgXLP6ewTrSuBkTrqE8wj/",
+				LabelFrontendAuthHeaderField: "X-WebAuth-User",
+			},
+			expected: &Auth{
+				HeaderField: "X-WebAuth-User",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			auth := GetAuthFromLabels(func(label string) (string, bool) {
+				value, ok := test.labels[label]
+				return value, ok
+			})
+			assert.Equal(t, test.expected, auth)
+		})
+	}
+}
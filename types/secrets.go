@@ -0,0 +1,127 @@
+package types
+
+import traefikTls "github.com/containous/traefik/tls"
+
+// WithoutSecrets returns the configurations without their secrets, to be exposed through the API.
+func (c Configurations) WithoutSecrets() Configurations {
+	if c == nil {
+		return nil
+	}
+	withoutSecrets := make(Configurations, len(c))
+	for providerID, configuration := range c {
+		withoutSecrets[providerID] = configuration.WithoutSecrets()
+	}
+	return withoutSecrets
+}
+
+// WithoutSecrets returns a copy of the configuration without the secrets of its frontends, backends and certificates,
+// to be exposed through the API.
+func (c *Configuration) WithoutSecrets() *Configuration {
+	if c == nil {
+		return nil
+	}
+	withoutSecrets := *c
+
+	if c.Frontends != nil {
+		withoutSecrets.Frontends = make(map[string]*Frontend, len(c.Frontends))
+		for frontendID, frontend := range c.Frontends {
+			withoutSecrets.Frontends[frontendID] = frontend.WithoutSecrets()
+		}
+	}
+
+	if c.Backends != nil {
+		withoutSecrets.Backends = make(map[string]*Backend, len(c.Backends))
+		for backendID, backend := range c.Backends {
+			withoutSecrets.Backends[backendID] = backend.WithoutSecrets()
+		}
+	}
+
+	if c.TLSConfiguration != nil {
+		withoutSecrets.TLSConfiguration = make([]*traefikTls.Configuration, len(c.TLSConfiguration))
+		for i, tlsConfiguration := range c.TLSConfiguration {
+			withoutSecrets.TLSConfiguration[i] = tlsConfiguration.WithoutSecrets()
+		}
+	}
+
+	return &withoutSecrets
+}
+
+// WithoutSecrets returns a copy of the backend without the secret of its stickiness,
+// nor the private key of its servers transport.
+func (b *Backend) WithoutSecrets() *Backend {
+	if b == nil {
+		return nil
+	}
+	withoutSecrets := *b
+
+	if b.LoadBalancer != nil && b.LoadBalancer.Stickiness != nil && len(b.LoadBalancer.Stickiness.Secret) > 0 {
+		stickiness := *b.LoadBalancer.Stickiness
+		stickiness.Secret = ""
+		loadBalancer := *b.LoadBalancer
+		loadBalancer.Stickiness = &stickiness
+		withoutSecrets.LoadBalancer = &loadBalancer
+	}
+
+	if b.ServersTransport != nil && len(b.ServersTransport.Key) > 0 {
+		serversTransport := *b.ServersTransport
+		serversTransport.Key = ""
+		withoutSecrets.ServersTransport = &serversTransport
+	}
+
+	return &withoutSecrets
+}
+
+// WithoutSecrets returns a copy of the frontend without the users of its basic authentication,
+// nor the secrets of its authentication.
+func (f *Frontend) WithoutSecrets() *Frontend {
+	if f == nil {
+		return nil
+	}
+	withoutSecrets := *f
+	withoutSecrets.BasicAuth = nil
+	withoutSecrets.Auth = f.Auth.withoutSecrets()
+	return &withoutSecrets
+}
+
+func (a *Auth) withoutSecrets() *Auth {
+	if a == nil {
+		return nil
+	}
+	withoutSecrets := *a
+
+	if a.Basic != nil {
+		basic := *a.Basic
+		basic.Users = nil
+		withoutSecrets.Basic = &basic
+	}
+
+	if a.Digest != nil {
+		digest := *a.Digest
+		digest.Users = nil
+		withoutSecrets.Digest = &digest
+	}
+
+	if a.Forward != nil && a.Forward.TLS != nil {
+		tls := *a.Forward.TLS
+		tls.Key = ""
+		forward := *a.Forward
+		forward.TLS = &tls
+		withoutSecrets.Forward = &forward
+	}
+
+	// the keys may be HMAC secrets
+	if a.JWT != nil {
+		jwt := *a.JWT
+		jwt.Keys = nil
+		withoutSecrets.JWT = &jwt
+	}
+
+	if a.OIDC != nil {
+		oidc := *a.OIDC
+		oidc.ClientSecret = ""
+		oidc.Secret = ""
+		withoutSecrets.OIDC = &oidc
+	}
+
+	return &withoutSecrets
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -20,11 +21,13 @@ import (
 
 // Backend holds backend configuration.
 type Backend struct {
-	Servers        map[string]Server `json:"servers,omitempty"`
-	CircuitBreaker *CircuitBreaker   `json:"circuitBreaker,omitempty"`
-	LoadBalancer   *LoadBalancer     `json:"loadBalancer,omitempty"`
-	MaxConn        *MaxConn          `json:"maxConn,omitempty"`
-	HealthCheck    *HealthCheck      `json:"healthCheck,omitempty"`
+	Servers          map[string]Server `json:"servers,omitempty"`
+	CircuitBreaker   *CircuitBreaker   `json:"circuitBreaker,omitempty"`
+	LoadBalancer     *LoadBalancer     `json:"loadBalancer,omitempty"`
+	MaxConn          *MaxConn          `json:"maxConn,omitempty"`
+	HealthCheck      *HealthCheck      `json:"healthCheck,omitempty"`
+	ServersTransport *ServersTransport `json:"serversTransport,omitempty"`
+	Retry            *Retry            `json:"retry,omitempty"`
 }
 
 // MaxConn holds maximum connection configuration
@@ -34,20 +37,46 @@ type MaxConn struct {
 }
 
 // LoadBalancer holds load balancing configuration.
+// HashOn is the request value hashed by the ringhash method: client.ip, request.header.<name> or request.cookie.<name>.
 type LoadBalancer struct {
 	Method     string      `json:"method,omitempty"`
 	Sticky     bool        `json:"sticky,omitempty"` // Deprecated: use Stickiness instead
 	Stickiness *Stickiness `json:"stickiness,omitempty"`
+	HashOn     string      `json:"hashOn,omitempty"`
 }
 
 // Stickiness holds sticky session configuration.
+// The clients stick to the server stored in a cookie (mode cookie, the default), or to the server chosen by
+// a consistent hash of the HashOn value (mode hash): client.ip, request.header.<name> or request.cookie.<name>.
 type Stickiness struct {
-	CookieName string `json:"cookieName,omitempty"`
+	CookieName  string `json:"cookieName,omitempty"`
+	Secure      bool   `json:"secure,omitempty"`
+	HTTPOnly    bool   `json:"httpOnly,omitempty"`
+	SameSite    string `json:"sameSite,omitempty"`
+	MaxAge      int    `json:"maxAge,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+	Obfuscation string `json:"obfuscation,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+	Mode        string `json:"mode,omitempty"`
+	HashOn      string `json:"hashOn,omitempty"`
 }
 
 // CircuitBreaker holds circuit breaker configuration.
 type CircuitBreaker struct {
-	Expression string `json:"expression,omitempty"`
+	Expression       string                  `json:"expression,omitempty"`
+	FallbackDuration flaeg.Duration          `json:"fallbackDuration,omitempty"`
+	RecoveryDuration flaeg.Duration          `json:"recoveryDuration,omitempty"`
+	CheckPeriod      flaeg.Duration          `json:"checkPeriod,omitempty"`
+	Fallback         *CircuitBreakerFallback `json:"fallback,omitempty"`
+}
+
+// CircuitBreakerFallback holds the response to the requests while the circuit breaker is tripped:
+// either a static response or the response of a fallback backend.
+type CircuitBreakerFallback struct {
+	StatusCode  int    `json:"statusCode,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body,omitempty"`
+	Backend     string `json:"backend,omitempty"`
 }
 
 // HealthCheck holds HealthCheck configuration
@@ -57,6 +86,76 @@ type HealthCheck struct {
 	Interval string `json:"interval,omitempty"`
 }
 
+// Retry holds the retry policy of a backend, overriding the global retry configuration.
+// StatusCodes are ranges like the error pages ones, e.g. "502-504".
+type Retry struct {
+	Attempts        int            `json:"attempts,omitempty"`
+	StatusCodes     []string       `json:"statusCodes,omitempty"`
+	Methods         []string       `json:"methods,omitempty"`
+	InitialInterval flaeg.Duration `json:"initialInterval,omitempty"`
+	MaxInterval     flaeg.Duration `json:"maxInterval,omitempty"`
+	MaxDuration     flaeg.Duration `json:"maxDuration,omitempty"`
+	MaxBodySize     int64          `json:"maxBodySize,omitempty"`
+}
+
+// ServersTransport holds the TLS and connection configuration used to reach the servers of a backend.
+// RootCAs, Cert and Key can be either a file path or the file content itself.
+type ServersTransport struct {
+	ServerName          string   `json:"serverName,omitempty"`
+	InsecureSkipVerify  bool     `json:"insecureSkipVerify,omitempty"`
+	RootCAs             []string `json:"rootCAs,omitempty"`
+	Cert                string   `json:"cert,omitempty"`
+	Key                 string   `json:"key,omitempty"`
+	MaxIdleConnsPerHost int      `json:"maxIdleConnsPerHost,omitempty"`
+}
+
+// CreateTLSConfig creates the client TLS config used to connect to the backend servers.
+func (st *ServersTransport) CreateTLSConfig() (*tls.Config, error) {
+	if st == nil {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName:         st.ServerName,
+		InsecureSkipVerify: st.InsecureSkipVerify,
+	}
+
+	if len(st.RootCAs) > 0 {
+		pool := x509.NewCertPool()
+		for _, rootCA := range st.RootCAs {
+			ca, err := traefikTls.FileOrContent(rootCA).Read()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read root CA %s: %s", rootCA, err)
+			}
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("invalid root CA %s", rootCA)
+			}
+		}
+		config.RootCAs = pool
+	}
+
+	if len(st.Cert) > 0 || len(st.Key) > 0 {
+		if len(st.Cert) == 0 || len(st.Key) == 0 {
+			return nil, errors.New("both cert and key must be set to present a client certificate")
+		}
+		cert, err := traefikTls.FileOrContent(st.Cert).Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %s", err)
+		}
+		key, err := traefikTls.FileOrContent(st.Key).Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client key: %s", err)
+		}
+		keyPair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client keypair: %s", err)
+		}
+		config.Certificates = []tls.Certificate{keyPair}
+	}
+
+	return config, nil
+}
+
 // Server holds server configuration.
 type Server struct {
 	URL    string `json:"url,omitempty"`
@@ -69,10 +168,15 @@ type Route struct {
 }
 
 //ErrorPage holds custom error page configuration
+//The page is fetched from the Query path of the Backend, or served from a File, or from an inline Content.
+//The File is read from the static configuration of the entry points only, never from the provider ones.
 type ErrorPage struct {
-	Status  []string `json:"status,omitempty"`
-	Backend string   `json:"backend,omitempty"`
-	Query   string   `json:"query,omitempty"`
+	Status      []string `json:"status,omitempty"`
+	Backend     string   `json:"backend,omitempty"`
+	Query       string   `json:"query,omitempty"`
+	File        string   `json:"file,omitempty"`
+	Content     string   `json:"content,omitempty"`
+	ContentType string   `json:"contentType,omitempty"`
 }
 
 // Rate holds a rate limiting configuration for a specific time period
@@ -86,6 +190,69 @@ type Rate struct {
 type RateLimit struct {
 	RateSet       map[string]*Rate `json:"rateset,omitempty"`
 	ExtractorFunc string           `json:"extractorFunc,omitempty"`
+	Algorithm     string           `json:"algorithm,omitempty"`
+}
+
+// Redirect holds a frontend redirection, either to an entry point or with a regex
+type Redirect struct {
+	EntryPoint  string `json:"entryPoint,omitempty"`
+	Regex       string `json:"regex,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// RequestID holds the request ID configuration of an entry point.
+// The request ID is forwarded to the backends and returned to the clients in the Header,
+// which defaults to X-Request-Id, and generated in the Format, uuid (default) or hex.
+// With TrustIncoming, the valid request IDs of the incoming requests are kept.
+type RequestID struct {
+	Header        string `json:"header,omitempty"`
+	Format        string `json:"format,omitempty"`
+	TrustIncoming bool   `json:"trustIncoming,omitempty"`
+}
+
+// Compress holds the response compression configuration.
+// The zero values select the defaults.
+type Compress struct {
+	Encodings            []string `json:"encodings,omitempty"`
+	Level                int      `json:"level,omitempty"`
+	BrotliLevel          int      `json:"brotliLevel,omitempty"`
+	MinSize              int      `json:"minSize,omitempty"`
+	ContentTypes         []string `json:"contentTypes,omitempty"`
+	ExcludedContentTypes []string `json:"excludedContentTypes,omitempty"`
+}
+
+// Cache holds the HTTP response cache configuration of a frontend.
+// The freshness of the responses comes from their Cache-Control and Expires headers:
+// DefaultTTL only applies to the cacheable responses without them.
+// StaleWhileRevalidate and StaleIfError apply to the responses without the matching Cache-Control extensions.
+// The zero values select the defaults.
+type Cache struct {
+	Store                string         `json:"store,omitempty"`
+	Directory            string         `json:"directory,omitempty"`
+	MaxSize              int64          `json:"maxSize,omitempty"`
+	MaxEntrySize         int64          `json:"maxEntrySize,omitempty"`
+	DefaultTTL           flaeg.Duration `json:"defaultTTL,omitempty"`
+	StaleWhileRevalidate flaeg.Duration `json:"staleWhileRevalidate,omitempty"`
+	StaleIfError         flaeg.Duration `json:"staleIfError,omitempty"`
+}
+
+// IPStrategy holds how the client IP is extracted from the requests.
+// Depth uses the IP at this position, from the right, of the X-Forwarded-For header.
+// TrustedProxies skips these addresses from the right of the X-Forwarded-For header.
+// When both are empty, the forwarded headers trusted IPs of the entry point are used.
+type IPStrategy struct {
+	Depth          int      `json:"depth,omitempty"`
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+}
+
+// WhiteList holds the IP white list configuration.
+// With Deny, the source range is a black list.
+type WhiteList struct {
+	SourceRange      []string    `json:"sourceRange,omitempty"`
+	IPStrategy       *IPStrategy `json:"ipStrategy,omitempty"`
+	Deny             bool        `json:"deny,omitempty"`
+	RejectStatusCode int         `json:"rejectStatusCode,omitempty"`
+	RejectBody       string      `json:"rejectBody,omitempty"`
 }
 
 // Headers holds the custom header configuration
@@ -140,6 +307,11 @@ func (h Headers) HasSecureHeadersDefined() bool {
 		h.IsDevelopment
 }
 
+// FrontendPriorityLowest is the priority of the frontends matched after all the others,
+// e.g. the default backend of the Kubernetes Ingresses.
+// The other negative priorities are ignored, as the zero priority.
+const FrontendPriorityLowest = math.MinInt32
+
 // Frontend holds frontend configuration.
 type Frontend struct {
 	EntryPoints          []string             `json:"entryPoints,omitempty"`
@@ -150,9 +322,31 @@ type Frontend struct {
 	Priority             int                  `json:"priority"`
 	BasicAuth            []string             `json:"basicAuth"`
 	WhitelistSourceRange []string             `json:"whitelistSourceRange,omitempty"`
+	WhiteList            *WhiteList           `json:"whiteList,omitempty"`
 	Headers              Headers              `json:"headers,omitempty"`
 	Errors               map[string]ErrorPage `json:"errors,omitempty"`
 	RateLimit            *RateLimit           `json:"ratelimit,omitempty"`
+	Auth                 *Auth                `json:"auth,omitempty"`
+	Redirect             *Redirect            `json:"redirect,omitempty"`
+	Compress             *Compress            `json:"compress,omitempty"`
+	Cache                *Cache               `json:"cache,omitempty"`
+}
+
+// GetWhiteList returns the white list of the frontend, merging the deprecated WhitelistSourceRange, or nil when there is none.
+func (f *Frontend) GetWhiteList() *WhiteList {
+	if f.WhiteList == nil {
+		if len(f.WhitelistSourceRange) == 0 {
+			return nil
+		}
+		return &WhiteList{SourceRange: f.WhitelistSourceRange}
+	}
+
+	if len(f.WhiteList.SourceRange) == 0 && len(f.WhitelistSourceRange) > 0 {
+		whiteList := *f.WhiteList
+		whiteList.SourceRange = f.WhitelistSourceRange
+		return &whiteList
+	}
+	return f.WhiteList
 }
 
 // LoadBalancerMethod holds the method of load balancing to use.
@@ -163,11 +357,23 @@ const (
 	Wrr LoadBalancerMethod = iota
 	// Drr = Dynamic Round Robin
 	Drr
+	// LeastConn = Least outstanding requests
+	LeastConn
+	// LeastLatency = Least moving average of the latencies
+	LeastLatency
+	// P2c = Power of two random choices
+	P2c
+	// RingHash = Consistent hashing of a request value
+	RingHash
 )
 
 var loadBalancerMethodNames = []string{
 	"Wrr",
 	"Drr",
+	"LeastConn",
+	"LeastLatency",
+	"P2c",
+	"RingHash",
 }
 
 // NewLoadBalancerMethod create a new LoadBalancerMethod from a given LoadBalancer.
@@ -330,6 +536,8 @@ type Auth struct {
 	Basic       *Basic   `export:"true"`
 	Digest      *Digest  `export:"true"`
 	Forward     *Forward `export:"true"`
+	JWT         *JWT     `export:"true"`
+	OIDC        *OIDC    `export:"true"`
 	HeaderField string   `export:"true"`
 }
 
@@ -338,21 +546,71 @@ type Users []string
 
 // Basic HTTP basic authentication
 type Basic struct {
-	Users     `mapstructure:","`
-	UsersFile string
+	Users        `mapstructure:","`
+	UsersFile    string
+	Realm        string
+	RemoveHeader bool
 }
 
 // Digest HTTP authentication
 type Digest struct {
-	Users     `mapstructure:","`
-	UsersFile string
+	Users        `mapstructure:","`
+	UsersFile    string
+	Realm        string
+	RemoveHeader bool
 }
 
 // Forward authentication
 type Forward struct {
-	Address            string     `description:"Authentication server address"`
-	TLS                *ClientTLS `description:"Enable TLS support" export:"true"`
-	TrustForwardHeader bool       `description:"Trust X-Forwarded-* headers" export:"true"`
+	Address             string         `description:"Authentication server address"`
+	TLS                 *ClientTLS     `description:"Enable TLS support" export:"true"`
+	TrustForwardHeader  bool           `description:"Trust X-Forwarded-* headers" export:"true"`
+	AuthResponseHeaders []string       `description:"Headers to be copied from the authentication server response to the forwarded request" export:"true"`
+	ForwardMethod       bool           `description:"Use the original request method to call the authentication server" export:"true"`
+	ForwardPath         bool           `description:"Append the original request path and query to the authentication server address" export:"true"`
+	ForwardBody         bool           `description:"Send the original request body to the authentication server" export:"true"`
+	MaxBodySize         int64          `description:"Maximum size in bytes of a request body sent to the authentication server (default 1MB)" export:"true"`
+	Timeout             flaeg.Duration `description:"Maximum duration of a call to the authentication server. If zero, no timeout is set" export:"true"`
+	DialTimeout         flaeg.Duration `description:"Maximum duration to establish a connection to the authentication server. Defaults to 30 seconds" export:"true"`
+	Cache               *ForwardCache  `description:"Cache the successful authentication server responses" export:"true"`
+}
+
+// ForwardCache holds the forward authentication response cache configuration.
+// Requests are cached by the values of the given headers and cookies.
+type ForwardCache struct {
+	TTL        flaeg.Duration `description:"Duration a successful authentication server response is kept" export:"true"`
+	KeyHeaders []string       `description:"Request headers used to build the cache key" export:"true"`
+	KeyCookies []string       `description:"Request cookies used to build the cache key" export:"true"`
+	MaxEntries int            `description:"Maximum number of cached responses (default 10000)" export:"true"`
+}
+
+// JWT bearer token authentication
+type JWT struct {
+	Keys            []string          `description:"Public keys (PEM) or HMAC secrets used to verify the token signature, as files or contents"`
+	JWKSURL         string            `description:"URL of a JSON Web Key Set used to verify the token signature" export:"true"`
+	JWKSRefresh     flaeg.Duration    `description:"Interval between two refreshes of the JSON Web Key Set (default 1h)" export:"true"`
+	Algorithms      []string          `description:"Accepted signing algorithms (default: all algorithms matching the keys)" export:"true"`
+	Issuer          string            `description:"Expected token issuer (iss claim)" export:"true"`
+	Audience        []string          `description:"Accepted token audiences (aud claim)" export:"true"`
+	RequiredClaims  map[string]string `description:"Claims that must be present in the token, with their expected value if not empty" export:"true"`
+	ClaimsToHeaders map[string]string `description:"Claims to be copied to the forwarded request, as claim name to header name" export:"true"`
+	ClockSkew       flaeg.Duration    `description:"Clock skew tolerated when checking the token time claims" export:"true"`
+}
+
+// OIDC OpenID Connect authentication
+type OIDC struct {
+	Issuer          string            `description:"OpenID Connect provider URL, used for the discovery" export:"true"`
+	ClientID        string            `description:"Client ID registered on the provider" export:"true"`
+	ClientSecret    string            `description:"Client secret registered on the provider"`
+	Scopes          []string          `description:"Requested scopes (default: openid, profile, email)" export:"true"`
+	RedirectURL     string            `description:"Callback URL, or path on the requested host (default /oauth2/callback)" export:"true"`
+	LogoutPath      string            `description:"Path which clears the session" export:"true"`
+	Secret          string            `description:"Secret used to encrypt the session cookie"`
+	CookieName      string            `description:"Name of the session cookie (default _traefik_oidc)" export:"true"`
+	CookieDomain    string            `description:"Domain of the session cookie" export:"true"`
+	SessionTTL      flaeg.Duration    `description:"Maximum lifetime of a session (default 24h)" export:"true"`
+	TokenTTL        flaeg.Duration    `description:"Lifetime of the tokens returned without expiry nor ID token expiry (default 5m)" export:"true"`
+	ClaimsToHeaders map[string]string `description:"Claims to be copied to the forwarded request, as claim name to header name" export:"true"`
 }
 
 // CanonicalDomain returns a lower case domain with trim space
@@ -2,8 +2,11 @@ package types
 
 import (
 	"testing"
+	"time"
 
+	"github.com/containous/traefik/tls/generate"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHeaders_ShouldReturnFalseWhenNotHasCustomHeadersDefined(t *testing.T) {
@@ -35,3 +38,75 @@ func TestHeaders_ShouldReturnTrueWhenHasSecureHeadersDefined(t *testing.T) {
 
 	assert.True(t, headers.HasSecureHeadersDefined())
 }
+
+func TestServersTransportCreateTLSConfig(t *testing.T) {
+	cert, key, err := generate.KeyPair("backend.localhost", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc                 string
+		serversTransport     *ServersTransport
+		expectedErr          bool
+		expectedCertificates int
+		expectedRootCAs      bool
+	}{
+		{
+			desc: "nil transport",
+		},
+		{
+			desc: "server name and insecure only",
+			serversTransport: &ServersTransport{
+				ServerName:         "backend.localhost",
+				InsecureSkipVerify: true,
+			},
+		},
+		{
+			desc: "client certificate and root CA",
+			serversTransport: &ServersTransport{
+				RootCAs: []string{string(cert)},
+				Cert:    string(cert),
+				Key:     string(key),
+			},
+			expectedCertificates: 1,
+			expectedRootCAs:      true,
+		},
+		{
+			desc: "cert without key",
+			serversTransport: &ServersTransport{
+				Cert: string(cert),
+			},
+			expectedErr: true,
+		},
+		{
+			desc: "invalid root CA",
+			serversTransport: &ServersTransport{
+				RootCAs: []string{"not a certificate"},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := test.serversTransport.CreateTLSConfig()
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if test.serversTransport == nil {
+				assert.Nil(t, config)
+				return
+			}
+
+			assert.Equal(t, test.serversTransport.ServerName, config.ServerName)
+			assert.Equal(t, test.serversTransport.InsecureSkipVerify, config.InsecureSkipVerify)
+			assert.Len(t, config.Certificates, test.expectedCertificates)
+			assert.Equal(t, test.expectedRootCAs, config.RootCAs != nil)
+		})
+	}
+}
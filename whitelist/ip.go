@@ -3,6 +3,7 @@ package whitelist
 import (
 	"fmt"
 	"net"
+	"net/http"
 
 	"github.com/pkg/errors"
 )
@@ -20,7 +21,7 @@ func NewIP(whitelistStrings []string, insecure bool) (*IP, error) {
 		return nil, errors.New("no whiteListsNet provided")
 	}
 
-	ip := IP{}
+	ip := IP{insecure: insecure}
 
 	if !insecure {
 		for _, whitelistString := range whitelistStrings {
@@ -55,6 +56,15 @@ func (ip *IP) Contains(addr string) (bool, net.IP, error) {
 	return contains, ipAddr, err
 }
 
+// ContainsRequest checks if the client IP of the request, extracted by the strategy, is in the white list.
+// The address of the connection is used when no strategy is given
+func (ip *IP) ContainsRequest(req *http.Request, strategy Strategy) (bool, net.IP, error) {
+	if strategy == nil {
+		strategy = &RemoteAddrStrategy{}
+	}
+	return ip.Contains(strategy.GetIP(req))
+}
+
 // ContainsIP checks if provided address is in the white list
 func (ip *IP) ContainsIP(addr net.IP) (bool, error) {
 	if ip.insecure {
@@ -316,3 +316,12 @@ func TestBrokenIPs(t *testing.T) {
 	}
 
 }
+
+func TestContainsInsecure(t *testing.T) {
+	whitelister, err := NewIP(nil, true)
+	require.NoError(t, err)
+
+	allowed, _, err := whitelister.Contains("8.8.8.8")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,97 @@
+package whitelist
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	xForwardedFor = "X-Forwarded-For"
+	xRealIP       = "X-Real-Ip"
+)
+
+// Strategy extracts the IP of the client from a request
+type Strategy interface {
+	GetIP(req *http.Request) string
+}
+
+// RemoteAddrStrategy uses the address of the connection as the client IP
+type RemoteAddrStrategy struct{}
+
+// GetIP returns the IP of the connection
+func (s *RemoteAddrStrategy) GetIP(req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return ip
+}
+
+// DepthStrategy uses the IP at the given depth, counted from the right, of the X-Forwarded-For header:
+// with a depth of 1, the IP added by the last proxy is used
+type DepthStrategy struct {
+	Depth int
+}
+
+// GetIP returns the IP at the depth of the forwarding chain, or an empty string when the chain is too short
+func (s *DepthStrategy) GetIP(req *http.Request) string {
+	chain := forwardedChain(req)
+	if s.Depth <= 0 || s.Depth > len(chain) {
+		return ""
+	}
+	return chain[len(chain)-s.Depth]
+}
+
+// TrustedProxiesStrategy walks the X-Forwarded-For header from the right, skipping the trusted proxies:
+// the client IP is the first address which is not a trusted proxy.
+// Insecure proxies, which would trust every address, are not trusted at all
+type TrustedProxiesStrategy struct {
+	Proxies *IP
+}
+
+// GetIP returns the right-most IP of the forwarding chain that is not a trusted proxy,
+// or the IP of the connection when every address of the chain is trusted
+func (s *TrustedProxiesStrategy) GetIP(req *http.Request) string {
+	remoteIP := (&RemoteAddrStrategy{}).GetIP(req)
+	if !s.isTrusted(remoteIP) {
+		return remoteIP
+	}
+
+	chain := forwardedChain(req)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !s.isTrusted(chain[i]) {
+			return chain[i]
+		}
+	}
+
+	// the left-most address of the chain may be set by the client, it is not used
+	return remoteIP
+}
+
+func (s *TrustedProxiesStrategy) isTrusted(addr string) bool {
+	if s.Proxies == nil || s.Proxies.insecure {
+		return false
+	}
+	trusted, _, err := s.Proxies.Contains(addr)
+	return err == nil && trusted
+}
+
+// forwardedChain returns the IPs of the X-Forwarded-For headers, or of the X-Real-Ip header when there is none
+func forwardedChain(req *http.Request) []string {
+	var chain []string
+	for _, value := range req.Header[xForwardedFor] {
+		for _, ip := range strings.Split(value, ",") {
+			if ip = strings.TrimSpace(ip); len(ip) > 0 {
+				chain = append(chain, ip)
+			}
+		}
+	}
+
+	if len(chain) == 0 {
+		if ip := strings.TrimSpace(req.Header.Get(xRealIP)); len(ip) > 0 {
+			chain = append(chain, ip)
+		}
+	}
+	return chain
+}
@@ -0,0 +1,141 @@
+package whitelist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRequest(remoteAddr string, headers map[string][]string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1", nil)
+	req.RemoteAddr = remoteAddr
+	for name, values := range headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	return req
+}
+
+func TestRemoteAddrStrategy(t *testing.T) {
+	strategy := &RemoteAddrStrategy{}
+
+	req := newRequest("10.0.0.1:1234", map[string][]string{xForwardedFor: {"1.2.3.4"}})
+	assert.Equal(t, "10.0.0.1", strategy.GetIP(req))
+}
+
+func TestDepthStrategy(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		depth      int
+		headers    map[string][]string
+		expectedIP string
+	}{
+		{
+			desc:       "last proxy",
+			depth:      1,
+			headers:    map[string][]string{xForwardedFor: {"1.1.1.1, 2.2.2.2, 3.3.3.3"}},
+			expectedIP: "3.3.3.3",
+		},
+		{
+			desc:       "several headers",
+			depth:      2,
+			headers:    map[string][]string{xForwardedFor: {"1.1.1.1, 2.2.2.2", "3.3.3.3"}},
+			expectedIP: "2.2.2.2",
+		},
+		{
+			desc:    "chain too short",
+			depth:   3,
+			headers: map[string][]string{xForwardedFor: {"1.1.1.1, 2.2.2.2"}},
+		},
+		{
+			desc:       "real IP without forwarded for",
+			depth:      1,
+			headers:    map[string][]string{xRealIP: {"4.4.4.4"}},
+			expectedIP: "4.4.4.4",
+		},
+		{
+			desc:  "no header",
+			depth: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			strategy := &DepthStrategy{Depth: test.depth}
+			assert.Equal(t, test.expectedIP, strategy.GetIP(newRequest("10.0.0.1:1234", test.headers)))
+		})
+	}
+}
+
+func TestTrustedProxiesStrategy(t *testing.T) {
+	proxies, err := NewIP([]string{"10.0.0.0/8"}, false)
+	require.NoError(t, err)
+
+	allProxies, err := NewIP(nil, true)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		remoteAddr string
+		headers    map[string][]string
+		insecure   bool
+		expectedIP string
+	}{
+		{
+			desc:       "untrusted connection",
+			remoteAddr: "1.1.1.1:1234",
+			headers:    map[string][]string{xForwardedFor: {"2.2.2.2"}},
+			expectedIP: "1.1.1.1",
+		},
+		{
+			desc:       "trusted proxies skipped",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{xForwardedFor: {"1.1.1.1, 2.2.2.2, 10.0.0.2"}},
+			expectedIP: "2.2.2.2",
+		},
+		{
+			desc:       "only trusted proxies",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{xForwardedFor: {"10.0.0.3, 10.0.0.2"}},
+			expectedIP: "10.0.0.1",
+		},
+		{
+			desc:       "real IP",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{xRealIP: {"2.2.2.2"}},
+			expectedIP: "2.2.2.2",
+		},
+		{
+			desc:       "no header",
+			remoteAddr: "10.0.0.1:1234",
+			expectedIP: "10.0.0.1",
+		},
+		{
+			desc:       "insecure proxies are not trusted",
+			remoteAddr: "1.1.1.1:1234",
+			headers:    map[string][]string{xForwardedFor: {"2.2.2.2, 3.3.3.3"}},
+			insecure:   true,
+			expectedIP: "1.1.1.1",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			strategy := &TrustedProxiesStrategy{Proxies: proxies}
+			if test.insecure {
+				strategy.Proxies = allProxies
+			}
+			assert.Equal(t, test.expectedIP, strategy.GetIP(newRequest(test.remoteAddr, test.headers)))
+		})
+	}
+}